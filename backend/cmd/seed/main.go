@@ -16,12 +16,14 @@ func main() {
 	seed.SeedUsers()
 	seed.CurriculumSeed()
 	seed.SeedCourseGroups()
+	seed.SeedCourses()
 	seed.SeedTemplateBlocks()
 	seed.SeedTemplatesSections()
 	seed.SeedCategoryTemplates()
 	seed.SeedTemplates()
 	seed.SeedTypeWorkings()
 	seed.SeedActivities()
+	seed.SeedTypeSkills()
 	seed.SeedColors()
 	seed.FontSeed()
 	seed.SeedPortfolioSubmissions()
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/sut68/team14/backend/services"
+)
+
+// สร้างคู่คีย์ VAPID สำหรับ Web Push แล้วนำไปใส่ใน .env
+func main() {
+	public, private, err := services.GenerateVAPIDKeys()
+	if err != nil {
+		log.Fatal("Failed to generate VAPID keys:", err)
+	}
+	fmt.Printf("VAPID_PUBLIC_KEY=%s\n", public)
+	fmt.Printf("VAPID_PRIVATE_KEY=%s\n", private)
+	fmt.Println("VAPID_SUBJECT=mailto:admin@example.com")
+}
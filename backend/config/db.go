@@ -78,25 +78,46 @@ func SetupDatabase() {
 		&entity.PortfolioActivity{},
 		&entity.PortfolioSubmission{},
 		&entity.Feedback{},
+		&entity.FeedbackComment{},
+		&entity.CommentSnippet{},
+		&entity.Application{},
+		&entity.ApplicationDocument{},
 		&entity.Scorecard{},
 		&entity.Evaluation{},
 		&entity.ScoreCriteria{},
 		&entity.CriteriaScore{},
 		&entity.Cetagory{},
 		&entity.Announcement{},
+		&entity.AnnouncementAudience{},
+		&entity.AnnouncementRevision{},
+		&entity.AnnouncementReceipt{},
 		&entity.Announcement_Attachment{},
+		&entity.UploadScan{},
 		&entity.Notification{},
+		&entity.NotificationPreference{},
+		&entity.NotificationSetting{},
+		&entity.NotificationDelivery{},
+		&entity.PushSubscription{},
+		&entity.EmailMessage{},
 		&entity.Admin_Log{},
 		&entity.Faculty{},
 		&entity.Program{},
 		&entity.Course{},
 		&entity.Curriculum{},
+		&entity.CurriculumVersion{},
+		&entity.FacultyAdmin{},
 		&entity.DocumentType{},
 		&entity.CurriculumRequiredDocument{},
+		&entity.ApplicationRound{},
+		&entity.CurriculumRequirement{},
 		&entity.CurriculumSkill{},
 		&entity.Skill{},
 		&entity.CourseGroup{},
 		&entity.CourseGroupSkill{},
+		&entity.TypeActivitySkill{},
+		&entity.TypeWorkingSkill{},
+		&entity.ActivitySkill{},
+		&entity.WorkingSkill{},
 		&entity.CurriculumCourseGroup{},
 		&entity.SectionBlock{},
 		&entity.TemplateSectionLink{},
@@ -105,6 +126,8 @@ func SetupDatabase() {
 	); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
+
+	runDataMigrations()
 }
 
 // get
@@ -112,6 +135,11 @@ func GetDB() *gorm.DB {
 	return db
 }
 
+// SetDB replaces the connection, so tests can run controllers against their own database.
+func SetDB(database *gorm.DB) {
+	db = database
+}
+
 func ConnectionSQLite() {
 	database, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
 	if err != nil {
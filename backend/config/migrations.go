@@ -0,0 +1,125 @@
+package config
+
+import (
+	"log"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// runDataMigrations แปลงข้อมูลเดิมให้เข้ากับ schema ใหม่ ทุกขั้นตอนต้องรันซ้ำได้ (idempotent)
+func runDataMigrations() {
+	migrateApplicationRounds()
+	createCurriculumSearchIndexes()
+	normalizeAnnouncementStatuses()
+	normalizeNotificationTypes()
+	clearUnreadNotificationReadAt()
+}
+
+// clearUnreadNotificationReadAt sets read_at back to NULL on unread notifications: before
+// read_at was nullable, marking a notification unread wrote the zero time (0001-01-01).
+func clearUnreadNotificationReadAt() {
+	result := db.Model(&entity.Notification{}).
+		Where("is_read = ? AND read_at IS NOT NULL", false).
+		Update("read_at", nil)
+	if result.Error != nil {
+		log.Println("clear notification read_at:", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("cleared read_at on %d unread notification(s)", result.RowsAffected)
+	}
+}
+
+// normalizeNotificationTypes lowercases the notification types written before they became
+// constants ("ANNOUNCEMENT", "Alert"...) and renames the scheduler's "Reminder" to
+// entity.NotificationTypeDeadlineReminder.
+func normalizeNotificationTypes() {
+	renamed := db.Model(&entity.Notification{}).
+		Where("LOWER(notification_type) = ?", "reminder").
+		Update("notification_type", entity.NotificationTypeDeadlineReminder)
+	if renamed.Error != nil {
+		log.Println("normalize notification types:", renamed.Error)
+		return
+	}
+	result := db.Model(&entity.Notification{}).
+		Where("notification_type <> LOWER(notification_type)").
+		Update("notification_type", gorm.Expr("LOWER(notification_type)"))
+	if result.Error != nil {
+		log.Println("normalize notification types:", result.Error)
+		return
+	}
+	if n := renamed.RowsAffected + result.RowsAffected; n > 0 {
+		log.Printf("normalized %d notification type(s)", n)
+	}
+}
+
+// normalizeAnnouncementStatuses lowercases the DRAFT/PUBLISHED/SCHEDULED values written by
+// the old announcement handlers, so they match entity.Announcement's status values.
+func normalizeAnnouncementStatuses() {
+	result := db.Model(&entity.Announcement{}).
+		Where("status <> LOWER(status)").
+		Update("status", gorm.Expr("LOWER(status)"))
+	if result.Error != nil {
+		log.Println("normalize announcement statuses:", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("normalized %d announcement status(es) to lowercase", result.RowsAffected)
+	}
+}
+
+// curriculumSearchDocument must match services.CurriculumSearchDocument (without the table
+// prefix) or PostgreSQL will not use the indexes below.
+const curriculumSearchDocument = "coalesce(code, '') || ' ' || coalesce(name, '') || ' ' || coalesce(description, '')"
+
+// createCurriculumSearchIndexes adds the GIN indexes used by the curriculum full-text search
+// (PostgreSQL only). pg_trgm speeds up the substring match used for Thai; without the
+// extension the search still works, only slower.
+func createCurriculumSearchIndexes() {
+	if db.Dialector.Name() != "postgres" {
+		return
+	}
+	statements := []string{
+		"CREATE INDEX IF NOT EXISTS idx_curriculums_fts_english ON curriculums USING GIN (to_tsvector('english', " + curriculumSearchDocument + "))",
+		"CREATE INDEX IF NOT EXISTS idx_curriculums_fts_simple ON curriculums USING GIN (to_tsvector('simple', " + curriculumSearchDocument + "))",
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		"CREATE INDEX IF NOT EXISTS idx_curriculums_name_trgm ON curriculums USING GIN (name gin_trgm_ops)",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			log.Println("create curriculum search index:", err)
+			return
+		}
+	}
+}
+
+// migrateApplicationRounds creates one ApplicationRound for every curriculum that never had
+// one, from its StartDate/EndDate or the legacy "start|end" ApplicationPeriod string.
+// Deleted rounds count too, so a curriculum whose rounds were removed stays without rounds.
+func migrateApplicationRounds() {
+	var curricula []entity.Curriculum
+	if err := db.
+		Where("NOT EXISTS (SELECT 1 FROM application_rounds r WHERE r.curriculum_id = curriculums.id)").
+		Find(&curricula).Error; err != nil {
+		log.Println("migrate application rounds:", err)
+		return
+	}
+
+	migrated := 0
+	for i := range curricula {
+		round, ok := curricula[i].LegacyRound()
+		if !ok {
+			log.Printf("migrate application rounds: curriculum %d has no usable period (%q), skipped", curricula[i].ID, curricula[i].ApplicationPeriod)
+			continue
+		}
+		if err := db.Create(&round).Error; err != nil {
+			log.Printf("migrate application rounds: curriculum %d: %v", curricula[i].ID, err)
+			continue
+		}
+		migrated++
+	}
+	if migrated > 0 {
+		log.Printf("migrated %d legacy application periods to rounds", migrated)
+	}
+}
@@ -63,6 +63,7 @@ func (ac *ActivityController) GetActivity(c *gin.Context) {
 		Preload("ActivityDetail.LevelActivity").
 		Preload("ActivityDetail.Images").
 		Preload("Reward"). // Note: Reward is directly on Activity, not Detail, based on entity definition
+		Preload("Skills.Skill").
 		Preload("User").
 		First(&activity, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
@@ -105,6 +106,7 @@ func (ac *ActivityController) ListActivities(c *gin.Context) {
 		Preload("ActivityDetail.TypeActivity").
 		Preload("ActivityDetail.LevelActivity").
 		Preload("Reward").
+		Preload("Skills.Skill").
 		Where("user_id = ?", userID)
 
 	// Only preload images if explicitly requested
@@ -261,6 +263,7 @@ func (ac *ActivityController) ListActivitiesByUser(c *gin.Context) {
 		Preload("ActivityDetail.TypeActivity").
 		Preload("ActivityDetail.LevelActivity").
 		Preload("Reward").
+		Preload("Skills.Skill").
 		Where("user_id = ?", userId)
 
 	if includeImages {
@@ -1,23 +1,91 @@
 package controller
 
 import (
+	"errors"
+	"html"
+	"io"
+	"log"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sut68/team14/backend/config"
 	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm"
 )
 
 type CreateAnnouncementInput struct {
 	Title                string  `json:"title" `
 	Content              string  `json:"content" `
+	ContentFormat        string  `json:"content_format"` // plain (ค่าเริ่มต้น), markdown, html
 	Is_Pinned            *bool    `json:"is_pinned"`
 	Scheduled_Publish_At *string `json:"scheduled_publish_at" `
 	Expires_At           *string `json:"expires_at"`
 	Send_Notification    bool    `json:"send_notification"`
 	CetagoryID           uint    `json:"cetagory_id" `
 	Status               string  `json:"status"`
+	RequiresAck          *bool   `json:"requires_ack"`
+	AckDeadline          *string `json:"ack_deadline"` // ว่าง = ไม่มีกำหนด
+
+	// กลุ่มเป้าหมาย [{kind, target_id, value}] ไม่ส่ง = ไม่เปลี่ยน, [] = ทุกคน
+	// คณะ/สาขา/หลักสูตร/ปีการศึกษา ถึงเฉพาะผู้ที่เลือก สมัคร หรือรับผิดชอบหลักสูตรในกลุ่มนั้น
+	Audiences *[]entity.AnnouncementAudience `json:"audiences"`
+}
+
+// notifyAudienceAsync fans the announcement out to its audience without blocking the request.
+func notifyAudienceAsync(db *gorm.DB, announcement entity.Announcement) {
+	go func() {
+		if _, err := services.NotifyAnnouncementAudience(db, &announcement, time.Now()); err != nil {
+			log.Printf("❌ Failed to create notifications for announcement ID %d: %v", announcement.ID, err)
+		}
+	}()
+}
+
+// parseAnnouncementTime parses a datetime-local value ("2006-01-02T15:04") in Thai time, or an
+// RFC 3339 timestamp. An empty string means "not set".
+func parseAnnouncementTime(raw string) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02T15:04", raw, services.AnnouncementLocation())
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// applyAnnouncementStatus moves the announcement to the requested status through the
+// lifecycle and returns the action taken ("" when the status did not change).
+func applyAnnouncementStatus(announcement *entity.Announcement, status string, scheduledAt *string, now time.Time) (string, error) {
+	action, err := services.AnnouncementActionForStatus(announcement, status)
+	if err != nil || action == "" {
+		return "", err
+	}
+	var at *time.Time
+	if action == services.AnnouncementActionSchedule {
+		if scheduledAt == nil {
+			return "", errors.New("scheduled_publish_at is required for scheduled publish")
+		}
+		if at, err = parseAnnouncementTime(*scheduledAt); err != nil {
+			return "", errors.New("invalid scheduled_publish_at format")
+		}
+	}
+	return action, services.TransitionAnnouncement(announcement, action, at, now)
+}
+
+// announcementErrorStatus: 409 for lifecycle conflicts, 400 for everything else.
+func announcementErrorStatus(err error) int {
+	if errors.Is(err, services.ErrAnnouncementTransition) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
 }
 
 // ================= CREATE =================
@@ -28,67 +96,93 @@ func CreateAnnouncement(c *gin.Context) {
 		return
 	}
 
-
-	if input.Status == "" {
-		input.Status = "DRAFT"
+	uid, err := getAuthUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
 	}
 
-	userIDRaw, _ := c.Get("user_id")
-	uid := userIDRaw.(uint)
-
-	var scheduledAt *time.Time
-	var publishedAt *time.Time
-
-	switch input.Status {
-
-	case "PUBLISHED":
-		now := time.Now()
-		publishedAt = &now
-		scheduledAt = &now
-
-	case "SCHEDULED":
-		if input.Scheduled_Publish_At == nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "scheduled_publish_at is required for scheduled publish",
-			})
-			return
-		}
-		t, err := time.Parse("2006-01-02T15:04", *input.Scheduled_Publish_At)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid scheduled_publish_at format"})
+	db := config.GetDB()
+	audiences := []entity.AnnouncementAudience{}
+	if input.Audiences != nil {
+		if audiences, err = services.NormalizeAudiences(db, *input.Audiences); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		scheduledAt = &t
-		publishedAt = nil
+	}
 
-	case "DRAFT":
-		// ไม่ต้องตั้งเวลา
+	expiresAt, err := parseAnnouncementTime(derefString(input.Expires_At))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expires_at format"})
+		return
+	}
 
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+	format, content, err := services.PrepareAnnouncementContent(input.ContentFormat, input.Content)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ackDeadline, err := parseAnnouncementTime(derefString(input.AckDeadline))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ack_deadline format"})
+		return
+	}
+	requiresAck := input.RequiresAck != nil && *input.RequiresAck
+	if ackDeadline != nil && !requiresAck {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ack_deadline needs requires_ack"})
 		return
 	}
 
+	// เริ่มเป็นฉบับร่างเสมอ แล้วเลื่อนสถานะตามที่ขอผ่าน lifecycle
 	announcement := entity.Announcement{
-		Title:                input.Title,
-		Content:              input.Content,
-		Is_Pinned:            input.Is_Pinned,
-		Status:               input.Status,
-		Scheduled_Publish_At: scheduledAt,
-		Published_At:         publishedAt,
-		Send_Notification:    input.Send_Notification,
-		UserID:               uid,
-		CetagoryID:           input.CetagoryID,
-	}
-
-	if err := config.GetDB().Create(&announcement).Error; err != nil {
+		Title:             input.Title,
+		Content:           content,
+		ContentFormat:     format,
+		Is_Pinned:         input.Is_Pinned,
+		Status:            entity.AnnouncementDraft,
+		Expires_At:        expiresAt,
+		Send_Notification: input.Send_Notification,
+		RequiresAck:       requiresAck,
+		AckDeadline:       ackDeadline,
+		UserID:            uid,
+		CetagoryID:        input.CetagoryID,
+	}
+	if input.Status != "" {
+		if _, err := applyAnnouncementStatus(&announcement, input.Status, input.Scheduled_Publish_At, time.Now()); err != nil {
+			c.JSON(announcementErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if _, err := services.SaveAnnouncement(db, &announcement, &audiences, services.AnnouncementRevisionCreate, &uid); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// เผยแพร่ทันที ไม่ผ่าน scheduler จึงต้องส่ง notification เอง
+	if announcement.Published_At != nil && announcement.Send_Notification {
+		notifyAudienceAsync(db, announcement)
+	}
+
+	services.RenderAnnouncement(&announcement)
 	c.JSON(http.StatusCreated, announcement)
 }
 
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func equalTime(x, y *time.Time) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+	return x.Equal(*y)
+}
+
 
 // ================= READ ALL =================
 func GetAdminAnnouncements(c *gin.Context) {
@@ -97,10 +191,10 @@ func GetAdminAnnouncements(c *gin.Context) {
 
 	status := c.Query("status")
 
-	query := db.Preload("User").Preload("Cetagory")
+	query := db.Preload("User").Preload("Cetagory").Preload("Audiences")
 
 	if status != "" {
-		query = query.Where("status = ?", status)
+		query = query.Where("status = ?", services.NormalizeAnnouncementStatus(status))
 	}
 
 	if err := query.
@@ -111,6 +205,7 @@ func GetAdminAnnouncements(c *gin.Context) {
 		return
 	}
 
+	services.RenderAnnouncements(announcements)
 	c.JSON(http.StatusOK, announcements)
 }
 
@@ -119,13 +214,13 @@ func GetAnnouncements(c *gin.Context) {
 	var announcements []entity.Announcement
 
 	// เวลาไทย
-	location, _ := time.LoadLocation("Asia/Bangkok")
-	now := time.Now().In(location)
+	now := time.Now().In(services.AnnouncementLocation())
 
 	err := db.
 		Preload("User").
 		Preload("Cetagory").
-		Where("status = ?", "PUBLISHED").
+		Preload("Audiences").
+		Where("status = ?", entity.AnnouncementPublished).
 		Where("published_at <= ?", now).
 		Where("(expires_at IS NULL OR expires_at > ?)", now).
 		Order("is_pinned DESC").
@@ -137,37 +232,178 @@ func GetAnnouncements(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, announcements)
+	member, err := announcementMember(c, db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	visible := services.FilterAnnouncementsFor(announcements, member)
+	if member.UserID != 0 {
+		if err := services.AttachReceipts(db, visible, member.UserID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	services.RenderAnnouncements(visible)
+	c.JSON(http.StatusOK, visible)
+}
+
+// announcementMember loads the caller's audience memberships. On the public routes there is
+// no user, so only announcements for everyone match.
+func announcementMember(c *gin.Context, db *gorm.DB) (*services.AudienceMember, error) {
+	if _, ok := c.Get("user_id"); !ok {
+		return services.LoadAudienceMember(db, nil)
+	}
+	user, err := getAuthUser(c, db)
+	if err != nil {
+		return nil, err
+	}
+	return services.LoadAudienceMember(db, user)
 }
 
 // ================= READ ONE =================
-func GetAnnouncementByID(c *gin.Context) {
-	id := c.Param("id")
-	db := config.GetDB()
 
+// loadVisibleAnnouncement loads announcement :id if the caller may see it: published and
+// for them (admins see everything). It writes the error response itself.
+func loadVisibleAnnouncement(c *gin.Context, db *gorm.DB) (*entity.Announcement, *services.AudienceMember, bool) {
 	var announcement entity.Announcement
 	if err := db.
 		Preload("User").
 		Preload("Cetagory").
-		First(&announcement, id).Error; err != nil {
+		Preload("Audiences").
+		First(&announcement, c.Param("id")).Error; err != nil {
 
 		c.JSON(http.StatusNotFound, gin.H{"error": "announcement not found"})
+		return nil, nil, false
+	}
+
+	member, err := announcementMember(c, db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	// ยังไม่เผยแพร่ หรือไม่อยู่ในกลุ่มเป้าหมาย ตอบเหมือนไม่มีประกาศ (ยกเว้นแอดมิน)
+	if member.RoleID != entity.UserTypeAdmin &&
+		(announcement.Status != entity.AnnouncementPublished || !services.AudienceMatches(announcement.Audiences, member)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "announcement not found"})
+		return nil, nil, false
+	}
+	return &announcement, member, true
+}
+
+func GetAnnouncementByID(c *gin.Context) {
+	db := config.GetDB()
+	announcement, member, ok := loadVisibleAnnouncement(c, db)
+	if !ok {
 		return
 	}
 
+	// นับการเปิดอ่านเฉพาะประกาศที่เผยแพร่แล้ว และผู้อ่านอยู่ในกลุ่มเป้าหมาย (ไม่นับแอดมินที่เปิดดูตัวอย่าง)
+	if member.UserID != 0 && announcement.Status == entity.AnnouncementPublished &&
+		services.AudienceMatches(announcement.Audiences, member) {
+		receipt, err := services.MarkAnnouncementViewed(db, announcement.ID, member.UserID, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		announcement.Receipt = receipt
+	}
+
+	services.RenderAnnouncement(announcement)
 	c.JSON(http.StatusOK, announcement)
 }
 
+// AcknowledgeAnnouncement : POST /student/announcements/:id/acknowledge (และ /teacher/...)
+// กดรับทราบประกาศที่กำหนดให้ต้องรับทราบ
+func AcknowledgeAnnouncement(c *gin.Context) {
+	db := config.GetDB()
+	announcement, member, ok := loadVisibleAnnouncement(c, db)
+	if !ok {
+		return
+	}
+	if announcement.Status != entity.AnnouncementPublished || !services.AudienceMatches(announcement.Audiences, member) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "announcement is not addressed to you"})
+		return
+	}
+
+	receipt, err := services.AcknowledgeAnnouncement(db, announcement, member.UserID, time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrAckNotRequired) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": receipt})
+}
+
+// ================= ANALYTICS =================
+
+// loadManagedAnnouncementAudiences is loadManagedAnnouncement with the audiences loaded.
+func loadManagedAnnouncementAudiences(c *gin.Context, db *gorm.DB) (*entity.Announcement, bool) {
+	announcement, _, ok := loadManagedAnnouncement(c, db)
+	if !ok {
+		return nil, false
+	}
+	if err := db.Model(announcement).Association("Audiences").Find(&announcement.Audiences); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return announcement, true
+}
+
+// GetAnnouncementAnalytics : GET /admin/announcements/:id/analytics
+// จำนวนผู้รับ/อ่าน/รับทราบ รวม แยกตามกลุ่มเป้าหมาย และแยกตามบทบาท
+func GetAnnouncementAnalytics(c *gin.Context) {
+	db := config.GetDB()
+	announcement, ok := loadManagedAnnouncementAudiences(c, db)
+	if !ok {
+		return
+	}
+
+	report, err := services.BuildAnnouncementAnalytics(db, announcement, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// RemindAnnouncement : POST /admin/announcements/:id/remind
+// ส่งแจ้งเตือนให้ผู้ที่ยังไม่กดรับทราบทันที ไม่ต้องรอถึงกำหนด
+func RemindAnnouncement(c *gin.Context) {
+	db := config.GetDB()
+	announcement, ok := loadManagedAnnouncementAudiences(c, db)
+	if !ok {
+		return
+	}
+	if announcement.Status != entity.AnnouncementPublished {
+		c.JSON(http.StatusConflict, gin.H{"error": "only published announcements can send reminders"})
+		return
+	}
+
+	sent, err := services.SendAckReminders(db, announcement, time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrAckNotRequired) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"sent": sent}})
+}
+
 // ================= UPDATE =================
 func UpdateAdminAnnouncement(c *gin.Context) {
-	id := c.Param("id")
 	db := config.GetDB()
-
-	var announcement entity.Announcement
-	if err := db.First(&announcement, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "announcement not found"})
+	announcement, user, ok := loadManagedAnnouncement(c, db)
+	if !ok {
 		return
 	}
+	uid := user.ID
 
 	var input CreateAnnouncementInput
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -175,42 +411,33 @@ func UpdateAdminAnnouncement(c *gin.Context) {
 		return
 	}
 
-	// ===== STATUS (อัปเดตเฉพาะถ้าส่งมา) =====
-	if input.Status != "" {
-		switch input.Status {
-		case "PUBLISHED":
-			if announcement.Published_At == nil {
-				now := time.Now()
-				announcement.Status = "PUBLISHED"
-				announcement.Published_At = &now
-				announcement.Scheduled_Publish_At = &now
-			}
-
-		case "SCHEDULED":
-			if input.Scheduled_Publish_At == nil {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"error": "scheduled_publish_at is required for scheduled publish",
-				})
-				return
-			}
-			t, err := time.Parse("2006-01-02T15:04", *input.Scheduled_Publish_At)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid scheduled_publish_at format"})
-				return
-			}
-			announcement.Status = "SCHEDULED"
-			announcement.Scheduled_Publish_At = &t
-			announcement.Published_At = nil
+	var audiences *[]entity.AnnouncementAudience
+	if input.Audiences != nil {
+		normalized, err := services.NormalizeAudiences(db, *input.Audiences)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		audiences = &normalized
+	}
 
-		case "DRAFT":
-			announcement.Status = "DRAFT"
-			announcement.Published_At = nil
-			announcement.Scheduled_Publish_At = nil
+	// ประกาศที่สร้างก่อนมีประวัติ: เก็บสภาพเดิมไว้ก่อน เพื่อให้ย้อนกลับได้
+	if err := services.EnsureAnnouncementBaseline(db, announcement); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-		default:
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+	// ===== STATUS (อัปเดตเฉพาะถ้าส่งมา) =====
+	action := services.AnnouncementRevisionEdit
+	if input.Status != "" {
+		applied, err := applyAnnouncementStatus(announcement, input.Status, input.Scheduled_Publish_At, time.Now())
+		if err != nil {
+			c.JSON(announcementErrorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
+		if applied != "" {
+			action = applied
+		}
 	}
 
 	// ===== FIELD อื่น ๆ (อัปเดตได้เสมอ) =====
@@ -218,35 +445,280 @@ func UpdateAdminAnnouncement(c *gin.Context) {
 		announcement.Title = input.Title
 	}
 
-	if input.Content != "" {
-		announcement.Content = input.Content
+	if input.Content != "" || input.ContentFormat != "" {
+		content, format := announcement.Content, announcement.ContentFormat
+		if input.Content != "" {
+			content = input.Content
+		}
+		if input.ContentFormat != "" {
+			format = input.ContentFormat
+		}
+		format, content, err := services.PrepareAnnouncementContent(format, content)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		announcement.Content, announcement.ContentFormat = content, format
 	}
 
 	if input.Is_Pinned != nil {
 		announcement.Is_Pinned = input.Is_Pinned
 	}
 
+	if input.Expires_At != nil {
+		expiresAt, err := parseAnnouncementTime(*input.Expires_At)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expires_at format"})
+			return
+		}
+		announcement.Expires_At = expiresAt
+	}
+
 	announcement.Send_Notification = input.Send_Notification
 
+	if input.RequiresAck != nil {
+		announcement.RequiresAck = *input.RequiresAck
+	}
+	if input.AckDeadline != nil {
+		ackDeadline, err := parseAnnouncementTime(*input.AckDeadline)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ack_deadline format"})
+			return
+		}
+		if !equalTime(announcement.AckDeadline, ackDeadline) {
+			announcement.AckReminderSentAt = nil // กำหนดใหม่ เตือนใหม่ได้
+		}
+		announcement.AckDeadline = ackDeadline
+	}
+	if announcement.AckDeadline != nil && !announcement.RequiresAck {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ack_deadline needs requires_ack"})
+		return
+	}
+
 	if input.CetagoryID != 0 {
 		announcement.CetagoryID = input.CetagoryID
 	}
 
-	if err := db.Save(&announcement).Error; err != nil {
+	if _, err := services.SaveAnnouncement(db, announcement, audiences, action, &uid); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if action == services.AnnouncementActionPublish && announcement.Send_Notification {
+		notifyAudienceAsync(db, *announcement)
+	}
+
+	services.RenderAnnouncement(announcement)
 	c.JSON(http.StatusOK, announcement)
 }
 
+// announcementAction handles POST /admin/announcements/:id/<action>:
+//
+//	publish                         draft/scheduled -> published
+//	schedule (scheduled_publish_at) draft/scheduled -> scheduled
+//	unpublish                       scheduled/published/expired -> draft
+//	archive                         -> archived
+//	unarchive                       archived -> draft
+func announcementAction(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := config.GetDB()
+		announcement, user, ok := loadManagedAnnouncement(c, db)
+		if !ok {
+			return
+		}
+		uid := user.ID
+
+		var payload struct {
+			Scheduled_Publish_At string `json:"scheduled_publish_at"`
+		}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&payload); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		at, err := parseAnnouncementTime(payload.Scheduled_Publish_At)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid scheduled_publish_at format"})
+			return
+		}
+
+		if err := services.EnsureAnnouncementBaseline(db, announcement); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := services.TransitionAnnouncement(announcement, action, at, time.Now()); err != nil {
+			c.JSON(announcementErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		if _, err := services.SaveAnnouncement(db, announcement, nil, action, &uid); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if action == services.AnnouncementActionPublish && announcement.Send_Notification {
+			notifyAudienceAsync(db, *announcement)
+		}
+
+		services.RenderAnnouncement(announcement)
+		c.JSON(http.StatusOK, gin.H{"data": announcement})
+	}
+}
+
+var (
+	PublishAnnouncement   = announcementAction(services.AnnouncementActionPublish)
+	ScheduleAnnouncement  = announcementAction(services.AnnouncementActionSchedule)
+	UnpublishAnnouncement = announcementAction(services.AnnouncementActionUnpublish)
+	ArchiveAnnouncement   = announcementAction(services.AnnouncementActionArchive)
+	UnarchiveAnnouncement = announcementAction(services.AnnouncementActionUnarchive)
+)
+
+// ================= REVISIONS =================
+
+// ListAnnouncementRevisions : GET /admin/announcements/:id/revisions (ล่าสุดก่อน)
+func ListAnnouncementRevisions(c *gin.Context) {
+	db := config.GetDB()
+	announcement, _, ok := loadManagedAnnouncement(c, db)
+	if !ok {
+		return
+	}
+	var revisions []entity.AnnouncementRevision
+	if err := db.Preload("Editor").
+		Where("announcement_id = ?", announcement.ID).
+		Order("revision desc").
+		Find(&revisions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": revisions})
+}
+
+// GetAnnouncementRevision : GET /admin/announcements/:id/revisions/:revision
+func GetAnnouncementRevision(c *gin.Context) {
+	db := config.GetDB()
+	announcement, _, ok := loadManagedAnnouncement(c, db)
+	if !ok {
+		return
+	}
+	var revision entity.AnnouncementRevision
+	if err := db.Preload("Editor").
+		Where("announcement_id = ? AND revision = ?", announcement.ID, c.Param("revision")).
+		First(&revision).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "revision not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": revision})
+}
+
+// RestoreAnnouncementRevision : POST /admin/announcements/:id/revisions/:revision/restore
+// นำเนื้อหาของรุ่นเก่ากลับมาเป็นรุ่นใหม่ สถานะไม่เปลี่ยน
+func RestoreAnnouncementRevision(c *gin.Context) {
+	db := config.GetDB()
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid revision"})
+		return
+	}
+
+	announcement, user, ok := loadManagedAnnouncement(c, db)
+	if !ok {
+		return
+	}
+
+	restored, err := services.RestoreAnnouncementRevision(db, announcement, revision, user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "revision not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	services.RenderAnnouncement(announcement)
+	c.JSON(http.StatusOK, gin.H{"data": announcement, "revision": restored})
+}
+
+
+// ================= INLINE IMAGES =================
+
+// UploadAnnouncementImage : POST /admin/announcements/images (multipart: file, alt)
+// อัปโหลดรูปสำหรับแทรกในเนื้อหา คืน URL พร้อม Markdown/HTML ที่นำไปวางได้ทันที
+func UploadAnnouncementImage(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > MaxFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File size must not exceed 5MB"})
+		return
+	}
+	if !AllowedImageExtensions[strings.ToLower(filepath.Ext(header.Filename))] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only image files (JPG, PNG, GIF, WEBP, BMP) are allowed"})
+		return
+	}
+
+	// ดูจากเนื้อไฟล์จริง ไม่เชื่อนามสกุลหรือ Content-Type ที่ส่งมา
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	contentType := http.DetectContentType(head[:n])
+	if !strings.HasPrefix(contentType, "image/") || !AllowedMimeTypes[contentType] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File content is not a supported image"})
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if services.AzureStorage == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Storage service not initialized"})
+		return
+	}
+	uid, err := getAuthUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	scan, err := services.StoreScannedUpload(c.Request.Context(), config.GetDB(), services.AzureStorage, services.UploadMeta{
+		FileName: header.Filename, ContentType: contentType, Size: header.Size,
+		Purpose: services.UploadPurposeAnnouncementImage, UserID: &uid,
+	}, file)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+	url := scan.FileURL
+
+	alt := strings.TrimSpace(c.PostForm("alt"))
+	if alt == "" {
+		alt = strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"url":      url,
+		"markdown": "![" + strings.NewReplacer("[", `\[`, "]", `\]`).Replace(alt) + "](" + url + ")",
+		"html":     services.SanitizeHTML(`<img src="` + html.EscapeString(url) + `" alt="` + html.EscapeString(alt) + `">`),
+	})
+}
+
 
 // ================= DELETE =================
 func DeleteAnnouncement(c *gin.Context) {
-	id := c.Param("id")
 	db := config.GetDB()
+	announcement, _, ok := loadManagedAnnouncement(c, db)
+	if !ok {
+		return
+	}
 
-	if err := db.Delete(&entity.Announcement{}, id).Error; err != nil {
+	// ลบไฟล์แนบและไฟล์ใน storage ไปพร้อมกัน
+	if err := services.DeleteAnnouncementWithAttachments(db, attachmentStore(), announcement.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "announcement not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,537 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ApplicationController struct {
+	DB *gorm.DB
+}
+
+// submission status ที่ตามสถานะการตัดสินของใบสมัคร
+var decisionSubmissionStatus = map[string]string{
+	entity.ApplicationStatusUnderReview: "under_review",
+	entity.ApplicationStatusAccepted:    "approved",
+	entity.ApplicationStatusRejected:    "rejected",
+}
+
+var errQuotaFull = errors.New("the quota of this curriculum is full")
+
+// ===================== Student =====================
+
+// Create opens a draft application to a curriculum with one of the student's portfolios.
+// POST /api/applications  { "curriculum_id": 1, "portfolio_id": 2 }
+func (c *ApplicationController) Create(ctx *gin.Context) {
+	userID, err := getAuthUserID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var payload struct {
+		CurriculumID uint `json:"curriculum_id" binding:"required"`
+		PortfolioID  uint `json:"portfolio_id" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var portfolio entity.Portfolio
+	if err := c.DB.Where("id = ? AND user_id = ?", payload.PortfolioID, userID).First(&portfolio).Error; err != nil {
+		handleDBError(ctx, err, "portfolio not found")
+		return
+	}
+
+	app := entity.Application{
+		Status:       entity.ApplicationStatusDraft,
+		UserID:       userID,
+		CurriculumID: payload.CurriculumID,
+		PortfolioID:  portfolio.ID,
+	}
+	if ok, err := govalidator.ValidateStruct(&app); !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	issues, err := services.CreateApplication(c.DB, &app, time.Now())
+	switch {
+	case errors.Is(err, services.ErrApplicationExists):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrApplicationNotEligible):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "cannot apply to this curriculum", "issues": issues})
+		return
+	case err != nil:
+		handleDBError(ctx, err, "curriculum not found")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": app})
+}
+
+// ListMine returns the caller's applications, newest first.
+func (c *ApplicationController) ListMine(ctx *gin.Context) {
+	userID, err := getAuthUserID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var apps []entity.Application
+	if err := c.DB.
+		Preload("Curriculum.Program").
+		Preload("Curriculum.Faculty").
+		Preload("ApplicationRound").
+		Preload("Portfolio").
+		Preload("Documents.DocumentType").
+		Where("user_id = ?", userID).
+		Order("created_at desc").
+		Find(&apps).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": apps})
+}
+
+// Get returns one application to its owner or to a reviewer.
+func (c *ApplicationController) Get(ctx *gin.Context) {
+	app, _, ok := c.loadApplication(ctx, false)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": app})
+}
+
+// Check runs the submit checks without submitting, so the UI can show what is missing.
+func (c *ApplicationController) Check(ctx *gin.Context) {
+	app, _, ok := c.loadApplication(ctx, false)
+	if !ok {
+		return
+	}
+
+	issues, err := services.CheckApplication(c.DB, app, time.Now())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": gin.H{"eligible": len(issues) == 0, "issues": issues}})
+}
+
+// Checklist returns which required documents of the application are satisfied.
+// GET /api/applications/:id/checklist
+func (c *ApplicationController) Checklist(ctx *gin.Context) {
+	app, _, ok := c.loadApplication(ctx, false)
+	if !ok {
+		return
+	}
+
+	checklist, err := services.BuildDocumentChecklist(c.DB, app.UserID, app.CurriculumID, app)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": checklist})
+}
+
+// ChecklistForCurriculum computes the document checklist of a student for a curriculum,
+// also before an application exists. Reviewers may pass ?user_id= to check another student.
+// GET /api/applications/checklist?curriculum_id=1[&user_id=2]
+func (c *ApplicationController) ChecklistForCurriculum(ctx *gin.Context) {
+	user, err := getAuthUser(ctx, c.DB)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	curriculumID, err := parseUintParam(ctx.Query("curriculum_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "curriculum_id is required"})
+		return
+	}
+	var curriculum entity.Curriculum
+	if err := c.DB.First(&curriculum, curriculumID).Error; err != nil {
+		handleDBError(ctx, err, "curriculum not found")
+		return
+	}
+
+	studentID := user.ID
+	if raw := ctx.Query("user_id"); raw != "" {
+		id, err := parseUintParam(raw)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+		if id != user.ID && !isReviewer(user) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "not allowed to view another student's documents"})
+			return
+		}
+		studentID = id
+	}
+
+	checklist, err := services.BuildDocumentChecklist(c.DB, studentID, curriculum.ID, nil)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": checklist})
+}
+
+// AddDocument attaches a file uploaded by the student (see /upload, which returns upload_id)
+// to a draft application. Only the student's own uploads that passed the virus scan are accepted.
+// POST /api/applications/:id/documents  { "document_type_id": 1, "upload_id": 5 }
+func (c *ApplicationController) AddDocument(ctx *gin.Context) {
+	app, ok := c.loadEditableApplication(ctx)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		DocumentTypeID uint `json:"document_type_id" binding:"required"`
+		UploadID       uint `json:"upload_id" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var required int64
+	c.DB.Model(&entity.CurriculumRequiredDocument{}).
+		Where("curriculum_id = ? AND document_type_id = ?", app.CurriculumID, payload.DocumentTypeID).
+		Count(&required)
+	if required == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "document type is not requested by this curriculum"})
+		return
+	}
+
+	upload, err := services.FindUserUpload(c.DB, payload.UploadID, app.UserID)
+	if errors.Is(err, services.ErrUploadNotUsable) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	doc := entity.ApplicationDocument{
+		ApplicationID:  app.ID,
+		DocumentTypeID: payload.DocumentTypeID,
+		FileName:       upload.FileName,
+		FilePath:       upload.FileURL,
+		UploadScanID:   &upload.ID,
+	}
+	if ok, err := govalidator.ValidateStruct(&doc); !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := c.DB.Create(&doc).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.DB.Preload("DocumentType").First(&doc, doc.ID)
+	ctx.JSON(http.StatusCreated, gin.H{"data": doc})
+}
+
+// RemoveDocument deletes a file from a draft application.
+func (c *ApplicationController) RemoveDocument(ctx *gin.Context) {
+	app, ok := c.loadEditableApplication(ctx)
+	if !ok {
+		return
+	}
+
+	result := c.DB.Where("id = ? AND application_id = ?", ctx.Param("docId"), app.ID).Delete(&entity.ApplicationDocument{})
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// Submit checks the application and freezes the portfolio into a submission for the curriculum.
+func (c *ApplicationController) Submit(ctx *gin.Context) {
+	app, ok := c.loadEditableApplication(ctx)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	issues, err := services.SubmitApplication(c.DB, app, now)
+	if errors.Is(err, services.ErrApplicationNotEligible) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "issues": issues})
+		return
+	}
+	if errors.Is(err, services.ErrApplicationSubmitted) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": app})
+}
+
+// Withdraw cancels an application that has not been decided yet.
+func (c *ApplicationController) Withdraw(ctx *gin.Context) {
+	app, user, ok := c.loadApplication(ctx, false)
+	if !ok {
+		return
+	}
+	if app.UserID != user.ID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only the applicant can withdraw this application"})
+		return
+	}
+
+	switch app.Status {
+	case entity.ApplicationStatusDraft, entity.ApplicationStatusSubmitted, entity.ApplicationStatusUnderReview:
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "application can no longer be withdrawn"})
+		return
+	}
+
+	app.Status = entity.ApplicationStatusWithdrawn
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(app).Update("status", app.Status).Error; err != nil {
+			return err
+		}
+		if app.PortfolioSubmissionID != nil {
+			return tx.Model(&entity.PortfolioSubmission{}).
+				Where("id = ?", *app.PortfolioSubmissionID).
+				Update("status", entity.SubmissionStatusWithdrawn).Error
+		}
+		return nil
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if app.PortfolioSubmission != nil {
+		app.PortfolioSubmission.Status = entity.SubmissionStatusWithdrawn
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": app})
+}
+
+// ===================== Reviewer =====================
+
+type applicationGroup struct {
+	Curriculum *entity.Curriculum   `json:"curriculum"`
+	Quota      int                  `json:"quota"`
+	Accepted   int                  `json:"accepted"`
+	Counts     map[string]int       `json:"counts"`
+	Items      []entity.Application `json:"applications"`
+}
+
+// ListForReview returns submitted applications grouped by curriculum.
+// Query: ?curriculum_id=, ?status= (drafts are never listed)
+func (c *ApplicationController) ListForReview(ctx *gin.Context) {
+	if _, ok := c.requireReviewer(ctx); !ok {
+		return
+	}
+
+	query := c.DB.
+		Preload("User").
+		Preload("Curriculum.Program").
+		Preload("Portfolio").
+		Preload("PortfolioSubmission").
+		Preload("Documents.DocumentType").
+		Joins("JOIN curriculums ON curriculums.id = applications.curriculum_id AND curriculums.deleted_at IS NULL").
+		Where("applications.status <> ?", entity.ApplicationStatusDraft)
+
+	if curriculumID := ctx.Query("curriculum_id"); curriculumID != "" {
+		query = query.Where("applications.curriculum_id = ?", curriculumID)
+	}
+	if status := ctx.Query("status"); status != "" {
+		query = query.Where("applications.status = ?", status)
+	}
+
+	var apps []entity.Application
+	if err := query.Order("curriculums.code asc, applications.submitted_at asc").Find(&apps).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	groups := []*applicationGroup{}
+	byCurriculum := map[uint]*applicationGroup{}
+	for _, app := range apps {
+		group, ok := byCurriculum[app.CurriculumID]
+		if !ok {
+			group = &applicationGroup{Curriculum: app.Curriculum, Counts: map[string]int{}}
+			if app.Curriculum != nil {
+				group.Quota = app.Curriculum.Quota
+			}
+			byCurriculum[app.CurriculumID] = group
+			groups = append(groups, group)
+		}
+		group.Counts[app.Status]++
+		if app.Status == entity.ApplicationStatusAccepted {
+			group.Accepted++
+		}
+		app.Curriculum = nil
+		group.Items = append(group.Items, app)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": groups})
+}
+
+// Decide moves a submitted application to under_review, accepted or rejected.
+// PATCH /api/applications/:id/decision  { "status": "accepted", "note": "..." }
+func (c *ApplicationController) Decide(ctx *gin.Context) {
+	user, ok := c.requireReviewer(ctx)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Status string `json:"status" binding:"required"`
+		Note   string `json:"note"`
+	}
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	submissionStatus, ok := decisionSubmissionStatus[payload.Status]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "status must be under_review, accepted or rejected"})
+		return
+	}
+
+	var app entity.Application
+	if err := c.DB.Preload("Curriculum").First(&app, ctx.Param("id")).Error; err != nil {
+		handleDBError(ctx, err, "application not found")
+		return
+	}
+	if app.Status != entity.ApplicationStatusSubmitted && app.Status != entity.ApplicationStatusUnderReview {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "only submitted applications can be decided"})
+		return
+	}
+
+	previousStatus := app.Status
+	now := time.Now()
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if payload.Status == entity.ApplicationStatusAccepted {
+			// ล็อกแถวหลักสูตรไว้ ให้การรับเข้าของหลักสูตรเดียวกันทำทีละรายการ ไม่ให้เกินโควตา
+			var curriculum entity.Curriculum
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Select("id, quota").
+				First(&curriculum, app.CurriculumID).Error; err != nil {
+				return err
+			}
+			if curriculum.Quota > 0 {
+				accepted, err := services.CountAcceptedApplications(tx, app.CurriculumID)
+				if err != nil {
+					return err
+				}
+				if accepted >= int64(curriculum.Quota) {
+					return errQuotaFull
+				}
+			}
+		}
+
+		app.Status = payload.Status
+		updates := map[string]interface{}{"status": app.Status}
+		if payload.Status != entity.ApplicationStatusUnderReview {
+			app.DecidedAt = &now
+			app.DecidedByID = &user.ID
+			app.DecisionNote = strings.TrimSpace(payload.Note)
+			updates["decided_at"] = app.DecidedAt
+			updates["decided_by_id"] = app.DecidedByID
+			updates["decision_note"] = app.DecisionNote
+		}
+		if err := tx.Model(&app).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if app.PortfolioSubmissionID != nil {
+			if err := tx.Model(&entity.PortfolioSubmission{}).
+				Where("id = ?", *app.PortfolioSubmissionID).
+				Updates(map[string]interface{}{"status": submissionStatus, "reviewed_at": now}).Error; err != nil {
+				return err
+			}
+		}
+		// แจ้งผู้สมัครใน transaction เดียวกัน ผลการพิจารณาไม่บันทึกโดยไม่มีการแจ้ง
+		if app.Status != previousStatus {
+			return services.NotifyApplicationStatusChanged(tx, &app, now)
+		}
+		return nil
+	})
+	if errors.Is(err, errQuotaFull) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.DB.Preload("User").Preload("DecidedBy").First(&app, app.ID)
+	ctx.JSON(http.StatusOK, gin.H{"data": app})
+}
+
+// ===================== Helpers =====================
+
+func (c *ApplicationController) requireReviewer(ctx *gin.Context) (*entity.User, bool) {
+	user, err := getAuthUser(ctx, c.DB)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return nil, false
+	}
+	if !isReviewer(user) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only teachers and admins can review applications"})
+		return nil, false
+	}
+	return user, true
+}
+
+// loadApplication loads :id and checks the caller is the applicant or a reviewer.
+func (c *ApplicationController) loadApplication(ctx *gin.Context, ownerOnly bool) (*entity.Application, *entity.User, bool) {
+	user, err := getAuthUser(ctx, c.DB)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return nil, nil, false
+	}
+
+	var app entity.Application
+	if err := c.DB.
+		Preload("Curriculum").
+		Preload("Portfolio").
+		Preload("PortfolioSubmission").
+		Preload("Documents.DocumentType").
+		First(&app, ctx.Param("id")).Error; err != nil {
+		handleDBError(ctx, err, "application not found")
+		return nil, nil, false
+	}
+
+	if app.UserID != user.ID && (ownerOnly || !isReviewer(user)) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "not allowed to access this application"})
+		return nil, nil, false
+	}
+	return &app, user, true
+}
+
+// loadEditableApplication loads the caller's own application and requires it to be a draft.
+func (c *ApplicationController) loadEditableApplication(ctx *gin.Context) (*entity.Application, bool) {
+	app, _, ok := c.loadApplication(ctx, true)
+	if !ok {
+		return nil, false
+	}
+	if !app.IsEditable() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "application has already been submitted"})
+		return nil, false
+	}
+	return app, true
+}
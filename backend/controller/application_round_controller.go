@@ -0,0 +1,249 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm"
+)
+
+// ApplicationRoundPayload รับเวลาเป็น RFC3339 หรือเวลาท้องถิ่น ("2006-01-02T15:04") ตาม timezone ของรอบ
+type ApplicationRoundPayload struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	OpensAt     string `json:"opens_at"`
+	ClosesAt    string `json:"closes_at"`
+	AnnouncesAt string `json:"announces_at"`
+	Timezone    string `json:"timezone"`
+}
+
+func orderRounds(db *gorm.DB) *gorm.DB {
+	return db.Order("opens_at asc")
+}
+
+func (p *ApplicationRoundPayload) toRound(curriculumID uint) (entity.ApplicationRound, error) {
+	round := entity.ApplicationRound{
+		Name:         strings.TrimSpace(p.Name),
+		Timezone:     strings.TrimSpace(p.Timezone),
+		CurriculumID: curriculumID,
+	}
+	round.ID = p.ID
+	if round.Name == "" || len([]rune(round.Name)) > 100 {
+		return round, errors.New("round name is required and must not exceed 100 characters")
+	}
+	if round.Timezone == "" {
+		round.Timezone = entity.DefaultRoundTimezone
+	}
+	loc := round.Location()
+
+	var err error
+	if round.OpensAt, err = entity.ParseRoundTime(p.OpensAt, loc); err != nil {
+		return round, fmt.Errorf("opens_at: %w", err)
+	}
+	if round.ClosesAt, err = entity.ParseRoundTime(p.ClosesAt, loc); err != nil {
+		return round, fmt.Errorf("closes_at: %w", err)
+	}
+	if strings.TrimSpace(p.AnnouncesAt) != "" {
+		announces, err := entity.ParseRoundTime(p.AnnouncesAt, loc)
+		if err != nil {
+			return round, fmt.Errorf("announces_at: %w", err)
+		}
+		round.AnnouncesAt = &announces
+	}
+
+	return round, round.Validate()
+}
+
+// buildRounds turns the payload rounds into entities. Without rounds the legacy
+// application_period string becomes a single round.
+func (p *CurriculumPayload) buildRounds(curriculumID uint) ([]entity.ApplicationRound, error) {
+	if p.Rounds == nil {
+		if strings.TrimSpace(p.ApplicationPeriod) == "" {
+			return []entity.ApplicationRound{}, nil
+		}
+		loc := entity.LoadRoundLocation(entity.DefaultRoundTimezone)
+		opens, closes, err := entity.ParseLegacyApplicationPeriod(p.ApplicationPeriod, loc)
+		if err != nil {
+			return nil, errors.New("application_period must be \"start|end\"")
+		}
+		round := entity.ApplicationRound{
+			Name:         "รอบที่ 1",
+			OpensAt:      opens,
+			ClosesAt:     closes,
+			Timezone:     entity.DefaultRoundTimezone,
+			CurriculumID: curriculumID,
+		}
+		if err := round.Validate(); err != nil {
+			return nil, err
+		}
+		return []entity.ApplicationRound{round}, nil
+	}
+
+	rounds := make([]entity.ApplicationRound, 0, len(p.Rounds))
+	for i := range p.Rounds {
+		round, err := p.Rounds[i].toRound(curriculumID)
+		if err != nil {
+			return nil, fmt.Errorf("rounds[%d]: %w", i, err)
+		}
+		rounds = append(rounds, round)
+	}
+	return rounds, nil
+}
+
+// legacyPeriodChanged reports whether an update without rounds changes the curriculum's
+// only round through application_period. A blank period leaves the rounds alone.
+func (p *CurriculumPayload) legacyPeriodChanged(current []entity.ApplicationRound) bool {
+	if p.Rounds != nil || strings.TrimSpace(p.ApplicationPeriod) == "" || len(current) > 1 {
+		return false
+	}
+	if len(current) == 0 {
+		return true
+	}
+	opens, closes, err := entity.ParseLegacyApplicationPeriod(p.ApplicationPeriod, entity.LoadRoundLocation(entity.DefaultRoundTimezone))
+	if err != nil {
+		// buildRounds reports the error
+		return true
+	}
+	return !opens.Equal(current[0].OpensAt) || !closes.Equal(current[0].ClosesAt)
+}
+
+// replaceCurriculumRounds makes rounds the complete set of rounds of the curriculum:
+// rounds with a known ID are updated, new ones created and the rest removed.
+func replaceCurriculumRounds(tx *gorm.DB, curriculumID uint, rounds []entity.ApplicationRound) error {
+	keep := []uint{0}
+	for i := range rounds {
+		rounds[i].CurriculumID = curriculumID
+		if rounds[i].ID != 0 {
+			var existing entity.ApplicationRound
+			if err := tx.Where("id = ? AND curriculum_id = ?", rounds[i].ID, curriculumID).First(&existing).Error; err != nil {
+				rounds[i].ID = 0
+			} else {
+				rounds[i].CreatedAt = existing.CreatedAt
+			}
+		}
+		if err := tx.Save(&rounds[i]).Error; err != nil {
+			return err
+		}
+		keep = append(keep, rounds[i].ID)
+	}
+	return tx.Where("curriculum_id = ? AND id NOT IN ?", curriculumID, keep).Delete(&entity.ApplicationRound{}).Error
+}
+
+// refreshCurriculumStatus recomputes and stores the status after its rounds changed.
+func (cc *CurriculumController) refreshCurriculumStatus(curriculumID uint) {
+	var cur entity.Curriculum
+	if err := cc.db.Preload("Rounds").First(&cur, curriculumID).Error; err != nil {
+		return
+	}
+	cc.db.Model(&cur).Update("status", services.ComputeCurriculumStatus(&cur, time.Now()))
+}
+
+// -------------------- HANDLERS (Rounds) --------------------
+
+// GET /curricula/:id/rounds
+func (cc *CurriculumController) ListCurriculumRounds(c *gin.Context) {
+	var cur entity.Curriculum
+	if err := cc.db.Preload("Rounds", orderRounds).First(&cur, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "curriculum not found"})
+		return
+	}
+
+	now := time.Now()
+	type roundView struct {
+		entity.ApplicationRound
+		Status string `json:"status"`
+	}
+	views := make([]roundView, 0, len(cur.Rounds))
+	for _, r := range cur.Rounds {
+		views = append(views, roundView{ApplicationRound: r, Status: r.StatusAt(now)})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": views, "status": services.ComputeCurriculumStatus(&cur, now)})
+}
+
+// POST /admin/curricula/:id/rounds
+func (cc *CurriculumController) CreateCurriculumRound(c *gin.Context) {
+	defer cc.trackCurriculumChange(c, "added round")()
+
+	var cur entity.Curriculum
+	if err := cc.db.First(&cur, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "curriculum not found"})
+		return
+	}
+
+	var payload ApplicationRoundPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	payload.ID = 0
+	round, err := payload.toRound(cur.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := cc.db.Create(&round).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	cc.refreshCurriculumStatus(cur.ID)
+	c.JSON(http.StatusCreated, gin.H{"data": round})
+}
+
+// PUT /admin/curricula/:id/rounds/:roundId
+func (cc *CurriculumController) UpdateCurriculumRound(c *gin.Context) {
+	defer cc.trackCurriculumChange(c, "updated round")()
+
+	var existing entity.ApplicationRound
+	if err := cc.db.Where("id = ? AND curriculum_id = ?", c.Param("roundId"), c.Param("id")).First(&existing).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "round not found"})
+		return
+	}
+
+	var payload ApplicationRoundPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	payload.ID = existing.ID
+	round, err := payload.toRound(existing.CurriculumID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	round.CreatedAt = existing.CreatedAt
+
+	if err := cc.db.Save(&round).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	cc.refreshCurriculumStatus(existing.CurriculumID)
+	c.JSON(http.StatusOK, gin.H{"data": round})
+}
+
+// DELETE /admin/curricula/:id/rounds/:roundId
+func (cc *CurriculumController) DeleteCurriculumRound(c *gin.Context) {
+	defer cc.trackCurriculumChange(c, "removed round")()
+
+	result := cc.db.Where("id = ? AND curriculum_id = ?", c.Param("roundId"), c.Param("id")).Delete(&entity.ApplicationRound{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "round not found"})
+		return
+	}
+
+	if curriculumID, err := parseUintParam(c.Param("id")); err == nil {
+		cc.refreshCurriculumStatus(curriculumID)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": true})
+}
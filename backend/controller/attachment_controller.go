@@ -1,124 +1,154 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
-	
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sut68/team14/backend/config"
 	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm"
 )
 
-func CreateAttachment(c *gin.Context) {
-	db := config.GetDB()
-
-	var input struct {
-		File_name      string `json:"file_name"`
-		File_path      string `json:"file_path"`
-		AnnouncementID uint   `json:"announcement_id"`
-		CetagoryID     uint   `json:"cetagory_id"`
+// attachmentStore is the storage attachments go to, or nil when it is not configured.
+func attachmentStore() services.FileStore {
+	if services.AzureStorage == nil {
+		return nil
 	}
+	return services.AzureStorage
+}
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+// loadManagedAnnouncement loads announcement :id for a caller who may manage it (its
+// attachments, status, revisions, reminders and analytics): its author or an admin. It
+// writes the error response itself.
+func loadManagedAnnouncement(c *gin.Context, db *gorm.DB) (*entity.Announcement, *entity.User, bool) {
+	user, err := getAuthUser(c, db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return nil, nil, false
+	}
+	var announcement entity.Announcement
+	if err := db.First(&announcement, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "announcement not found"})
+		return nil, nil, false
 	}
+	if announcement.UserID != user.ID && !isAdmin(user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you cannot manage this announcement"})
+		return nil, nil, false
+	}
+	return &announcement, user, true
+}
 
-	// ✅ validate ที่จำเป็น
-	if input.File_name == "" || input.File_path == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "file_name and file_path are required",
-		})
+// UploadAnnouncementAttachment : POST /admin/announcements/:id/attachments (multipart: file)
+func UploadAnnouncementAttachment(c *gin.Context) {
+	db := config.GetDB()
+	announcement, user, ok := loadManagedAnnouncement(c, db)
+	if !ok {
 		return
 	}
 
-	if input.AnnouncementID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "announcement_id is required",
-		})
+	// กันไฟล์ใหญ่เกินก่อนอ่าน body ทั้งหมด (เผื่อพื้นที่ให้ส่วนหัวของ multipart)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxAttachmentSize+1024*1024)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required (max 10MB)"})
 		return
 	}
+	defer file.Close()
 
-	if input.CetagoryID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "cetagory_id is required",
-		})
+	store := attachmentStore()
+	if store == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Storage service not initialized"})
 		return
 	}
 
-	// ✅ check category
-	var category entity.Cetagory
-	if err := db.First(&category, input.CetagoryID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Category not found",
-		})
+	att, err := services.StoreAnnouncementAttachment(c.Request.Context(), db, store, announcement.ID,
+		header.Filename, header.Size, file, user.ID, time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAttachment) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		respondUploadError(c, err)
 		return
 	}
 
-	att := entity.Announcement_Attachment{
-		File_name:      input.File_name,
-		File_path:      input.File_path,
-		AnnouncementID: input.AnnouncementID,
-		CetagoryID:     input.CetagoryID,
-	}
+	atts := []entity.Announcement_Attachment{*att}
+	services.SetAttachmentDownloadURLs(atts)
+	c.JSON(http.StatusCreated, gin.H{"data": atts[0]})
+}
 
-	if err := db.Create(&att).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+// ListAnnouncementAttachments : GET /announcements/:id/attachments
+// เห็นไฟล์แนบเฉพาะประกาศที่ผู้เรียกเห็นได้
+func ListAnnouncementAttachments(c *gin.Context) {
+	db := config.GetDB()
+	announcement, _, ok := loadVisibleAnnouncement(c, db)
+	if !ok {
 		return
 	}
-
-	db.Preload("Announcement").Preload("Cetagory").First(&att, att.ID)
-	c.JSON(http.StatusOK, att)
+	listAnnouncementAttachments(c, db, announcement)
 }
 
-
-
-func GetAttachments(c *gin.Context) {
+// ListManagedAnnouncementAttachments : GET /admin/announcements/:id/attachments
+// เฉพาะผู้เขียนหรือแอดมิน เห็นไฟล์แนบของฉบับร่าง/ที่ถูกเก็บแล้วด้วย
+func ListManagedAnnouncementAttachments(c *gin.Context) {
 	db := config.GetDB()
-	var atts []entity.Announcement_Attachment
-	if err := db.Preload("Announcement").Preload("Cetagory").Find(&atts).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch"})
+	announcement, _, ok := loadManagedAnnouncement(c, db)
+	if !ok {
 		return
 	}
-	c.JSON(http.StatusOK, atts)
+	listAnnouncementAttachments(c, db, announcement)
 }
 
-func GetAttachmentsByAnnouncementID(c *gin.Context) {
-	announcementID := c.Param("announcement_id")
-	db := config.GetDB()
-	
-	var attachments []entity.Announcement_Attachment
-	if err := db.Where("announcement_id = ?", announcementID).Find(&attachments).Error; err != nil {
+func listAnnouncementAttachments(c *gin.Context, db *gorm.DB, announcement *entity.Announcement) {
+	var atts []entity.Announcement_Attachment
+	if err := db.Where("announcement_id = ?", announcement.ID).Order("id asc").Find(&atts).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachments"})
 		return
 	}
-	
-	c.JSON(http.StatusOK, attachments)
+	services.SetAttachmentDownloadURLs(atts)
+	c.JSON(http.StatusOK, atts)
 }
 
-func UpdateAttachment(c *gin.Context) {
-	id := c.Param("id")
+// DownloadAnnouncementAttachment : GET /announcements/:id/attachments/:attachment_id/download
+// นับจำนวนดาวน์โหลด แล้วส่งต่อไปยังไฟล์ใน storage
+func DownloadAnnouncementAttachment(c *gin.Context) {
 	db := config.GetDB()
+	announcement, _, ok := loadVisibleAnnouncement(c, db)
+	if !ok {
+		return
+	}
+
 	var att entity.Announcement_Attachment
-	if err := db.First(&att, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	if err := db.Where("announcement_id = ?", announcement.ID).First(&att, c.Param("attachment_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
 		return
 	}
-	if err := c.ShouldBindJSON(&att); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := services.RecordAttachmentDownload(db, &att); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	db.Save(&att)
-	c.JSON(http.StatusOK, att)
+	c.Redirect(http.StatusFound, att.File_path)
 }
 
-func DeleteAttachment(c *gin.Context) {
-	id := c.Param("id")
+// DeleteAnnouncementAttachment : DELETE /admin/announcements/:id/attachments/:attachment_id
+func DeleteAnnouncementAttachment(c *gin.Context) {
 	db := config.GetDB()
-	if err := db.Delete(&entity.Announcement_Attachment{}, id).Error; err != nil {
+	announcement, _, ok := loadManagedAnnouncement(c, db)
+	if !ok {
+		return
+	}
+
+	var att entity.Announcement_Attachment
+	if err := db.Where("announcement_id = ?", announcement.ID).First(&att, c.Param("attachment_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+		return
+	}
+	if err := services.DeleteAnnouncementAttachment(db, attachmentStore(), &att); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Deleted successfully"})
-}
\ No newline at end of file
+}
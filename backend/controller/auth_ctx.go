@@ -2,8 +2,11 @@ package controller
 
 import (
 	"errors"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
 )
 
 func getAuthUserID(ctx *gin.Context) (uint, error) {
@@ -25,3 +28,51 @@ func getAuthUserID(ctx *gin.Context) (uint, error) {
 
 	return 0, errors.New("invalid auth user_id in context")
 }
+
+// getAuthUser loads the authenticated user so handlers can check the account type.
+func getAuthUser(ctx *gin.Context, db *gorm.DB) (*entity.User, error) {
+	userID, err := getAuthUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var user entity.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// isReviewer reports whether the user may review portfolios (teacher or admin).
+func isReviewer(u *entity.User) bool {
+	return u != nil && (u.AccountTypeID == entity.UserTypeTeacher || u.AccountTypeID == entity.UserTypeAdmin)
+}
+
+func isAdmin(u *entity.User) bool {
+	return u != nil && u.AccountTypeID == entity.UserTypeAdmin
+}
+
+// studentIDFromQuery returns whose data to show: the caller, or ?user_id= when the caller is a
+// reviewer. It writes the error response itself and returns false on failure.
+func studentIDFromQuery(c *gin.Context, db *gorm.DB) (uint, bool) {
+	user, err := getAuthUser(c, db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return 0, false
+	}
+
+	raw := c.Query("user_id")
+	if raw == "" {
+		return user.ID, true
+	}
+	id, err := parseUintParam(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+		return 0, false
+	}
+	if id != user.ID && !isReviewer(user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to view another student's data"})
+		return 0, false
+	}
+	return id, true
+}
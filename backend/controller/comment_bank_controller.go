@@ -0,0 +1,252 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm"
+)
+
+type CommentBankController struct {
+	DB *gorm.DB
+}
+
+type commentSnippetPayload struct {
+	Title        string `json:"title"`
+	Body         string `json:"body"`
+	Category     string `json:"category"`
+	CriteriaName string `json:"criteria_name"`
+	IsShared     bool   `json:"is_shared"`
+}
+
+// List returns the caller's snippets plus the shared ones.
+// Query: ?scope=mine|shared|all, ?category=, ?criteria=, ?search=
+func (c *CommentBankController) List(ctx *gin.Context) {
+	user, ok := c.requireReviewer(ctx)
+	if !ok {
+		return
+	}
+
+	query := c.DB.Preload("User")
+	switch ctx.DefaultQuery("scope", "all") {
+	case "mine":
+		query = query.Where("user_id = ?", user.ID)
+	case "shared":
+		query = query.Where("is_shared = ?", true)
+	default:
+		query = query.Where("user_id = ? OR is_shared = ?", user.ID, true)
+	}
+
+	if category := ctx.Query("category"); category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if criteria := ctx.Query("criteria"); criteria != "" {
+		query = query.Where("criteria_name = ?", criteria)
+	}
+	if search := strings.TrimSpace(ctx.Query("search")); search != "" {
+		like := "%" + search + "%"
+		query = query.Where("title LIKE ? OR body LIKE ?", like, like)
+	}
+
+	var snippets []entity.CommentSnippet
+	if err := query.Order("usage_count desc, title asc").Find(&snippets).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, snippets)
+}
+
+func (c *CommentBankController) Create(ctx *gin.Context) {
+	user, ok := c.requireReviewer(ctx)
+	if !ok {
+		return
+	}
+
+	var payload commentSnippetPayload
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	snippet := entity.CommentSnippet{UserID: user.ID}
+	applySnippetPayload(&snippet, &payload)
+
+	if ok, err := govalidator.ValidateStruct(&snippet); !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := c.DB.Create(&snippet).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, snippet)
+}
+
+func (c *CommentBankController) Update(ctx *gin.Context) {
+	snippet, ok := c.loadOwnSnippet(ctx)
+	if !ok {
+		return
+	}
+
+	var payload commentSnippetPayload
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	applySnippetPayload(snippet, &payload)
+
+	if ok, err := govalidator.ValidateStruct(snippet); !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := c.DB.Save(snippet).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, snippet)
+}
+
+func (c *CommentBankController) Delete(ctx *gin.Context) {
+	snippet, ok := c.loadOwnSnippet(ctx)
+	if !ok {
+		return
+	}
+	if err := c.DB.Delete(snippet).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// Render fills the snippet placeholders for a submission and counts the usage.
+// POST /api/comment-bank/:id/render  { "portfolio_submission_id": 1, "curriculum_id": 2 }
+func (c *CommentBankController) Render(ctx *gin.Context) {
+	user, ok := c.requireReviewer(ctx)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		PortfolioSubmissionID uint  `json:"portfolio_submission_id" binding:"required"`
+		CurriculumID          *uint `json:"curriculum_id"`
+	}
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var snippet entity.CommentSnippet
+	if err := c.DB.Where("id = ? AND (user_id = ? OR is_shared = ?)", ctx.Param("id"), user.ID, true).
+		First(&snippet).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "snippet not found"})
+		return
+	}
+
+	vars, err := services.BuildSnippetVars(c.DB, payload.PortfolioSubmissionID, payload.CurriculumID, user)
+	if err != nil {
+		handleDBError(ctx, err, "submission or curriculum not found")
+		return
+	}
+
+	now := time.Now()
+	if err := c.DB.Model(&snippet).Updates(map[string]interface{}{
+		"usage_count":  gorm.Expr("usage_count + ?", 1),
+		"last_used_at": now,
+	}).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"snippet_id": snippet.ID,
+		"category":   snippet.Category,
+		"text":       services.RenderSnippet(snippet.Body, vars),
+	})
+}
+
+// Stats returns usage statistics of the snippets visible to the caller.
+func (c *CommentBankController) Stats(ctx *gin.Context) {
+	user, ok := c.requireReviewer(ctx)
+	if !ok {
+		return
+	}
+
+	type categoryStat struct {
+		Category     string `json:"category"`
+		SnippetCount int64  `json:"snippet_count"`
+		UsageCount   int64  `json:"usage_count"`
+	}
+
+	var byCategory []categoryStat
+	if err := c.DB.Model(&entity.CommentSnippet{}).
+		Select("category, COUNT(*) AS snippet_count, COALESCE(SUM(usage_count), 0) AS usage_count").
+		Where("user_id = ? OR is_shared = ?", user.ID, true).
+		Group("category").
+		Scan(&byCategory).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var top []entity.CommentSnippet
+	c.DB.Where("(user_id = ? OR is_shared = ?) AND usage_count > 0", user.ID, true).
+		Order("usage_count desc").
+		Limit(10).
+		Find(&top)
+
+	var unused int64
+	c.DB.Model(&entity.CommentSnippet{}).
+		Where("user_id = ? AND usage_count = 0", user.ID).
+		Count(&unused)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"by_category":  byCategory,
+		"most_used":    top,
+		"unused_count": unused,
+	})
+}
+
+// ===================== Helpers =====================
+
+func applySnippetPayload(snippet *entity.CommentSnippet, payload *commentSnippetPayload) {
+	snippet.Title = strings.TrimSpace(payload.Title)
+	snippet.Body = strings.TrimSpace(payload.Body)
+	snippet.Category = strings.TrimSpace(payload.Category)
+	snippet.CriteriaName = strings.TrimSpace(payload.CriteriaName)
+	snippet.IsShared = payload.IsShared
+}
+
+func (c *CommentBankController) requireReviewer(ctx *gin.Context) (*entity.User, bool) {
+	user, err := getAuthUser(ctx, c.DB)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return nil, false
+	}
+	if !isReviewer(user) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "comment bank is available to teachers and admins only"})
+		return nil, false
+	}
+	return user, true
+}
+
+func (c *CommentBankController) loadOwnSnippet(ctx *gin.Context) (*entity.CommentSnippet, bool) {
+	user, ok := c.requireReviewer(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	var snippet entity.CommentSnippet
+	if err := c.DB.First(&snippet, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "snippet not found"})
+		return nil, false
+	}
+	if snippet.UserID != user.ID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only the owner can change this snippet"})
+		return nil, false
+	}
+	return &snippet, true
+}
@@ -0,0 +1,375 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/config"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm"
+)
+
+// maxCourseCSVSize limits bulk course imports to 2MB.
+const maxCourseCSVSize = 2 << 20
+
+type CourseController struct {
+	db *gorm.DB
+}
+
+func NewCourseController() *CourseController {
+	return &CourseController{
+		db: config.GetDB(),
+	}
+}
+
+// RegisterRoutes registers the course catalogue routes
+func (crc *CourseController) RegisterRoutes(r *gin.Engine, protected *gin.RouterGroup) {
+	// Public routes - สำหรับนักเรียนดูรายวิชา
+	public := r.Group("/courses")
+	{
+		public.GET("", crc.ListCourses)
+		public.GET("/:id", crc.GetCourseByID)
+	}
+	r.GET("/course-groups/:id/courses", crc.ListCourseGroupCourses)
+	r.GET("/curricula/:id/credits", crc.GetCurriculumCredits)
+
+	// Protected routes - สำหรับ admin จัดการ
+	admin := protected.Group("/admin/courses")
+	{
+		admin.POST("", crc.CreateCourse)
+		admin.POST("/import", crc.ImportCourses)
+		admin.PUT("/:id", crc.UpdateCourse)
+		admin.DELETE("/:id", crc.DeleteCourse)
+	}
+	protected.PUT("/admin/course-groups/:id/courses", crc.ReplaceCourseGroupCourses)
+}
+
+// ==================== Payloads ====================
+
+type coursePayload struct {
+	CourseCode     string `json:"course_code" binding:"required"`
+	CourseNameTH   string `json:"course_name_th" binding:"required"`
+	CourseNameEN   string `json:"course_name_en"`
+	Credits        int    `json:"credits"`
+	Category       int    `json:"category"`
+	Description    string `json:"description"`
+	CourseGroupIDs []uint `json:"course_group_ids"`
+}
+
+func (p coursePayload) apply(course *entity.Course) {
+	course.CourseCode = strings.ToUpper(strings.TrimSpace(p.CourseCode))
+	course.CourseNameTH = strings.TrimSpace(p.CourseNameTH)
+	course.CourseNameEN = strings.TrimSpace(p.CourseNameEN)
+	course.Credits = p.Credits
+	course.Category = p.Category
+	course.Description = strings.TrimSpace(p.Description)
+}
+
+// ==================== Course Handlers ====================
+
+// ListCourses searches the catalogue
+// Query params: ?search=&category=&course_group_id=&page=1&limit=20
+func (crc *CourseController) ListCourses(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := crc.db.Model(&entity.Course{})
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		like := "%" + strings.ToLower(search) + "%"
+		query = query.Where("LOWER(course_code) LIKE ? OR LOWER(course_name_th) LIKE ? OR LOWER(course_name_en) LIKE ?", like, like, like)
+	}
+	if category := c.Query("category"); category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if groupID := c.Query("course_group_id"); groupID != "" {
+		query = query.Where("id IN (?)", crc.db.Table("course_group_courses").Select("course_id").Where("course_group_id = ?", groupID))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var courses []entity.Course
+	if err := query.Preload("CourseGroups").
+		Order("course_code asc").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&courses).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       courses,
+		"page":       page,
+		"limit":      limit,
+		"total":      total,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	})
+}
+
+// GetCourseByID returns a course with its course groups
+func (crc *CourseController) GetCourseByID(c *gin.Context) {
+	var course entity.Course
+	if err := crc.db.Preload("CourseGroups").First(&course, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "course not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": course})
+}
+
+// CreateCourse creates a course and optionally links it to course groups
+func (crc *CourseController) CreateCourse(c *gin.Context) {
+	var payload coursePayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var course entity.Course
+	payload.apply(&course)
+	if ok, err := govalidator.ValidateStruct(&course); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	groups, ok := crc.loadCourseGroups(c, payload.CourseGroupIDs)
+	if !ok {
+		return
+	}
+
+	// รหัสวิชาที่เคยถูกลบจะถูกกู้คืนแทนการสร้างแถวใหม่
+	if err := services.CreateCourse(crc.db, &course, groups); err != nil {
+		if errors.Is(err, services.ErrCourseCodeTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": course})
+}
+
+// UpdateCourse updates a course; course_group_ids (when sent) replaces its course groups
+func (crc *CourseController) UpdateCourse(c *gin.Context) {
+	var course entity.Course
+	if err := crc.db.First(&course, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "course not found"})
+		return
+	}
+
+	var payload coursePayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	payload.apply(&course)
+	if ok, err := govalidator.ValidateStruct(&course); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	taken, err := services.CourseCodeTaken(crc.db, course.CourseCode, course.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": services.ErrCourseCodeTaken.Error()})
+		return
+	}
+
+	var groups []entity.CourseGroup
+	if payload.CourseGroupIDs != nil {
+		var ok bool
+		if groups, ok = crc.loadCourseGroups(c, payload.CourseGroupIDs); !ok {
+			return
+		}
+	}
+
+	err = crc.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("CourseGroups").Save(&course).Error; err != nil {
+			return err
+		}
+		if payload.CourseGroupIDs != nil {
+			return tx.Model(&course).Association("CourseGroups").Replace(groups)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	crc.db.Preload("CourseGroups").First(&course, course.ID)
+	c.JSON(http.StatusOK, gin.H{"data": course})
+}
+
+// DeleteCourse deletes a course and unlinks it from its course groups
+func (crc *CourseController) DeleteCourse(c *gin.Context) {
+	var course entity.Course
+	if err := crc.db.First(&course, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "course not found"})
+		return
+	}
+
+	err := crc.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&course).Association("CourseGroups").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&course).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted successfully"})
+}
+
+// ImportCourses bulk-imports courses from a CSV file (form field "file"), upserting by course_code
+// Columns: course_code,course_name_th,course_name_en,credits,category,description,course_groups
+func (crc *CourseController) ImportCourses(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxCourseCSVSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File size must not exceed 2MB"})
+		return
+	}
+	if strings.ToLower(filepath.Ext(header.Filename)) != ".csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only CSV files are allowed"})
+		return
+	}
+
+	rows, rowErrors, err := services.ParseCourseCSV(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := services.ImportCourses(crc.db, rows)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	result.Errors = append(rowErrors, result.Errors...)
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// ==================== Course Group ↔ Course ====================
+
+// ListCourseGroupCourses returns the courses of a course group with their credit total
+func (crc *CourseController) ListCourseGroupCourses(c *gin.Context) {
+	var group entity.CourseGroup
+	if err := crc.db.Preload("Courses", func(db *gorm.DB) *gorm.DB {
+		return db.Order("course_code asc")
+	}).First(&group, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "course group not found"})
+		return
+	}
+
+	credits := 0
+	for _, course := range group.Courses {
+		credits += course.Credits
+	}
+	c.JSON(http.StatusOK, gin.H{"data": group.Courses, "total_credits": credits})
+}
+
+// ReplaceCourseGroupCourses sets the courses of a course group
+// PUT /admin/course-groups/:id/courses  { "course_ids": [1, 2, 3] }
+func (crc *CourseController) ReplaceCourseGroupCourses(c *gin.Context) {
+	var group entity.CourseGroup
+	if err := crc.db.First(&group, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "course group not found"})
+		return
+	}
+
+	var payload struct {
+		CourseIDs []uint `json:"course_ids"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var courses []entity.Course
+	if len(payload.CourseIDs) > 0 {
+		if err := crc.db.Where("id IN ?", payload.CourseIDs).Find(&courses).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(courses) != len(uniqueUints(payload.CourseIDs)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "one or more courses not found"})
+			return
+		}
+	}
+
+	if err := crc.db.Model(&group).Association("Courses").Replace(courses); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	crc.ListCourseGroupCourses(c)
+}
+
+// GetCurriculumCredits compares each course group's credits with its CreditPercentage
+// GET /curricula/:id/credits
+func (crc *CourseController) GetCurriculumCredits(c *gin.Context) {
+	var curriculum entity.Curriculum
+	if err := crc.db.Preload("CourseGroups.CourseGroup.Courses").First(&curriculum, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "curriculum not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": services.CurriculumCreditBreakdown(&curriculum)})
+}
+
+// ==================== Helpers ====================
+
+func (crc *CourseController) loadCourseGroups(c *gin.Context, ids []uint) ([]entity.CourseGroup, bool) {
+	if len(ids) == 0 {
+		return nil, true
+	}
+	var groups []entity.CourseGroup
+	if err := crc.db.Where("id IN ?", ids).Find(&groups).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if len(groups) != len(uniqueUints(ids)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "one or more course groups not found"})
+		return nil, false
+	}
+	return groups, true
+}
+
+func uniqueUints(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	out := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
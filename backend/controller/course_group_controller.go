@@ -33,6 +33,9 @@ func (cgc *CourseGroupController) RegisterRoutes(r *gin.Engine, protected *gin.R
 	// Public skills route - สำหรับดูรายการทักษะ
 	r.GET("/skills", cgc.ListAllSkills)
 
+	// Protected: สรุปทักษะของนักเรียนจากกิจกรรม/ผลงาน
+	protected.GET("/skills/summary", cgc.GetStudentSkillSummary)
+
 	// Protected routes - สำหรับ admin จัดการ
 	admin := protected.Group("/admin/course-groups")
 	{
@@ -55,6 +58,10 @@ func (cgc *CourseGroupController) RegisterRoutes(r *gin.Engine, protected *gin.R
 		skillAdmin.POST("", cgc.CreateSkill)
 		skillAdmin.PUT("/:id", cgc.UpdateSkill)
 		skillAdmin.DELETE("/:id", cgc.DeleteSkill)
+
+		// Activity/Working type → skill mappings (ใช้ในการแนะนำหลักสูตร)
+		skillAdmin.GET("/type-mappings", cgc.ListTypeSkillMappings)
+		skillAdmin.PUT("/type-mappings/:kind", cgc.ReplaceTypeSkillMappings)
 	}
 }
 
@@ -107,6 +114,7 @@ func (cgc *CourseGroupController) GetCourseGroupByID(c *gin.Context) {
 	var courseGroup entity.CourseGroup
 	if err := cgc.db.
 		Preload("CourseGroupSkills.Skill").
+		Preload("Courses").
 		First(&courseGroup, id).Error; err != nil {
 
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -195,6 +203,11 @@ func (cgc *CourseGroupController) DeleteCourseGroup(c *gin.Context) {
 			return err
 		}
 
+		// Unlink its courses (the courses themselves stay in the catalogue)
+		if err := tx.Exec("DELETE FROM course_group_courses WHERE course_group_id = ?", id).Error; err != nil {
+			return err
+		}
+
 		// Delete related CurriculumCourseGroups
 		if err := tx.Where("course_group_id = ?", id).Delete(&entity.CurriculumCourseGroup{}).Error; err != nil {
 			return err
@@ -1,13 +1,14 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sut68/team14/backend/config"
 	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
 	"gorm.io/gorm"
 )
 
@@ -21,61 +22,6 @@ func NewCurriculumController() *CurriculumController {
 	}
 }
 
-// -------------------- HELPER FUNCTION --------------------
-
-// ✅ ฟังก์ชันคำนวณสถานะตามเวลาจริง (Private Helper)
-func calculateCurriculumStatus(c *entity.Curriculum) {
-	// ถ้าไม่มีข้อมูลวันที่ -> ปิด
-	if c.ApplicationPeriod == "" {
-		c.Status = "closed"
-		return
-	}
-
-	parts := strings.Split(c.ApplicationPeriod, "|")
-	if len(parts) < 2 {
-		c.Status = "closed"
-		return
-	}
-
-	// รูปแบบวันที่ที่รองรับ
-	layout1 := "2006-01-02T15:04"
-	layout2 := "2006-01-02T15:04:05"
-
-	startStr := strings.TrimSpace(parts[0])
-	endStr := strings.TrimSpace(parts[1])
-
-	// Parse เวลาเริ่ม
-	start, err1 := time.ParseInLocation(layout1, startStr, time.Local)
-	if err1 != nil {
-		start, err1 = time.ParseInLocation(layout2, startStr, time.Local)
-	}
-
-	// Parse เวลาสิ้นสุด
-	end, err2 := time.ParseInLocation(layout1, endStr, time.Local)
-	if err2 != nil {
-		end, err2 = time.ParseInLocation(layout2, endStr, time.Local)
-	}
-
-	if err1 != nil || err2 != nil {
-		c.Status = "closed"
-		return
-	}
-
-	now := time.Now()
-
-	// 🕒 Logic เปลี่ยนสถานะอัตโนมัติ
-	if now.Before(start) {
-		// 1. ยังไม่ถึงเวลาเปิด -> กำลังเปิด (Opening)
-		c.Status = "opening"
-	} else if now.After(end) {
-		// 3. เลยเวลาปิดแล้ว -> ปิด (Closed)
-		c.Status = "closed"
-	} else {
-		// 2. อยู่ระหว่างช่วงเวลา -> เปิดอยู่ (Open)
-		c.Status = "open"
-	}
-}
-
 // -------------------- ROUTES --------------------
 
 func (cc *CurriculumController) RegisterRoutes(r *gin.Engine, protected *gin.RouterGroup) {
@@ -83,15 +29,21 @@ func (cc *CurriculumController) RegisterRoutes(r *gin.Engine, protected *gin.Rou
 	public := r.Group("/curricula")
 	{
 		public.GET("/public", cc.ListPublishedCurricula)
+		public.GET("/search", cc.SearchCurricula) // ค้นหาแบบ full-text พร้อม facet และแบ่งหน้า
 		public.GET("/:id", cc.GetCurriculumByID)
-		public.GET("/:id/course-groups", cc.ListCurriculumCourseGroups) // ดูกลุ่มวิชาของหลักสูตร
+		public.GET("/:id/rounds", cc.publishedOnly(cc.ListCurriculumRounds))
+		public.GET("/:id/course-groups", cc.publishedOnly(cc.ListCurriculumCourseGroups)) // ดูกลุ่มวิชาของหลักสูตร
 	}
 
 	// Protected: สำหรับครูและแอดมินจัดการกลุ่มวิชาในหลักสูตร
 	curriculaCG := protected.Group("/curricula")
 	{
+		curriculaCG.GET("/eligible", cc.ListEligibleCurricula)
+		curriculaCG.GET("/recommendations", cc.GetSkillRecommendations)
+		curriculaCG.GET("/:id/eligibility", cc.GetCurriculumEligibility)
 		curriculaCG.PUT("/:id/recommendation", cc.UpdateCurriculumRecommendation)
 		curriculaCG.POST("/:id/course-groups", cc.AddCourseGroupToCurriculum)
+		curriculaCG.PUT("/:id/course-groups", cc.ReplaceCurriculumCourseGroups)
 		curriculaCG.PUT("/:id/course-groups/:cgId", cc.UpdateCurriculumCourseGroup)
 		curriculaCG.DELETE("/:id/course-groups/:cgId", cc.RemoveCourseGroupFromCurriculum)
 	}
@@ -103,27 +55,58 @@ func (cc *CurriculumController) RegisterRoutes(r *gin.Engine, protected *gin.Rou
 		admin.POST("/curricula", cc.CreateCurriculum)
 		admin.PUT("/curricula/:id", cc.UpdateCurriculum)
 		admin.DELETE("/curricula/:id", cc.DeleteCurriculum)
+		admin.POST("/curricula/:id/clone", cc.CloneCurriculum)
+		admin.POST("/curricula/:id/submit", cc.curriculumWorkflowAction(services.CurriculumActionSubmit))
+		admin.POST("/curricula/:id/approve", cc.curriculumWorkflowAction(services.CurriculumActionApprove))
+		admin.POST("/curricula/:id/reject", cc.curriculumWorkflowAction(services.CurriculumActionReject))
+		admin.POST("/curricula/:id/archive", cc.curriculumWorkflowAction(services.CurriculumActionArchive))
+		admin.POST("/curricula/:id/restore", cc.curriculumWorkflowAction(services.CurriculumActionRestore))
+		admin.GET("/curricula/:id/versions", cc.ListCurriculumVersions)
+		admin.GET("/curricula/:id/versions/:version", cc.GetCurriculumVersion)
+		admin.GET("/curricula/:id/requirements", cc.ListCurriculumRequirements)
+		admin.PUT("/curricula/:id/requirements", cc.ReplaceCurriculumRequirements)
+		admin.PUT("/curricula/:id/eligibility-rules", cc.UpdateCurriculumEligibilityRules)
+		admin.GET("/eligibility-rules/fields", cc.ListEligibilityRuleFields)
+		admin.POST("/eligibility-rules/test", cc.TestEligibilityRules)
+		admin.GET("/curricula/:id/rounds", cc.ListCurriculumRounds)
+		admin.GET("/curricula/:id/course-groups", cc.ListCurriculumCourseGroups)
+		admin.POST("/curricula/:id/rounds", cc.CreateCurriculumRound)
+		admin.PUT("/curricula/:id/rounds/:roundId", cc.UpdateCurriculumRound)
+		admin.DELETE("/curricula/:id/rounds/:roundId", cc.DeleteCurriculumRound)
 		admin.GET("/curricula/summary", cc.GetCurriculumSummary)
 	}
 }
 
+// publishedOnly answers 404 for curricula students may not see yet, like GetCurriculumByID:
+// drafts, curricula in review and archived ones are served through /admin only.
+func (cc *CurriculumController) publishedOnly(next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var cur entity.Curriculum
+		if err := cc.db.Select("id", "publish_state").First(&cur, c.Param("id")).Error; err != nil || !cur.IsPublished() {
+			c.JSON(http.StatusNotFound, gin.H{"error": "curriculum not found"})
+			return
+		}
+		next(c)
+	}
+}
+
 // -------------------- HANDLERS (Student) --------------------
 
 // ListPublishedCurricula : ใช้ในหน้าค้นหาฝั่งนักเรียน
 func (cc *CurriculumController) ListPublishedCurricula(c *gin.Context) {
 	search := c.Query("search")
-	// ดึงสถานะที่ Active ใน DB (รวม open, opening) เพื่อนำมาคำนวณต่อ
-	activeStatuses := []string{"open", "opening", "published"}
+	now := time.Now()
 
-	query := cc.db.
+	// เฉพาะหลักสูตรที่อนุมัติเผยแพร่แล้ว และรอบรับสมัครยังเปิด/กำลังจะเปิด
+	query := services.VisibleToStudents(cc.db, now).
 		Model(&entity.Curriculum{}).
 		Select("curriculums.*").
 		Preload("Faculty").
 		Preload("Program").
 		Preload("RequiredDocuments.DocumentType").
+		Preload("Rounds", orderRounds).
 		Joins("LEFT JOIN faculties ON faculties.id = curriculums.faculty_id").
-		Joins("LEFT JOIN programs ON programs.id = curriculums.program_id").
-		Where("curriculums.status IN ?", activeStatuses)
+		Joins("LEFT JOIN programs ON programs.id = curriculums.program_id")
 
 	if search != "" {
 		like := "%" + search + "%"
@@ -141,7 +124,7 @@ func (cc *CurriculumController) ListPublishedCurricula(c *gin.Context) {
 
 	// ✅ Loop เพื่อคำนวณสถานะใหม่ตามเวลาจริง ก่อนส่งกลับไป
 	for i := range curricula {
-		calculateCurriculumStatus(&curricula[i])
+		services.ApplyCurriculumStatus(&curricula[i], now)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": curricula})
@@ -155,16 +138,27 @@ func (cc *CurriculumController) GetCurriculumByID(c *gin.Context) {
 		Preload("Faculty").
 		Preload("Program").
 		Preload("RequiredDocuments.DocumentType").
+		Preload("Rounds", orderRounds).
+		Preload("Requirements.EducationLevel").
+		Preload("Requirements.CurriculumType").
 		Preload("Skills.Skill").
 		Preload("CourseGroups.CourseGroup.CourseGroupSkills.Skill").
+		Preload("CourseGroups.CourseGroup.Courses").
 		First(&curriculum, id).Error; err != nil {
 
 		c.JSON(http.StatusNotFound, gin.H{"error": "curriculum not found"})
 		return
 	}
+	// หน้านี้เป็น public: ฉบับร่าง/รอตรวจ/เก็บถาวร ให้ดูผ่าน /admin เท่านั้น
+	if !curriculum.IsPublished() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "curriculum not found"})
+		return
+	}
 
 	// ✅ คำนวณสถานะด้วย
-	calculateCurriculumStatus(&curriculum)
+	services.ApplyCurriculumStatus(&curriculum, time.Now())
+	structure := services.CurriculumStructureOf(curriculum.CourseGroups)
+	curriculum.Structure = &structure
 
 	c.JSON(http.StatusOK, gin.H{"data": curriculum})
 }
@@ -180,6 +174,8 @@ func (cc *CurriculumController) ListAllCurricula(c *gin.Context) {
 		Preload("Faculty").
 		Preload("Program").
 		Preload("RequiredDocuments.DocumentType").
+		Preload("Rounds", orderRounds).
+		Preload("CourseGroups").
 		Joins("LEFT JOIN faculties ON faculties.id = curriculums.faculty_id").
 		Joins("LEFT JOIN programs ON programs.id = curriculums.program_id")
 
@@ -190,6 +186,10 @@ func (cc *CurriculumController) ListAllCurricula(c *gin.Context) {
 			like, like, like, like, like,
 		)
 	}
+	// ?publish_state=in_review = คิวรออนุมัติ
+	if state := c.Query("publish_state"); state != "" {
+		query = query.Where("curriculums.publish_state = ?", state)
+	}
 
 	var curricula []entity.Curriculum
 	if err := query.Find(&curricula).Error; err != nil {
@@ -197,9 +197,12 @@ func (cc *CurriculumController) ListAllCurricula(c *gin.Context) {
 		return
 	}
 
-	// ✅ Admin ก็ควรเห็นสถานะจริงเช่นกัน
+	// ✅ Admin ก็ควรเห็นสถานะจริงเช่นกัน พร้อมความครบถ้วนของโครงสร้างหน่วยกิต
+	now := time.Now()
 	for i := range curricula {
-		calculateCurriculumStatus(&curricula[i])
+		services.ApplyCurriculumStatus(&curricula[i], now)
+		structure := services.CurriculumStructureOf(curricula[i].CourseGroups)
+		curricula[i].Structure = &structure
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": curricula})
@@ -219,6 +222,9 @@ type CurriculumPayload struct {
 	UserID            uint    `json:"user_id"`
 	ApplicationPeriod string  `json:"application_period"`
 	Quota             int     `json:"quota"`
+
+	// ถ้าไม่ส่ง rounds มา จะสร้างรอบเดียวจาก application_period แบบเดิม
+	Rounds []ApplicationRoundPayload `json:"rounds"`
 }
 
 func (cc *CurriculumController) CreateCurriculum(c *gin.Context) {
@@ -228,9 +234,11 @@ func (cc *CurriculumController) CreateCurriculum(c *gin.Context) {
 		return
 	}
 
-	// ✅ คำนวณสถานะอัตโนมัติจากช่วงเวลาที่กรอกมา
-	// (ไม่สนว่า Admin ส่ง status อะไรมา เราจะทับด้วยค่าที่ถูกต้องเสมอ)
-	calculatedStatus := getCalculatedStatus(payload.ApplicationPeriod)
+	rounds, err := payload.buildRounds(0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	cur := entity.Curriculum{
 		Code:              payload.Code,
@@ -239,14 +247,20 @@ func (cc *CurriculumController) CreateCurriculum(c *gin.Context) {
 		Link:              payload.Link,
 		GPAXMin:           payload.GPAXMin,
 		PortfolioMaxPages: payload.PortfolioMaxPages,
-		Status:            calculatedStatus, // ✅ ใช้ค่าที่คำนวณใหม่
 		FacultyID:         payload.FacultyID,
 		ProgramID:         payload.ProgramID,
 		UserID:            payload.UserID,
-		ApplicationPeriod: payload.ApplicationPeriod,
 		Quota:             payload.Quota,
+		Rounds:            rounds,
+		// ApplicationPeriod ไม่ถูกบันทึก: ใช้สร้าง Rounds เท่านั้น ไม่งั้น migration จะสร้างรอบให้ใหม่
+		// หลักสูตรใหม่ต้องผ่านการอนุมัติก่อนนักเรียนจะเห็น
+		PublishState: entity.CurriculumDraft,
 	}
 
+	// ✅ คำนวณสถานะอัตโนมัติจากรอบรับสมัคร
+	// (ไม่สนว่า Admin ส่ง status อะไรมา เราจะทับด้วยค่าที่ถูกต้องเสมอ)
+	services.ApplyCurriculumStatus(&cur, time.Now())
+
 	if err := cc.db.Create(&cur).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -255,6 +269,8 @@ func (cc *CurriculumController) CreateCurriculum(c *gin.Context) {
 }
 
 func (cc *CurriculumController) UpdateCurriculum(c *gin.Context) {
+	defer cc.trackCurriculumChange(c, "updated curriculum")()
+
 	id := c.Param("id")
 	var payload CurriculumPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
@@ -263,11 +279,23 @@ func (cc *CurriculumController) UpdateCurriculum(c *gin.Context) {
 	}
 
 	var cur entity.Curriculum
-	if err := cc.db.First(&cur, id).Error; err != nil {
+	if err := cc.db.Preload("Rounds").First(&cur, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "curriculum not found"})
 		return
 	}
 
+	// ส่ง rounds มา = แทนที่ทั้งหมด, ไม่ส่งแต่แก้ application_period = แก้รอบเดียวแบบเดิม
+	// (application_period ว่างไม่ใช่การลบรอบ ต้องส่ง rounds: [] มาแทน)
+	replaceRounds := payload.Rounds != nil || payload.legacyPeriodChanged(cur.Rounds)
+	var rounds []entity.ApplicationRound
+	if replaceRounds {
+		var err error
+		if rounds, err = payload.buildRounds(cur.ID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// อัปเดตฟิลด์อื่นๆ
 	cur.Code = payload.Code
 	cur.Name = payload.Name
@@ -279,15 +307,25 @@ func (cc *CurriculumController) UpdateCurriculum(c *gin.Context) {
 	cur.ProgramID = payload.ProgramID
 	cur.UserID = payload.UserID
 	cur.Quota = payload.Quota
+	// ApplicationPeriod ไม่ถูกเขียนทับ: ช่วงรับสมัครอยู่ใน Rounds แล้ว
 
-	// อัปเดตช่วงเวลา
-	cur.ApplicationPeriod = payload.ApplicationPeriod
+	if replaceRounds {
+		cur.Rounds = rounds
+	}
 
 	// ✅ คำนวณสถานะใหม่ทันที แล้วบันทึกลง DB
 	// เพื่อให้ Query ฝั่งนักเรียน (ที่ Filter status='open') มองเห็นรายการนี้ทันที
-	cur.Status = getCalculatedStatus(payload.ApplicationPeriod)
-
-	if err := cc.db.Save(&cur).Error; err != nil {
+	services.ApplyCurriculumStatus(&cur, time.Now())
+
+	err := cc.db.Transaction(func(tx *gorm.DB) error {
+		if replaceRounds {
+			if err := replaceCurriculumRounds(tx, cur.ID, cur.Rounds); err != nil {
+				return err
+			}
+		}
+		return tx.Omit("Rounds").Save(&cur).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -321,8 +359,7 @@ func (cc *CurriculumController) GetCurriculumSummary(c *gin.Context) {
 	var total, open int64
 	cc.db.Model(&entity.Curriculum{}).Count(&total)
 
-	activeStatuses := []string{"open", "opening", "published"}
-	cc.db.Model(&entity.Curriculum{}).Where("status IN ?", activeStatuses).Count(&open)
+	services.VisibleToStudents(cc.db, time.Now()).Model(&entity.Curriculum{}).Count(&open)
 
 	var totalStudents int64
 	cc.db.Model(&entity.Education{}).Count(&totalStudents)
@@ -345,50 +382,6 @@ func (cc *CurriculumController) GetCurriculumSummary(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": resp})
 }
 
-// -------------------- HELPER ใหม่ (Return String) --------------------
-
-// getCalculatedStatus: คำนวณสถานะแล้วคืนค่าเป็น string เพื่อเอาไปบันทึก
-func getCalculatedStatus(period string) string {
-	if period == "" {
-		return "closed"
-	}
-
-	parts := strings.Split(period, "|")
-	if len(parts) < 2 {
-		return "closed"
-	}
-
-	layout1 := "2006-01-02T15:04"
-	layout2 := "2006-01-02T15:04:05"
-
-	startStr := strings.TrimSpace(parts[0])
-	endStr := strings.TrimSpace(parts[1])
-
-	start, err1 := time.ParseInLocation(layout1, startStr, time.Local)
-	if err1 != nil {
-		start, err1 = time.ParseInLocation(layout2, startStr, time.Local)
-	}
-
-	end, err2 := time.ParseInLocation(layout1, endStr, time.Local)
-	if err2 != nil {
-		end, err2 = time.ParseInLocation(layout2, endStr, time.Local)
-	}
-
-	if err1 != nil || err2 != nil {
-		return "closed"
-	}
-
-	now := time.Now()
-
-	if now.Before(start) {
-		return "opening" // ยังไม่ถึงเวลา
-	} else if now.After(end) {
-		return "closed" // หมดเวลา
-	} else {
-		return "open" // เปิดอยู่
-	}
-}
-
 // Struct สำหรับรับผลลัพธ์ Query
 type StatResult struct {
 	Name      string `json:"name"`
@@ -450,6 +443,8 @@ type CurriculumRecommendationPayload struct {
 
 // UpdateCurriculumRecommendation - อัปเดตคำแนะนำหลักสูตร
 func (cc *CurriculumController) UpdateCurriculumRecommendation(c *gin.Context) {
+	defer cc.trackCurriculumChange(c, "updated recommendation")()
+
 	curriculumId := c.Param("id")
 
 	var payload CurriculumRecommendationPayload
@@ -487,11 +482,13 @@ func (cc *CurriculumController) ListCurriculumCourseGroups(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": courseGroups})
+	c.JSON(http.StatusOK, gin.H{"data": courseGroups, "structure": services.CurriculumStructureOf(courseGroups)})
 }
 
 // AddCourseGroupToCurriculum - เพิ่มกลุ่มวิชาเข้าหลักสูตร
 func (cc *CurriculumController) AddCourseGroupToCurriculum(c *gin.Context) {
+	defer cc.trackCurriculumChange(c, "added course group")()
+
 	curriculumId := c.Param("id")
 
 	var payload CurriculumCourseGroupPayload
@@ -500,6 +497,11 @@ func (cc *CurriculumController) AddCourseGroupToCurriculum(c *gin.Context) {
 		return
 	}
 
+	if err := services.ValidateCreditPercentage(payload.CreditPercentage); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// ตรวจสอบว่ามีหลักสูตรอยู่จริง
 	var curriculum entity.Curriculum
 	if err := cc.db.First(&curriculum, curriculumId).Error; err != nil {
@@ -521,6 +523,13 @@ func (cc *CurriculumController) AddCourseGroupToCurriculum(c *gin.Context) {
 		return
 	}
 
+	// รวมแล้วต้องไม่เกิน 100%
+	structure, err := services.CheckCreditPercentageChange(cc.db, curriculum.ID, payload.CourseGroupID, payload.CreditPercentage)
+	if err != nil {
+		cc.creditPercentageError(c, err, structure)
+		return
+	}
+
 	// สร้าง record ใหม่
 	ccg := entity.CurriculumCourseGroup{
 		CurriculumID:     curriculum.ID,
@@ -537,11 +546,13 @@ func (cc *CurriculumController) AddCourseGroupToCurriculum(c *gin.Context) {
 	// Preload ข้อมูลเพิ่มเติมเพื่อส่งกลับ
 	cc.db.Preload("CourseGroup.CourseGroupSkills.Skill").First(&ccg, ccg.ID)
 
-	c.JSON(http.StatusCreated, gin.H{"data": ccg})
+	c.JSON(http.StatusCreated, gin.H{"data": ccg, "structure": structure})
 }
 
 // UpdateCurriculumCourseGroup - อัปเดตกลุ่มวิชาในหลักสูตร
 func (cc *CurriculumController) UpdateCurriculumCourseGroup(c *gin.Context) {
+	defer cc.trackCurriculumChange(c, "updated course group")()
+
 	curriculumId := c.Param("id")
 	courseGroupId := c.Param("cgId")
 
@@ -557,6 +568,16 @@ func (cc *CurriculumController) UpdateCurriculumCourseGroup(c *gin.Context) {
 		return
 	}
 
+	if err := services.ValidateCreditPercentage(payload.CreditPercentage); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	structure, err := services.CheckCreditPercentageChange(cc.db, ccg.CurriculumID, ccg.CourseGroupID, payload.CreditPercentage)
+	if err != nil {
+		cc.creditPercentageError(c, err, structure)
+		return
+	}
+
 	ccg.CreditPercentage = payload.CreditPercentage
 	ccg.Description = payload.Description
 
@@ -568,11 +589,13 @@ func (cc *CurriculumController) UpdateCurriculumCourseGroup(c *gin.Context) {
 	// Preload ข้อมูลเพิ่มเติมเพื่อส่งกลับ
 	cc.db.Preload("CourseGroup.CourseGroupSkills.Skill").First(&ccg, ccg.ID)
 
-	c.JSON(http.StatusOK, gin.H{"data": ccg})
+	c.JSON(http.StatusOK, gin.H{"data": ccg, "structure": structure})
 }
 
 // RemoveCourseGroupFromCurriculum - ลบกลุ่มวิชาออกจากหลักสูตร
 func (cc *CurriculumController) RemoveCourseGroupFromCurriculum(c *gin.Context) {
+	defer cc.trackCurriculumChange(c, "removed course group")()
+
 	curriculumId := c.Param("id")
 	courseGroupId := c.Param("cgId")
 
@@ -587,5 +610,70 @@ func (cc *CurriculumController) RemoveCourseGroupFromCurriculum(c *gin.Context)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": true})
+	var remaining []entity.CurriculumCourseGroup
+	cc.db.Where("curriculum_id = ?", curriculumId).Find(&remaining)
+
+	c.JSON(http.StatusOK, gin.H{"data": true, "structure": services.CurriculumStructureOf(remaining)})
+}
+
+// ReplaceCurriculumCourseGroups - แทนที่กลุ่มวิชาทั้งหมดของหลักสูตรในครั้งเดียว (รวมต้องได้ 100%)
+// PUT /curricula/:id/course-groups  { "course_groups": [ { "course_group_id": 1, "credit_percentage": 60 }, ... ] }
+func (cc *CurriculumController) ReplaceCurriculumCourseGroups(c *gin.Context) {
+	defer cc.trackCurriculumChange(c, "replaced course groups")()
+
+	var curriculum entity.Curriculum
+	if err := cc.db.First(&curriculum, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "curriculum not found"})
+		return
+	}
+
+	var payload struct {
+		CourseGroups []CurriculumCourseGroupPayload `json:"course_groups" binding:"dive"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	groups := make([]entity.CurriculumCourseGroup, 0, len(payload.CourseGroups))
+	ids := make([]uint, 0, len(payload.CourseGroups))
+	for _, p := range payload.CourseGroups {
+		groups = append(groups, entity.CurriculumCourseGroup{
+			CourseGroupID:    p.CourseGroupID,
+			CreditPercentage: p.CreditPercentage,
+			Description:      p.Description,
+		})
+		ids = append(ids, p.CourseGroupID)
+	}
+	if err := services.ValidateCurriculumStructure(groups); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "structure": services.CurriculumStructureOf(groups)})
+		return
+	}
+
+	var found int64
+	if len(ids) > 0 {
+		if err := cc.db.Model(&entity.CourseGroup{}).Where("id IN ?", ids).Count(&found).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if int(found) != len(ids) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "course group not found"})
+			return
+		}
+	}
+
+	if err := services.ReplaceCurriculumCourseGroups(cc.db, curriculum.ID, groups); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	cc.ListCurriculumCourseGroups(c)
+}
+
+// creditPercentageError reports a rejected percentage together with the current structure
+func (cc *CurriculumController) creditPercentageError(c *gin.Context, err error, structure entity.CurriculumStructure) {
+	if errors.Is(err, services.ErrCreditPercentageExceeded) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "structure": structure})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 }
@@ -0,0 +1,352 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+func preloadRequirements(db *gorm.DB) *gorm.DB {
+	return db.Preload("Requirements.EducationLevel").Preload("Requirements.CurriculumType")
+}
+
+// -------------------- HANDLERS (Eligibility) --------------------
+
+// GetCurriculumEligibility : ตรวจว่านักเรียนมีคุณสมบัติตามเกณฑ์ของหลักสูตรหรือไม่ พร้อมเหตุผลทีละข้อ
+// GET /curricula/:id/eligibility  (ครู/แอดมินส่ง ?user_id= เพื่อดูของนักเรียนคนอื่นได้)
+func (cc *CurriculumController) GetCurriculumEligibility(c *gin.Context) {
+	studentID, ok := cc.eligibilityStudentID(c)
+	if !ok {
+		return
+	}
+
+	var curriculum entity.Curriculum
+	if err := preloadRequirements(cc.db).First(&curriculum, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "curriculum not found"})
+		return
+	}
+
+	profile, err := services.LoadStudentProfile(cc.db, studentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": services.EvaluateEligibility(&curriculum, profile)})
+}
+
+// ListEligibleCurricula : หลักสูตรที่เปิดรับทั้งหมดพร้อมผลการตรวจคุณสมบัติ (ที่ผ่านขึ้นก่อน)
+// GET /curricula/eligible?only_eligible=true
+func (cc *CurriculumController) ListEligibleCurricula(c *gin.Context) {
+	studentID, ok := cc.eligibilityStudentID(c)
+	if !ok {
+		return
+	}
+
+	var curricula []entity.Curriculum
+	if err := preloadRequirements(services.VisibleToStudents(cc.db, time.Now())).
+		Preload("Faculty").
+		Preload("Program").
+		Order("code asc").
+		Find(&curricula).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := services.LoadStudentProfile(cc.db, studentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	byID := make(map[uint]*entity.Curriculum, len(curricula))
+	for i := range curricula {
+		byID[curricula[i].ID] = &curricula[i]
+	}
+
+	type item struct {
+		Curriculum  *entity.Curriculum         `json:"curriculum"`
+		Eligibility services.EligibilityResult `json:"eligibility"`
+	}
+	onlyEligible := c.Query("only_eligible") == "true"
+	items := []item{}
+	for _, res := range services.EligibleCurricula(curricula, profile) {
+		if onlyEligible && !res.Eligible {
+			continue
+		}
+		items = append(items, item{Curriculum: byID[res.CurriculumID], Eligibility: res})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// -------------------- HANDLERS (Admin Requirements) --------------------
+
+// GET /admin/curricula/:id/requirements
+func (cc *CurriculumController) ListCurriculumRequirements(c *gin.Context) {
+	var reqs []entity.CurriculumRequirement
+	if err := cc.db.Preload("EducationLevel").Preload("CurriculumType").
+		Where("curriculum_id = ?", c.Param("id")).
+		Order("id asc").
+		Find(&reqs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": reqs})
+}
+
+// ReplaceCurriculumRequirements แทนที่เกณฑ์ทั้งหมดของหลักสูตรในครั้งเดียว
+// PUT /admin/curricula/:id/requirements  { "requirements": [ { "kind": "language_score", "group": "english", "test_type": "IELTS", "min_score": 6 } ] }
+func (cc *CurriculumController) ReplaceCurriculumRequirements(c *gin.Context) {
+	defer cc.trackCurriculumChange(c, "replaced requirements")()
+
+	var cur entity.Curriculum
+	if err := cc.db.First(&cur, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "curriculum not found"})
+		return
+	}
+
+	var payload struct {
+		Requirements []entity.CurriculumRequirement `json:"requirements"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reqs := make([]entity.CurriculumRequirement, 0, len(payload.Requirements))
+	for i, in := range payload.Requirements {
+		req := entity.CurriculumRequirement{
+			Kind:             strings.TrimSpace(in.Kind),
+			Group:            strings.TrimSpace(in.Group),
+			Subject:          strings.ToLower(strings.TrimSpace(in.Subject)),
+			TestType:         strings.ToUpper(strings.TrimSpace(in.TestType)),
+			MinScore:         in.MinScore,
+			EducationLevelID: in.EducationLevelID,
+			CurriculumTypeID: in.CurriculumTypeID,
+			Description:      strings.TrimSpace(in.Description),
+			CurriculumID:     cur.ID,
+		}
+		if ok, err := govalidator.ValidateStruct(&req); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("requirements[%d]: %v", i, err)})
+			return
+		}
+		if err := validateRequirementTarget(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("requirements[%d]: %v", i, err)})
+			return
+		}
+		reqs = append(reqs, req)
+	}
+	if len(reqs) > 0 && services.HasEligibilityRules(&cur) {
+		c.JSON(http.StatusConflict, gin.H{"error": "this curriculum uses eligibility rules; remove them before adding requirements"})
+		return
+	}
+
+	err := cc.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("curriculum_id = ?", cur.ID).Delete(&entity.CurriculumRequirement{}).Error; err != nil {
+			return err
+		}
+		if len(reqs) == 0 {
+			return nil
+		}
+		return tx.Create(&reqs).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": reqs})
+}
+
+// -------------------- HANDLERS (Admin Rule Engine) --------------------
+
+// UpdateCurriculumEligibilityRules ตั้งกฎคุณสมบัติแบบ AND/OR ของหลักสูตร (ส่ง rules: null เพื่อลบ)
+// PUT /admin/curricula/:id/eligibility-rules  { "rules": { "op": "or", "rules": [ { "field": "language.IELTS.score", "cmp": ">=", "value": 6 } ] } }
+func (cc *CurriculumController) UpdateCurriculumEligibilityRules(c *gin.Context) {
+	defer cc.trackCurriculumChange(c, "updated eligibility rules")()
+
+	var cur entity.Curriculum
+	if err := cc.db.First(&cur, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "curriculum not found"})
+		return
+	}
+
+	var payload struct {
+		Rules json.RawMessage `json:"rules"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := services.ParseRules(payload.Rules)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if rule != nil {
+		var count int64
+		if err := cc.db.Model(&entity.CurriculumRequirement{}).Where("curriculum_id = ?", cur.ID).Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "this curriculum uses requirements; remove them before setting eligibility rules"})
+			return
+		}
+	}
+
+	var stored datatypes.JSON
+	if rule != nil {
+		// เก็บแบบที่ parse แล้ว เพื่อตัดฟิลด์ที่ไม่รู้จักออก
+		if stored, err = json.Marshal(rule); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if err := cc.db.Model(&cur).Update("eligibility_rules", stored).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	cur.EligibilityRules = stored
+	c.JSON(http.StatusOK, gin.H{"data": cur})
+}
+
+// ListEligibilityRuleFields : ฟิลด์ของโปรไฟล์ที่ใช้ในกฎได้ พร้อม comparator ของแต่ละชนิด
+// GET /admin/eligibility-rules/fields
+func (cc *CurriculumController) ListEligibilityRuleFields(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"fields":      services.RuleFields(),
+		"comparators": services.RuleComparators(),
+	}})
+}
+
+// TestEligibilityRules ทดสอบกฎกับโปรไฟล์ตัวอย่าง (และ/หรือนักเรียนจริงผ่าน user_ids) โดยไม่บันทึก
+// POST /admin/eligibility-rules/test
+//
+//	{ "rules": {...} หรือ "curriculum_id": 1,
+//	  "profiles": [ { "name": "IELTS 6.5", "facts": { "language.IELTS.score": 6.5 } } ],
+//	  "user_ids": [12] }
+func (cc *CurriculumController) TestEligibilityRules(c *gin.Context) {
+	// user_ids อ่านเกรดจริงของนักเรียน จึงให้เฉพาะแอดมิน
+	user, err := getAuthUser(c, cc.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	if !isAdmin(user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+		return
+	}
+
+	var payload struct {
+		Rules        json.RawMessage `json:"rules"`
+		CurriculumID uint            `json:"curriculum_id"`
+		Profiles     []struct {
+			Name  string               `json:"name"`
+			Facts services.SampleFacts `json:"facts"`
+		} `json:"profiles"`
+		UserIDs []uint `json:"user_ids"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	raw := []byte(payload.Rules)
+	if len(raw) == 0 && payload.CurriculumID != 0 {
+		var cur entity.Curriculum
+		if err := cc.db.First(&cur, payload.CurriculumID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "curriculum not found"})
+			return
+		}
+		raw = cur.EligibilityRules
+	}
+	rule, err := services.ParseRules(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if rule == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rules are required"})
+		return
+	}
+	if len(payload.Profiles) == 0 && len(payload.UserIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one profile or user_id is required"})
+		return
+	}
+
+	type item struct {
+		Name   string              `json:"name"`
+		UserID uint                `json:"user_id,omitempty"`
+		Result services.RuleResult `json:"result"`
+	}
+	items := []item{}
+	for i, p := range payload.Profiles {
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("profile %d", i+1)
+		}
+		items = append(items, item{Name: name, Result: services.EvaluateRule(rule, p.Facts)})
+	}
+	for _, id := range payload.UserIDs {
+		profile, err := services.LoadStudentProfile(cc.db, id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		items = append(items, item{
+			Name:   fmt.Sprintf("user %d", id),
+			UserID: id,
+			Result: services.EvaluateRule(rule, services.ProfileFacts(profile)),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// validateRequirementTarget checks the fields each kind needs.
+func validateRequirementTarget(req *entity.CurriculumRequirement) error {
+	switch req.Kind {
+	case entity.RequirementSubjectGPA:
+		if _, ok := services.LookupGPASubject(req.Subject); !ok {
+			return fmt.Errorf("subject must be one of %s", strings.Join(services.GPASubjectNames(), ", "))
+		}
+		fallthrough
+	case entity.RequirementGPAX:
+		if req.MinScore < 0 || req.MinScore > 4 {
+			return fmt.Errorf("min_score must be between 0 and 4")
+		}
+	case entity.RequirementGEDScore:
+		if _, ok := services.LookupGEDSubject(req.Subject); req.Subject != "" && !ok {
+			return fmt.Errorf("subject must be one of %s", strings.Join(services.GEDSubjectNames(), ", "))
+		}
+	case entity.RequirementLanguageScore:
+		if req.TestType == "" {
+			return fmt.Errorf("test_type is required")
+		}
+	case entity.RequirementEducationLevel:
+		if req.EducationLevelID == nil {
+			return fmt.Errorf("education_level_id is required")
+		}
+	case entity.RequirementCurriculumType:
+		if req.CurriculumTypeID == nil {
+			return fmt.Errorf("curriculum_type_id is required")
+		}
+	}
+	return nil
+}
+
+// eligibilityStudentID returns whose profile to check: the caller, or ?user_id= for reviewers.
+func (cc *CurriculumController) eligibilityStudentID(c *gin.Context) (uint, bool) {
+	return studentIDFromQuery(c, cc.db)
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/services"
+)
+
+// SearchCurricula : ค้นหาหลักสูตรฝั่งนักเรียน แบบ full-text พร้อมตัวกรองและจำนวนในแต่ละตัวกรอง
+// GET /curricula/search?q=&faculty_id=1,2&program_id=&academic_year=2568&round=Portfolio 1
+//
+//	&status=open&gpax_range=3.00-3.49 (หรือ gpax_min= / gpax_max=)&skill_id=&sort=relevance&page=1&limit=20
+//
+// sort: relevance (ค่าเริ่มต้นเมื่อมี q), name, gpax_asc, gpax_desc, quota_desc, newest, closing_soon
+func (cc *CurriculumController) SearchCurricula(c *gin.Context) {
+	params := services.CurriculumSearchParams{
+		Query:         c.Query("q"),
+		AcademicYears: splitQueryList(c.Query("academic_year")),
+		Rounds:        splitQueryList(c.Query("round")),
+		Statuses:      splitQueryList(c.Query("status")),
+		Sort:          c.Query("sort"),
+		Page:          parseIntWithDefault(c.Query("page"), 1),
+		Limit:         parseIntWithDefault(c.Query("limit"), 20),
+	}
+
+	var err error
+	for name, target := range map[string]*[]uint{
+		"faculty_id": &params.FacultyIDs,
+		"program_id": &params.ProgramIDs,
+		"skill_id":   &params.SkillIDs,
+	} {
+		if *target, err = parseUintList(c.Query(name)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+			return
+		}
+	}
+
+	if raw := c.Query("gpax_range"); raw != "" {
+		lo, hi, err := services.ParseGPAXRange(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		params.GPAXMin, params.GPAXMax = &lo, &hi
+	}
+	for name, target := range map[string]**float64{"gpax_min": &params.GPAXMin, "gpax_max": &params.GPAXMax} {
+		if raw := c.Query(name); raw != "" {
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil || v < 0 || v > 4 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be between 0 and 4"})
+				return
+			}
+			*target = &v
+		}
+	}
+
+	if !services.ValidCurriculumSort(params.Sort) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown sort " + params.Sort})
+		return
+	}
+
+	result, err := services.SearchCurricula(cc.db, params, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       result.Curricula,
+		"facets":     result.Facets,
+		"page":       result.Page,
+		"limit":      result.Limit,
+		"total":      result.Total,
+		"totalPages": (result.Total + int64(result.Limit) - 1) / int64(result.Limit),
+	})
+}
+
+// splitQueryList splits a comma-separated query value, dropping empty items.
+func splitQueryList(raw string) []string {
+	var out []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// parseUintList parses a comma-separated list of IDs.
+func parseUintList(raw string) ([]uint, error) {
+	var ids []uint
+	for _, v := range splitQueryList(raw) {
+		id, err := parseUintParam(v)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm"
+)
+
+// trackCurriculumChange versions edits of a published curriculum and keeps the ones that
+// change who may apply behind the review workflow:
+//
+//	defer cc.trackCurriculumChange(c, "updated rounds")()
+//
+// The baseline version is taken when the defer statement runs (before the change); if the
+// handler answered with a 2xx status, the returned func hands the edit to
+// services.ReviseCurriculum, which records it as a new version or sends the curriculum back
+// to in_review until it is approved again.
+func (cc *CurriculumController) trackCurriculumChange(c *gin.Context, summary string) func() {
+	curriculumID, err := parseUintParam(c.Param("id"))
+	if err != nil {
+		return func() {}
+	}
+	var editorID uint
+	var changedBy *uint
+	if userID, err := getAuthUserID(c); err == nil {
+		editorID, changedBy = userID, &userID
+	}
+	if err := services.EnsureCurriculumBaseline(cc.db, curriculumID, changedBy); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("curriculum %d: baseline version: %v", curriculumID, err)
+	}
+
+	return func() {
+		if status := c.Writer.Status(); status < 200 || status >= 300 {
+			return
+		}
+		revised, err := services.ReviseCurriculum(cc.db, curriculumID, editorID, summary, time.Now())
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("curriculum %d: revise: %v", curriculumID, err)
+			return
+		}
+		if revised {
+			log.Printf("curriculum %d: %s, back in review until approved", curriculumID, summary)
+		}
+	}
+}
+
+// -------------------- HANDLERS (Clone) --------------------
+
+type CloneCurriculumPayload struct {
+	AcademicYear string `json:"academic_year"`
+	Code         string `json:"code"`
+	// ไม่ส่ง rounds = เลื่อนรอบเดิมไปตามจำนวนปี
+	Rounds []ApplicationRoundPayload `json:"rounds"`
+}
+
+// CloneCurriculum สร้างหลักสูตรฉบับร่างสำหรับปีการศึกษาใหม่ โดยคัดลอกรอบ เกณฑ์ เอกสาร ทักษะ และกลุ่มวิชาทั้งหมด
+// POST /admin/curricula/:id/clone  { "academic_year": "2569" }
+func (cc *CurriculumController) CloneCurriculum(c *gin.Context) {
+	var src entity.Curriculum
+	if err := services.PreloadCurriculumChildren(cc.db).First(&src, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "curriculum not found"})
+		return
+	}
+
+	var payload CloneCurriculumPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts := services.CloneOptions{AcademicYear: payload.AcademicYear, Code: payload.Code}
+	if userID, err := getAuthUserID(c); err == nil {
+		opts.UserID = userID
+	}
+	if payload.Rounds != nil {
+		opts.Rounds = make([]entity.ApplicationRound, 0, len(payload.Rounds))
+		for i := range payload.Rounds {
+			payload.Rounds[i].ID = 0
+			round, err := payload.Rounds[i].toRound(0)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "rounds[" + strconv.Itoa(i) + "]: " + err.Error()})
+				return
+			}
+			opts.Rounds = append(opts.Rounds, round)
+		}
+	}
+
+	var clone *entity.Curriculum
+	err := cc.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		clone, err = services.CloneCurriculum(tx, &src, opts)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": clone})
+}
+
+// -------------------- HANDLERS (Versions) --------------------
+
+// ListCurriculumVersions : ประวัติการแก้ไขหลักสูตรที่เผยแพร่แล้ว (ใหม่สุดก่อน ไม่รวม snapshot)
+// GET /admin/curricula/:id/versions
+func (cc *CurriculumController) ListCurriculumVersions(c *gin.Context) {
+	var versions []entity.CurriculumVersion
+	if err := cc.db.
+		Omit("snapshot").
+		Preload("ChangedBy").
+		Where("curriculum_id = ?", c.Param("id")).
+		Order("version desc").
+		Find(&versions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": versions})
+}
+
+// GetCurriculumVersion : snapshot ของเวอร์ชัน พร้อมสิ่งที่เปลี่ยนจากเวอร์ชันก่อนหน้า (หรือ ?compare=<version>)
+// GET /admin/curricula/:id/versions/:version
+func (cc *CurriculumController) GetCurriculumVersion(c *gin.Context) {
+	version, ok := cc.findCurriculumVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+
+	compareTo := strconv.Itoa(version.Version - 1)
+	if raw := c.Query("compare"); raw != "" {
+		compareTo = raw
+	}
+
+	var before []byte
+	if compareTo != "0" {
+		base, ok := cc.findCurriculumVersion(c, compareTo)
+		if !ok {
+			return
+		}
+		before = base.Snapshot
+	}
+
+	changes, err := services.DiffSnapshots(before, version.Snapshot)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": version, "changes": changes})
+}
+
+func (cc *CurriculumController) findCurriculumVersion(c *gin.Context, raw string) (*entity.CurriculumVersion, bool) {
+	number, err := strconv.Atoi(raw)
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return nil, false
+	}
+	var version entity.CurriculumVersion
+	if err := cc.db.Preload("ChangedBy").
+		Where("curriculum_id = ? AND version = ?", c.Param("id"), number).
+		First(&version).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "version not found"})
+		return nil, false
+	}
+	return &version, true
+}
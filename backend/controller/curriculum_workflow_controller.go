@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+type CurriculumWorkflowPayload struct {
+	Note string `json:"note"`
+}
+
+// curriculumWorkflowAction handles POST /admin/curricula/:id/<action>:
+//
+//	submit  (ครู/แอดมิน)        draft -> in_review
+//	approve (ผู้ดูแลคณะ)         in_review -> published
+//	reject  (ผู้ดูแลคณะ, note)   in_review -> draft
+//	archive (แอดมิน)            published -> archived
+//	restore (แอดมิน)            archived -> draft
+func (cc *CurriculumController) curriculumWorkflowAction(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getAuthUser(c, cc.db)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			return
+		}
+
+		var cur entity.Curriculum
+		if err := cc.db.First(&cur, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "curriculum not found"})
+			return
+		}
+
+		var payload CurriculumWorkflowPayload
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&payload); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		switch action {
+		case services.CurriculumActionSubmit:
+			if !isReviewer(user) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "only teachers and admins may submit a curriculum for review"})
+				return
+			}
+		case services.CurriculumActionApprove, services.CurriculumActionReject:
+			ok, err := services.CanReviewCurriculum(cc.db, user, &cur)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if !ok {
+				c.JSON(http.StatusForbidden, gin.H{"error": services.ErrNotFacultyAdmin.Error()})
+				return
+			}
+		default:
+			if !isAdmin(user) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+				return
+			}
+		}
+
+		if err := services.TransitionCurriculum(cc.db, &cur, action, user.ID, payload.Note, time.Now()); err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, services.ErrCurriculumTransition) {
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		// ทุกครั้งที่เผยแพร่ บันทึกเป็นเวอร์ชันใหม่ (ถ้าเนื้อหาเปลี่ยนจากครั้งก่อน)
+		if action == services.CurriculumActionApprove {
+			if _, err := services.RecordCurriculumVersion(cc.db, cur.ID, "published", &user.ID); err != nil {
+				log.Printf("curriculum %d: record version: %v", cur.ID, err)
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": cur})
+	}
+}
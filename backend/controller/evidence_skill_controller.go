@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/config"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+type skillTagsPayload struct {
+	Skills []services.SkillTagInput `json:"skills"`
+}
+
+// ReplaceActivitySkills sets the skills an activity demonstrates
+// PUT /activities/:id/skills  { "skills": [ { "skill_id": 1, "level": 3 } ] }
+func (ac *ActivityController) ReplaceActivitySkills(c *gin.Context) {
+	db := config.GetDB()
+
+	var activity entity.Activity
+	if err := db.First(&activity, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
+		return
+	}
+	userID, err := getAuthUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	if activity.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not the owner of this activity"})
+		return
+	}
+
+	var payload skillTagsPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tags, err := services.NormalizeSkillTags(db, payload.Skills)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	links, err := services.ReplaceActivitySkills(db, activity.ID, tags)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": links})
+}
+
+// ListTypeActivitySkills returns the default skills suggested for an activity type
+// GET /type_activities/:id/skills
+func (ac *ActivityController) ListTypeActivitySkills(c *gin.Context) {
+	var links []entity.TypeActivitySkill
+	if err := config.GetDB().Preload("Skill").
+		Where("type_activity_id = ?", c.Param("id")).
+		Order("weight desc").
+		Find(&links).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": links})
+}
+
+// ReplaceWorkingSkills sets the skills a working demonstrates
+// PUT /workings/:id/skills  { "skills": [ { "skill_id": 1, "level": 3 } ] }
+func (wc *WorkingController) ReplaceWorkingSkills(c *gin.Context) {
+	db := config.GetDB()
+
+	var working entity.Working
+	if err := db.First(&working, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Working not found"})
+		return
+	}
+	userID, err := getAuthUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	if working.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not the owner of this working"})
+		return
+	}
+
+	var payload skillTagsPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tags, err := services.NormalizeSkillTags(db, payload.Skills)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	links, err := services.ReplaceWorkingSkills(db, working.ID, tags)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": links})
+}
+
+// ListTypeWorkingSkills returns the default skills suggested for a working type
+// GET /type_workings/:id/skills
+func (wc *WorkingController) ListTypeWorkingSkills(c *gin.Context) {
+	var links []entity.TypeWorkingSkill
+	if err := config.GetDB().Preload("Skill").
+		Where("type_working_id = ?", c.Param("id")).
+		Order("weight desc").
+		Find(&links).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": links})
+}
+
+// GetStudentSkillSummary aggregates the skill evidence of a student's activities and workings
+// GET /skills/summary  (ครู/แอดมินส่ง ?user_id= เพื่อดูของนักเรียนคนอื่นได้)
+func (cgc *CourseGroupController) GetStudentSkillSummary(c *gin.Context) {
+	studentID, ok := studentIDFromQuery(c, cgc.db)
+	if !ok {
+		return
+	}
+
+	maps, err := services.LoadSkillTypeMaps(cgc.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	skills, err := services.LoadStudentSkills(cgc.db, studentID, maps)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"user_id": studentID, "skills": skills}})
+}
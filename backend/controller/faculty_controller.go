@@ -23,6 +23,8 @@ func (fc *FacultyController) RegisterRoutes(r *gin.Engine, protected *gin.Router
 	admin := protected.Group("/admin")
 	{
 		admin.GET("/faculties", fc.ListFaculties)
+		admin.GET("/faculties/:id/admins", fc.ListFacultyAdmins)
+		admin.PUT("/faculties/:id/admins", fc.ReplaceFacultyAdmins)
 	}
 }
 
@@ -34,3 +36,72 @@ func (fc *FacultyController) ListFaculties(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"data": facs})
 }
+
+// ListFacultyAdmins : ผู้ดูแลคณะที่อนุมัติการเผยแพร่หลักสูตรของคณะนี้ได้
+// GET /admin/faculties/:id/admins
+func (fc *FacultyController) ListFacultyAdmins(c *gin.Context) {
+	var admins []entity.FacultyAdmin
+	if err := fc.db.Preload("User").Where("faculty_id = ?", c.Param("id")).Order("id asc").Find(&admins).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": admins})
+}
+
+// ReplaceFacultyAdmins กำหนดผู้ดูแลคณะใหม่ทั้งหมด (ต้องเป็นบัญชีแอดมิน)
+// PUT /admin/faculties/:id/admins  { "user_ids": [3, 7] }
+func (fc *FacultyController) ReplaceFacultyAdmins(c *gin.Context) {
+	user, err := getAuthUser(c, fc.db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	if !isAdmin(user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+		return
+	}
+
+	var faculty entity.Faculty
+	if err := fc.db.First(&faculty, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "faculty not found"})
+		return
+	}
+
+	var payload struct {
+		UserIDs []uint `json:"user_ids"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ids := uniqueUints(payload.UserIDs)
+
+	if len(ids) > 0 {
+		var count int64
+		if err := fc.db.Model(&entity.User{}).Where("id IN ? AND account_type_id = ?", ids, entity.UserTypeAdmin).Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if int(count) != len(ids) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "every faculty admin must be an admin account"})
+			return
+		}
+	}
+
+	err = fc.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("faculty_id = ?", faculty.ID).Delete(&entity.FacultyAdmin{}).Error; err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := tx.Create(&entity.FacultyAdmin{FacultyID: faculty.ID, UserID: id}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	fc.ListFacultyAdmins(c)
+}
@@ -0,0 +1,330 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm"
+)
+
+type FeedbackCommentController struct {
+	DB *gorm.DB
+}
+
+type feedbackCommentPayload struct {
+	Body               string `json:"body"`
+	PortfolioSectionID *uint  `json:"portfolio_section_id"`
+	PortfolioBlockID   *uint  `json:"portfolio_block_id"`
+	ParentID           *uint  `json:"parent_id"`
+}
+
+// ===================== Threads =====================
+
+// ListBySubmission returns the comment threads of a submission (root comments with their replies).
+// Query: ?resolved=true|false, ?section_id=, ?block_id=
+func (c *FeedbackCommentController) ListBySubmission(ctx *gin.Context) {
+	submission, _, ok := c.loadSubmissionForUser(ctx)
+	if !ok {
+		return
+	}
+
+	query := c.DB.
+		Preload("User").
+		Preload("ResolvedBy").
+		Preload("Replies", func(db *gorm.DB) *gorm.DB { return db.Order("created_at asc") }).
+		Preload("Replies.User").
+		Where("portfolio_submission_id = ? AND parent_id IS NULL", submission.ID)
+
+	switch ctx.Query("resolved") {
+	case "true":
+		query = query.Where("is_resolved = ?", true)
+	case "false":
+		query = query.Where("is_resolved = ?", false)
+	}
+	if sectionID := ctx.Query("section_id"); sectionID != "" {
+		query = query.Where("portfolio_section_id = ?", sectionID)
+	}
+	if blockID := ctx.Query("block_id"); blockID != "" {
+		query = query.Where("portfolio_block_id = ?", blockID)
+	}
+
+	var comments []entity.FeedbackComment
+	if err := query.Order("created_at asc").Find(&comments).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var open int64
+	c.DB.Model(&entity.FeedbackComment{}).
+		Where("portfolio_submission_id = ? AND parent_id IS NULL AND is_resolved = ?", submission.ID, false).
+		Count(&open)
+
+	ctx.JSON(http.StatusOK, gin.H{"data": comments, "open_count": open})
+}
+
+// Create adds a root comment anchored to a section/block, or a reply when parent_id is given.
+// Only reviewers may open a new thread; the submission owner may reply.
+func (c *FeedbackCommentController) Create(ctx *gin.Context) {
+	submission, user, ok := c.loadSubmissionForUser(ctx)
+	if !ok {
+		return
+	}
+
+	var payload feedbackCommentPayload
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment := entity.FeedbackComment{
+		Body:                  strings.TrimSpace(payload.Body),
+		PortfolioSubmissionID: submission.ID,
+		UserID:                user.ID,
+	}
+
+	if payload.ParentID != nil {
+		var parent entity.FeedbackComment
+		if err := c.DB.Where("id = ? AND portfolio_submission_id = ?", *payload.ParentID, submission.ID).First(&parent).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "parent comment not found"})
+			return
+		}
+		// replies always hang off the root comment and share its anchor
+		rootID := parent.ID
+		if parent.ParentID != nil {
+			rootID = *parent.ParentID
+		}
+		comment.ParentID = &rootID
+		comment.PortfolioSectionID = parent.PortfolioSectionID
+		comment.PortfolioBlockID = parent.PortfolioBlockID
+	} else {
+		if !isReviewer(user) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "only reviewers can start a comment thread"})
+			return
+		}
+		if err := c.resolveAnchor(submission, &payload, &comment); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if ok, err := govalidator.ValidateStruct(&comment); !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the comment, the reopened thread and the owner's notification are stored together
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+
+		// reopening discussion on a resolved thread puts it back to open
+		if comment.ParentID != nil {
+			if err := tx.Model(&entity.FeedbackComment{}).
+				Where("id = ? AND is_resolved = ?", *comment.ParentID, true).
+				Updates(map[string]interface{}{"is_resolved": false, "resolved_at": nil, "resolved_by_id": nil}).Error; err != nil {
+				return err
+			}
+		}
+
+		return services.NotifyFeedbackPosted(tx, submission.ID, user.ID, comment.Body,
+			fmt.Sprintf("comment:%d", comment.ID), time.Now())
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.DB.Preload("User").First(&comment, comment.ID)
+	ctx.JSON(http.StatusCreated, comment)
+}
+
+// Update edits the body of a comment. Only the author may edit.
+func (c *FeedbackCommentController) Update(ctx *gin.Context) {
+	comment, user, ok := c.loadComment(ctx)
+	if !ok {
+		return
+	}
+	if comment.UserID != user.ID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only the author can edit this comment"})
+		return
+	}
+
+	var payload struct {
+		Body string `json:"body"`
+	}
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment.Body = strings.TrimSpace(payload.Body)
+	if ok, err := govalidator.ValidateStruct(comment); !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.DB.Model(comment).Update("body", comment.Body).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, comment)
+}
+
+// Resolve marks a thread as resolved. Only reviewers decide whether their feedback has been
+// addressed; the submission owner answers with a reply instead, which reopens the thread.
+func (c *FeedbackCommentController) Resolve(ctx *gin.Context) {
+	c.setResolved(ctx, true)
+}
+
+// Reopen marks a resolved thread as open again. Reviewers only, like Resolve.
+func (c *FeedbackCommentController) Reopen(ctx *gin.Context) {
+	c.setResolved(ctx, false)
+}
+
+// Delete removes a comment (and its replies when it is a root). Only the author may delete.
+func (c *FeedbackCommentController) Delete(ctx *gin.Context) {
+	comment, user, ok := c.loadComment(ctx)
+	if !ok {
+		return
+	}
+	if comment.UserID != user.ID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only the author can delete this comment"})
+		return
+	}
+
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("parent_id = ?", comment.ID).Delete(&entity.FeedbackComment{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(comment).Error
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// ===================== Helpers =====================
+
+func (c *FeedbackCommentController) setResolved(ctx *gin.Context, resolved bool) {
+	comment, user, ok := c.loadComment(ctx)
+	if !ok {
+		return
+	}
+	if comment.ParentID != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "only root comments can be resolved"})
+		return
+	}
+	if !isReviewer(user) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only reviewers can resolve or reopen a thread"})
+		return
+	}
+
+	comment.IsResolved = resolved
+	if resolved {
+		now := time.Now()
+		comment.ResolvedAt = &now
+		comment.ResolvedByID = &user.ID
+	} else {
+		comment.ResolvedAt = nil
+		comment.ResolvedByID = nil
+	}
+
+	if err := c.DB.Model(comment).Updates(map[string]interface{}{
+		"is_resolved":    comment.IsResolved,
+		"resolved_at":    comment.ResolvedAt,
+		"resolved_by_id": comment.ResolvedByID,
+	}).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, comment)
+}
+
+// resolveAnchor checks that the section/block belongs to the submitted portfolio.
+func (c *FeedbackCommentController) resolveAnchor(submission *entity.PortfolioSubmission, payload *feedbackCommentPayload, comment *entity.FeedbackComment) error {
+	if payload.PortfolioBlockID != nil {
+		var block entity.PortfolioBlock
+		if err := c.DB.Preload("PortfolioSection").First(&block, *payload.PortfolioBlockID).Error; err != nil {
+			return errors.New("portfolio block not found")
+		}
+		if block.PortfolioSection.PortfolioID != submission.PortfolioID {
+			return errors.New("portfolio block does not belong to this submission")
+		}
+		sectionID := block.PortfolioSectionID
+		comment.PortfolioBlockID = &block.ID
+		comment.PortfolioSectionID = &sectionID
+		return nil
+	}
+
+	if payload.PortfolioSectionID != nil {
+		var section entity.PortfolioSection
+		if err := c.DB.First(&section, *payload.PortfolioSectionID).Error; err != nil {
+			return errors.New("portfolio section not found")
+		}
+		if section.PortfolioID != submission.PortfolioID {
+			return errors.New("portfolio section does not belong to this submission")
+		}
+		comment.PortfolioSectionID = &section.ID
+		return nil
+	}
+
+	return errors.New("portfolio_section_id or portfolio_block_id is required")
+}
+
+// loadSubmissionForUser loads the submission from :id and checks the caller may see it.
+func (c *FeedbackCommentController) loadSubmissionForUser(ctx *gin.Context) (*entity.PortfolioSubmission, *entity.User, bool) {
+	user, err := getAuthUser(ctx, c.DB)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return nil, nil, false
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid submission id"})
+		return nil, nil, false
+	}
+
+	var submission entity.PortfolioSubmission
+	if err := c.DB.First(&submission, id).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "submission not found"})
+		return nil, nil, false
+	}
+
+	if !isReviewer(user) && submission.UserID != user.ID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "not allowed to access this submission"})
+		return nil, nil, false
+	}
+
+	return &submission, user, true
+}
+
+// loadComment loads :commentId scoped to the submission in :id.
+func (c *FeedbackCommentController) loadComment(ctx *gin.Context) (*entity.FeedbackComment, *entity.User, bool) {
+	submission, user, ok := c.loadSubmissionForUser(ctx)
+	if !ok {
+		return nil, nil, false
+	}
+
+	var comment entity.FeedbackComment
+	if err := c.DB.
+		Where("id = ? AND portfolio_submission_id = ?", ctx.Param("commentId"), submission.ID).
+		First(&comment).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+		return nil, nil, false
+	}
+	comment.PortfolioSubmission = submission
+
+	return &comment, user, true
+}
@@ -1,11 +1,14 @@
 package controller
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
 	"gorm.io/gorm"
 )
 
@@ -19,7 +22,15 @@ func (c *FeedbackController) Create(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := c.DB.Create(&feedback).Error; err != nil {
+	// บันทึก feedback พร้อมการแจ้งเจ้าของผลงานใน transaction เดียว
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&feedback).Error; err != nil {
+			return err
+		}
+		return services.NotifyFeedbackPosted(tx, feedback.PortfolioSubmissionID, feedback.UserID, feedback.Overall_comment,
+			fmt.Sprintf("feedback:%d", feedback.ID), time.Now())
+	})
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
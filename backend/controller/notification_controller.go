@@ -1,69 +1,206 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
+
 	"github.com/gin-gonic/gin"
-	"github.com/sut68/team14/backend/entity"
 	"github.com/sut68/team14/backend/config"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
 )
 
+// CreateNotification : POST /notifications (แอดมินเท่านั้น)
+// ส่งแจ้งเตือนถึงผู้ใช้คนเดียว ผ่านทุกช่องทางที่ผู้ใช้เปิดไว้
 func CreateNotification(c *gin.Context) {
+	db := config.GetDB()
+	user, err := getAuthUser(c, db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	if !isAdmin(user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+		return
+	}
+
 	var notif entity.Notification
 	if err := c.ShouldBindJSON(&notif); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	db := config.GetDB()
-	if err := db.Create(&notif).Error; err != nil {
+	if notif.UserID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		return
+	}
+	now := time.Now()
+	notif.Notification_Type = entity.NormalizeNotificationType(notif.Notification_Type)
+	notif.Is_Read, notif.Archived_At = false, nil
+	notif.Sent_At, notif.Created_At = now, now
+
+	notifications := []entity.Notification{notif}
+	if err := services.CreateNotifications(db, notifications, now); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create"})
 		return
 	}
-	c.JSON(http.StatusOK, notif)
+	c.JSON(http.StatusCreated, gin.H{"data": notifications[0]})
 }
 
+// GetNotifications : GET /notifications?type=a,b&read=true|false&archived=true&group=false&cursor=&limit=20
+// กล่องแจ้งเตือนของผู้ใช้ เรียงใหม่สุดก่อน แบ่งหน้าด้วย cursor (ส่ง next_cursor กลับมาเพื่อโหลดหน้าถัดไป)
+// ค่าเริ่มต้นรวมแจ้งเตือนซ้ำในกลุ่มเดียวกันเป็นรายการเดียว (group_count บอกจำนวนในกลุ่ม)
 func GetNotifications(c *gin.Context) {
-	db := config.GetDB()
-	var notifs []entity.Notification
-	if err := db.Preload("User").Preload("Announcement").Find(&notifs).Error; err != nil {
+	userID, err := getAuthUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	filter := services.InboxFilter{
+		UserID:   userID,
+		Types:    splitQueryList(c.Query("type")),
+		Archived: c.Query("archived") == "true",
+		Group:    c.Query("group") != "false",
+		Limit:    parseIntWithDefault(c.Query("limit"), services.DefaultInboxLimit),
+	}
+	if raw := c.Query("read"); raw != "" {
+		read, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "read must be true or false"})
+			return
+		}
+		filter.Read = &read
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := parseUintParam(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+			return
+		}
+		filter.Cursor = cursor
+	}
+
+	items, next, err := services.ListInbox(config.GetDB(), filter)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch"})
 		return
 	}
-	c.JSON(http.StatusOK, notifs)
+	var nextCursor interface{}
+	if next > 0 {
+		nextCursor = next
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items, "next_cursor": nextCursor})
+}
+
+// GetUnreadNotificationCount : GET /notifications/unread-count
+func GetUnreadNotificationCount(c *gin.Context) {
+	userID, err := getAuthUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	byType, total, err := services.UnreadCounts(config.GetDB(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"total": total, "by_type": byType}})
 }
 
 func GetNotificationByID(c *gin.Context) {
-	id := c.Param("id")
+	userID, err := getAuthUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
 	db := config.GetDB()
 	var notif entity.Notification
-	if err := db.Preload("User").Preload("Announcement").First(&notif, id).Error; err != nil {
+	if err := db.Preload("Announcement").Preload("Event").
+		Where("user_id = ?", userID).First(&notif, c.Param("id")).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
 		return
 	}
-	c.JSON(http.StatusOK, notif)
+	c.JSON(http.StatusOK, gin.H{"data": notif})
 }
 
-func UpdateNotification(c *gin.Context) {
-	id := c.Param("id")
-	db := config.GetDB()
-	var notif entity.Notification
-	if err := db.First(&notif, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+// applyNotificationAction runs one inbox action and writes the response.
+func applyNotificationAction(c *gin.Context, userID uint, action string, ids []uint) {
+	n, err := services.ApplyInboxAction(config.GetDB(), userID, action, ids, time.Now())
+	if errors.Is(err, services.ErrInvalidInboxAction) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action must be read, unread, archive, unarchive or delete"})
 		return
 	}
-	if err := c.ShouldBindJSON(&notif); err != nil {
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"updated": n}})
+}
+
+// notificationActionByID handles PATCH /notifications/:id/read, /archive ... for one
+// notification (and the rest of its group).
+func notificationActionByID(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			return
+		}
+		id, err := parseUintParam(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification id"})
+			return
+		}
+		applyNotificationAction(c, userID, action, []uint{id})
+	}
+}
+
+var (
+	MarkNotificationRead   = notificationActionByID(services.InboxActionRead)
+	MarkNotificationUnread = notificationActionByID(services.InboxActionUnread)
+	ArchiveNotification    = notificationActionByID(services.InboxActionArchive)
+	UnarchiveNotification  = notificationActionByID(services.InboxActionUnarchive)
+	DeleteNotification     = notificationActionByID(services.InboxActionDelete)
+)
+
+// BulkNotificationInput: action = read | unread | archive | unarchive | delete
+type BulkNotificationInput struct {
+	Action string `json:"action" binding:"required"`
+	IDs    []uint `json:"ids" binding:"required"`
+}
+
+// BulkUpdateNotifications : POST /notifications/bulk
+func BulkUpdateNotifications(c *gin.Context) {
+	userID, err := getAuthUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	var input BulkNotificationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	db.Save(&notif)
-	c.JSON(http.StatusOK, notif)
+	if len(input.IDs) > services.MaxInboxLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many ids"})
+		return
+	}
+	applyNotificationAction(c, userID, input.Action, input.IDs)
 }
 
-func DeleteNotification(c *gin.Context) {
-	id := c.Param("id")
-	db := config.GetDB()
-	if err := db.Delete(&entity.Notification{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete"})
+// MarkAllNotificationsRead : POST /notifications/read-all?type=a,b
+func MarkAllNotificationsRead(c *gin.Context) {
+	userID, err := getAuthUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Deleted successfully"})
-}
\ No newline at end of file
+	n, err := services.MarkAllNotificationsRead(config.GetDB(), userID, splitQueryList(c.Query("type")), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"updated": n}})
+}
@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/config"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type notificationPreferenceInput struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	Enabled bool   `json:"enabled"`
+}
+
+// UpdateNotificationPreferencesInput: ฟิลด์ที่เป็น nil จะไม่ถูกแก้ไข
+type UpdateNotificationPreferencesInput struct {
+	Preferences     []notificationPreferenceInput `json:"preferences"`
+	QuietHoursStart *string                       `json:"quiet_hours_start"`
+	QuietHoursEnd   *string                       `json:"quiet_hours_end"`
+	WebhookURL      *string                       `json:"webhook_url"`
+	WebhookToken    *string                       `json:"webhook_token"`
+	WebhookFormat   *string                       `json:"webhook_format"`
+	Language        *string                       `json:"language"` // th | en (ภาษาของอีเมล)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// loadNotificationSetting returns the user's setting, or a new unsaved one.
+func loadNotificationSetting(db *gorm.DB, userID uint) (entity.NotificationSetting, error) {
+	setting := entity.NotificationSetting{UserID: userID, WebhookFormat: entity.WebhookFormatJSON, Language: entity.LanguageTH}
+	err := db.Where("user_id = ?", userID).First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	return setting, err
+}
+
+func respondNotificationPreferences(c *gin.Context, db *gorm.DB, userID uint) {
+	matrix, err := services.NotificationPreferenceMatrix(db, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	setting, err := loadNotificationSetting(db, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"types":             entity.NotificationTypes,
+		"channels":          entity.NotificationChannels,
+		"preferences":       matrix,
+		"quiet_hours_start": setting.QuietHoursStart,
+		"quiet_hours_end":   setting.QuietHoursEnd,
+		"webhook_url":       setting.WebhookURL,
+		"webhook_format":    setting.WebhookFormat,
+		"has_webhook_token": setting.WebhookToken != "",
+		"language":          services.EmailLanguage(setting.Language),
+		"languages":         services.EmailLanguages,
+	}})
+}
+
+// GetNotificationPreferences : GET /notifications/preferences
+// ตารางเปิด/ปิดแจ้งเตือนแต่ละประเภทแยกตามช่องทาง พร้อมช่วงงดแจ้งเตือนและ webhook
+func GetNotificationPreferences(c *gin.Context) {
+	userID, err := getAuthUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	respondNotificationPreferences(c, config.GetDB(), userID)
+}
+
+// UpdateNotificationPreferences : PUT /notifications/preferences
+func UpdateNotificationPreferences(c *gin.Context) {
+	userID, err := getAuthUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	var input UpdateNotificationPreferencesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prefs := make([]entity.NotificationPreference, 0, len(input.Preferences))
+	for _, p := range input.Preferences {
+		typ := entity.NormalizeNotificationType(p.Type)
+		if !containsString(entity.NotificationTypes, typ) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown notification type: " + p.Type})
+			return
+		}
+		if !containsString(entity.NotificationChannels, p.Channel) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown channel: " + p.Channel})
+			return
+		}
+		if p.Channel == entity.NotificationChannelInApp && !p.Enabled {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "in-app notifications cannot be turned off"})
+			return
+		}
+		prefs = append(prefs, entity.NotificationPreference{UserID: userID, Type: typ, Channel: p.Channel, Enabled: p.Enabled})
+	}
+
+	db := config.GetDB()
+	setting, err := loadNotificationSetting(db, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if input.QuietHoursStart != nil {
+		setting.QuietHoursStart = *input.QuietHoursStart
+	}
+	if input.QuietHoursEnd != nil {
+		setting.QuietHoursEnd = *input.QuietHoursEnd
+	}
+	if (setting.QuietHoursStart == "") != (setting.QuietHoursEnd == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "quiet_hours_start and quiet_hours_end must be set together"})
+		return
+	}
+	for _, clock := range []string{setting.QuietHoursStart, setting.QuietHoursEnd} {
+		if _, err := services.ParseClock(clock); clock != "" && err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quiet hours must be HH:MM"})
+			return
+		}
+	}
+	if input.WebhookURL != nil {
+		if *input.WebhookURL != "" {
+			if err := services.ValidateWebhookURL(*input.WebhookURL); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		setting.WebhookURL = *input.WebhookURL
+	}
+	if input.WebhookToken != nil {
+		setting.WebhookToken = *input.WebhookToken
+	}
+	if input.WebhookFormat != nil {
+		if *input.WebhookFormat != entity.WebhookFormatJSON && *input.WebhookFormat != entity.WebhookFormatLineNotify {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "webhook_format must be json or line_notify"})
+			return
+		}
+		setting.WebhookFormat = *input.WebhookFormat
+	}
+	if input.Language != nil {
+		if !containsString(services.EmailLanguages, *input.Language) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "language must be th or en"})
+			return
+		}
+		setting.Language = *input.Language
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if len(prefs) > 0 {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}, {Name: "channel"}},
+				DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+			}).Create(&prefs).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(&setting).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	respondNotificationPreferences(c, db, userID)
+}
+
+// GetNotificationDeliveries : GET /notifications/:id/deliveries
+// สถานะการส่งแจ้งเตือนแต่ละช่องทาง (เจ้าของแจ้งเตือนหรือแอดมิน)
+func GetNotificationDeliveries(c *gin.Context) {
+	db := config.GetDB()
+	user, err := getAuthUser(c, db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	id, err := parseUintParam(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification id"})
+		return
+	}
+	var notification entity.Notification
+	if err := db.First(&notification, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "notification not found"})
+		return
+	}
+	if (notification.UserID == nil || *notification.UserID != user.ID) && !isAdmin(user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to view this notification"})
+		return
+	}
+
+	var deliveries []entity.NotificationDelivery
+	if err := db.Where("notification_id = ?", notification.ID).Order("channel").Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": deliveries})
+}
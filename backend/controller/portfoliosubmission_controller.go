@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
 	"gorm.io/gorm"
 )
 
@@ -35,34 +36,14 @@ func (c *PortfolioSubmissionController) Create(ctx *gin.Context) {
 	}
 	userID := userIDAny.(uint)
 
-	// 3️⃣ หา version ล่าสุด
-	var last entity.PortfolioSubmission
-	version := 1
-	err := c.DB.
-		Where("portfolio_id = ? AND user_id = ?", body.PortfolioID, userID).
-		Order("version desc").
-		First(&last).Error
-
-	if err == nil {
-		version = last.Version + 1
-
-		// ปิด current version ตัวเก่า
-		c.DB.Model(&entity.PortfolioSubmission{}).
-			Where("portfolio_id = ? AND user_id = ? AND is_current_version = ?", body.PortfolioID, userID, true).
-			Update("is_current_version", false)
-	}
-
-	// 4️⃣ สร้าง submission ใหม่
-	submission := entity.PortfolioSubmission{
-		PortfolioID:     body.PortfolioID,
-		UserID:          userID,
-		Status:          "awaiting",
-		Version:         version,
-		Is_current_version:true,
-		Submission_at:    time.Now(),
-	}
-
-	if err := c.DB.Create(&submission).Error; err != nil {
+	// 3️⃣ สร้าง submission version ใหม่ (ยก comment จาก version ก่อนหน้ามาด้วย)
+	var submission *entity.PortfolioSubmission
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		submission, err = services.CreateSubmissionVersion(tx, body.PortfolioID, userID, nil, "awaiting")
+		return err
+	})
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
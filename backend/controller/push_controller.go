@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/config"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm/clause"
+)
+
+// PushSubscriptionInput is the JSON of the browser's PushSubscription.
+type PushSubscriptionInput struct {
+	Endpoint string `json:"endpoint" binding:"required"`
+	Keys     struct {
+		P256dh string `json:"p256dh" binding:"required"`
+		Auth   string `json:"auth" binding:"required"`
+	} `json:"keys" binding:"required"`
+}
+
+func requireWebPush(c *gin.Context) bool {
+	if services.WebPush == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "web push is not configured"})
+		return false
+	}
+	return true
+}
+
+// GetPushPublicKey : GET /notifications/push/public-key
+// คีย์ VAPID สำหรับ pushManager.subscribe({ applicationServerKey })
+func GetPushPublicKey(c *gin.Context) {
+	if !requireWebPush(c) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"public_key": services.WebPush.Keys.PublicKey}})
+}
+
+// SubscribePush : POST /notifications/push/subscriptions
+// บันทึกอุปกรณ์ของผู้ใช้ (endpoint เดิมจะถูกย้ายมาเป็นของผู้ใช้ที่ล็อกอินอยู่)
+func SubscribePush(c *gin.Context) {
+	userID, err := getAuthUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	var input PushSubscriptionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := services.ValidatePushSubscription(input.Endpoint, input.Keys.P256dh, input.Keys.Auth); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub := entity.PushSubscription{
+		UserID:    userID,
+		Endpoint:  input.Endpoint,
+		P256dh:    input.Keys.P256dh,
+		Auth:      input.Keys.Auth,
+		UserAgent: c.Request.UserAgent(),
+	}
+	db := config.GetDB()
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "endpoint"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "p256dh", "auth", "user_agent", "last_error", "updated_at"}),
+	}).Create(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := db.Where("endpoint = ?", sub.Endpoint).First(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": sub})
+}
+
+// ListPushSubscriptions : GET /notifications/push/subscriptions
+func ListPushSubscriptions(c *gin.Context) {
+	userID, err := getAuthUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	var subs []entity.PushSubscription
+	if err := config.GetDB().Where("user_id = ?", userID).Order("id DESC").Find(&subs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": subs})
+}
+
+// UnsubscribePush : POST /notifications/push/unsubscribe {endpoint}
+func UnsubscribePush(c *gin.Context) {
+	userID, err := getAuthUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	var input struct {
+		Endpoint string `json:"endpoint" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := config.GetDB().Unscoped().
+		Where("user_id = ? AND endpoint = ?", userID, input.Endpoint).
+		Delete(&entity.PushSubscription{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "unsubscribed"})
+}
+
+// SendTestPush : POST /notifications/push/test
+// ส่งแจ้งเตือนทดสอบไปทุกอุปกรณ์ของผู้ใช้ และบอกผลแยกรายอุปกรณ์
+func SendTestPush(c *gin.Context) {
+	if !requireWebPush(c) {
+		return
+	}
+	userID, err := getAuthUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	var subs []entity.PushSubscription
+	if err := config.GetDB().Where("user_id = ?", userID).Find(&subs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(subs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no devices are subscribed to push notifications"})
+		return
+	}
+
+	payload, _ := json.Marshal(services.PushPayload{
+		Title: "ทดสอบการแจ้งเตือน",
+		Body:  "อุปกรณ์นี้จะได้รับการแจ้งเตือนจากระบบแล้ว",
+		URL:   "/",
+		Tag:   "test",
+		Type:  entity.NotificationTypeSystem,
+	})
+	results, err := services.WebPush.SendToSubscriptions(c.Request.Context(), subs, payload,
+		services.WebPushOptions{TTL: time.Minute, Urgency: "normal"}, time.Now())
+	switch {
+	case errors.Is(err, services.ErrNoRecipientAddress):
+		// ทุก subscription หมดอายุและถูกลบไปแล้ว ต้องสมัครใหม่
+		c.JSON(http.StatusGone, gin.H{"error": "all push subscriptions have expired", "data": results})
+	case err != nil:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "data": results})
+	default:
+		c.JSON(http.StatusOK, gin.H{"data": results})
+	}
+}
@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	exportFormatCSV  = "csv"
+	exportFormatXLSX = "xlsx"
+)
+
+// ExportByCurriculum streams the ranked scorecards of a curriculum round.
+// GET /api/scorecards/export/curriculum/:curriculumId?format=csv|xlsx
+func (c *ScorecardController) ExportByCurriculum(ctx *gin.Context) {
+	user, err := getAuthUser(ctx, c.DB)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	if !isReviewer(user) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only teachers and admins can export scorecards"})
+		return
+	}
+
+	format := strings.ToLower(ctx.DefaultQuery("format", exportFormatCSV))
+	if format != exportFormatCSV && format != exportFormatXLSX {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return
+	}
+
+	var curriculum entity.Curriculum
+	if err := c.DB.First(&curriculum, ctx.Param("curriculumId")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "curriculum not found"})
+		return
+	}
+
+	criteria, err := services.ListExportCriteria(c.DB, curriculum.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	columns := services.ScorecardExportColumns(criteria)
+	filename := fmt.Sprintf("scorecards_%s_%s.%s", sanitizeFilename(curriculum.Code), time.Now().Format("20060102_150405"), format)
+
+	if format == exportFormatXLSX {
+		c.writeXLSX(ctx, &curriculum, columns, filename)
+		return
+	}
+	c.writeCSV(ctx, &curriculum, columns, filename)
+}
+
+func (c *ScorecardController) writeCSV(ctx *gin.Context, curriculum *entity.Curriculum, columns []services.ExportColumn, filename string) {
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	ctx.Status(http.StatusOK)
+
+	// BOM ให้ Excel อ่านภาษาไทยได้ถูกต้อง
+	ctx.Writer.Write([]byte("\xEF\xBB\xBF"))
+
+	w := csv.NewWriter(ctx.Writer)
+	w.Write(services.ExportHeader(columns))
+
+	count := 0
+	err := services.StreamCurriculumScorecards(c.DB, curriculum, func(row services.ScorecardExportRow) error {
+		w.Write(services.ExportRecord(columns, &row))
+		count++
+		if count%100 == 0 {
+			w.Flush()
+			ctx.Writer.Flush()
+		}
+		return w.Error()
+	})
+	w.Flush()
+
+	if err != nil {
+		// headers are already sent, the best we can do is to cut the stream short
+		ctx.Error(err)
+		return
+	}
+	ctx.Writer.Flush()
+}
+
+func (c *ScorecardController) writeXLSX(ctx *gin.Context, curriculum *entity.Curriculum, columns []services.ExportColumn, filename string) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Scorecards"
+	f.SetSheetName("Sheet1", sheet)
+
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	header := services.ExportHeader(columns)
+	headerRow := make([]interface{}, len(header))
+	for i, h := range header {
+		headerRow[i] = h
+	}
+	if err := sw.SetRow("A1", headerRow); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	rowNum := 2
+	err = services.StreamCurriculumScorecards(c.DB, curriculum, func(row services.ScorecardExportRow) error {
+		// numeric columns stay numeric so the sheet can be sorted/filtered
+		values := services.ExportValues(columns, &row)
+		cell, _ := excelize.CoordinatesToCellName(1, rowNum)
+		rowNum++
+		return sw.SetRow(cell, values)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := sw.Flush(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	ctx.Status(http.StatusOK)
+	if err := f.Write(ctx.Writer); err != nil {
+		ctx.Error(err)
+	}
+}
+
+func sanitizeFilename(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "curriculum"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == '"' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+}
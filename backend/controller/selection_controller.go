@@ -2,10 +2,12 @@ package controller
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sut68/team14/backend/config"
 	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
 )
 
 type SelectionController struct{}
@@ -55,16 +57,17 @@ func (sc *SelectionController) GetMySelections(c *gin.Context) {
 	var selections []entity.Selection
 
 	// Preload Curriculum เพื่อเอาข้อมูลวิชาไปแสดง
-	if err := db.Preload("Curriculum").Preload("Curriculum.Program").Preload("Curriculum.Faculty").Where("user_id = ?", userId).Find(&selections).Error; err != nil {
+	if err := db.Preload("Curriculum").Preload("Curriculum.Rounds").Preload("Curriculum.Program").Preload("Curriculum.Faculty").Where("user_id = ?", userId).Find(&selections).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// ✅✅✅ ส่วนที่เพิ่ม: วนลูปคำนวณสถานะใหม่ตามเวลาจริง ✅✅✅
-	// (ใช้ฟังก์ชัน calculateCurriculumStatus จากไฟล์ curriculum_controller.go ใน package เดียวกัน)
+	// (ใช้ services.ApplyCurriculumStatus ตัวเดียวกับหน้าหลักสูตร)
+	now := time.Now()
 	for i := range selections {
 		if selections[i].Curriculum != nil {
-			calculateCurriculumStatus(selections[i].Curriculum)
+			services.ApplyCurriculumStatus(selections[i].Curriculum, now)
 		}
 	}
 	c.JSON(http.StatusOK, gin.H{"data": selections})
@@ -105,32 +108,3 @@ func (sc *SelectionController) ToggleNotification(c *gin.Context) {
 		"is_notified": selection.IsNotified,
 	})
 }
-
-// 4. ฟังก์ชันดึงการแจ้งเตือน (Get Notifications)
-func (sc *SelectionController) GetNotifications(c *gin.Context) {
-	userId := c.Query("user_id")
-	db := config.GetDB()
-	var notis []entity.Notification
-
-	// ดึงแจ้งเตือนที่ยังไม่ได้อ่าน
-	if err := db.Where("user_id = ? AND is_read = ?", userId, false).Order("created_at desc").Find(&notis).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"data": notis})
-}
-
-// 5. ฟังก์ชัน Mark as Read (เปลี่ยนสถานะเป็นอ่านแล้ว)
-func (sc *SelectionController) MarkAsRead(c *gin.Context) {
-	id := c.Param("id") // รับ ID ของ Notification ที่จะแก้
-	db := config.GetDB()
-
-	// อัปเดตเฉพาะรายการนั้นให้ is_read = true
-	if err := db.Model(&entity.Notification{}).Where("id = ?", id).Update("is_read", true).Error; err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(200, gin.H{"message": "success"})
-}
\ No newline at end of file
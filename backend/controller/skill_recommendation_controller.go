@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm"
+)
+
+// ==================== Skill Recommendation ====================
+
+// GetSkillRecommendations : แนะนำหลักสูตรตามทักษะที่นักเรียนแสดงผ่านกิจกรรม/ผลงาน พร้อมทักษะที่ยังขาด
+// GET /curricula/recommendations?limit=10  (ครู/แอดมินส่ง ?user_id= เพื่อดูของนักเรียนคนอื่นได้)
+func (cc *CurriculumController) GetSkillRecommendations(c *gin.Context) {
+	studentID, ok := cc.eligibilityStudentID(c)
+	if !ok {
+		return
+	}
+
+	limit := 10
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+		limit = n
+	}
+
+	rec, err := services.BuildSkillRecommendation(cc.db, studentID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rec})
+}
+
+// ==================== Type → Skill Mappings ====================
+
+type typeSkillPayload struct {
+	TypeID  uint `json:"type_id" binding:"required"`
+	SkillID uint `json:"skill_id" binding:"required"`
+	Weight  int  `json:"weight"`
+}
+
+// ListTypeSkillMappings returns which skills each activity/working type demonstrates
+func (cgc *CourseGroupController) ListTypeSkillMappings(c *gin.Context) {
+	maps, err := services.LoadSkillTypeMaps(cgc.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"activity": maps.Activity, "working": maps.Working}})
+}
+
+// ReplaceTypeSkillMappings replaces all mappings of one kind in a single transaction
+// PUT /admin/skills/type-mappings/:kind  (kind = activity | working)  { "mappings": [ { "type_id": 1, "skill_id": 2, "weight": 3 } ] }
+func (cgc *CourseGroupController) ReplaceTypeSkillMappings(c *gin.Context) {
+	kind := c.Param("kind")
+	if kind != "activity" && kind != "working" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be activity or working"})
+		return
+	}
+
+	var payload struct {
+		Mappings []typeSkillPayload `json:"mappings" binding:"dive"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i, m := range payload.Mappings {
+		if m.Weight == 0 {
+			payload.Mappings[i].Weight = 1
+		} else if m.Weight < 1 || m.Weight > 5 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("mappings[%d]: weight must be between 1 and 5", i)})
+			return
+		}
+		if err := cgc.db.First(&entity.Skill{}, m.SkillID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("mappings[%d]: skill not found", i)})
+			return
+		}
+		var typeModel interface{} = &entity.TypeActivity{}
+		if kind == "working" {
+			typeModel = &entity.TypeWorking{}
+		}
+		if err := cgc.db.First(typeModel, m.TypeID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("mappings[%d]: %s type not found", i, kind)})
+			return
+		}
+	}
+
+	err := cgc.db.Transaction(func(tx *gorm.DB) error {
+		if kind == "activity" {
+			if err := tx.Where("1 = 1").Delete(&entity.TypeActivitySkill{}).Error; err != nil {
+				return err
+			}
+			for _, m := range payload.Mappings {
+				link := entity.TypeActivitySkill{TypeActivityID: m.TypeID, SkillID: m.SkillID, Weight: m.Weight}
+				if err := tx.Create(&link).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := tx.Where("1 = 1").Delete(&entity.TypeWorkingSkill{}).Error; err != nil {
+			return err
+		}
+		for _, m := range payload.Mappings {
+			link := entity.TypeWorkingSkill{TypeWorkingID: m.TypeID, SkillID: m.SkillID, Weight: m.Weight}
+			if err := tx.Create(&link).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	cgc.ListTypeSkillMappings(c)
+}
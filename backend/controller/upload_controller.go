@@ -1,11 +1,13 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"path/filepath"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/config"
 	"github.com/sut68/team14/backend/services"
 )
 
@@ -112,11 +114,34 @@ func (u *UploadController) UploadFile(c *gin.Context) {
 		return
 	}
 
-	url, err := services.AzureStorage.UploadFile(file, header.Filename, contentType)
+	scan, err := services.StoreScannedUpload(c.Request.Context(), config.GetDB(), services.AzureStorage, services.UploadMeta{
+		FileName: header.Filename, ContentType: contentType, Size: header.Size,
+		Purpose: services.UploadPurposeGeneral, UserID: optionalAuthUserID(c),
+	}, file)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file: " + err.Error()})
+		respondUploadError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"url": url})
+	c.JSON(http.StatusOK, gin.H{"url": scan.FileURL, "upload_id": scan.ID})
+}
+
+// optionalAuthUserID is the caller on routes where login is optional.
+func optionalAuthUserID(c *gin.Context) *uint {
+	if id, err := getAuthUserID(c); err == nil {
+		return &id
+	}
+	return nil
+}
+
+// respondUploadError answers a failed StoreScannedUpload.
+func respondUploadError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrFileInfected):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "File was rejected by the virus scan"})
+	case errors.Is(err, services.ErrScanUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "File cannot be scanned right now, please try again later"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file: " + err.Error()})
+	}
 }
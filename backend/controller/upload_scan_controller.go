@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/config"
+	"github.com/sut68/team14/backend/entity"
+)
+
+// ListUploadScans : GET /admin/upload-scans?status=infected&purpose=&user_id=&page=1&limit=20
+// บันทึกการสแกนไฟล์อัปโหลด สำหรับตรวจสอบไฟล์ที่ถูกปฏิเสธ (แอดมินเท่านั้น)
+func ListUploadScans(c *gin.Context) {
+	db := config.GetDB()
+	user, err := getAuthUser(c, db)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	if !isAdmin(user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+		return
+	}
+
+	page := parseIntWithDefault(c.Query("page"), 1)
+	limit := parseIntWithDefault(c.Query("limit"), 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := db.Model(&entity.UploadScan{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if purpose := c.Query("purpose"); purpose != "" {
+		query = query.Where("purpose = ?", purpose)
+	}
+	if userID := parseIntWithDefault(c.Query("user_id"), 0); userID > 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var scans []entity.UploadScan
+	if err := query.Preload("User").
+		Order("id DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&scans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       scans,
+		"page":       page,
+		"limit":      limit,
+		"total":      total,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	})
+}
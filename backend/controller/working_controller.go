@@ -62,6 +62,7 @@ func (wc *WorkingController) GetWorking(c *gin.Context) {
 		Preload("WorkingDetail.TypeWorking").
 		Preload("WorkingDetail.Images").
 		Preload("WorkingDetail.Links").
+		Preload("Skills.Skill").
 		Preload("User").
 		First(&working, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Working not found"})
@@ -95,7 +96,8 @@ func (wc *WorkingController) ListWorkings(c *gin.Context) {
 	db.Model(&entity.Working{}).Count(&total)
 
 	query := db.Preload("WorkingDetail").
-		Preload("WorkingDetail.TypeWorking")
+		Preload("WorkingDetail.TypeWorking").
+		Preload("Skills.Skill")
 
 	// Only preload images/links if explicitly requested
 	if includeImages {
@@ -135,6 +137,8 @@ func (wc *WorkingController) UpdateWorking(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// skills are managed through PUT /workings/:id/skills
+	working.Skills = nil
 
 	if err := db.Save(&working).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -195,6 +199,7 @@ func (wc *WorkingController) ListWorkingsByUser(c *gin.Context) {
 
 	query := db.Preload("WorkingDetail").
 		Preload("WorkingDetail.TypeWorking").
+		Preload("Skills.Skill").
 		Where("user_id = ?", userId)
 
 	if includeImages {
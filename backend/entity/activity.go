@@ -15,6 +15,9 @@ type Activity struct {
 	User            *User           `gorm:"foreignKey:UserID" json:"user"`
 	RewardID       uint            `json:"reward_id"`
 	Reward         *Reward         `gorm:"foreignKey:RewardID" json:"reward"`
+
+	// ทักษะที่กิจกรรมนี้แสดง (จัดการผ่าน PUT /activities/:id/skills)
+	Skills []ActivitySkill `gorm:"foreignKey:ActivityID" json:"skills" valid:"-"`
 }
 
 // BeforeCreate checks for duplicate ActivityName and validates struct
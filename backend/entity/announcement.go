@@ -5,21 +5,53 @@ import (
 	"time"
 )
 
+// Announcement lifecycle statuses (see services.TransitionAnnouncement).
+const (
+	AnnouncementDraft     = "draft"
+	AnnouncementScheduled = "scheduled"
+	AnnouncementPublished = "published"
+	AnnouncementExpired   = "expired"
+	AnnouncementArchived  = "archived"
+)
+
+// Announcement content formats (see services.RenderAnnouncementContent).
+const (
+	AnnouncementFormatPlain    = "plain"
+	AnnouncementFormatMarkdown = "markdown"
+	AnnouncementFormatHTML     = "html" // HTML แบบจำกัดแท็ก ผ่าน services.SanitizeHTML ก่อนบันทึก
+)
+
 type Announcement struct {
 	gorm.Model `valid:"-"`
 	
 	Title                string     `json:"title" valid:"required~Title is required,stringlength(3|200)~Title must be between 3-200 characters"`
-	Content              string     `json:"content" valid:"required~Content is required,stringlength(10|5000)~Content must be between 10-5000 characters"`
+	Content              string     `json:"content" valid:"required~Content is required,stringlength(10|50000)~Content must be between 10-50000 characters"`
+	ContentFormat        string     `json:"content_format" gorm:"size:20;default:'plain'" valid:"in(plain|markdown|html)~Content format must be plain/markdown/html"`
+	// ContentHTML: เนื้อหาที่แปลงเป็น HTML ปลอดภัยแล้ว สร้างตอนอ่าน ไม่เก็บในฐานข้อมูล
+	ContentHTML string `json:"content_html" gorm:"-" valid:"-"`
+	// Receipt: การอ่าน/รับทราบของผู้ใช้ที่เรียกดู ไม่เก็บในตารางนี้
+	Receipt *AnnouncementReceipt `json:"receipt,omitempty" gorm:"-" valid:"-"`
 	Is_Pinned            *bool      `json:"is_pinned" valid:"-"`
 	Scheduled_Publish_At *time.Time `json:"scheduled_publish_at" valid:"-"`
 	Published_At         *time.Time `json:"published_at" valid:"-"`
 	Expires_At           *time.Time `json:"expires_at" valid:"-"`
 	Send_Notification    bool       `json:"send_notification" valid:"-"`
-	Status               string     `json:"status" valid:"required~Status is required,matches(^(draft|published|scheduled|expired|archived)$)~Status must be draft, published, scheduled, expired, or archived"`  // FIXED: use matches instead of in
+	Status               string     `json:"status" valid:"required~Status is required,in(draft|published|scheduled|expired|archived)~Status must be draft/scheduled/published/expired/archived"`
 
 	UserID uint  `json:"user_id" valid:"required~UserID is required"`
 	User   *User `gorm:"foreignKey:UserID" json:"user" valid:"-"`
 
 	CetagoryID uint      `json:"cetagory_id" valid:"required~CetagoryID is required"`
 	Cetagory   *Cetagory `gorm:"foreignKey:CetagoryID" json:"cetagory" valid:"-"`
+
+	// RequiresAck: ผู้รับต้องกดรับทราบ ภายใน AckDeadline (ถ้ากำหนด)
+	RequiresAck       bool       `json:"requires_ack" gorm:"default:false" valid:"-"`
+	AckDeadline       *time.Time `json:"ack_deadline" valid:"-"`
+	AckReminderSentAt *time.Time `json:"ack_reminder_sent_at" valid:"-"`
+
+	// Revision: เลขรุ่นล่าสุดใน AnnouncementRevision
+	Revision int `json:"revision" gorm:"default:0" valid:"-"`
+
+	// กลุ่มเป้าหมาย ว่าง = ทุกคน
+	Audiences []AnnouncementAudience `json:"audiences" valid:"-"`
 }
\ No newline at end of file
@@ -7,6 +7,8 @@ import (
 	"time"
 )
 
+// Announcement_Attachment is a file uploaded to an announcement through
+// services.StoreAnnouncementAttachment; File_path is always a URL of our storage.
 type Announcement_Attachment struct {
 	gorm.Model
 
@@ -14,11 +16,19 @@ type Announcement_Attachment struct {
 	File_path       string    `json:"file_path" valid:"required~File_path is required"` //resolve to URL in frontend
 	Uploaded_At    time.Time `json:"uploaded_at" valid:"required~Uploaded_At is required"`
 
+	// Storage_Key: ชื่อไฟล์ใน storage ใช้ลบไฟล์เมื่อลบไฟล์แนบ/ประกาศ
+	Storage_Key    string `json:"-" valid:"-"`
+	Content_Type   string `json:"content_type" valid:"-"`
+	File_Size      int64  `json:"file_size" valid:"-"`
+	Download_Count int    `json:"download_count" gorm:"default:0" valid:"-"`
+	// Download_URL: ลิงก์ดาวน์โหลดที่นับจำนวนครั้ง สร้างตอนตอบกลับ
+	Download_URL string `json:"download_url,omitempty" gorm:"-" valid:"-"`
+
 	//FK
-	AnnouncementID uint        `json:"announcement_id" valid:"required~AnnouncementID is required"`
-	Announcement   *Announcement `gorm:"foreignKey:AnnouncementID" json:"announcement"`
+	AnnouncementID uint        `json:"announcement_id" gorm:"index" valid:"required~AnnouncementID is required"`
+	Announcement   *Announcement `gorm:"foreignKey:AnnouncementID" json:"announcement,omitempty" valid:"-"`
 
-	CetagoryID  uint     `json:"cetagory_id" valid:"required~CetagoryID is required"`
-	Cetagory    *Cetagory  `gorm:"foreignKey:CetagoryID" json:"cetagory" `
+	UploadedByID *uint `json:"uploaded_by_id" valid:"-"`
+	UploadedBy   *User `gorm:"foreignKey:UploadedByID" json:"uploaded_by,omitempty" valid:"-"`
 }
 
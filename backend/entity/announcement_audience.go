@@ -0,0 +1,31 @@
+package entity
+
+import "gorm.io/gorm"
+
+// Audience kinds of an announcement.
+const (
+	AudienceRole         = "role"          // TargetID = AccountTypeID (UserTypeStudent/Teacher/Admin)
+	AudienceFaculty      = "faculty"       // TargetID = FacultyID
+	AudienceProgram      = "program"       // TargetID = ProgramID
+	AudienceCurriculum   = "curriculum"    // TargetID = CurriculumID
+	AudienceAcademicYear = "academic_year" // Value = ปีการศึกษา เช่น "2568"
+	AudienceUser         = "user"          // TargetID = UserID
+)
+
+// AnnouncementAudience is one target of an announcement. An announcement without audience
+// rows is for everyone. See services.AudienceMatches for how rows are combined.
+//
+// Faculty, program, curriculum and academic-year targets reach the users linked to a matching
+// curriculum: students who bookmarked or applied to it and the teacher responsible for it
+// (plus the faculty's admins for a faculty target). There are no enrollment records, so a
+// student or teacher without such a link is not reached; use role or user targets for them.
+type AnnouncementAudience struct {
+	gorm.Model `valid:"-"`
+
+	AnnouncementID uint          `json:"announcement_id" gorm:"index;not null" valid:"-"`
+	Announcement   *Announcement `gorm:"foreignKey:AnnouncementID" json:"announcement,omitempty" valid:"-"`
+
+	Kind     string `json:"kind" gorm:"size:20;not null" valid:"required~Kind is required,in(role|faculty|program|curriculum|academic_year|user)~Kind is not supported"`
+	TargetID uint   `json:"target_id" valid:"-"`
+	Value    string `json:"value" gorm:"size:20" valid:"stringlength(0|20)~Value must not exceed 20 characters"`
+}
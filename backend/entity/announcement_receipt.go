@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AnnouncementReceipt records that a user opened an announcement and, for announcements that
+// require it, when they acknowledged it. One row per announcement and user.
+type AnnouncementReceipt struct {
+	gorm.Model
+
+	AnnouncementID uint          `json:"announcement_id" gorm:"uniqueIndex:idx_announcement_receipt;not null"`
+	Announcement   *Announcement `gorm:"foreignKey:AnnouncementID" json:"announcement,omitempty"`
+	UserID         uint          `json:"user_id" gorm:"uniqueIndex:idx_announcement_receipt;index;not null"`
+	User           *User         `gorm:"foreignKey:UserID" json:"user,omitempty"`
+
+	FirstViewedAt  time.Time  `json:"first_viewed_at"`
+	LastViewedAt   time.Time  `json:"last_viewed_at"`
+	ViewCount      int        `json:"view_count" gorm:"default:0"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+}
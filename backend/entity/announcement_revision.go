@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// AnnouncementRevision is the state of an announcement after one change: who made it
+// (EditorID, nil for the scheduler), when (CreatedAt) and which action it was.
+type AnnouncementRevision struct {
+	gorm.Model
+
+	AnnouncementID uint          `json:"announcement_id" gorm:"uniqueIndex:idx_announcement_revision;not null"`
+	Announcement   *Announcement `gorm:"foreignKey:AnnouncementID" json:"announcement,omitempty"`
+	Revision       int           `json:"revision" gorm:"uniqueIndex:idx_announcement_revision;not null"`
+	// Action: create, edit, publish, schedule, unpublish, expire, archive, unarchive, restore, baseline
+	Action string `json:"action" gorm:"size:20"`
+	// RestoredFrom: รุ่นที่ถูกนำกลับมา เมื่อ Action = restore
+	RestoredFrom *int `json:"restored_from"`
+
+	Title                string         `json:"title"`
+	Content              string         `json:"content"`
+	ContentFormat        string         `json:"content_format" gorm:"size:20"`
+	Status               string         `json:"status" gorm:"size:20"`
+	Is_Pinned            bool           `json:"is_pinned"`
+	Scheduled_Publish_At *time.Time     `json:"scheduled_publish_at"`
+	Published_At         *time.Time     `json:"published_at"`
+	Expires_At           *time.Time     `json:"expires_at"`
+	Send_Notification    bool           `json:"send_notification"`
+	RequiresAck          bool           `json:"requires_ack"`
+	AckDeadline          *time.Time     `json:"ack_deadline"`
+	CetagoryID           uint           `json:"cetagory_id"`
+	Audiences            datatypes.JSON `json:"audiences"`
+
+	EditorID *uint `json:"editor_id"`
+	Editor   *User `gorm:"foreignKey:EditorID" json:"editor,omitempty"`
+}
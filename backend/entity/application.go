@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Application statuses. An application starts as a draft, is frozen on submit and
+// is then moved by reviewers until a final decision is made.
+const (
+	ApplicationStatusDraft       = "draft"
+	ApplicationStatusSubmitted   = "submitted"
+	ApplicationStatusUnderReview = "under_review"
+	ApplicationStatusAccepted    = "accepted"
+	ApplicationStatusRejected    = "rejected"
+	ApplicationStatusWithdrawn   = "withdrawn"
+)
+
+// Application is a student's application to one curriculum with a chosen portfolio.
+// Submitting it creates a PortfolioSubmission tied to the curriculum.
+type Application struct {
+	gorm.Model `valid:"-"`
+
+	Status       string     `json:"status" gorm:"index" valid:"required~Status is required,in(draft|submitted|under_review|accepted|rejected|withdrawn)~Status must be draft/submitted/under_review/accepted/rejected/withdrawn"`
+	SubmittedAt  *time.Time `json:"submitted_at" valid:"-"`
+	DecidedAt    *time.Time `json:"decided_at" valid:"-"`
+	DecisionNote string     `json:"decision_note" valid:"stringlength(0|1000)~Decision note must not exceed 1000 characters"`
+
+	// FK
+	// ใบสมัครที่ยังไม่ถอน/ไม่ถูกปฏิเสธ มีได้ใบเดียวต่อหลักสูตร
+	UserID uint  `json:"user_id" gorm:"index;uniqueIndex:idx_applications_active,where:status <> 'withdrawn' AND status <> 'rejected' AND deleted_at IS NULL" valid:"required~UserID is required"`
+	User   *User `gorm:"foreignKey:UserID" json:"user,omitempty" valid:"-"`
+
+	CurriculumID uint        `json:"curriculum_id" gorm:"index;uniqueIndex:idx_applications_active" valid:"required~CurriculumID is required"`
+	Curriculum   *Curriculum `gorm:"foreignKey:CurriculumID" json:"curriculum,omitempty" valid:"-"`
+
+	// รอบที่เปิดอยู่ตอนสร้างใบสมัคร
+	ApplicationRoundID *uint             `json:"application_round_id" gorm:"index" valid:"-"`
+	ApplicationRound   *ApplicationRound `gorm:"foreignKey:ApplicationRoundID" json:"application_round,omitempty" valid:"-"`
+
+	PortfolioID uint       `json:"portfolio_id" valid:"required~PortfolioID is required"`
+	Portfolio   *Portfolio `gorm:"foreignKey:PortfolioID" json:"portfolio,omitempty" valid:"-"`
+
+	PortfolioSubmissionID *uint                `json:"portfolio_submission_id" valid:"-"`
+	PortfolioSubmission   *PortfolioSubmission `gorm:"foreignKey:PortfolioSubmissionID" json:"portfolio_submission,omitempty" valid:"-"`
+
+	DecidedByID *uint `json:"decided_by_id" valid:"-"`
+	DecidedBy   *User `gorm:"foreignKey:DecidedByID" json:"decided_by,omitempty" valid:"-"`
+
+	Documents []ApplicationDocument `json:"documents,omitempty" valid:"-"`
+}
+
+// IsActive reports whether the application still holds a place in the curriculum round.
+func (a *Application) IsActive() bool {
+	return a.Status != ApplicationStatusWithdrawn && a.Status != ApplicationStatusRejected
+}
+
+// IsEditable reports whether the student may still change the application.
+func (a *Application) IsEditable() bool {
+	return a.Status == ApplicationStatusDraft
+}
@@ -0,0 +1,23 @@
+package entity
+
+import "gorm.io/gorm"
+
+// ApplicationDocument is a file uploaded by the student for one of the
+// curriculum's required document types.
+type ApplicationDocument struct {
+	gorm.Model `valid:"-"`
+
+	FileName string `json:"file_name" valid:"required~File name is required,stringlength(1|255)~File name must not exceed 255 characters"`
+	FilePath string `json:"file_path" valid:"required~File path is required"`
+
+	// FK
+	ApplicationID uint         `json:"application_id" gorm:"index" valid:"required~ApplicationID is required"`
+	Application   *Application `gorm:"foreignKey:ApplicationID" json:"application,omitempty" valid:"-"`
+
+	DocumentTypeID uint          `json:"document_type_id" valid:"required~DocumentTypeID is required"`
+	DocumentType   *DocumentType `gorm:"foreignKey:DocumentTypeID" json:"document_type,omitempty" valid:"-"`
+
+	// UploadScanID: ไฟล์ที่อัปโหลดและผ่านการสแกนไวรัสแล้ว (ไฟล์เดิมก่อนมีการสแกนเป็น nil)
+	UploadScanID *uint       `json:"upload_scan_id" gorm:"index" valid:"-"`
+	UploadScan   *UploadScan `gorm:"foreignKey:UploadScanID" json:"-" valid:"-"`
+}
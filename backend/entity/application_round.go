@@ -0,0 +1,158 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // Asia/Bangkok ต้องโหลดได้แม้ image ไม่มี zoneinfo
+
+	"gorm.io/gorm"
+)
+
+// DefaultRoundTimezone is used for rounds created without a timezone and for
+// interpreting legacy ApplicationPeriod strings, which were entered in Thai time.
+const DefaultRoundTimezone = "Asia/Bangkok"
+
+// Curriculum/round statuses computed from the clock.
+const (
+	RoundStatusOpening = "opening" // ยังไม่ถึงเวลาเปิด
+	RoundStatusOpen    = "open"
+	RoundStatusClosed  = "closed"
+)
+
+// roundTimeLayouts are the formats accepted for round timestamps without an offset
+// (the "datetime-local" inputs of the admin page).
+var roundTimeLayouts = []string{"2006-01-02T15:04", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// ApplicationRound is one application period of a curriculum, e.g. Portfolio 1 or Quota 2.
+type ApplicationRound struct {
+	gorm.Model `valid:"-"`
+
+	Name        string     `json:"name" valid:"required~Round name is required,stringlength(1|100)~Round name must not exceed 100 characters"`
+	OpensAt     time.Time  `json:"opens_at" gorm:"index" valid:"required~Opening time is required"`
+	ClosesAt    time.Time  `json:"closes_at" gorm:"index" valid:"required~Closing time is required"`
+	AnnouncesAt *time.Time `json:"announces_at" valid:"-"`
+	Timezone    string     `json:"timezone" gorm:"size:64;default:'Asia/Bangkok'" valid:"-"`
+
+	// FK
+	CurriculumID uint        `json:"curriculum_id" gorm:"index" valid:"required~CurriculumID is required"`
+	Curriculum   *Curriculum `gorm:"foreignKey:CurriculumID" json:"curriculum,omitempty" valid:"-"`
+}
+
+// Location returns the round's timezone, falling back to DefaultRoundTimezone.
+func (r *ApplicationRound) Location() *time.Location {
+	return LoadRoundLocation(r.Timezone)
+}
+
+// StatusAt returns opening/open/closed for the given instant. ClosesAt is inclusive.
+func (r *ApplicationRound) StatusAt(now time.Time) string {
+	switch {
+	case now.Before(r.OpensAt):
+		return RoundStatusOpening
+	case now.After(r.ClosesAt):
+		return RoundStatusClosed
+	default:
+		return RoundStatusOpen
+	}
+}
+
+// Validate checks the timestamps and the timezone of the round.
+func (r *ApplicationRound) Validate() error {
+	if r.OpensAt.IsZero() || r.ClosesAt.IsZero() {
+		return errors.New("opens_at and closes_at are required")
+	}
+	if !r.ClosesAt.After(r.OpensAt) {
+		return errors.New("closes_at must be after opens_at")
+	}
+	if r.AnnouncesAt != nil && r.AnnouncesAt.Before(r.ClosesAt) {
+		return errors.New("announces_at must not be before closes_at")
+	}
+	if r.Timezone != "" {
+		if _, err := time.LoadLocation(r.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", r.Timezone)
+		}
+	}
+	return nil
+}
+
+func (r *ApplicationRound) BeforeSave(tx *gorm.DB) error {
+	if r.Timezone == "" {
+		r.Timezone = DefaultRoundTimezone
+	}
+	return r.Validate()
+}
+
+// LoadRoundLocation loads a timezone name, falling back to DefaultRoundTimezone.
+func LoadRoundLocation(name string) *time.Location {
+	if name == "" {
+		name = DefaultRoundTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		loc, _ = time.LoadLocation(DefaultRoundTimezone)
+	}
+	return loc
+}
+
+// ParseRoundTime parses an RFC3339 timestamp, or a wall-clock time in loc.
+func ParseRoundTime(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range roundTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
+
+// ParseLegacyApplicationPeriod แกะ ApplicationPeriod แบบเดิม ("Start|End") เป็นเวลาเปิด/ปิด
+func ParseLegacyApplicationPeriod(period string, loc *time.Location) (time.Time, time.Time, error) {
+	parts := strings.Split(period, "|")
+	if len(parts) < 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid application period %q", period)
+	}
+	start, err := ParseRoundTime(parts[0], loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := ParseRoundTime(parts[1], loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, end, nil
+}
+
+// LegacyRound builds the round that the curriculum's StartDate/EndDate or legacy
+// ApplicationPeriod string describe. ok is false when neither holds a usable period.
+// Only the data migration and the seed use it; everything else reads Rounds.
+func (c *Curriculum) LegacyRound() (ApplicationRound, bool) {
+	round := ApplicationRound{
+		CurriculumID: c.ID,
+		Name:         strings.TrimSpace(c.RoundName),
+		Timezone:     DefaultRoundTimezone,
+	}
+	if round.Name == "" {
+		round.Name = "รอบที่ 1"
+	}
+
+	switch {
+	case !c.StartDate.IsZero() && !c.EndDate.IsZero():
+		round.OpensAt, round.ClosesAt = c.StartDate, c.EndDate
+	default:
+		start, end, err := ParseLegacyApplicationPeriod(c.ApplicationPeriod, LoadRoundLocation(DefaultRoundTimezone))
+		if err != nil {
+			return round, false
+		}
+		round.OpensAt, round.ClosesAt = start, end
+	}
+	if !c.AnnouncementDate.IsZero() && !c.AnnouncementDate.Before(round.ClosesAt) {
+		announces := c.AnnouncementDate
+		round.AnnouncesAt = &announces
+	}
+
+	return round, round.Validate() == nil
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Comment bank categories, one per free-text field of Feedback.
+const (
+	SnippetCategoryOverall     = "overall_comment"
+	SnippetCategoryStrengths   = "strengths"
+	SnippetCategoryImprovement = "areas_for_improvement"
+)
+
+// CommentSnippet is a reusable piece of reviewer feedback. Snippets are private to
+// their owner unless IsShared is set. Body may contain placeholders such as
+// {{student_name}} which are filled in when the snippet is inserted.
+type CommentSnippet struct {
+	gorm.Model `valid:"-"`
+
+	Title        string     `json:"title" valid:"required~Title is required,stringlength(3|100)~Title must be between 3-100 characters"`
+	Body         string     `json:"body" gorm:"type:text" valid:"required~Body is required,stringlength(3|1000)~Body must be between 3-1000 characters"`
+	Category     string     `json:"category" gorm:"index" valid:"required~Category is required,in(overall_comment|strengths|areas_for_improvement)~Category must be one of overall_comment/strengths/areas_for_improvement"`
+	CriteriaName string     `json:"criteria_name" gorm:"index" valid:"stringlength(0|200)~Criteria_Name must not exceed 200 characters"`
+	IsShared     bool       `json:"is_shared" valid:"-"`
+	UsageCount   int        `json:"usage_count" gorm:"default:0" valid:"-"`
+	LastUsedAt   *time.Time `json:"last_used_at" valid:"-"`
+
+	UserID uint  `json:"user_id" gorm:"index" valid:"required~UserID is required"`
+	User   *User `gorm:"foreignKey:UserID" json:"user,omitempty" valid:"-"`
+}
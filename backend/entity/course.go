@@ -4,10 +4,13 @@ import "gorm.io/gorm"
 
 type Course struct {
 	gorm.Model
-	CourseCode   string `json:"course_code"`
-	CourseNameTH string `json:"course_name_th"`
-	CourseNameEN string `json:"course_name_en"`
-	Credits      int    `json:"credits"`
-	Category     int    `json:"category"`
-	Description  string `json:"description" gorm:"type:text"`
+	CourseCode   string `json:"course_code" gorm:"size:20;uniqueIndex" valid:"required~Course code is required,stringlength(1|20)~Course code must not exceed 20 characters"`
+	CourseNameTH string `json:"course_name_th" valid:"required~Thai course name is required"`
+	CourseNameEN string `json:"course_name_en" valid:"-"`
+	Credits      int    `json:"credits" valid:"range(0|30)~Credits must be between 0 and 30"`
+	Category     int    `json:"category" valid:"-"`
+	Description  string `json:"description" gorm:"type:text" valid:"-"`
+
+	// กลุ่มวิชาที่รายวิชานี้อยู่ (หนึ่งรายวิชาอยู่ได้หลายกลุ่ม)
+	CourseGroups []CourseGroup `json:"course_groups,omitempty" gorm:"many2many:course_group_courses" valid:"-"`
 }
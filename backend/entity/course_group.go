@@ -12,4 +12,5 @@ type CourseGroup struct {
 
 	CourseGroupSkills      []CourseGroupSkill      `json:"course_group_skills" gorm:"foreignKey:CourseGroupID"`
 	CurriculumCourseGroups []CurriculumCourseGroup `json:"curriculum_course_groups" gorm:"foreignKey:CourseGroupID"`
+	Courses                []Course                `json:"courses" gorm:"many2many:course_group_courses"`
 }
@@ -4,9 +4,19 @@ import (
 	"errors"
 	"time"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
+// Editorial states of a curriculum (PublishState). They are set by people through the
+// review workflow and are independent of Status, which is derived from the rounds.
+const (
+	CurriculumDraft     = "draft"
+	CurriculumInReview  = "in_review"
+	CurriculumPublished = "published"
+	CurriculumArchived  = "archived"
+)
+
 type Curriculum struct {
 	gorm.Model
 	// เพิ่ม valid tags เพื่อใช้ในการ Testing
@@ -18,6 +28,21 @@ type Curriculum struct {
 	PortfolioMaxPages int     `json:"portfolio_max_pages" valid:"range(1|100)~Pages must be positive"`     // ต้องเป็นบวก
 	Status            string  `json:"status" valid:"required~Status is required"`
 
+	// สถานะการเผยแพร่ (draft/in_review/published/archived) นักเรียนเห็นเฉพาะ published
+	// ส่วน Status เป็นสถานะตามเวลาของรอบรับสมัคร (opening/open/closed)
+	PublishState string `json:"publish_state" gorm:"size:20;default:'published';index"`
+
+	// การส่งตรวจและอนุมัติโดยผู้ดูแลคณะ
+	SubmittedByID *uint      `json:"submitted_by_id"`
+	SubmittedAt   *time.Time `json:"submitted_at"`
+	ReviewedByID  *uint      `json:"reviewed_by_id"`
+	ReviewedAt    *time.Time `json:"reviewed_at"`
+	ReviewNote    string     `json:"review_note" gorm:"type:text"`
+	PublishedAt   *time.Time `json:"published_at"`
+	// หลักสูตรต้นฉบับ เมื่อสร้างจากการ clone ข้ามปีการศึกษา
+	ClonedFromID *uint `json:"cloned_from_id"`
+
+	// ฟิลด์ช่วงเวลาแบบเดิม ใช้สร้าง ApplicationRound ตอน migrate เท่านั้น (ดู Rounds)
 	RoundName        string    `json:"round_name"`
 	AcademicYear     string    `json:"academic_year"`
 	StartDate        time.Time `json:"start_date"`
@@ -34,12 +59,32 @@ type Curriculum struct {
 	UserID uint  `json:"user_id"`
 	User   *User `gorm:"foreignKey:UserID" json:"user"`
 
-	ApplicationPeriod string `json:"application_period" valid:"required~Application Period is required"`
+	// ApplicationPeriod: ช่วงรับสมัครแบบเดิม ("start|end") ใช้แค่ตอน migrate เป็น Rounds
+	ApplicationPeriod string `json:"application_period"`
 	Quota             int    `json:"quota" valid:"range(1|1000)~Quota must be positive"` // ห้ามติดลบและต้องมากกว่า 0
 
+	// รอบรับสมัคร (แทน ApplicationPeriod / StartDate / EndDate แบบเดิม)
+	Rounds []ApplicationRound `json:"rounds"`
+
+	// เกณฑ์คุณสมบัติผู้สมัคร (นอกเหนือจาก GPAXMin)
+	Requirements []CurriculumRequirement `json:"requirements"`
+
+	// กฎคุณสมบัติแบบกำหนดเอง (AND/OR) เก็บเป็น JSON ตรวจด้วย services.ParseRules
+	// ใช้แทน Requirements: หลักสูตรหนึ่งมีได้อย่างใดอย่างหนึ่ง (GPAXMin ใช้เสมอ)
+	EligibilityRules datatypes.JSON `json:"eligibility_rules"`
+
 	RequiredDocuments []CurriculumRequiredDocument `json:"required_documents"`
 	Skills            []CurriculumSkill            `json:"skills"`
 	CourseGroups      []CurriculumCourseGroup      `json:"course_groups"`
+
+	// คำนวณตอนส่งออก ไม่ได้เก็บใน DB
+	Structure *CurriculumStructure `json:"structure,omitempty" gorm:"-"`
+}
+
+// IsPublished reports whether students may see the curriculum. Rows created before
+// PublishState existed count as published.
+func (c *Curriculum) IsPublished() bool {
+	return c.PublishState == CurriculumPublished || c.PublishState == ""
 }
 
 func (c *Curriculum) validateDates() error {
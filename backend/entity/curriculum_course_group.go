@@ -13,3 +13,12 @@ type CurriculumCourseGroup struct {
 	CreditPercentage int    `json:"credit_percentage" gorm:"default:0"`
 	Description      string `json:"description" gorm:"type:text"`
 }
+
+// CurriculumStructure summarises how the course groups of a curriculum split its credits.
+// It is computed (services.CurriculumStructureOf), not stored.
+type CurriculumStructure struct {
+	Groups          int  `json:"groups"`
+	TotalPercentage int  `json:"total_percentage"`
+	Remaining       int  `json:"remaining"`
+	Complete        bool `json:"complete"`
+}
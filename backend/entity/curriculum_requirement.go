@@ -0,0 +1,39 @@
+package entity
+
+import "gorm.io/gorm"
+
+// Requirement kinds of a curriculum's eligibility rules.
+const (
+	RequirementGPAX           = "gpax"
+	RequirementSubjectGPA     = "subject_gpa"
+	RequirementLanguageScore  = "language_score"
+	RequirementGEDScore       = "ged_score"
+	RequirementEducationLevel = "education_level"
+	RequirementCurriculumType = "curriculum_type"
+)
+
+// CurriculumRequirement is one eligibility rule of a curriculum. Rules sharing the same
+// non-empty Group are alternatives (any one must pass, e.g. IELTS 6.0 or TOEFL 80);
+// every group and every ungrouped rule must pass.
+type CurriculumRequirement struct {
+	gorm.Model `valid:"-"`
+
+	Kind  string `json:"kind" valid:"required~Kind is required,in(gpax|subject_gpa|language_score|ged_score|education_level|curriculum_type)~Kind is not supported"`
+	Group string `json:"group" gorm:"size:50" valid:"stringlength(0|50)~Group must not exceed 50 characters"`
+
+	// Subject: math/science/thai/english/social สำหรับ subject_gpa, total/rla/math/science/social สำหรับ ged_score
+	Subject  string  `json:"subject" gorm:"size:30" valid:"-"`
+	TestType string  `json:"test_type" gorm:"size:50" valid:"-"`
+	MinScore float64 `json:"min_score" valid:"-"`
+
+	EducationLevelID *uint           `json:"education_level_id" valid:"-"`
+	EducationLevel   *EducationLevel `gorm:"foreignKey:EducationLevelID" json:"education_level,omitempty" valid:"-"`
+	CurriculumTypeID *uint           `json:"curriculum_type_id" valid:"-"`
+	CurriculumType   *CurriculumType `gorm:"foreignKey:CurriculumTypeID" json:"curriculum_type,omitempty" valid:"-"`
+
+	Description string `json:"description" valid:"stringlength(0|255)~Description must not exceed 255 characters"`
+
+	// FK
+	CurriculumID uint        `json:"curriculum_id" gorm:"index" valid:"required~CurriculumID is required"`
+	Curriculum   *Curriculum `gorm:"foreignKey:CurriculumID" json:"curriculum,omitempty" valid:"-"`
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// CurriculumVersion is a snapshot of a published curriculum (with its rounds, documents,
+// skills, course groups and requirements) taken after each change, so changes can be reviewed.
+type CurriculumVersion struct {
+	gorm.Model
+
+	CurriculumID uint        `json:"curriculum_id" gorm:"index;not null"`
+	Curriculum   *Curriculum `gorm:"foreignKey:CurriculumID" json:"curriculum,omitempty"`
+
+	Version int    `json:"version" gorm:"not null"`
+	Summary string `json:"summary" gorm:"size:255"`
+	// ChangedFields: ฟิลด์ระดับบนสุดที่เปลี่ยนจากเวอร์ชันก่อนหน้า เช่น ["name","rounds"]
+	ChangedFields datatypes.JSON `json:"changed_fields"`
+	Snapshot      datatypes.JSON `json:"snapshot,omitempty"`
+
+	ChangedByID *uint `json:"changed_by_id"`
+	ChangedBy   *User `gorm:"foreignKey:ChangedByID" json:"changed_by,omitempty"`
+}
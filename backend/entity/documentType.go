@@ -2,10 +2,22 @@ package entity
 
 import "gorm.io/gorm"
 
+// DocumentType codes that are satisfied from the student's profile instead of an upload.
+const (
+	DocumentCodeTranscript          = "transcript"
+	DocumentCodeGEDCertificate      = "ged_certificate"
+	DocumentCodeLanguageCertificate = "language_certificate"
+)
+
 type DocumentType struct {
 	gorm.Model
 	Name        string `json:"name"`
 	Description string `json:"description"`
 
+	// Code บอกว่าเอกสารนี้ดึงจากโปรไฟล์ได้หรือไม่ (ว่าง = ต้องอัปโหลดในใบสมัคร)
+	Code string `json:"code" gorm:"size:50;index"`
+	// TestType จำกัดใบรับรองภาษาเฉพาะประเภทการสอบ เช่น IELTS (ว่าง = ประเภทใดก็ได้)
+	TestType string `json:"test_type" gorm:"size:50"`
+
 	RequiredFor []CurriculumRequiredDocument `json:"required_for"`
 }
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EmailMessage is one transactional email in the outbox. It is rendered when the event
+// happens and sent later by services.EmailQueue, with retries. Status uses the Delivery*
+// constants.
+type EmailMessage struct {
+	gorm.Model
+
+	// EventKey กันส่งอีเมลซ้ำสำหรับเหตุการณ์เดียวกัน เช่น "status_changed:application:12:accepted"
+	EventKey string `json:"event_key" gorm:"size:191;uniqueIndex;not null"`
+	Template string `json:"template" gorm:"size:50;index"`
+	Language string `json:"language" gorm:"size:5"`
+
+	UserID *uint  `json:"user_id" gorm:"index"`
+	To     string `json:"to" gorm:"not null"`
+
+	Subject  string `json:"subject"`
+	HTMLBody string `json:"-"`
+	TextBody string `json:"-"`
+
+	Status        string     `json:"status" gorm:"size:20;index"`
+	Attempts      int        `json:"attempts" gorm:"default:0"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index"`
+	SentAt        *time.Time `json:"sent_at"`
+}
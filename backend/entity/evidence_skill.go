@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"github.com/asaskevich/govalidator"
+	"gorm.io/gorm"
+)
+
+// ActivitySkill tags an activity with a skill it demonstrates.
+type ActivitySkill struct {
+	gorm.Model
+	ActivityID uint `json:"activity_id" gorm:"index;not null" valid:"-"`
+
+	SkillID uint   `json:"skill_id" gorm:"index;not null" valid:"required~Skill is required"`
+	Skill   *Skill `json:"skill,omitempty" gorm:"foreignKey:SkillID" valid:"-"`
+
+	// Level: ระดับความชำนาญที่นักเรียนประเมินเอง 1-5, 0 = ไม่ระบุ
+	Level int `json:"level" valid:"range(0|5)~Level must be between 0 and 5"`
+}
+
+// WorkingSkill tags a working with a skill it demonstrates.
+type WorkingSkill struct {
+	gorm.Model
+	WorkingID uint `json:"working_id" gorm:"index;not null" valid:"-"`
+
+	SkillID uint   `json:"skill_id" gorm:"index;not null" valid:"required~Skill is required"`
+	Skill   *Skill `json:"skill,omitempty" gorm:"foreignKey:SkillID" valid:"-"`
+
+	Level int `json:"level" valid:"range(0|5)~Level must be between 0 and 5"`
+}
+
+func (s *ActivitySkill) BeforeSave(tx *gorm.DB) (err error) {
+	_, err = govalidator.ValidateStruct(s)
+	return err
+}
+
+func (s *WorkingSkill) BeforeSave(tx *gorm.DB) (err error) {
+	_, err = govalidator.ValidateStruct(s)
+	return err
+}
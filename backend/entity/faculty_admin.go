@@ -0,0 +1,15 @@
+package entity
+
+import "gorm.io/gorm"
+
+// FacultyAdmin assigns an admin account to a faculty. Only a faculty's admins may approve
+// its curricula for publication; a faculty without assigned admins accepts any admin.
+type FacultyAdmin struct {
+	gorm.Model
+
+	FacultyID uint     `json:"faculty_id" gorm:"uniqueIndex:idx_faculty_admin;not null"`
+	Faculty   *Faculty `gorm:"foreignKey:FacultyID" json:"faculty,omitempty"`
+
+	UserID uint  `json:"user_id" gorm:"uniqueIndex:idx_faculty_admin;not null"`
+	User   *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FeedbackComment is an inline reviewer comment anchored to a section or block
+// of a submitted portfolio. Replies point at their root comment via ParentID.
+type FeedbackComment struct {
+	gorm.Model `valid:"-"`
+
+	Body         string     `json:"body" valid:"required~Body is required,stringlength(1|2000)~Body must be between 1-2000 characters"`
+	IsResolved   bool       `json:"is_resolved" valid:"-"`
+	ResolvedAt   *time.Time `json:"resolved_at" valid:"-"`
+	ResolvedByID *uint      `json:"resolved_by_id" valid:"-"`
+	ResolvedBy   *User      `gorm:"foreignKey:ResolvedByID" json:"resolved_by,omitempty" valid:"-"`
+
+	// FK
+	PortfolioSubmissionID uint                 `json:"portfolio_submission_id" gorm:"index" valid:"required~PortfolioSubmissionID is required"`
+	PortfolioSubmission   *PortfolioSubmission `gorm:"foreignKey:PortfolioSubmissionID" json:"portfolio_submission,omitempty" valid:"-"`
+
+	PortfolioSectionID *uint             `json:"portfolio_section_id" gorm:"index" valid:"-"`
+	PortfolioSection   *PortfolioSection `gorm:"foreignKey:PortfolioSectionID" json:"portfolio_section,omitempty" valid:"-"`
+
+	PortfolioBlockID *uint           `json:"portfolio_block_id" gorm:"index" valid:"-"`
+	PortfolioBlock   *PortfolioBlock `gorm:"foreignKey:PortfolioBlockID" json:"portfolio_block,omitempty" valid:"-"`
+
+	ParentID *uint             `json:"parent_id" gorm:"index" valid:"-"`
+	Replies  []FeedbackComment `gorm:"foreignKey:ParentID" json:"replies,omitempty" valid:"-"`
+
+	// CarriedFromID points at the comment of the previous submission version this one was copied from.
+	CarriedFromID *uint `json:"carried_from_id" valid:"-"`
+
+	UserID uint  `json:"user_id" valid:"required~UserID is required"`
+	User   *User `gorm:"foreignKey:UserID" json:"user,omitempty" valid:"-"`
+}
+
+// IsAnchored reports whether the comment targets a section or a block.
+func (f *FeedbackComment) IsAnchored() bool {
+	return f.PortfolioSectionID != nil || f.PortfolioBlockID != nil
+}
@@ -1,21 +1,68 @@
 package entity
 
 import(
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// Notification types. Preferences are set per type (see NotificationPreference).
+const (
+	NotificationTypeSystem               = "system"
+	NotificationTypeAlert                = "alert"
+	NotificationTypeAnnouncement         = "announcement"
+	NotificationTypeAnnouncementReminder = "announcement_reminder"
+	NotificationTypeDeadlineReminder     = "deadline_reminder"
+	NotificationTypeApplicationUpdate    = "application_update" // ได้รับใบสมัคร เปลี่ยนสถานะ มีความเห็นใหม่
+)
+
+// NotificationTypes lists every notification type.
+var NotificationTypes = []string{
+	NotificationTypeSystem,
+	NotificationTypeAlert,
+	NotificationTypeAnnouncement,
+	NotificationTypeAnnouncementReminder,
+	NotificationTypeDeadlineReminder,
+	NotificationTypeApplicationUpdate,
+}
+
+// legacyNotificationTypes maps the values written before the types were normalized.
+var legacyNotificationTypes = map[string]string{
+	"reminder": NotificationTypeDeadlineReminder,
+}
+
+// NormalizeNotificationType maps legacy spellings ("ANNOUNCEMENT", "System", "Reminder") to
+// the NotificationType constants. Unknown values are returned lowercased.
+func NormalizeNotificationType(t string) string {
+	t = strings.ToLower(strings.TrimSpace(t))
+	if mapped, ok := legacyNotificationTypes[t]; ok {
+		return mapped
+	}
+	return t
+}
+
+// NotificationGroupKey builds a Group_Key such as "curriculum:12".
+func NotificationGroupKey(kind string, id uint) string {
+	return kind + ":" + strconv.FormatUint(uint64(id), 10)
+}
+
 type Notification struct {
 	gorm.Model
 	Notification_Title   string `json:"notification_title" valid:"required~Title is required"`
-    Notification_Type    string `json:"notification_type" valid:"in(System|Alert|Announcement)~Invalid type"`
+	Notification_Type    string `json:"notification_type" gorm:"size:50;index" valid:"in(system|alert|announcement|announcement_reminder|deadline_reminder|application_update)~Invalid type"`
 	Notification_Message string    `json:"notification_message"`
 	Created_At           time.Time `json:"created_at"`
 	Is_Read              bool      `json:"is_read"`
-	Read_At             time.Time `json:"read_at"`
+	Read_At             *time.Time `json:"read_at"`
 	Sent_At			   time.Time `json:"sent_at"`
 
+	// Group_Key รวมแจ้งเตือนประเภทเดียวกันที่ซ้ำกันเป็นรายการเดียวในกล่องแจ้งเตือน
+	// เช่น เตือนปิดรับสมัครหลายครั้งของหลักสูตรเดียวกัน (ว่าง = ไม่รวม)
+	Group_Key   string     `json:"group_key" gorm:"size:100;index"`
+	Archived_At *time.Time `json:"archived_at" gorm:"index"`
+
 	//FK
 	UserID *uint `json:"user_id"`
 	User   User  `json:"user"`
@@ -25,4 +72,4 @@ type Notification struct {
 	// ✅ ส่วนที่เพิ่ม: เชื่อมกับกิจกรรม (Event)
 	EventID *uint  `json:"event_id"`
 	Event   *Event `gorm:"foreignKey:EventID" json:"event"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Notification delivery statuses.
+const (
+	DeliveryPending  = "pending"
+	DeliveryDeferred = "deferred" // รอพ้นช่วงงดแจ้งเตือน
+	DeliverySent     = "sent"
+	DeliveryFailed   = "failed"  // ส่งไม่สำเร็จจนครบจำนวนครั้ง
+	DeliverySkipped  = "skipped" // ผู้รับไม่มีที่อยู่สำหรับช่องทางนี้
+)
+
+// NotificationDelivery tracks sending one notification on one channel other than in-app.
+type NotificationDelivery struct {
+	gorm.Model
+
+	NotificationID uint          `json:"notification_id" gorm:"uniqueIndex:idx_notification_delivery;not null"`
+	Notification   *Notification `gorm:"foreignKey:NotificationID" json:"notification,omitempty"`
+	Channel        string        `json:"channel" gorm:"size:20;uniqueIndex:idx_notification_delivery;not null"`
+	UserID         uint          `json:"user_id" gorm:"index;not null"`
+
+	Status        string     `json:"status" gorm:"size:20;index"`
+	Attempts      int        `json:"attempts" gorm:"default:0"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index"`
+	SentAt        *time.Time `json:"sent_at"`
+}
@@ -0,0 +1,61 @@
+package entity
+
+import "gorm.io/gorm"
+
+// Notification channels. In-app is the Notification row itself and is always on.
+const (
+	NotificationChannelInApp   = "in_app"
+	NotificationChannelEmail   = "email"
+	NotificationChannelWebhook = "webhook" // HTTP POST แบบ webhook / LINE Notify
+	NotificationChannelWebPush = "web_push"
+)
+
+// NotificationChannels lists every channel.
+var NotificationChannels = []string{
+	NotificationChannelInApp,
+	NotificationChannelEmail,
+	NotificationChannelWebhook,
+	NotificationChannelWebPush,
+}
+
+// Webhook body formats.
+const (
+	WebhookFormatJSON       = "json"
+	WebhookFormatLineNotify = "line_notify" // form message=... พร้อม Bearer token
+)
+
+// NotificationPreference turns one channel on or off for one notification type. Without a
+// row the channel's default applies (see services.DefaultChannelEnabled).
+type NotificationPreference struct {
+	gorm.Model
+
+	UserID  uint   `json:"user_id" gorm:"uniqueIndex:idx_notification_preference;not null"`
+	Type    string `json:"type" gorm:"size:50;uniqueIndex:idx_notification_preference;not null"`
+	Channel string `json:"channel" gorm:"size:20;uniqueIndex:idx_notification_preference;not null"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Email languages.
+const (
+	LanguageTH = "th"
+	LanguageEN = "en"
+)
+
+// NotificationSetting holds a user's quiet hours, webhook address and email language.
+type NotificationSetting struct {
+	gorm.Model
+
+	UserID uint `json:"user_id" gorm:"uniqueIndex;not null"`
+
+	// QuietHoursStart/End: "HH:MM" เวลาไทย ช่วงนี้เลื่อนการส่งช่องทางอื่นนอกจาก in-app
+	// ว่าง = ไม่มีช่วงงดแจ้งเตือน, Start > End = ข้ามเที่ยงคืน (เช่น 22:00-07:00)
+	QuietHoursStart string `json:"quiet_hours_start" gorm:"size:5"`
+	QuietHoursEnd   string `json:"quiet_hours_end" gorm:"size:5"`
+
+	WebhookURL    string `json:"webhook_url"`
+	WebhookToken  string `json:"-"`
+	WebhookFormat string `json:"webhook_format" gorm:"size:20;default:'json'"`
+
+	// Language ภาษาของอีเมล (th/en) ว่าง = ภาษาไทย
+	Language string `json:"language" gorm:"size:5;default:'th'"`
+}
@@ -5,11 +5,14 @@ import (
 	"gorm.io/gorm"
 )
 
+// SubmissionStatusWithdrawn is set when the application the submission was made for is withdrawn.
+const SubmissionStatusWithdrawn = "withdrawn"
+
 type PortfolioSubmission struct {
 	gorm.Model `valid:"-"`
 
 	Version            int        `json:"version" valid:"required~Version is required,range(1|1000)~Version must be at least 1"`
-	Status             string     `json:"status" valid:"required~Status is required,matches(^(draft|submitted|under_review|approved|rejected|revision_required)$)~Status must be draft, submitted, under_review, approved, rejected, or revision_required"`  // FIXED: use matches instead of in
+	Status             string     `json:"status" valid:"required~Status is required,matches(^(draft|submitted|under_review|approved|rejected|revision_required|withdrawn)$)~Status must be draft, submitted, under_review, approved, rejected, revision_required, or withdrawn"`  // FIXED: use matches instead of in
 	Submission_at      time.Time  `json:"submission_at" valid:"required~Submission_at is required"`
 	ReviewedAt         *time.Time `json:"reviewed_at" valid:"-"`
 	ApprovedAt         *time.Time `json:"approved_at" valid:"-"`
@@ -20,4 +23,8 @@ type PortfolioSubmission struct {
 
 	UserID uint  `json:"user_id" valid:"required~UserID is required"`
 	User   *User `gorm:"foreignKey:UserID" json:"user" valid:"-"`
+
+	// หลักสูตรที่ยื่นสมัครด้วย submission นี้ (ว่างได้สำหรับ submission ที่ไม่ได้มาจากการสมัคร)
+	CurriculumID *uint       `json:"curriculum_id" gorm:"index" valid:"-"`
+	Curriculum   *Curriculum `gorm:"foreignKey:CurriculumID" json:"curriculum,omitempty" valid:"-"`
 }
\ No newline at end of file
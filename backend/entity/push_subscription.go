@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PushSubscription is one browser/device subscribed to Web Push for a user. The endpoint is
+// unique: subscribing the same browser again under another account moves it to that account.
+type PushSubscription struct {
+	gorm.Model
+
+	UserID uint  `json:"user_id" gorm:"index;not null"`
+	User   *User `gorm:"foreignKey:UserID" json:"-"`
+
+	Endpoint string `json:"endpoint" gorm:"size:1024;uniqueIndex;not null"`
+	P256dh   string `json:"-" gorm:"not null"` // public key ของ browser (base64url)
+	Auth     string `json:"-" gorm:"not null"` // auth secret (base64url)
+
+	UserAgent  string     `json:"user_agent"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastError  string     `json:"last_error,omitempty"`
+}
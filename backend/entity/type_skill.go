@@ -0,0 +1,28 @@
+package entity
+
+import "gorm.io/gorm"
+
+// TypeActivitySkill maps an activity type to a skill that its activities demonstrate.
+type TypeActivitySkill struct {
+	gorm.Model
+	TypeActivityID uint          `json:"type_activity_id" gorm:"index;not null"`
+	TypeActivity   *TypeActivity `json:"type_activity" gorm:"foreignKey:TypeActivityID"`
+
+	SkillID uint   `json:"skill_id" gorm:"index;not null"`
+	Skill   *Skill `json:"skill" gorm:"foreignKey:SkillID"`
+
+	// Weight: หนึ่งกิจกรรมของประเภทนี้นับเป็นหลักฐานของทักษะกี่หน่วย (1-5)
+	Weight int `json:"weight" gorm:"default:1"`
+}
+
+// TypeWorkingSkill maps a working type to a skill that its workings demonstrate.
+type TypeWorkingSkill struct {
+	gorm.Model
+	TypeWorkingID uint         `json:"type_working_id" gorm:"index;not null"`
+	TypeWorking   *TypeWorking `json:"type_working" gorm:"foreignKey:TypeWorkingID"`
+
+	SkillID uint   `json:"skill_id" gorm:"index;not null"`
+	Skill   *Skill `json:"skill" gorm:"foreignKey:SkillID"`
+
+	Weight int `json:"weight" gorm:"default:1"`
+}
@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Upload scan statuses (see services.StoreScannedUpload).
+const (
+	UploadScanQuarantined = "quarantined" // รับไฟล์แล้ว รอสแกน ยังไม่อยู่ใน storage
+	UploadScanClean       = "clean"
+	UploadScanInfected    = "infected"
+	UploadScanFailed      = "failed"  // สแกนไม่สำเร็จ ไฟล์ถูกปฏิเสธ
+	UploadScanSkipped     = "skipped" // ไม่ได้ตั้งค่า scanner
+)
+
+// UploadScan is the audit record of one uploaded file: who sent it, what the virus scanner
+// said, and where the file went once it was clean.
+type UploadScan struct {
+	gorm.Model
+
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256" gorm:"size:64;index"`
+	// Purpose: ช่องทางที่อัปโหลด เช่น upload, announcement_image, announcement_attachment
+	Purpose string `json:"purpose" gorm:"size:50"`
+
+	Status    string     `json:"status" gorm:"size:20;index"`
+	Scanner   string     `json:"scanner"`
+	Threat    string     `json:"threat,omitempty"`
+	Detail    string     `json:"detail,omitempty"` // ข้อความผิดพลาดจาก scanner
+	ScannedAt *time.Time `json:"scanned_at"`
+
+	// FileURL: ที่อยู่ไฟล์ใน storage มีเฉพาะไฟล์ที่ผ่านการสแกนแล้ว
+	FileURL string `json:"file_url,omitempty"`
+
+	UserID *uint `json:"user_id"`
+	User   *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
@@ -4,6 +4,13 @@ import (
 	"gorm.io/gorm"
 )
 
+// Account type IDs as created by seed.SeedUsers (Student, Teacher, Admin).
+const (
+	UserTypeStudent uint = 1
+	UserTypeTeacher uint = 2
+	UserTypeAdmin   uint = 3
+)
+
 type UserTypes struct {
 	gorm.Model
 	TypeName string `json:"type_name"`
@@ -14,6 +14,8 @@ type Working struct {
 	UserID         uint          `json:"user_id"`
 	User           *User         `gorm:"foreignKey:UserID" json:"user"`
 
+	// ทักษะที่ผลงานนี้แสดง (จัดการผ่าน PUT /workings/:id/skills)
+	Skills []WorkingSkill `gorm:"foreignKey:WorkingID" json:"skills"`
 }
 
 // BeforeCreate checks for duplicate WorkingName
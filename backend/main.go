@@ -21,8 +21,13 @@ func main() {
 		log.Println("Azure Storage initialized successfully")
 	}
 
+	if err := services.InitUploadScanner(); err != nil {
+		log.Fatal("Failed to configure virus scanner:", err)
+	}
+
 	services.StartNotificationScheduler()
 	config.ConnectionDatabase()
+	services.InitNotificationDispatcher(config.GetDB())
 
 	r := router.SetupRoutes()
 	port := resolvePort()
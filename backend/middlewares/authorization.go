@@ -36,3 +36,20 @@ func Authorization() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// OptionalAuthorization sets user_id like Authorization when a valid bearer token is sent,
+// and lets the request through anonymously otherwise (for public pages that show more to
+// signed-in users).
+func OptionalAuthorization() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
+			jwtWrapper := services.NewJWTWrapper()
+			if claims, err := jwtWrapper.ValidateToken(strings.TrimSpace(parts[1])); err == nil {
+				c.Set("user_id", claims.UserID)
+				c.Set("user_email", claims.Email)
+			}
+		}
+		c.Next()
+	}
+}
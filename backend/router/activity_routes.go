@@ -13,8 +13,10 @@ func ActivityRoutes(r gin.IRoutes) {
 	r.POST("/activities", ac.CreateActivity)
 	r.PATCH("/activities/:id", ac.UpdateActivity)
 	r.DELETE("/activities/:id", ac.DeleteActivity)
+	r.PUT("/activities/:id/skills", ac.ReplaceActivitySkills)
 
 	r.GET("/type_activities", ac.ListTypeActivities)
+	r.GET("/type_activities/:id/skills", ac.ListTypeActivitySkills)
 	r.GET("/level_activities", ac.ListLevelActivities)
 	r.GET("/rewards", ac.ListRewards) // Note: "rewards" plural as common convention
 
@@ -14,15 +14,37 @@ func AnnouncementRouter(r *gin.Engine) {
         auth.PUT("/admin/announcements/:id", controller.UpdateAdminAnnouncement)
         auth.DELETE("/admin/announcements/:id", controller.DeleteAnnouncement)
         auth.GET("/admin/announcements", controller.GetAdminAnnouncements)
+        auth.POST("/admin/announcements/images", controller.UploadAnnouncementImage)
+        auth.GET("/admin/announcements/:id/attachments", controller.ListManagedAnnouncementAttachments)
+        auth.POST("/admin/announcements/:id/attachments", controller.UploadAnnouncementAttachment)
+        auth.DELETE("/admin/announcements/:id/attachments/:attachment_id", controller.DeleteAnnouncementAttachment)
+        auth.POST("/admin/announcements/:id/publish", controller.PublishAnnouncement)
+        auth.POST("/admin/announcements/:id/schedule", controller.ScheduleAnnouncement)
+        auth.POST("/admin/announcements/:id/unpublish", controller.UnpublishAnnouncement)
+        auth.POST("/admin/announcements/:id/archive", controller.ArchiveAnnouncement)
+        auth.POST("/admin/announcements/:id/unarchive", controller.UnarchiveAnnouncement)
+        auth.GET("/admin/announcements/:id/analytics", controller.GetAnnouncementAnalytics)
+        auth.POST("/admin/announcements/:id/remind", controller.RemindAnnouncement)
+        auth.GET("/admin/announcements/:id/revisions", controller.ListAnnouncementRevisions)
+        auth.GET("/admin/announcements/:id/revisions/:revision", controller.GetAnnouncementRevision)
+        auth.POST("/admin/announcements/:id/revisions/:revision/restore", controller.RestoreAnnouncementRevision)
 
         auth.GET("/teacher/announcements", controller.GetAnnouncements)
         auth.GET("/teacher/announcements/:id", controller.GetAnnouncementByID)
+        auth.POST("/teacher/announcements/:id/acknowledge", controller.AcknowledgeAnnouncement)
 
         auth.GET("/student/announcements", controller.GetAnnouncements)
         auth.GET("/student/announcements/:id", controller.GetAnnouncementByID)
+        auth.POST("/student/announcements/:id/acknowledge", controller.AcknowledgeAnnouncement)
     }
 
-    // public
-    r.GET("/announcements", controller.GetAnnouncements)
-    r.GET("/announcements/:id", controller.GetAnnouncementByID)
+    // public: ส่ง token มาด้วยได้ เพื่อเห็นประกาศเฉพาะกลุ่ม/ฉบับร่าง (แอดมิน)
+    public := r.Group("/")
+    public.Use(middlewares.OptionalAuthorization())
+    {
+        public.GET("/announcements", controller.GetAnnouncements)
+        public.GET("/announcements/:id", controller.GetAnnouncementByID)
+        public.GET("/announcements/:id/attachments", controller.ListAnnouncementAttachments)
+        public.GET("/announcements/:id/attachments/:attachment_id/download", controller.DownloadAnnouncementAttachment)
+    }
 }
@@ -0,0 +1,30 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/controller"
+	"github.com/sut68/team14/backend/middlewares"
+	"gorm.io/gorm"
+)
+
+func RegisterApplicationRoutes(r *gin.Engine, db *gorm.DB) {
+	c := controller.ApplicationController{DB: db}
+	group := r.Group("/api/applications", middlewares.Authorization())
+	{
+		// student
+		group.POST("", c.Create)
+		group.GET("/me", c.ListMine)
+		group.GET("/checklist", c.ChecklistForCurriculum)
+		group.GET("/:id", c.Get)
+		group.GET("/:id/check", c.Check)
+		group.GET("/:id/checklist", c.Checklist)
+		group.POST("/:id/documents", c.AddDocument)
+		group.DELETE("/:id/documents/:docId", c.RemoveDocument)
+		group.POST("/:id/submit", c.Submit)
+		group.POST("/:id/withdraw", c.Withdraw)
+
+		// reviewer
+		group.GET("", c.ListForReview)
+		group.PATCH("/:id/decision", c.Decide)
+	}
+}
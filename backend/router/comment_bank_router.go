@@ -0,0 +1,21 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/controller"
+	"github.com/sut68/team14/backend/middlewares"
+	"gorm.io/gorm"
+)
+
+func RegisterCommentBankRoutes(r *gin.Engine, db *gorm.DB) {
+	c := controller.CommentBankController{DB: db}
+	group := r.Group("/api/comment-bank", middlewares.Authorization())
+	{
+		group.GET("", c.List)
+		group.POST("", c.Create)
+		group.GET("/stats", c.Stats)
+		group.PUT("/:id", c.Update)
+		group.DELETE("/:id", c.Delete)
+		group.POST("/:id/render", c.Render)
+	}
+}
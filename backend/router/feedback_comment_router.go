@@ -0,0 +1,22 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/controller"
+	"github.com/sut68/team14/backend/middlewares"
+	"gorm.io/gorm"
+)
+
+func RegisterFeedbackCommentRoutes(r *gin.Engine, db *gorm.DB) {
+	c := controller.FeedbackCommentController{DB: db}
+	group := r.Group("/api/submissions/:id/comments", middlewares.Authorization())
+	{
+		group.GET("", c.ListBySubmission)
+		group.POST("", c.Create)
+		group.PUT("/:commentId", c.Update)
+		group.DELETE("/:commentId", c.Delete)
+
+		group.PATCH("/:commentId/resolve", c.Resolve)
+		group.PATCH("/:commentId/reopen", c.Reopen)
+	}
+}
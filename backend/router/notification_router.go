@@ -3,12 +3,34 @@ package router
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/sut68/team14/backend/controller"
+	"github.com/sut68/team14/backend/middlewares"
 )
 
 func NotificationRouter(r *gin.Engine) {
-	r.POST("/notifications", controller.CreateNotification)
-	r.GET("/notifications", controller.GetNotifications)
-	r.GET("/notifications/:id", controller.GetNotificationByID)
-	r.PUT("/notifications/:id", controller.UpdateNotification)
-	r.DELETE("/notifications/:id", controller.DeleteNotification)
-}
\ No newline at end of file
+	notifications := r.Group("/notifications")
+	notifications.Use(middlewares.Authorization())
+	{
+		notifications.GET("", controller.GetNotifications)
+		notifications.POST("", controller.CreateNotification)
+		notifications.GET("/unread-count", controller.GetUnreadNotificationCount)
+		notifications.POST("/read-all", controller.MarkAllNotificationsRead)
+		notifications.POST("/bulk", controller.BulkUpdateNotifications)
+
+		notifications.GET("/preferences", controller.GetNotificationPreferences)
+		notifications.PUT("/preferences", controller.UpdateNotificationPreferences)
+
+		notifications.GET("/push/public-key", controller.GetPushPublicKey)
+		notifications.GET("/push/subscriptions", controller.ListPushSubscriptions)
+		notifications.POST("/push/subscriptions", controller.SubscribePush)
+		notifications.POST("/push/unsubscribe", controller.UnsubscribePush)
+		notifications.POST("/push/test", controller.SendTestPush)
+
+		notifications.GET("/:id", controller.GetNotificationByID)
+		notifications.DELETE("/:id", controller.DeleteNotification)
+		notifications.PATCH("/:id/read", controller.MarkNotificationRead)
+		notifications.PATCH("/:id/unread", controller.MarkNotificationUnread)
+		notifications.PATCH("/:id/archive", controller.ArchiveNotification)
+		notifications.PATCH("/:id/unarchive", controller.UnarchiveNotification)
+		notifications.GET("/:id/deliveries", controller.GetNotificationDeliveries)
+	}
+}
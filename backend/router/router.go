@@ -82,6 +82,7 @@ func SetupRoutes() *gin.Engine {
 	referenceController := controller.NewReferenceController(db)
 	educationAdminController := controller.NewEducationAdminController(db)
 	courseGroupController := controller.NewCourseGroupController()
+	courseController := controller.NewCourseController()
 
 	// --- Public Routes ---
 	authController.RegisterRoutes(r)
@@ -89,9 +90,9 @@ func SetupRoutes() *gin.Engine {
 		c.JSON(200, gin.H{"message": "pong"})
 	})
 
-	// Upload Route (Public)
+	// Upload Route (Public; token optional, recorded in the upload scan log)
 	uploadController := controller.NewUploadController()
-	r.POST("/upload", uploadController.UploadFile)
+	r.POST("/upload", middlewares.OptionalAuthorization(), uploadController.UploadFile)
 
 	// --- Protected Routes (ต้อง Login) ---
 	protected := r.Group("")
@@ -131,13 +132,12 @@ func SetupRoutes() *gin.Engine {
 	facultyController.RegisterRoutes(r, protected)
 	programController.RegisterRoutes(r, protected)
 	courseGroupController.RegisterRoutes(r, protectedOnboarded)
+	courseController.RegisterRoutes(r, protectedOnboarded)
 
-	// Selection & Notification Routes
+	// Selection Routes
 	r.POST("/selections", selectionController.ToggleSelection)
 	r.GET("/selections", selectionController.GetMySelections)
 	r.POST("/selections/notify", selectionController.ToggleNotification)
-	r.GET("/notifications", selectionController.GetNotifications)
-	r.PATCH("/notifications/:id/read", selectionController.MarkAsRead)
 
 	r.GET("/ws", controller.WebSocketHandler)
 
@@ -170,6 +170,9 @@ func SetupRoutes() *gin.Engine {
 	RegisterCriteriaScoreRoutes(r, db)
 	RegisterEvaluationRoutes(r, db)
 	RegisterFeedbackRoutes(r, db)
+	RegisterFeedbackCommentRoutes(r, db)
+	RegisterCommentBankRoutes(r, db)
+	RegisterApplicationRoutes(r, db)
 	RegisterPortfolioSubmissionRoutes(r, db)
 	RegisterScoreCriteriaRoutes(r, db)
 	RegisterScorecardRoutes(r, db)
@@ -186,8 +189,9 @@ func SetupRoutes() *gin.Engine {
 	//ของประกาศ
 	AnnouncementRouter(r)
 	CetagoryRouter(r)
-	AttachmentRouter(r)
 	AdminLogRouter(r)
+	UploadScanRouter(r)
+	NotificationRouter(r)
 
 	return r
 }
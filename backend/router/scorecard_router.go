@@ -3,6 +3,7 @@ package router
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/sut68/team14/backend/controller"
+	"github.com/sut68/team14/backend/middlewares"
 	"gorm.io/gorm"
 )
 
@@ -13,5 +14,7 @@ func RegisterScorecardRoutes(r *gin.Engine, db *gorm.DB) {
 		group.POST("", c.Create)
 		group.GET("", c.GetAll)
 		group.GET("/:id", c.GetByID)
+
+		group.GET("/export/curriculum/:curriculumId", middlewares.Authorization(), c.ExportByCurriculum)
 	}
-}
\ No newline at end of file
+}
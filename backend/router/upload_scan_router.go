@@ -0,0 +1,15 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sut68/team14/backend/controller"
+	"github.com/sut68/team14/backend/middlewares"
+)
+
+func UploadScanRouter(r *gin.Engine) {
+	scans := r.Group("/admin/upload-scans")
+	scans.Use(middlewares.Authorization())
+	{
+		scans.GET("", controller.ListUploadScans)
+	}
+}
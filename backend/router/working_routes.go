@@ -14,7 +14,9 @@ func WorkingRoutes(r gin.IRoutes) {
 	r.GET("/workings/:id", wc.GetWorking)
 	r.PATCH("/workings/:id", wc.UpdateWorking)
 	r.DELETE("/workings/:id", wc.DeleteWorking)
+	r.PUT("/workings/:id/skills", wc.ReplaceWorkingSkills)
 	
 	r.GET("/type_workings", wc.ListTypeWorkings)
+	r.GET("/type_workings/:id/skills", wc.ListTypeWorkingSkills)
 	r.GET("/workings/user/:userId", wc.ListWorkingsByUser)
 }
@@ -0,0 +1,49 @@
+package seed
+
+import (
+	"log"
+
+	"github.com/sut68/team14/backend/config"
+	"github.com/sut68/team14/backend/entity"
+)
+
+// SeedCourses inserts sample courses and links them to the seeded course groups.
+// Run after SeedCourseGroups.
+func SeedCourses() {
+	db := config.GetDB()
+	if db == nil {
+		log.Println("SeedCourses ERROR: DB is nil")
+		return
+	}
+	if skipIfSeededDefault(db, &entity.Course{}, "courses") {
+		return
+	}
+
+	courses := []struct {
+		Course entity.Course
+		Group  string
+	}{
+		{entity.Course{CourseCode: "MAT101", CourseNameTH: "แคลคูลัส 1", CourseNameEN: "Calculus I", Credits: 4, Category: 1}, "วิชาคำนวณและตรรกะ"},
+		{entity.Course{CourseCode: "MAT102", CourseNameTH: "คณิตศาสตร์ดิสครีต", CourseNameEN: "Discrete Mathematics", Credits: 4, Category: 1}, "วิชาคำนวณและตรรกะ"},
+		{entity.Course{CourseCode: "PHY101", CourseNameTH: "ปฏิบัติการฟิสิกส์", CourseNameEN: "Physics Laboratory", Credits: 1, Category: 2}, "วิชาปฏิบัติการ/แลป"},
+		{entity.Course{CourseCode: "CPE101", CourseNameTH: "การเขียนโปรแกรมคอมพิวเตอร์", CourseNameEN: "Computer Programming", Credits: 4, Category: 3}, "วิชาเขียนโปรแกรม"},
+		{entity.Course{CourseCode: "CPE201", CourseNameTH: "โครงสร้างข้อมูลและอัลกอริทึม", CourseNameEN: "Data Structures and Algorithms", Credits: 4, Category: 3}, "วิชาเขียนโปรแกรม"},
+		{entity.Course{CourseCode: "CPE202", CourseNameTH: "ทฤษฎีการคำนวณ", CourseNameEN: "Theory of Computation", Credits: 4, Category: 3}, "วิชาทฤษฎีและหลักการ"},
+		{entity.Course{CourseCode: "ENG101", CourseNameTH: "ภาษาอังกฤษเพื่อการสื่อสาร", CourseNameEN: "English for Communication", Credits: 3, Category: 4}, "วิชาการสื่อสารและนำเสนอ"},
+	}
+
+	for _, item := range courses {
+		course := item.Course
+		var group entity.CourseGroup
+		if err := db.Where("name = ?", item.Group).First(&group).Error; err == nil {
+			course.CourseGroups = []entity.CourseGroup{group}
+		} else {
+			log.Printf("course group %s not found, seeding %s without a group\n", item.Group, course.CourseCode)
+		}
+		if err := db.Create(&course).Error; err != nil {
+			log.Printf("failed to seed course %s: %v\n", course.CourseCode, err)
+		}
+	}
+
+	log.Println("Seed courses completed")
+}
@@ -26,6 +26,7 @@ func CurriculumSeed() {
 	// 1. Seed ข้อมูลคณะและสาขาวิชาก่อน (เพราะหลักสูตรต้องอ้างอิงสิ่งเหล่านี้)
 	seedFaculties(db)
 	seedPrograms(db)
+	seedDocumentTypes(db)
 
 	// 2. Seed ข้อมูลหลักสูตร
 	seedCurriculums(db, seedUserID)
@@ -92,6 +93,29 @@ func seedPrograms(db *gorm.DB) {
 	log.Println("seed programs completed")
 }
 
+// เอกสารที่หลักสูตรเรียกได้ (ที่มี Code จะตรวจจากโปรไฟล์นักเรียนให้อัตโนมัติ)
+func seedDocumentTypes(db *gorm.DB) {
+	if skipIfSeededDefault(db, &entity.DocumentType{}, "document_types") {
+		return
+	}
+
+	docs := []entity.DocumentType{
+		{Name: "ใบแสดงผลการเรียน (Transcript)", Code: entity.DocumentCodeTranscript},
+		{Name: "ใบรับรองผล GED", Code: entity.DocumentCodeGEDCertificate},
+		{Name: "ผลสอบภาษาอังกฤษ", Description: "IELTS, TOEFL หรือผลสอบอื่นที่เทียบเท่า", Code: entity.DocumentCodeLanguageCertificate},
+		{Name: "ผลสอบ IELTS", Code: entity.DocumentCodeLanguageCertificate, TestType: "IELTS"},
+		{Name: "สำเนาบัตรประชาชน"},
+		{Name: "จดหมายแนะนำตัว (Statement of Purpose)"},
+	}
+
+	if err := db.Create(&docs).Error; err != nil {
+		log.Println("seed document types error:", err)
+		return
+	}
+
+	log.Println("seed document types completed")
+}
+
 // ฟังก์ชันสำหรับ Seed หลักสูตร
 func seedCurriculums(db *gorm.DB, userID uint) {
 	if skipIfSeededDefault(db, &entity.Curriculum{}, "curriculums") {
@@ -120,7 +144,8 @@ func seedCurriculums(db *gorm.DB, userID uint) {
 			Link:              "https://cpe.sut.ac.th",
 			GPAXMin:           2.75,
 			PortfolioMaxPages: 10,
-			Status:            "published", // สถานะ published จะทำให้นักเรียนเห็นทันที
+			Status:            entity.RoundStatusOpen,     // ตามรอบรับสมัคร (เปิดวันนี้)
+			PublishState:      entity.CurriculumPublished, // published จะทำให้นักเรียนเห็นทันที
 			RoundName:         "Portfolio 1/2567",
 			AcademicYear:      "2567",
 			StartDate:         time.Now(),                  // วันเปิดรับสมัคร (วันนี้)
@@ -140,7 +165,8 @@ func seedCurriculums(db *gorm.DB, userID uint) {
 			Link:              "https://cpe.sut.ac.th/quota",
 			GPAXMin:           3.00,
 			PortfolioMaxPages: 10,
-			Status:            "draft", // สถานะ draft นักเรียนจะไม่เห็น (เห็นเฉพาะ Admin)
+			Status:            entity.RoundStatusOpening, // ตามรอบรับสมัคร (เปิดอีก 2 เดือน)
+			PublishState:      entity.CurriculumDraft,    // draft นักเรียนจะไม่เห็น (เห็นเฉพาะ Admin)
 			RoundName:         "Quota 2/2568",
 			AcademicYear:      "2568",
 			StartDate:         time.Now().AddDate(0, 2, 0),
@@ -160,6 +186,15 @@ func seedCurriculums(db *gorm.DB, userID uint) {
 		return
 	}
 
+	// สร้างรอบรับสมัครจาก StartDate/EndDate ของแต่ละหลักสูตร
+	for i := range curriculums {
+		if round, ok := curriculums[i].LegacyRound(); ok {
+			if err := db.Create(&round).Error; err != nil {
+				log.Println("seed application round error:", err)
+			}
+		}
+	}
+
 	log.Println("seed curriculums completed")
 }
 
@@ -0,0 +1,68 @@
+package seed
+
+import (
+	"log"
+
+	"github.com/sut68/team14/backend/config"
+	"github.com/sut68/team14/backend/entity"
+)
+
+// SeedTypeSkills links the seeded activity/working types to the skills they demonstrate.
+// Run after SeedActivities and SeedTypeWorkings.
+func SeedTypeSkills() {
+	db := config.GetDB()
+	if db == nil {
+		log.Println("SeedTypeSkills ERROR: DB is nil")
+		return
+	}
+
+	skillIDs := ensureSkillsExist(db)
+
+	if !skipIfSeededDefault(db, &entity.TypeActivitySkill{}, "type_activity_skills") {
+		activitySkills := map[string]map[string]int{
+			"ค่ายวิชาการ":    {"reading": 2, "critical_thinking": 2, "teamwork": 1},
+			"ศิลปะหัตถกรรม":  {"patience": 3},
+			"วิทยาศาตร์":     {"critical_thinking": 2, "patience": 2, "math": 1},
+			"คอมพิวเตอร์":    {"logic": 3, "problem_solving": 3},
+			"ศิลปะ":          {"communication": 2, "patience": 1},
+			"วิศวกรรมศาสตร์": {"math": 2, "problem_solving": 2, "teamwork": 1},
+		}
+		for typeName, skills := range activitySkills {
+			var t entity.TypeActivity
+			if err := db.Where("type_name = ?", typeName).First(&t).Error; err != nil {
+				log.Printf("type_activity %s not found, skipping skill links\n", typeName)
+				continue
+			}
+			for key, weight := range skills {
+				link := entity.TypeActivitySkill{TypeActivityID: t.ID, SkillID: skillIDs[key], Weight: weight}
+				if err := db.Create(&link).Error; err != nil {
+					log.Printf("failed to seed type_activity_skill %s/%s: %v\n", typeName, key, err)
+				}
+			}
+		}
+	}
+
+	if !skipIfSeededDefault(db, &entity.TypeWorkingSkill{}, "type_working_skills") {
+		workingSkills := map[string]map[string]int{
+			"วิศวกรรม":    {"math": 2, "problem_solving": 3, "patience": 1},
+			"ศิลปะ":       {"communication": 2, "patience": 2},
+			"คอมพิวเตอร์": {"logic": 3, "problem_solving": 3},
+			"วิทยาศาสตร์": {"critical_thinking": 3, "reading": 1},
+		}
+		for typeName, skills := range workingSkills {
+			var t entity.TypeWorking
+			if err := db.Where("type_name = ?", typeName).First(&t).Error; err != nil {
+				log.Printf("type_working %s not found, skipping skill links\n", typeName)
+				continue
+			}
+			for key, weight := range skills {
+				link := entity.TypeWorkingSkill{TypeWorkingID: t.ID, SkillID: skillIDs[key], Weight: weight}
+				if err := db.Create(&link).Error; err != nil {
+					log.Printf("failed to seed type_working_skill %s/%s: %v\n", typeName, key, err)
+				}
+			}
+		}
+	}
+
+	log.Println("Seed type skills completed")
+}
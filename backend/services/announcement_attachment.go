@@ -0,0 +1,231 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FileStore is where uploaded files are kept. *AzureStorageService implements it.
+type FileStore interface {
+	// UploadFile stores the file under a new unique name and returns its URL.
+	UploadFile(file io.Reader, fileName string, contentType string) (string, error)
+	// DeleteFile removes the file stored under fileName (see StorageKeyFromURL).
+	DeleteFile(fileName string) error
+}
+
+const (
+	// MaxAttachmentSize is the largest file that can be attached to an announcement.
+	MaxAttachmentSize = 10 * 1024 * 1024
+	// MaxAttachmentsPerAnnouncement limits how many files one announcement can have.
+	MaxAttachmentsPerAnnouncement = 10
+)
+
+// ErrInvalidAttachment is wrapped by every error about the uploaded file itself.
+var ErrInvalidAttachment = errors.New("invalid attachment")
+
+// attachmentTypes maps each allowed extension to the content type it is stored with and the
+// type its first bytes must sniff as (see sniffContentType).
+var attachmentTypes = map[string]struct{ contentType, sniffed string }{
+	".jpg":  {"image/jpeg", "image/jpeg"},
+	".jpeg": {"image/jpeg", "image/jpeg"},
+	".png":  {"image/png", "image/png"},
+	".gif":  {"image/gif", "image/gif"},
+	".webp": {"image/webp", "image/webp"},
+	".bmp":  {"image/bmp", "image/bmp"},
+	".pdf":  {"application/pdf", "application/pdf"},
+	// เอกสาร Office แบบใหม่เป็นไฟล์ zip แบบเก่าเป็น OLE
+	".docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/zip"},
+	".xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "application/zip"},
+	".doc":  {"application/msword", oleContentType},
+	".xls":  {"application/vnd.ms-excel", oleContentType},
+}
+
+const oleContentType = "application/x-ole-storage"
+
+var oleMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+func sniffContentType(head []byte) string {
+	if bytes.HasPrefix(head, oleMagic) {
+		return oleContentType
+	}
+	return http.DetectContentType(head)
+}
+
+// ValidateAttachment checks the size, extension and actual content of an upload and returns
+// the content type to store it with. The file is rewound afterwards.
+func ValidateAttachment(name string, size int64, file io.ReadSeeker) (string, error) {
+	if size <= 0 {
+		return "", fmt.Errorf("%w: file is empty", ErrInvalidAttachment)
+	}
+	if size > MaxAttachmentSize {
+		return "", fmt.Errorf("%w: file must not exceed %dMB", ErrInvalidAttachment, MaxAttachmentSize/(1024*1024))
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	t, ok := attachmentTypes[ext]
+	if !ok {
+		return "", fmt.Errorf("%w: only images (JPG, PNG, GIF, WEBP, BMP) and documents (PDF, DOC, DOCX, XLS, XLSX) are allowed", ErrInvalidAttachment)
+	}
+
+	// ดูจากเนื้อไฟล์จริง ไม่เชื่อนามสกุลหรือ Content-Type ที่ส่งมา
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if sniffContentType(head[:n]) != t.sniffed {
+		return "", fmt.Errorf("%w: file content does not match %s", ErrInvalidAttachment, ext)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return t.contentType, nil
+}
+
+// cleanAttachmentName keeps only the base name of an uploaded file, at most 255 characters.
+func cleanAttachmentName(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, `\`, "/")))
+	if utf8.RuneCountInString(name) > 255 {
+		ext := filepath.Ext(name)
+		runes := []rune(strings.TrimSuffix(name, ext))
+		name = string(runes[:255-utf8.RuneCountInString(ext)]) + ext
+	}
+	return name
+}
+
+// StorageKeyFromURL is the name a file returned by FileStore.UploadFile is stored under.
+func StorageKeyFromURL(fileURL string) string {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return ""
+	}
+	return path.Base(u.Path)
+}
+
+// StoreAnnouncementAttachment validates an uploaded file, passes it through StoreScannedUpload
+// and records it as an attachment of the announcement.
+func StoreAnnouncementAttachment(ctx context.Context, db *gorm.DB, store FileStore, announcementID uint,
+	name string, size int64, file io.ReadSeeker, uploaderID uint, now time.Time) (*entity.Announcement_Attachment, error) {
+
+	name = cleanAttachmentName(name)
+	if name == "" || name == "." || name == "/" {
+		return nil, fmt.Errorf("%w: file name is required", ErrInvalidAttachment)
+	}
+	contentType, err := ValidateAttachment(name, size, file)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := db.Model(&entity.Announcement_Attachment{}).Where("announcement_id = ?", announcementID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= MaxAttachmentsPerAnnouncement {
+		return nil, fmt.Errorf("%w: an announcement can have at most %d attachments", ErrInvalidAttachment, MaxAttachmentsPerAnnouncement)
+	}
+
+	scan, err := StoreScannedUpload(ctx, db, store, UploadMeta{
+		FileName: name, ContentType: contentType, Size: size,
+		Purpose: UploadPurposeAnnouncementAttachment, UserID: &uploaderID,
+	}, file)
+	if err != nil {
+		return nil, err
+	}
+	fileURL := scan.FileURL
+	att := entity.Announcement_Attachment{
+		File_name:      name,
+		File_path:      fileURL,
+		Uploaded_At:    now,
+		Storage_Key:    StorageKeyFromURL(fileURL),
+		Content_Type:   contentType,
+		File_Size:      size,
+		AnnouncementID: announcementID,
+		UploadedByID:   &uploaderID,
+	}
+	if err := db.Omit(clause.Associations).Create(&att).Error; err != nil {
+		// ไม่ให้มีไฟล์ค้างใน storage ที่ไม่มีใครอ้างถึง
+		deleteStoredFile(store, att.Storage_Key)
+		return nil, err
+	}
+	return &att, nil
+}
+
+// DeleteAnnouncementAttachment removes the attachment and its stored file.
+func DeleteAnnouncementAttachment(db *gorm.DB, store FileStore, att *entity.Announcement_Attachment) error {
+	if err := db.Unscoped().Delete(&entity.Announcement_Attachment{}, att.ID).Error; err != nil {
+		return err
+	}
+	deleteStoredFile(store, att.Storage_Key)
+	return nil
+}
+
+// DeleteAnnouncementWithAttachments deletes the announcement together with its attachments
+// and their stored files.
+func DeleteAnnouncementWithAttachments(db *gorm.DB, store FileStore, announcementID uint) error {
+	var keys []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Announcement_Attachment{}).Where("announcement_id = ?", announcementID).
+			Pluck("storage_key", &keys).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("announcement_id = ?", announcementID).Delete(&entity.Announcement_Attachment{}).Error; err != nil {
+			return err
+		}
+		res := tx.Delete(&entity.Announcement{}, announcementID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// ลบไฟล์หลัง commit: ถ้าลบไม่สำเร็จ เหลือแค่ไฟล์กำพร้า ไม่กระทบข้อมูล
+	for _, key := range keys {
+		deleteStoredFile(store, key)
+	}
+	return nil
+}
+
+func deleteStoredFile(store FileStore, key string) {
+	if store == nil || key == "" {
+		return
+	}
+	if err := store.DeleteFile(key); err != nil {
+		log.Printf("⚠️ failed to delete stored file %s: %v", key, err)
+	}
+}
+
+// RecordAttachmentDownload counts one download of the attachment.
+func RecordAttachmentDownload(db *gorm.DB, att *entity.Announcement_Attachment) error {
+	if err := db.Model(&entity.Announcement_Attachment{}).Where("id = ?", att.ID).
+		UpdateColumn("download_count", gorm.Expr("download_count + 1")).Error; err != nil {
+		return err
+	}
+	att.Download_Count++
+	return nil
+}
+
+// SetAttachmentDownloadURLs fills Download_URL, the link that counts downloads.
+func SetAttachmentDownloadURLs(atts []entity.Announcement_Attachment) {
+	for i := range atts {
+		atts[i].Download_URL = fmt.Sprintf("/announcements/%d/attachments/%d/download", atts[i].AnnouncementID, atts[i].ID)
+	}
+}
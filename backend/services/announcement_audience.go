@@ -0,0 +1,362 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// AudienceMember is what a user is, for matching announcement audiences: their role, and
+// the curricula linked to them (see curriculumLinksSQL) with those curricula's programs,
+// faculties and academic years, plus the faculties they administer.
+type AudienceMember struct {
+	UserID        uint
+	RoleID        uint
+	CurriculumIDs map[uint]bool
+	ProgramIDs    map[uint]bool
+	FacultyIDs    map[uint]bool
+	AcademicYears map[string]bool
+}
+
+// curriculumLinksSQL lists (user_id, curriculum_id) pairs: the curricula a student bookmarked
+// (Selection) or applied to (Application, unless withdrawn), and the curricula a teacher or
+// admin is responsible for (Curriculum.UserID). The system keeps no enrollment or staff
+// affiliation records, so a faculty, program or academic-year audience only reaches users
+// linked to one of its curricula this way, plus the faculty's admins for a faculty audience.
+const curriculumLinksSQL = "SELECT user_id, curriculum_id FROM selections WHERE deleted_at IS NULL" +
+	" UNION SELECT user_id, curriculum_id FROM applications WHERE deleted_at IS NULL AND status <> '" + entity.ApplicationStatusWithdrawn + "'" +
+	" UNION SELECT user_id, id FROM curriculums WHERE deleted_at IS NULL AND user_id > 0"
+
+// LoadAudienceMember loads the memberships of a user. A nil user is an anonymous visitor,
+// who only matches announcements for everyone.
+func LoadAudienceMember(db *gorm.DB, user *entity.User) (*AudienceMember, error) {
+	m := &AudienceMember{
+		CurriculumIDs: map[uint]bool{},
+		ProgramIDs:    map[uint]bool{},
+		FacultyIDs:    map[uint]bool{},
+		AcademicYears: map[string]bool{},
+	}
+	if user == nil {
+		return m, nil
+	}
+	m.UserID, m.RoleID = user.ID, user.AccountTypeID
+
+	var linked []struct {
+		ID           uint
+		ProgramID    uint
+		FacultyID    uint
+		AcademicYear string
+	}
+	if err := db.Table("("+curriculumLinksSQL+") AS links").
+		Select("curriculums.id, curriculums.program_id, curriculums.faculty_id, curriculums.academic_year").
+		Joins("JOIN curriculums ON curriculums.id = links.curriculum_id AND curriculums.deleted_at IS NULL").
+		Where("links.user_id = ?", user.ID).
+		Scan(&linked).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range linked {
+		m.CurriculumIDs[c.ID] = true
+		m.ProgramIDs[c.ProgramID] = true
+		m.FacultyIDs[c.FacultyID] = true
+		if c.AcademicYear != "" {
+			m.AcademicYears[c.AcademicYear] = true
+		}
+	}
+
+	var administered []uint
+	if err := db.Model(&entity.FacultyAdmin{}).Where("user_id = ?", user.ID).Pluck("faculty_id", &administered).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range administered {
+		m.FacultyIDs[id] = true
+	}
+	return m, nil
+}
+
+// AudienceMatches decides whether an announcement is for the member. No rows = everyone.
+// Role rows narrow the other rows: "student" + "faculty ENG" means students of ENG. Rows of
+// any other kind are alternatives (any one is enough).
+func AudienceMatches(audiences []entity.AnnouncementAudience, m *AudienceMember) bool {
+	if len(audiences) == 0 {
+		return true
+	}
+	if m == nil || m.UserID == 0 {
+		return false
+	}
+
+	hasRole, roleOK := false, false
+	hasTarget, targetOK := false, false
+	for _, a := range audiences {
+		if a.Kind == entity.AudienceRole {
+			hasRole = true
+			roleOK = roleOK || a.TargetID == m.RoleID
+			continue
+		}
+		hasTarget = true
+		switch a.Kind {
+		case entity.AudienceUser:
+			targetOK = targetOK || a.TargetID == m.UserID
+		case entity.AudienceCurriculum:
+			targetOK = targetOK || m.CurriculumIDs[a.TargetID]
+		case entity.AudienceProgram:
+			targetOK = targetOK || m.ProgramIDs[a.TargetID]
+		case entity.AudienceFaculty:
+			targetOK = targetOK || m.FacultyIDs[a.TargetID]
+		case entity.AudienceAcademicYear:
+			targetOK = targetOK || m.AcademicYears[a.Value]
+		}
+	}
+	return (!hasRole || roleOK) && (!hasTarget || targetOK)
+}
+
+// FilterAnnouncementsFor keeps the announcements whose audience matches the member. The
+// announcements need Audiences preloaded.
+func FilterAnnouncementsFor(announcements []entity.Announcement, m *AudienceMember) []entity.Announcement {
+	out := make([]entity.Announcement, 0, len(announcements))
+	for _, a := range announcements {
+		if AudienceMatches(a.Audiences, m) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// NormalizeAudiences validates audience rows from a request, checks that their targets
+// exist, and drops duplicates.
+func NormalizeAudiences(db *gorm.DB, in []entity.AnnouncementAudience) ([]entity.AnnouncementAudience, error) {
+	out := make([]entity.AnnouncementAudience, 0, len(in))
+	seen := map[string]bool{}
+	for i, a := range in {
+		row := entity.AnnouncementAudience{Kind: strings.TrimSpace(a.Kind), TargetID: a.TargetID, Value: strings.TrimSpace(a.Value)}
+		if ok, err := govalidator.ValidateStruct(&row); !ok {
+			return nil, fmt.Errorf("audiences[%d]: %v", i, err)
+		}
+		if err := checkAudienceTarget(db, &row); err != nil {
+			return nil, fmt.Errorf("audiences[%d]: %v", i, err)
+		}
+		key := row.Kind + ":" + strconv.FormatUint(uint64(row.TargetID), 10) + ":" + row.Value
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, row)
+	}
+	return out, nil
+}
+
+func checkAudienceTarget(db *gorm.DB, a *entity.AnnouncementAudience) error {
+	var model interface{}
+	switch a.Kind {
+	case entity.AudienceRole:
+		if a.TargetID != entity.UserTypeStudent && a.TargetID != entity.UserTypeTeacher && a.TargetID != entity.UserTypeAdmin {
+			return fmt.Errorf("unknown role %d", a.TargetID)
+		}
+		return nil
+	case entity.AudienceAcademicYear:
+		if _, err := strconv.Atoi(a.Value); err != nil {
+			return fmt.Errorf("academic_year value must be a year, e.g. 2568")
+		}
+		a.TargetID = 0
+		return nil
+	case entity.AudienceFaculty:
+		model = &entity.Faculty{}
+	case entity.AudienceProgram:
+		model = &entity.Program{}
+	case entity.AudienceCurriculum:
+		model = &entity.Curriculum{}
+	case entity.AudienceUser:
+		model = &entity.User{}
+	}
+	a.Value = ""
+	var count int64
+	if err := db.Model(model).Where("id = ?", a.TargetID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%s %d not found", a.Kind, a.TargetID)
+	}
+	return nil
+}
+
+// ReplaceAnnouncementAudiences makes audiences the complete audience of the announcement.
+func ReplaceAnnouncementAudiences(tx *gorm.DB, announcementID uint, audiences []entity.AnnouncementAudience) error {
+	if err := tx.Unscoped().Where("announcement_id = ?", announcementID).Delete(&entity.AnnouncementAudience{}).Error; err != nil {
+		return err
+	}
+	for i := range audiences {
+		audiences[i].ID = 0
+		audiences[i].AnnouncementID = announcementID
+	}
+	if len(audiences) == 0 {
+		return nil
+	}
+	return tx.Create(&audiences).Error
+}
+
+// ResolveAudienceUserIDs lists the users an announcement is for, using the same rules as
+// AudienceMatches: every user when there is no audience.
+func ResolveAudienceUserIDs(db *gorm.DB, audiences []entity.AnnouncementAudience) ([]uint, error) {
+	var roles, users, curricula, programs, faculties []uint
+	var years []string
+	for _, a := range audiences {
+		switch a.Kind {
+		case entity.AudienceRole:
+			roles = append(roles, a.TargetID)
+		case entity.AudienceUser:
+			users = append(users, a.TargetID)
+		case entity.AudienceCurriculum:
+			curricula = append(curricula, a.TargetID)
+		case entity.AudienceProgram:
+			programs = append(programs, a.TargetID)
+		case entity.AudienceFaculty:
+			faculties = append(faculties, a.TargetID)
+		case entity.AudienceAcademicYear:
+			years = append(years, a.Value)
+		}
+	}
+
+	q := db.Model(&entity.User{})
+	if len(roles) > 0 {
+		q = q.Where("account_type_id IN ?", roles)
+	}
+
+	if len(users)+len(curricula)+len(programs)+len(faculties)+len(years) > 0 {
+		targets := map[uint]bool{}
+		for _, id := range users {
+			targets[id] = true
+		}
+
+		// ผู้ที่เกี่ยวข้องกับหลักสูตรที่ตรงกับเป้าหมาย (เลือกไว้ สมัคร หรือรับผิดชอบ)
+		var conds []string
+		var args []interface{}
+		for _, c := range []struct {
+			sql string
+			ids interface{}
+			n   int
+		}{
+			{"curriculums.id IN ?", curricula, len(curricula)},
+			{"curriculums.program_id IN ?", programs, len(programs)},
+			{"curriculums.faculty_id IN ?", faculties, len(faculties)},
+			{"curriculums.academic_year IN ?", years, len(years)},
+		} {
+			if c.n > 0 {
+				conds = append(conds, c.sql)
+				args = append(args, c.ids)
+			}
+		}
+		if len(conds) > 0 {
+			var linked []uint
+			if err := db.Table("("+curriculumLinksSQL+") AS links").
+				Joins("JOIN curriculums ON curriculums.id = links.curriculum_id AND curriculums.deleted_at IS NULL").
+				Where("("+strings.Join(conds, " OR ")+")", args...).
+				Distinct().
+				Pluck("links.user_id", &linked).Error; err != nil {
+				return nil, err
+			}
+			for _, id := range linked {
+				targets[id] = true
+			}
+		}
+
+		// ผู้ดูแลคณะ
+		if len(faculties) > 0 {
+			var admins []uint
+			if err := db.Model(&entity.FacultyAdmin{}).Where("faculty_id IN ?", faculties).Pluck("user_id", &admins).Error; err != nil {
+				return nil, err
+			}
+			for _, id := range admins {
+				targets[id] = true
+			}
+		}
+
+		if len(targets) == 0 {
+			return []uint{}, nil
+		}
+		ids := make([]uint, 0, len(targets))
+		for id := range targets {
+			ids = append(ids, id)
+		}
+		q = q.Where("id IN ?", ids)
+	}
+
+	var ids []uint
+	if err := q.Order("id asc").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// notificationBatchSize limits how many notifications are inserted per statement.
+const notificationBatchSize = 500
+
+// notificationPreviewLength is how much of the announcement a notification shows.
+const notificationPreviewLength = 300
+
+// NotifyAnnouncementAudience creates one notification per user in the announcement's
+// audience. Users who already have a notification for the announcement are skipped, so it
+// is safe to call again. Returns how many notifications were created.
+func NotifyAnnouncementAudience(db *gorm.DB, announcement *entity.Announcement, sentTime time.Time) (int, error) {
+	audiences := announcement.Audiences
+	if audiences == nil {
+		if err := db.Where("announcement_id = ?", announcement.ID).Find(&audiences).Error; err != nil {
+			return 0, err
+		}
+	}
+	userIDs, err := ResolveAudienceUserIDs(db, audiences)
+	if err != nil {
+		return 0, err
+	}
+
+	var notified []uint
+	if err := db.Model(&entity.Notification{}).
+		Where("announcement_id = ? AND user_id IS NOT NULL AND notification_type = ?", announcement.ID, entity.NotificationTypeAnnouncement).
+		Pluck("user_id", &notified).Error; err != nil {
+		return 0, err
+	}
+	skip := make(map[uint]bool, len(notified))
+	for _, id := range notified {
+		skip[id] = true
+	}
+
+	preview := AnnouncementPreview(announcement, notificationPreviewLength)
+	notifications := make([]entity.Notification, 0, len(userIDs))
+	for _, id := range userIDs {
+		if skip[id] {
+			continue
+		}
+		userID := id
+		notifications = append(notifications, entity.Notification{
+			Notification_Title:   announcement.Title,
+			Notification_Type:    entity.NotificationTypeAnnouncement,
+			Notification_Message: preview,
+			Is_Read:              false,
+			Sent_At:              sentTime,
+			Created_At:           sentTime,
+			UserID:               &userID,
+			AnnouncementID:       &announcement.ID,
+		})
+	}
+	if len(notifications) == 0 {
+		return 0, nil
+	}
+	// อีเมลต้องเข้าคิวพร้อมกับการแจ้งเตือน: ถ้าพลาด รอบถัดไปจะข้ามผู้ที่ได้แจ้งเตือนแล้ว
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := CreateNotifications(tx, notifications, sentTime); err != nil {
+			return err
+		}
+		return QueueEmails(tx, announcementEmails(announcement, notifications), sentTime)
+	})
+	if err != nil {
+		return 0, err
+	}
+	log.Printf("📬 Announcement %d: notified %d user(s)", announcement.ID, len(notifications))
+	return len(notifications), nil
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sut68/team14/backend/entity"
+)
+
+// MaxAnnouncementContentLength is the longest content an announcement may have, in characters.
+const MaxAnnouncementContentLength = 50000
+
+// PrepareAnnouncementContent checks the content format and length, and sanitizes HTML
+// content before it is stored. Markdown is stored as written and sanitized when rendered.
+func PrepareAnnouncementContent(format, content string) (string, string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	switch format {
+	case "":
+		format = entity.AnnouncementFormatPlain
+	case entity.AnnouncementFormatPlain, entity.AnnouncementFormatMarkdown:
+	case entity.AnnouncementFormatHTML:
+		content = SanitizeHTML(content)
+	default:
+		return "", "", fmt.Errorf("content_format must be plain, markdown or html")
+	}
+	if utf8.RuneCountInString(content) > MaxAnnouncementContentLength {
+		return "", "", fmt.Errorf("content must not exceed %d characters", MaxAnnouncementContentLength)
+	}
+	return format, content, nil
+}
+
+// RenderAnnouncementContent returns the content as safe HTML.
+func RenderAnnouncementContent(format, content string) string {
+	switch format {
+	case entity.AnnouncementFormatMarkdown:
+		return RenderMarkdown(content)
+	case entity.AnnouncementFormatHTML:
+		// sanitize ซ้ำตอนอ่าน เผื่อข้อมูลเก่าที่บันทึกก่อนปรับรายการแท็กที่อนุญาต
+		return SanitizeHTML(content)
+	}
+	return renderPlainText(content)
+}
+
+// renderPlainText keeps the paragraphs and line breaks of plain text.
+func renderPlainText(content string) string {
+	var b strings.Builder
+	for _, para := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n\n") {
+		if para = strings.TrimSpace(para); para != "" {
+			b.WriteString("<p>" + strings.ReplaceAll(html.EscapeString(para), "\n", "<br>") + "</p>\n")
+		}
+	}
+	return b.String()
+}
+
+// RenderAnnouncement fills ContentHTML for the response.
+func RenderAnnouncement(a *entity.Announcement) {
+	a.ContentHTML = RenderAnnouncementContent(a.ContentFormat, a.Content)
+}
+
+// RenderAnnouncements fills ContentHTML of every announcement.
+func RenderAnnouncements(announcements []entity.Announcement) {
+	for i := range announcements {
+		RenderAnnouncement(&announcements[i])
+	}
+}
+
+// AnnouncementPreview is the content as plain text of at most max characters, for
+// notifications.
+func AnnouncementPreview(a *entity.Announcement, max int) string {
+	text := HTMLToText(RenderAnnouncementContent(a.ContentFormat, a.Content))
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:max-1])) + "…"
+}
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Lifecycle actions on an announcement.
+const (
+	AnnouncementActionPublish   = "publish"   // draft/scheduled -> published
+	AnnouncementActionSchedule  = "schedule"  // draft/scheduled -> scheduled
+	AnnouncementActionUnpublish = "unpublish" // scheduled/published/expired -> draft
+	AnnouncementActionExpire    = "expire"    // published -> expired
+	AnnouncementActionArchive   = "archive"   // anything but archived -> archived
+	AnnouncementActionUnarchive = "unarchive" // archived -> draft
+)
+
+// Revision actions that are not lifecycle transitions.
+const (
+	AnnouncementRevisionCreate   = "create"
+	AnnouncementRevisionEdit     = "edit"
+	AnnouncementRevisionRestore  = "restore"
+	AnnouncementRevisionBaseline = "baseline" // state before the first tracked change
+)
+
+var announcementTransitions = map[string]struct {
+	from []string
+	to   string
+}{
+	AnnouncementActionPublish:   {[]string{entity.AnnouncementDraft, entity.AnnouncementScheduled}, entity.AnnouncementPublished},
+	AnnouncementActionSchedule:  {[]string{entity.AnnouncementDraft, entity.AnnouncementScheduled}, entity.AnnouncementScheduled},
+	AnnouncementActionUnpublish: {[]string{entity.AnnouncementScheduled, entity.AnnouncementPublished, entity.AnnouncementExpired}, entity.AnnouncementDraft},
+	AnnouncementActionExpire:    {[]string{entity.AnnouncementPublished}, entity.AnnouncementExpired},
+	AnnouncementActionArchive:   {[]string{entity.AnnouncementDraft, entity.AnnouncementScheduled, entity.AnnouncementPublished, entity.AnnouncementExpired}, entity.AnnouncementArchived},
+	AnnouncementActionUnarchive: {[]string{entity.AnnouncementArchived}, entity.AnnouncementDraft},
+}
+
+// ErrAnnouncementTransition is returned when an action does not apply to the current status.
+var ErrAnnouncementTransition = errors.New("invalid announcement state transition")
+
+// AnnouncementLocation is the timezone announcement times are entered and published in.
+func AnnouncementLocation() *time.Location {
+	location, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// NormalizeAnnouncementStatus lowercases a status; older clients send DRAFT/PUBLISHED/SCHEDULED.
+func NormalizeAnnouncementStatus(status string) string {
+	return strings.ToLower(strings.TrimSpace(status))
+}
+
+func currentAnnouncementStatus(a *entity.Announcement) string {
+	if status := NormalizeAnnouncementStatus(a.Status); status != "" {
+		return status
+	}
+	return entity.AnnouncementDraft
+}
+
+// AnnouncementActionForStatus returns the action that moves an announcement to the target
+// status, or "" when it is already there.
+func AnnouncementActionForStatus(a *entity.Announcement, target string) (string, error) {
+	current := currentAnnouncementStatus(a)
+	switch NormalizeAnnouncementStatus(target) {
+	case entity.AnnouncementDraft:
+		switch current {
+		case entity.AnnouncementDraft:
+			return "", nil
+		case entity.AnnouncementArchived:
+			return AnnouncementActionUnarchive, nil
+		}
+		return AnnouncementActionUnpublish, nil
+	case entity.AnnouncementScheduled:
+		return AnnouncementActionSchedule, nil // ตั้งเวลาใหม่ได้ แม้ตั้งไว้แล้ว
+	case entity.AnnouncementPublished:
+		if current == entity.AnnouncementPublished {
+			return "", nil
+		}
+		return AnnouncementActionPublish, nil
+	case entity.AnnouncementExpired:
+		if current == entity.AnnouncementExpired {
+			return "", nil
+		}
+		return AnnouncementActionExpire, nil
+	case entity.AnnouncementArchived:
+		if current == entity.AnnouncementArchived {
+			return "", nil
+		}
+		return AnnouncementActionArchive, nil
+	}
+	return "", errors.New("invalid status")
+}
+
+// TransitionAnnouncement applies a lifecycle action to a in memory; save it with
+// SaveAnnouncement. at is the publish time for schedule.
+func TransitionAnnouncement(a *entity.Announcement, action string, at *time.Time, now time.Time) error {
+	t, ok := announcementTransitions[action]
+	if !ok {
+		return fmt.Errorf("unknown action %q", action)
+	}
+	current := currentAnnouncementStatus(a)
+	allowed := false
+	for _, from := range t.from {
+		allowed = allowed || from == current
+	}
+	if !allowed {
+		return fmt.Errorf("%w: cannot %s an announcement that is %s", ErrAnnouncementTransition, action, current)
+	}
+
+	switch action {
+	case AnnouncementActionPublish:
+		a.Published_At = &now
+		if a.Scheduled_Publish_At == nil || a.Scheduled_Publish_At.After(now) {
+			a.Scheduled_Publish_At = &now
+		}
+	case AnnouncementActionSchedule:
+		if at == nil {
+			return errors.New("scheduled_publish_at is required for scheduled publish")
+		}
+		if !at.After(now) {
+			return errors.New("scheduled_publish_at must be in the future")
+		}
+		a.Scheduled_Publish_At = at
+		a.Published_At = nil
+	case AnnouncementActionUnpublish, AnnouncementActionUnarchive:
+		a.Published_At = nil
+		a.Scheduled_Publish_At = nil
+	case AnnouncementActionExpire:
+		if a.Expires_At == nil || a.Expires_At.After(now) {
+			a.Expires_At = &now
+		}
+	}
+	a.Status = t.to
+	return nil
+}
+
+// SaveAnnouncement creates or saves a, replaces its audience when audiences is not nil, and
+// records the result as a new revision by editorID, all in one transaction.
+func SaveAnnouncement(db *gorm.DB, a *entity.Announcement, audiences *[]entity.AnnouncementAudience, action string, editorID *uint) (*entity.AnnouncementRevision, error) {
+	return saveAnnouncement(db, a, audiences, action, editorID, nil)
+}
+
+func saveAnnouncement(db *gorm.DB, a *entity.Announcement, audiences *[]entity.AnnouncementAudience, action string, editorID *uint, restoredFrom *int) (*entity.AnnouncementRevision, error) {
+	var revision *entity.AnnouncementRevision
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(a).Error; err != nil {
+			return err
+		}
+		if audiences != nil {
+			if err := ReplaceAnnouncementAudiences(tx, a.ID, *audiences); err != nil {
+				return err
+			}
+		}
+		var err error
+		revision, err = recordAnnouncementRevision(tx, a, action, editorID, restoredFrom)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// audienceSnapshot is an audience row as stored in a revision.
+type audienceSnapshot struct {
+	Kind     string `json:"kind"`
+	TargetID uint   `json:"target_id,omitempty"`
+	Value    string `json:"value,omitempty"`
+}
+
+// recordAnnouncementRevision stores the saved state of a (with the audience currently in the
+// database) as its next revision, and reloads a.Audiences.
+func recordAnnouncementRevision(tx *gorm.DB, a *entity.Announcement, action string, editorID *uint, restoredFrom *int) (*entity.AnnouncementRevision, error) {
+	if err := tx.Where("announcement_id = ?", a.ID).Order("id asc").Find(&a.Audiences).Error; err != nil {
+		return nil, err
+	}
+	snapshot := make([]audienceSnapshot, 0, len(a.Audiences))
+	for _, row := range a.Audiences {
+		snapshot = append(snapshot, audienceSnapshot{Kind: row.Kind, TargetID: row.TargetID, Value: row.Value})
+	}
+	audiences, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest int
+	if err := tx.Model(&entity.AnnouncementRevision{}).
+		Where("announcement_id = ?", a.ID).
+		Select("COALESCE(MAX(revision), 0)").
+		Scan(&latest).Error; err != nil {
+		return nil, err
+	}
+
+	revision := entity.AnnouncementRevision{
+		AnnouncementID:       a.ID,
+		Revision:             latest + 1,
+		Action:               action,
+		RestoredFrom:         restoredFrom,
+		Title:                a.Title,
+		Content:              a.Content,
+		ContentFormat:        a.ContentFormat,
+		Status:               a.Status,
+		Is_Pinned:            a.Is_Pinned != nil && *a.Is_Pinned,
+		Scheduled_Publish_At: a.Scheduled_Publish_At,
+		Published_At:         a.Published_At,
+		Expires_At:           a.Expires_At,
+		Send_Notification:    a.Send_Notification,
+		RequiresAck:          a.RequiresAck,
+		AckDeadline:          a.AckDeadline,
+		CetagoryID:           a.CetagoryID,
+		Audiences:            audiences,
+		EditorID:             editorID,
+	}
+	if err := tx.Create(&revision).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&entity.Announcement{}).Where("id = ?", a.ID).UpdateColumn("revision", revision.Revision).Error; err != nil {
+		return nil, err
+	}
+	a.Revision = revision.Revision
+	return &revision, nil
+}
+
+// EnsureAnnouncementBaseline records the current state of an announcement that predates
+// revision history, before its first tracked change, so that state can be restored.
+func EnsureAnnouncementBaseline(db *gorm.DB, a *entity.Announcement) error {
+	var count int64
+	if err := db.Model(&entity.AnnouncementRevision{}).Where("announcement_id = ?", a.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		_, err := recordAnnouncementRevision(tx, a, AnnouncementRevisionBaseline, nil, nil)
+		return err
+	})
+}
+
+// RestoreAnnouncementRevision copies the content of an earlier revision (title, content and
+// its format, pin, category, expiry, notification flag, acknowledgement settings and audience)
+// back onto a and records that as a new revision. The status is left alone; it only changes through the lifecycle actions.
+func RestoreAnnouncementRevision(db *gorm.DB, a *entity.Announcement, revision int, editorID uint) (*entity.AnnouncementRevision, error) {
+	var rev entity.AnnouncementRevision
+	if err := db.Where("announcement_id = ? AND revision = ?", a.ID, revision).First(&rev).Error; err != nil {
+		return nil, err
+	}
+	var snapshot []audienceSnapshot
+	if len(rev.Audiences) > 0 {
+		if err := json.Unmarshal(rev.Audiences, &snapshot); err != nil {
+			return nil, err
+		}
+	}
+	audiences := make([]entity.AnnouncementAudience, 0, len(snapshot))
+	for _, row := range snapshot {
+		audiences = append(audiences, entity.AnnouncementAudience{Kind: row.Kind, TargetID: row.TargetID, Value: row.Value})
+	}
+
+	pinned := rev.Is_Pinned
+	a.Title = rev.Title
+	a.Content = rev.Content
+	a.ContentFormat = rev.ContentFormat
+	a.Is_Pinned = &pinned
+	a.CetagoryID = rev.CetagoryID
+	a.Expires_At = rev.Expires_At
+	a.Send_Notification = rev.Send_Notification
+	if !equalTimePtr(a.AckDeadline, rev.AckDeadline) {
+		a.AckReminderSentAt = nil // กำหนดใหม่ เตือนใหม่ได้
+	}
+	a.RequiresAck = rev.RequiresAck
+	a.AckDeadline = rev.AckDeadline
+	return saveAnnouncement(db, a, &audiences, AnnouncementRevisionRestore, &editorID, &rev.Revision)
+}
+
+// PublishDueAnnouncements publishes scheduled announcements whose time has come and returns
+// them. An announcement published by another instance in the meantime is skipped.
+func PublishDueAnnouncements(db *gorm.DB, now time.Time) ([]entity.Announcement, error) {
+	var due []entity.Announcement
+	if err := db.Where("status = ? AND scheduled_publish_at <= ? AND published_at IS NULL", entity.AnnouncementScheduled, now).
+		Find(&due).Error; err != nil {
+		return nil, err
+	}
+	return applyDueAction(db, due, entity.AnnouncementScheduled, AnnouncementActionPublish, map[string]interface{}{
+		"status":       entity.AnnouncementPublished,
+		"published_at": now,
+	}, now)
+}
+
+// ExpireDueAnnouncements moves published announcements past their expires_at to expired.
+func ExpireDueAnnouncements(db *gorm.DB, now time.Time) ([]entity.Announcement, error) {
+	var due []entity.Announcement
+	if err := db.Where("status = ? AND expires_at <= ?", entity.AnnouncementPublished, now).
+		Find(&due).Error; err != nil {
+		return nil, err
+	}
+	return applyDueAction(db, due, entity.AnnouncementPublished, AnnouncementActionExpire, map[string]interface{}{
+		"status": entity.AnnouncementExpired,
+	}, now)
+}
+
+// applyDueAction runs a scheduler action on each announcement still in status from, and
+// records a revision without an editor for each one it changed.
+func applyDueAction(db *gorm.DB, due []entity.Announcement, from, action string, updates map[string]interface{}, now time.Time) ([]entity.Announcement, error) {
+	done := make([]entity.Announcement, 0, len(due))
+	for i := range due {
+		a := &due[i]
+		err := db.Transaction(func(tx *gorm.DB) error {
+			res := tx.Model(&entity.Announcement{}).Where("id = ? AND status = ?", a.ID, from).Updates(updates)
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error
+			}
+			if err := TransitionAnnouncement(a, action, nil, now); err != nil {
+				return err
+			}
+			if _, err := recordAnnouncementRevision(tx, a, action, nil, nil); err != nil {
+				return err
+			}
+			done = append(done, *a)
+			return nil
+		})
+		if err != nil {
+			return done, fmt.Errorf("announcement %d: %w", a.ID, err)
+		}
+	}
+	return done, nil
+}
+
+func equalTimePtr(x, y *time.Time) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+	return x.Equal(*y)
+}
@@ -0,0 +1,369 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AckReminderLead is how long before the acknowledgement deadline the reminder goes out.
+const AckReminderLead = 24 * time.Hour
+
+// ErrAckNotRequired is returned when acknowledging an announcement that does not ask for it.
+var ErrAckNotRequired = errors.New("this announcement does not require acknowledgement")
+
+var receiptConflict = []clause.Column{{Name: "announcement_id"}, {Name: "user_id"}}
+
+// MarkAnnouncementViewed records that the user opened the announcement: the first time, and
+// every time after that as last_viewed_at and view_count.
+func MarkAnnouncementViewed(db *gorm.DB, announcementID, userID uint, now time.Time) (*entity.AnnouncementReceipt, error) {
+	receipt := entity.AnnouncementReceipt{AnnouncementID: announcementID, UserID: userID, FirstViewedAt: now, LastViewedAt: now, ViewCount: 1}
+	if err := db.Clauses(clause.OnConflict{
+		Columns: receiptConflict,
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_viewed_at": now,
+			"view_count":     gorm.Expr("announcement_receipts.view_count + 1"),
+			"updated_at":     now,
+		}),
+	}).Create(&receipt).Error; err != nil {
+		return nil, err
+	}
+	return findReceipt(db, announcementID, userID)
+}
+
+// AcknowledgeAnnouncement records that the user acknowledged the announcement. Acknowledging
+// again keeps the first time.
+func AcknowledgeAnnouncement(db *gorm.DB, a *entity.Announcement, userID uint, now time.Time) (*entity.AnnouncementReceipt, error) {
+	if !a.RequiresAck {
+		return nil, ErrAckNotRequired
+	}
+	receipt := entity.AnnouncementReceipt{AnnouncementID: a.ID, UserID: userID, FirstViewedAt: now, LastViewedAt: now, ViewCount: 1, AcknowledgedAt: &now}
+	if err := db.Clauses(clause.OnConflict{
+		Columns: receiptConflict,
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"acknowledged_at": gorm.Expr("COALESCE(announcement_receipts.acknowledged_at, ?)", now),
+			"updated_at":      now,
+		}),
+	}).Create(&receipt).Error; err != nil {
+		return nil, err
+	}
+	return findReceipt(db, a.ID, userID)
+}
+
+func findReceipt(db *gorm.DB, announcementID, userID uint) (*entity.AnnouncementReceipt, error) {
+	var receipt entity.AnnouncementReceipt
+	if err := db.Where("announcement_id = ? AND user_id = ?", announcementID, userID).First(&receipt).Error; err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// AttachReceipts fills Receipt of each announcement with the user's receipt, if any.
+func AttachReceipts(db *gorm.DB, announcements []entity.Announcement, userID uint) error {
+	if len(announcements) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(announcements))
+	for i, a := range announcements {
+		ids[i] = a.ID
+	}
+	var receipts []entity.AnnouncementReceipt
+	if err := db.Where("user_id = ? AND announcement_id IN ?", userID, ids).Find(&receipts).Error; err != nil {
+		return err
+	}
+	byAnnouncement := make(map[uint]*entity.AnnouncementReceipt, len(receipts))
+	for i := range receipts {
+		byAnnouncement[receipts[i].AnnouncementID] = &receipts[i]
+	}
+	for i := range announcements {
+		announcements[i].Receipt = byAnnouncement[announcements[i].ID]
+	}
+	return nil
+}
+
+// -------------------- REMINDERS --------------------
+
+// SendAckReminders notifies every user in the audience who has not acknowledged the
+// announcement yet, and records when the reminder went out. Returns how many were sent.
+func SendAckReminders(db *gorm.DB, a *entity.Announcement, now time.Time) (int, error) {
+	if !a.RequiresAck {
+		return 0, ErrAckNotRequired
+	}
+	pending, err := pendingAckUserIDs(db, a)
+	if err != nil {
+		return 0, err
+	}
+
+	message := "กรุณากดรับทราบประกาศนี้"
+	if a.AckDeadline != nil {
+		message += " ภายใน " + a.AckDeadline.In(AnnouncementLocation()).Format("02/01/2006 15:04")
+	}
+	notifications := make([]entity.Notification, 0, len(pending))
+	for _, id := range pending {
+		userID := id
+		notifications = append(notifications, entity.Notification{
+			Notification_Title:   "โปรดรับทราบ: " + a.Title,
+			Notification_Type:    entity.NotificationTypeAnnouncementReminder,
+			Notification_Message: message,
+			Sent_At:              now,
+			Created_At:           now,
+			UserID:               &userID,
+			AnnouncementID:       &a.ID,
+			Group_Key:            entity.NotificationGroupKey("announcement", a.ID),
+		})
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := CreateNotifications(tx, notifications, now); err != nil {
+			return err
+		}
+		return tx.Model(&entity.Announcement{}).Where("id = ?", a.ID).UpdateColumn("ack_reminder_sent_at", now).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	a.AckReminderSentAt = &now
+	return len(notifications), nil
+}
+
+// pendingAckUserIDs lists the audience members who have not acknowledged the announcement.
+func pendingAckUserIDs(db *gorm.DB, a *entity.Announcement) ([]uint, error) {
+	audience, err := announcementAudienceUserIDs(db, a)
+	if err != nil {
+		return nil, err
+	}
+	var acked []uint
+	if err := db.Model(&entity.AnnouncementReceipt{}).
+		Where("announcement_id = ? AND acknowledged_at IS NOT NULL", a.ID).
+		Pluck("user_id", &acked).Error; err != nil {
+		return nil, err
+	}
+	done := toSet(acked)
+	pending := make([]uint, 0, len(audience))
+	for _, id := range audience {
+		if !done[id] {
+			pending = append(pending, id)
+		}
+	}
+	return pending, nil
+}
+
+// SendDueAckReminders sends the reminder of every published announcement whose
+// acknowledgement deadline is within AckReminderLead and has not had one yet.
+func SendDueAckReminders(db *gorm.DB, now time.Time) (int, error) {
+	var due []entity.Announcement
+	if err := db.Where("status = ? AND requires_ack = ? AND ack_deadline IS NOT NULL AND ack_deadline <= ? AND ack_reminder_sent_at IS NULL",
+		entity.AnnouncementPublished, true, now.Add(AckReminderLead)).
+		Find(&due).Error; err != nil {
+		return 0, err
+	}
+	sent := 0
+	for i := range due {
+		n, err := SendAckReminders(db, &due[i], now)
+		if err != nil {
+			return sent, fmt.Errorf("announcement %d: %w", due[i].ID, err)
+		}
+		log.Printf("🔔 Announcement %d: reminded %d user(s) to acknowledge", due[i].ID, n)
+		sent += n
+	}
+	return sent, nil
+}
+
+// -------------------- ANALYTICS --------------------
+
+// AnnouncementEngagement counts the audience of an announcement (reach), how many of them
+// opened it (read) and acknowledged it.
+type AnnouncementEngagement struct {
+	Reach        int     `json:"reach"`
+	Read         int     `json:"read"`
+	Acknowledged int     `json:"acknowledged"`
+	ReadRate     float64 `json:"read_rate"`
+	AckRate      float64 `json:"ack_rate"`
+}
+
+// AudienceSegmentStats is the engagement of one part of the audience: one audience row, or
+// one role.
+type AudienceSegmentStats struct {
+	Kind     string `json:"kind"`
+	TargetID uint   `json:"target_id,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Label    string `json:"label"`
+	AnnouncementEngagement
+}
+
+// AnnouncementAnalytics is the engagement report of an announcement.
+type AnnouncementAnalytics struct {
+	AnnouncementID    uint                   `json:"announcement_id"`
+	RequiresAck       bool                   `json:"requires_ack"`
+	AckDeadline       *time.Time             `json:"ack_deadline"`
+	AckReminderSentAt *time.Time             `json:"ack_reminder_sent_at"`
+	Total             AnnouncementEngagement `json:"total"`
+	// Overdue: ยังไม่รับทราบ ทั้งที่เลยกำหนดแล้ว
+	Overdue  int                    `json:"overdue"`
+	Segments []AudienceSegmentStats `json:"segments"`
+	Roles    []AudienceSegmentStats `json:"roles"`
+}
+
+// AudienceEveryone is the segment kind of an announcement without audience rows.
+const AudienceEveryone = "everyone"
+
+var roleLabels = map[uint]string{
+	entity.UserTypeStudent: "student",
+	entity.UserTypeTeacher: "teacher",
+	entity.UserTypeAdmin:   "admin",
+}
+
+// BuildAnnouncementAnalytics counts reach, reads and acknowledgements of the announcement
+// overall, per audience row and per role. Only users in the current audience are counted.
+func BuildAnnouncementAnalytics(db *gorm.DB, a *entity.Announcement, now time.Time) (*AnnouncementAnalytics, error) {
+	if a.Audiences == nil {
+		if err := db.Where("announcement_id = ?", a.ID).Order("id asc").Find(&a.Audiences).Error; err != nil {
+			return nil, err
+		}
+	}
+	audience, err := announcementAudienceUserIDs(db, a)
+	if err != nil {
+		return nil, err
+	}
+	inAudience := toSet(audience)
+
+	var receipts []entity.AnnouncementReceipt
+	if err := db.Where("announcement_id = ?", a.ID).Find(&receipts).Error; err != nil {
+		return nil, err
+	}
+	read, acked := map[uint]bool{}, map[uint]bool{}
+	for _, r := range receipts {
+		read[r.UserID] = true
+		if r.AcknowledgedAt != nil {
+			acked[r.UserID] = true
+		}
+	}
+	engagement := func(ids []uint) AnnouncementEngagement {
+		var e AnnouncementEngagement
+		for _, id := range ids {
+			if !inAudience[id] {
+				continue
+			}
+			e.Reach++
+			if read[id] {
+				e.Read++
+			}
+			if acked[id] {
+				e.Acknowledged++
+			}
+		}
+		if e.Reach > 0 {
+			e.ReadRate = float64(e.Read) / float64(e.Reach)
+			e.AckRate = float64(e.Acknowledged) / float64(e.Reach)
+		}
+		return e
+	}
+
+	report := &AnnouncementAnalytics{
+		AnnouncementID:    a.ID,
+		RequiresAck:       a.RequiresAck,
+		AckDeadline:       a.AckDeadline,
+		AckReminderSentAt: a.AckReminderSentAt,
+		Total:             engagement(audience),
+		Segments:          []AudienceSegmentStats{},
+		Roles:             []AudienceSegmentStats{},
+	}
+	if a.RequiresAck && a.AckDeadline != nil && now.After(*a.AckDeadline) {
+		report.Overdue = report.Total.Reach - report.Total.Acknowledged
+	}
+
+	if len(a.Audiences) == 0 {
+		report.Segments = append(report.Segments, AudienceSegmentStats{Kind: AudienceEveryone, Label: "ทุกคน", AnnouncementEngagement: report.Total})
+	}
+	for _, row := range a.Audiences {
+		ids, err := ResolveAudienceUserIDs(db, []entity.AnnouncementAudience{row})
+		if err != nil {
+			return nil, err
+		}
+		label, err := audienceLabel(db, row)
+		if err != nil {
+			return nil, err
+		}
+		report.Segments = append(report.Segments, AudienceSegmentStats{
+			Kind: row.Kind, TargetID: row.TargetID, Value: row.Value, Label: label,
+			AnnouncementEngagement: engagement(ids),
+		})
+	}
+
+	var users []struct {
+		ID            uint
+		AccountTypeID uint
+	}
+	if err := db.Model(&entity.User{}).Select("id, account_type_id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	byRole := map[uint][]uint{}
+	for _, u := range users {
+		if inAudience[u.ID] {
+			byRole[u.AccountTypeID] = append(byRole[u.AccountTypeID], u.ID)
+		}
+	}
+	for _, role := range []uint{entity.UserTypeStudent, entity.UserTypeTeacher, entity.UserTypeAdmin} {
+		if ids := byRole[role]; len(ids) > 0 {
+			report.Roles = append(report.Roles, AudienceSegmentStats{
+				Kind: entity.AudienceRole, TargetID: role, Label: roleLabels[role],
+				AnnouncementEngagement: engagement(ids),
+			})
+		}
+	}
+	return report, nil
+}
+
+func announcementAudienceUserIDs(db *gorm.DB, a *entity.Announcement) ([]uint, error) {
+	audiences := a.Audiences
+	if audiences == nil {
+		if err := db.Where("announcement_id = ?", a.ID).Find(&audiences).Error; err != nil {
+			return nil, err
+		}
+	}
+	return ResolveAudienceUserIDs(db, audiences)
+}
+
+// audienceLabel names the target of an audience row for reports.
+func audienceLabel(db *gorm.DB, row entity.AnnouncementAudience) (string, error) {
+	var label string
+	var err error
+	switch row.Kind {
+	case entity.AudienceRole:
+		return roleLabels[row.TargetID], nil
+	case entity.AudienceAcademicYear:
+		return "ปีการศึกษา " + row.Value, nil
+	case entity.AudienceFaculty:
+		err = db.Model(&entity.Faculty{}).Where("id = ?", row.TargetID).Select("name").Scan(&label).Error
+	case entity.AudienceProgram:
+		err = db.Model(&entity.Program{}).Where("id = ?", row.TargetID).Select("name").Scan(&label).Error
+	case entity.AudienceCurriculum:
+		err = db.Model(&entity.Curriculum{}).Where("id = ?", row.TargetID).Select("name").Scan(&label).Error
+	case entity.AudienceUser:
+		var u entity.User
+		if err = db.Select("id, first_name_th, last_name_th, email").First(&u, row.TargetID).Error; err == nil {
+			label = strings.TrimSpace(u.FirstNameTH + " " + u.LastNameTH)
+			if label == "" {
+				label = u.Email
+			}
+		}
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	return label, err
+}
+
+func toSet(ids []uint) map[uint]bool {
+	set := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
@@ -28,87 +28,44 @@ func StartAnnouncementScheduler() {
 	go PublishScheduledAnnouncements()
 }
 
-// เผยแพร่ประกาศที่ถึงเวลาแล้ว
+// เผยแพร่ประกาศที่ถึงเวลาแล้ว ย้ายประกาศที่หมดอายุเป็น expired และเตือนให้กดรับทราบ
 func PublishScheduledAnnouncements() {
 	db := config.GetDB()
 
 	// ใช้ timezone ของไทย
-	location, err := time.LoadLocation("Asia/Bangkok")
-	if err != nil {
-		location = time.UTC // ถ้าโหลด timezone ไม่ได้ใช้ UTC
-	}
-	now := time.Now().In(location)
-
-	var announcements []entity.Announcement
+	now := time.Now().In(AnnouncementLocation())
 
-	// หาประกาศที่:
-	// 1. เวลา scheduled_publish_at ถึงหรือผ่านไปแล้ว
-	// 2. ยังไม่มี published_at (ยังไม่ได้ publish)
-	result := db.Where("scheduled_publish_at <= ? AND published_at IS NULL", now).
-		Find(&announcements)
-
-	if result.Error != nil {
-		log.Printf("❌ Error fetching scheduled announcements: %v", result.Error)
-		return
-	}
-
-	if len(announcements) == 0 {
-		return // ไม่มีประกาศที่ต้อง publish
+	published, err := PublishDueAnnouncements(db, now)
+	if err != nil {
+		log.Printf("❌ Error publishing scheduled announcements: %v", err)
 	}
+	for i := range published {
+		announcement := published[i]
+		log.Printf("✅ Published announcement ID %d: %s", announcement.ID, announcement.Title)
 
-	log.Printf("📢 Found %d announcement(s) to publish", len(announcements))
-
-	// อัปเดตแต่ละประกาศ
-	for _, announcement := range announcements {
-		err := publishAnnouncement(db, &announcement, now)
-		if err != nil {
-			log.Printf("❌ Failed to publish announcement ID %d: %v", announcement.ID, err)
-		} else {
-			log.Printf("✅ Published announcement ID %d: %s", announcement.ID, announcement.Title)
+		// ส่ง notification ถ้าเปิดใช้งาน
+		if announcement.Send_Notification {
+			go sendNotificationForAnnouncement(db, &announcement, now)
 		}
 	}
-}
-
-// เผยแพร่ประกาศเดี่ยว
-func publishAnnouncement(db *gorm.DB, announcement *entity.Announcement, now time.Time) error {
-
-	err := db.Model(&entity.Announcement{}).
-		Where("id = ? AND published_at IS NULL", announcement.ID).
-		Updates(map[string]interface{}{
-			"published_at": now,
-			"status":       "PUBLISHED",
-		}).Error
 
+	expired, err := ExpireDueAnnouncements(db, now)
 	if err != nil {
-		return err
+		log.Printf("❌ Error expiring announcements: %v", err)
 	}
-
-	// ส่ง notification ถ้าเปิดใช้งาน
-	if announcement.Send_Notification {
-		go sendNotificationForAnnouncement(db, announcement, now)
+	if len(expired) > 0 {
+		log.Printf("📭 Expired %d announcement(s)", len(expired))
 	}
 
-	return nil
+	if _, err := SendDueAckReminders(db, now); err != nil {
+		log.Printf("❌ Error sending acknowledgement reminders: %v", err)
+	}
 }
 
-// ส่ง notification (แยกเป็น goroutine เพื่อไม่ให้ block)
+// ส่ง notification ให้ผู้รับทุกคนตามกลุ่มเป้าหมาย (แยกเป็น goroutine เพื่อไม่ให้ block)
 func sendNotificationForAnnouncement(db *gorm.DB, announcement *entity.Announcement, sentTime time.Time) {
-	// สร้าง notification record ในฐานข้อมูล
-	notification := entity.Notification{
-		Notification_Title:   announcement.Title,
-		Notification_Type:    "ANNOUNCEMENT",
-		Notification_Message: announcement.Content,
-		Is_Read:              false,
-		Sent_At:              sentTime,
-		AnnouncementID:       &announcement.ID,
-		// UserID: nil, // ถ้าต้องการส่งให้ user เฉพาะคน ให้ใส่ UserID ที่นี่
-	}
-
-	err := db.Create(&notification).Error
-	if err != nil {
-		log.Printf("❌ Failed to create notification for announcement ID %d: %v",
+	if _, err := NotifyAnnouncementAudience(db, announcement, sentTime); err != nil {
+		log.Printf("❌ Failed to create notifications for announcement ID %d: %v",
 			announcement.ID, err)
-	} else {
-		log.Printf("📬 Notification created for announcement ID %d", announcement.ID)
 	}
 }
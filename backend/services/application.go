@@ -0,0 +1,265 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Reasons an application cannot be created or submitted.
+const (
+	IssueWindowNotOpen     = "window_not_open"
+	IssueWindowClosed      = "window_closed"
+	IssueNoWindow          = "no_window"
+	IssueRequirementNotMet = "requirement_not_met"
+	IssueQuotaFull         = "quota_full"
+	IssueMissingDocument   = "missing_document"
+	IssueNotPublished      = "not_published"
+)
+
+var (
+	// ErrApplicationNotEligible is returned by CreateApplication and SubmitApplication when checks fail.
+	ErrApplicationNotEligible = errors.New("application does not meet the curriculum requirements")
+	// ErrApplicationExists is returned by CreateApplication when the student already has an
+	// active application to the curriculum.
+	ErrApplicationExists = errors.New("you already applied to this curriculum")
+	// ErrApplicationSubmitted is returned by SubmitApplication when the application is no
+	// longer a draft.
+	ErrApplicationSubmitted = errors.New("application has already been submitted")
+)
+
+// ApplicationIssue is one failed check of an application against its curriculum.
+type ApplicationIssue struct {
+	Code           string `json:"code"`
+	Message        string `json:"message"`
+	DocumentTypeID uint   `json:"document_type_id,omitempty"`
+}
+
+// CountAcceptedApplications returns how many seats of the curriculum are taken.
+func CountAcceptedApplications(db *gorm.DB, curriculumID uint) (int64, error) {
+	var count int64
+	err := db.Model(&entity.Application{}).
+		Where("curriculum_id = ? AND status = ?", curriculumID, entity.ApplicationStatusAccepted).
+		Count(&count).Error
+	return count, err
+}
+
+// hasActiveApplication reports whether the user holds an application to the curriculum
+// that was neither withdrawn nor rejected.
+func hasActiveApplication(db *gorm.DB, userID, curriculumID uint) (bool, error) {
+	var count int64
+	err := db.Model(&entity.Application{}).
+		Where("user_id = ? AND curriculum_id = ? AND status NOT IN ?", userID, curriculumID,
+			[]string{entity.ApplicationStatusWithdrawn, entity.ApplicationStatusRejected}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CreateApplication checks that the student may apply to app.CurriculumID and stores app as
+// a draft in the round open at now. The checks and the insert run in one transaction with
+// the curriculum row locked; the unique index on active applications catches what slips
+// through (SQLite has no row locks). The failed checks are returned together with
+// ErrApplicationNotEligible.
+func CreateApplication(db *gorm.DB, app *entity.Application, now time.Time) ([]ApplicationIssue, error) {
+	var issues []ApplicationIssue
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var curriculum entity.Curriculum
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&curriculum, app.CurriculumID).Error; err != nil {
+			return err
+		}
+		exists, err := hasActiveApplication(tx, app.UserID, curriculum.ID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrApplicationExists
+		}
+
+		issues, err = CheckApplicationOpen(tx, &curriculum, app.UserID, now)
+		if err != nil {
+			return err
+		}
+		if len(issues) > 0 {
+			return ErrApplicationNotEligible
+		}
+
+		app.Status = entity.ApplicationStatusDraft
+		if round := CurrentRound(&curriculum, now); round != nil && round.ID != 0 {
+			app.ApplicationRoundID = &round.ID
+		}
+		return tx.Create(app).Error
+	})
+	if err != nil && !errors.Is(err, ErrApplicationExists) && !errors.Is(err, ErrApplicationNotEligible) {
+		// คำขอที่มาพร้อมกันชนกับ unique index
+		if exists, _ := hasActiveApplication(db, app.UserID, app.CurriculumID); exists {
+			return nil, ErrApplicationExists
+		}
+	}
+	return issues, err
+}
+
+// CheckApplicationOpen checks that a round is open, the eligibility requirements and the quota, i.e. whether
+// the student may apply to the curriculum at all.
+func CheckApplicationOpen(db *gorm.DB, curriculum *entity.Curriculum, userID uint, now time.Time) ([]ApplicationIssue, error) {
+	var issues []ApplicationIssue
+	if !curriculum.IsPublished() {
+		issues = append(issues, ApplicationIssue{Code: IssueNotPublished, Message: "curriculum is not published yet"})
+	}
+
+	if err := LoadCurriculumRounds(db, curriculum); err != nil {
+		return nil, err
+	}
+	round := CurrentRound(curriculum, now)
+	switch {
+	case round != nil && round.StatusAt(now) == entity.RoundStatusOpening:
+		issues = append(issues, ApplicationIssue{
+			Code:    IssueWindowNotOpen,
+			Message: round.Name + " opens at " + round.OpensAt.In(round.Location()).Format(time.RFC3339),
+		})
+	case round == nil && len(CurriculumRounds(curriculum)) > 0:
+		issues = append(issues, ApplicationIssue{Code: IssueWindowClosed, Message: "all application rounds are closed"})
+	case round == nil:
+		issues = append(issues, ApplicationIssue{Code: IssueNoWindow, Message: "curriculum has no application round"})
+	}
+
+	if curriculum.Requirements == nil {
+		if err := db.Preload("EducationLevel").Preload("CurriculumType").
+			Where("curriculum_id = ?", curriculum.ID).Find(&curriculum.Requirements).Error; err != nil {
+			return nil, err
+		}
+	}
+	profile, err := LoadStudentProfile(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	issues = append(issues, EligibilityIssues(EvaluateEligibility(curriculum, profile))...)
+
+	if curriculum.Quota > 0 {
+		accepted, err := CountAcceptedApplications(db, curriculum.ID)
+		if err != nil {
+			return nil, err
+		}
+		if accepted >= int64(curriculum.Quota) {
+			issues = append(issues, ApplicationIssue{Code: IssueQuotaFull, Message: "the quota of this curriculum is full"})
+		}
+	}
+
+	return issues, nil
+}
+
+// CheckApplication runs every check needed before an application can be submitted.
+func CheckApplication(db *gorm.DB, app *entity.Application, now time.Time) ([]ApplicationIssue, error) {
+	var curriculum entity.Curriculum
+	if err := db.First(&curriculum, app.CurriculumID).Error; err != nil {
+		return nil, err
+	}
+
+	issues, err := CheckApplicationOpen(db, &curriculum, app.UserID, now)
+	if err != nil {
+		return nil, err
+	}
+	missing, err := MissingRequiredDocuments(db, app)
+	if err != nil {
+		return nil, err
+	}
+	return append(issues, missing...), nil
+}
+
+// CreateSubmissionVersion creates the next version of a portfolio submission and makes it
+// the current one. Comment threads of the previous version are carried over.
+func CreateSubmissionVersion(tx *gorm.DB, portfolioID, userID uint, curriculumID *uint, status string) (*entity.PortfolioSubmission, error) {
+	var last entity.PortfolioSubmission
+	version := 1
+	err := tx.
+		Where("portfolio_id = ? AND user_id = ?", portfolioID, userID).
+		Order("version desc").
+		First(&last).Error
+	if err == nil {
+		version = last.Version + 1
+
+		// ปิด current version ตัวเก่า
+		if err := tx.Model(&entity.PortfolioSubmission{}).
+			Where("portfolio_id = ? AND user_id = ? AND is_current_version = ?", portfolioID, userID, true).
+			Update("is_current_version", false).Error; err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	submission := entity.PortfolioSubmission{
+		PortfolioID:        portfolioID,
+		UserID:             userID,
+		CurriculumID:       curriculumID,
+		Status:             status,
+		Version:            version,
+		Is_current_version: true,
+		Submission_at:      time.Now(),
+	}
+	if err := tx.Create(&submission).Error; err != nil {
+		return nil, err
+	}
+
+	// ยก comment จาก version ก่อนหน้ามาด้วย (คงสถานะ resolved ไว้)
+	if last.ID != 0 {
+		if err := CarryOverFeedbackComments(tx, last.ID, submission.ID); err != nil {
+			return nil, err
+		}
+	}
+	return &submission, nil
+}
+
+// SubmitApplication checks the application and, when everything passes, freezes it by
+// creating a portfolio submission for the curriculum. The checks and the status write run
+// in one transaction with the application row locked, so a double submit only goes through
+// once; the applicant's notification and email are queued in the same transaction, so a
+// submission is never stored without them. The failed checks are returned together with
+// ErrApplicationNotEligible.
+func SubmitApplication(db *gorm.DB, app *entity.Application, now time.Time) ([]ApplicationIssue, error) {
+	var issues []ApplicationIssue
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var current entity.Application
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id, status").
+			First(&current, app.ID).Error; err != nil {
+			return err
+		}
+		if !current.IsEditable() {
+			return ErrApplicationSubmitted
+		}
+
+		var err error
+		issues, err = CheckApplication(tx, app, now)
+		if err != nil {
+			return err
+		}
+		if len(issues) > 0 {
+			return ErrApplicationNotEligible
+		}
+
+		curriculumID := app.CurriculumID
+		submission, err := CreateSubmissionVersion(tx, app.PortfolioID, app.UserID, &curriculumID, "submitted")
+		if err != nil {
+			return err
+		}
+
+		app.Status = entity.ApplicationStatusSubmitted
+		app.SubmittedAt = &now
+		app.PortfolioSubmissionID = &submission.ID
+		if err := tx.Model(app).Updates(map[string]interface{}{
+			"status":                  app.Status,
+			"submitted_at":            app.SubmittedAt,
+			"portfolio_submission_id": app.PortfolioSubmissionID,
+		}).Error; err != nil {
+			return err
+		}
+		return NotifySubmissionReceived(tx, app, now)
+	})
+	if err != nil && !errors.Is(err, ErrApplicationNotEligible) {
+		return nil, err
+	}
+	return issues, err
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// ClamAVScanner scans files with clamd through its INSTREAM command, over TCP or a unix
+// socket.
+type ClamAVScanner struct {
+	Network string // "tcp" หรือ "unix"
+	Address string
+	// Timeout bounds one whole scan, connection included.
+	Timeout time.Duration
+	// ChunkSize is how much is sent per INSTREAM chunk; must stay under clamd's StreamMaxLength.
+	ChunkSize int
+}
+
+// NewClamAVScanner parses tcp://host:port, unix:///path/to/clamd.sock, host:port or an
+// absolute socket path.
+func NewClamAVScanner(address string) (*ClamAVScanner, error) {
+	s := &ClamAVScanner{Timeout: 60 * time.Second, ChunkSize: 64 * 1024}
+	switch {
+	case strings.HasPrefix(address, "tcp://"):
+		s.Network, s.Address = "tcp", strings.TrimPrefix(address, "tcp://")
+	case strings.HasPrefix(address, "unix://"):
+		s.Network, s.Address = "unix", strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "/"):
+		s.Network, s.Address = "unix", address
+	default:
+		s.Network, s.Address = "tcp", address
+	}
+	if s.Address == "" {
+		return nil, fmt.Errorf("invalid clamd address %q", address)
+	}
+	return s, nil
+}
+
+func (s *ClamAVScanner) Name() string { return "clamav" }
+
+func (s *ClamAVScanner) dial(ctx context.Context) (net.Conn, error) {
+	var deadline time.Time
+	if s.Timeout > 0 {
+		deadline = time.Now().Add(s.Timeout)
+	}
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, s.Network, s.Address)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Ping checks that clamd answers.
+func (s *ClamAVScanner) Ping(ctx context.Context) error {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("zPING\x00")); err != nil {
+		return err
+	}
+	reply, err := readClamdReply(conn)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("unexpected clamd reply %q", reply)
+	}
+	return nil
+}
+
+// Scan streams the file to clamd and reads its verdict.
+func (s *ClamAVScanner) Scan(ctx context.Context, fileName string, r io.Reader) (ScanResult, error) {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return ScanResult{}, err
+	}
+	defer conn.Close()
+
+	w := bufio.NewWriter(conn)
+	if _, err := w.WriteString("zINSTREAM\x00"); err != nil {
+		return ScanResult{}, err
+	}
+	chunkSize := s.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 64 * 1024
+	}
+	// แต่ละ chunk: ความยาว 4 ไบต์ (big endian) ตามด้วยข้อมูล ปิดท้ายด้วยความยาว 0
+	buf := make([]byte, chunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := w.Write(size); err != nil {
+				return ScanResult{}, err
+			}
+			if _, err := w.Write(buf[:n]); err != nil {
+				return ScanResult{}, err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return ScanResult{}, readErr
+		}
+	}
+	if _, err := w.Write([]byte{0, 0, 0, 0}); err != nil {
+		return ScanResult{}, err
+	}
+	if err := w.Flush(); err != nil {
+		return ScanResult{}, err
+	}
+
+	reply, err := readClamdReply(conn)
+	if err != nil {
+		return ScanResult{}, err
+	}
+	return parseClamdReply(reply)
+}
+
+// readClamdReply reads one NUL-terminated reply (the z-prefixed commands end replies with NUL).
+func readClamdReply(r io.Reader) (string, error) {
+	reply, err := bufio.NewReader(r).ReadBytes(0)
+	if err != nil && !(err == io.EOF && len(reply) > 0) {
+		return "", fmt.Errorf("reading clamd reply: %w", err)
+	}
+	return string(bytes.TrimRight(reply, "\x00\n")), nil
+}
+
+// parseClamdReply understands "stream: OK", "stream: <threat> FOUND" and "... ERROR".
+func parseClamdReply(reply string) (ScanResult, error) {
+	verdict := strings.TrimSpace(reply)
+	if i := strings.Index(verdict, ": "); i >= 0 {
+		verdict = verdict[i+2:]
+	}
+	switch {
+	case verdict == "OK":
+		return ScanResult{Clean: true}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return ScanResult{Threat: strings.TrimSuffix(verdict, " FOUND")}, nil
+	}
+	return ScanResult{}, fmt.Errorf("clamd: %s", reply)
+}
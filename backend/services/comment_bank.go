@@ -0,0 +1,85 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// Placeholders supported in comment bank snippets.
+const (
+	PlaceholderStudentName      = "student_name"
+	PlaceholderStudentFirstName = "student_first_name"
+	PlaceholderCurriculum       = "curriculum"
+	PlaceholderPortfolio        = "portfolio"
+	PlaceholderReviewerName     = "reviewer_name"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-z_]+)\s*\}\}`)
+
+// RenderSnippet replaces {{placeholder}} tokens with values from vars.
+// Unknown or empty placeholders are left untouched so the reviewer can fill them in.
+func RenderSnippet(body string, vars map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(body, func(token string) string {
+		name := placeholderPattern.FindStringSubmatch(token)[1]
+		if value := strings.TrimSpace(vars[name]); value != "" {
+			return value
+		}
+		return token
+	})
+}
+
+// DisplayName prefers the Thai name and falls back to the English one.
+func DisplayName(u *entity.User) string {
+	if u == nil {
+		return ""
+	}
+	if th := strings.TrimSpace(u.FirstNameTH + " " + u.LastNameTH); th != "" {
+		return th
+	}
+	return strings.TrimSpace(u.FirstNameEN + " " + u.LastNameEN)
+}
+
+func firstName(u *entity.User) string {
+	if u == nil {
+		return ""
+	}
+	if strings.TrimSpace(u.FirstNameTH) != "" {
+		return strings.TrimSpace(u.FirstNameTH)
+	}
+	return strings.TrimSpace(u.FirstNameEN)
+}
+
+// BuildSnippetVars collects placeholder values for a submission being reviewed.
+// curriculumID is optional and defaults to the curriculum the submission was applied to;
+// when neither is known the {{curriculum}} placeholder stays as is.
+func BuildSnippetVars(db *gorm.DB, submissionID uint, curriculumID *uint, reviewer *entity.User) (map[string]string, error) {
+	var submission entity.PortfolioSubmission
+	if err := db.Preload("User").Preload("Portfolio").First(&submission, submissionID).Error; err != nil {
+		return nil, err
+	}
+
+	vars := map[string]string{
+		PlaceholderStudentName:      DisplayName(submission.User),
+		PlaceholderStudentFirstName: firstName(submission.User),
+		PlaceholderReviewerName:     DisplayName(reviewer),
+	}
+	if submission.Portfolio != nil {
+		vars[PlaceholderPortfolio] = submission.Portfolio.PortfolioName
+	}
+
+	if curriculumID == nil {
+		curriculumID = submission.CurriculumID
+	}
+	if curriculumID != nil {
+		var curriculum entity.Curriculum
+		if err := db.First(&curriculum, *curriculumID).Error; err != nil {
+			return nil, err
+		}
+		vars[PlaceholderCurriculum] = curriculum.Name
+	}
+
+	return vars, nil
+}
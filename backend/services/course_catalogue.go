@@ -0,0 +1,292 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// CreditPercentageTolerance is how many percentage points a course group's share of the
+// curriculum's credits may differ from its CreditPercentage before it is flagged.
+const CreditPercentageTolerance = 5.0
+
+// courseCSVColumns are the columns of a course import file; course_code and course_name_th
+// are required, course_groups is a ";"-separated list of course group names.
+var courseCSVColumns = []string{"course_code", "course_name_th", "course_name_en", "credits", "category", "description", "course_groups"}
+
+// CourseImportRow is one parsed row of a course CSV.
+type CourseImportRow struct {
+	Line         int
+	Course       entity.Course
+	CourseGroups []string
+}
+
+// CourseImportError explains why a CSV row was rejected.
+type CourseImportError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// CourseImportResult summarises a bulk import.
+type CourseImportResult struct {
+	Created int                 `json:"created"`
+	Updated int                 `json:"updated"`
+	Errors  []CourseImportError `json:"errors"`
+}
+
+// ParseCourseCSV reads a course CSV with a header row. Rows that fail validation are
+// returned as errors; the others are returned for import.
+func ParseCourseCSV(r io.Reader) ([]CourseImportRow, []CourseImportError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	index := map[string]int{}
+	for i, h := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	for _, required := range courseCSVColumns[:2] {
+		if _, ok := index[required]; !ok {
+			return nil, nil, fmt.Errorf("missing column %s (expected %s)", required, strings.Join(courseCSVColumns, ","))
+		}
+	}
+
+	var rows []CourseImportRow
+	var rowErrors []CourseImportError
+	seen := map[string]int{}
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			rowErrors = append(rowErrors, CourseImportError{Line: line, Message: err.Error()})
+			continue
+		}
+		get := func(col string) string {
+			if i, ok := index[col]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := CourseImportRow{Line: line, Course: entity.Course{
+			CourseCode:   strings.ToUpper(get("course_code")),
+			CourseNameTH: get("course_name_th"),
+			CourseNameEN: get("course_name_en"),
+			Description:  get("description"),
+		}}
+		if row.Course.CourseCode == "" && row.Course.CourseNameTH == "" {
+			continue // blank line
+		}
+		if v := get("credits"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				rowErrors = append(rowErrors, CourseImportError{Line: line, Message: "credits must be a whole number"})
+				continue
+			}
+			row.Course.Credits = n
+		}
+		if v := get("category"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				rowErrors = append(rowErrors, CourseImportError{Line: line, Message: "category must be a whole number"})
+				continue
+			}
+			row.Course.Category = n
+		}
+		if ok, err := govalidator.ValidateStruct(&row.Course); !ok {
+			rowErrors = append(rowErrors, CourseImportError{Line: line, Message: err.Error()})
+			continue
+		}
+		if prev, dup := seen[row.Course.CourseCode]; dup {
+			rowErrors = append(rowErrors, CourseImportError{Line: line, Message: fmt.Sprintf("duplicate course code %s (line %d)", row.Course.CourseCode, prev)})
+			continue
+		}
+		seen[row.Course.CourseCode] = line
+
+		for _, name := range strings.Split(get("course_groups"), ";") {
+			if name = strings.TrimSpace(name); name != "" {
+				row.CourseGroups = append(row.CourseGroups, name)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, rowErrors, nil
+}
+
+// ErrCourseCodeTaken is returned when another course already uses the course code.
+var ErrCourseCodeTaken = errors.New("course code already exists")
+
+// CourseCodeTaken reports whether a course other than exceptID uses code. Deleted courses
+// count too: course_code is unique across them.
+func CourseCodeTaken(db *gorm.DB, code string, exceptID uint) (bool, error) {
+	var count int64
+	err := db.Unscoped().Model(&entity.Course{}).Where("course_code = ? AND id <> ?", code, exceptID).Count(&count).Error
+	return count > 0, err
+}
+
+// CreateCourse creates course in groups. When a deleted course has the same code it is
+// restored with course's fields instead, as ImportCourses does; a live one is
+// ErrCourseCodeTaken.
+func CreateCourse(db *gorm.DB, course *entity.Course, groups []entity.CourseGroup) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var existing entity.Course
+		err := tx.Unscoped().Where("course_code = ?", course.CourseCode).First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			course.CourseGroups = groups
+			return tx.Create(course).Error
+		case err != nil:
+			return err
+		case !existing.DeletedAt.Valid:
+			return ErrCourseCodeTaken
+		}
+
+		course.ID = existing.ID
+		course.CreatedAt = existing.CreatedAt
+		course.DeletedAt = gorm.DeletedAt{}
+		if err := tx.Unscoped().Omit("CourseGroups").Save(course).Error; err != nil {
+			return err
+		}
+		course.CourseGroups = groups
+		return tx.Model(course).Association("CourseGroups").Replace(groups)
+	})
+}
+
+// ImportCourses upserts parsed rows by course code in one transaction. Course group names
+// (TH or EN) are resolved to existing groups; the course is added to them.
+func ImportCourses(db *gorm.DB, rows []CourseImportRow) (CourseImportResult, error) {
+	result := CourseImportResult{Errors: []CourseImportError{}}
+
+	var groups []entity.CourseGroup
+	if err := db.Find(&groups).Error; err != nil {
+		return result, err
+	}
+	groupByName := map[string]entity.CourseGroup{}
+	for _, g := range groups {
+		groupByName[strings.ToLower(g.Name)] = g
+		if g.NameEN != "" {
+			groupByName[strings.ToLower(g.NameEN)] = g
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, row := range rows {
+			var linked []entity.CourseGroup
+			unknown := ""
+			for _, name := range row.CourseGroups {
+				g, ok := groupByName[strings.ToLower(name)]
+				if !ok {
+					unknown = name
+					break
+				}
+				linked = append(linked, g)
+			}
+			if unknown != "" {
+				result.Errors = append(result.Errors, CourseImportError{Line: row.Line, Message: "unknown course group " + unknown})
+				continue
+			}
+
+			var course entity.Course
+			err := tx.Unscoped().Where("course_code = ?", row.Course.CourseCode).First(&course).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				course = row.Course
+				if err := tx.Create(&course).Error; err != nil {
+					return err
+				}
+				result.Created++
+			case err != nil:
+				return err
+			default:
+				course.CourseNameTH = row.Course.CourseNameTH
+				course.CourseNameEN = row.Course.CourseNameEN
+				course.Credits = row.Course.Credits
+				course.Category = row.Course.Category
+				course.Description = row.Course.Description
+				course.DeletedAt = gorm.DeletedAt{}
+				if err := tx.Unscoped().Save(&course).Error; err != nil {
+					return err
+				}
+				result.Updated++
+			}
+
+			if len(linked) > 0 {
+				if err := tx.Model(&course).Association("CourseGroups").Append(linked); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// GroupCredits compares one course group's credits with its CreditPercentage.
+type GroupCredits struct {
+	CourseGroupID    uint    `json:"course_group_id"`
+	Name             string  `json:"name"`
+	Courses          int     `json:"courses"`
+	Credits          int     `json:"credits"`
+	CreditPercentage int     `json:"credit_percentage"`
+	ActualPercentage float64 `json:"actual_percentage"`
+	WithinTolerance  bool    `json:"within_tolerance"`
+}
+
+// CreditBreakdown is the credit structure of a curriculum.
+type CreditBreakdown struct {
+	CurriculumID uint           `json:"curriculum_id"`
+	TotalCredits int            `json:"total_credits"`
+	Groups       []GroupCredits `json:"groups"`
+	// Consistent is true when every group's share of credits is within tolerance of its
+	// CreditPercentage.
+	Consistent bool `json:"consistent"`
+}
+
+// CurriculumCreditBreakdown totals course credits per course group of a curriculum.
+// The curriculum needs CourseGroups.CourseGroup.Courses preloaded.
+func CurriculumCreditBreakdown(c *entity.Curriculum) CreditBreakdown {
+	out := CreditBreakdown{CurriculumID: c.ID, Groups: []GroupCredits{}, Consistent: true}
+	for _, ccg := range c.CourseGroups {
+		g := GroupCredits{CourseGroupID: ccg.CourseGroupID, CreditPercentage: ccg.CreditPercentage}
+		if ccg.CourseGroup != nil {
+			g.Name = ccg.CourseGroup.Name
+			g.Courses = len(ccg.CourseGroup.Courses)
+			for _, course := range ccg.CourseGroup.Courses {
+				g.Credits += course.Credits
+			}
+		}
+		out.TotalCredits += g.Credits
+		out.Groups = append(out.Groups, g)
+	}
+
+	for i := range out.Groups {
+		g := &out.Groups[i]
+		if out.TotalCredits > 0 {
+			g.ActualPercentage = math.Round(float64(g.Credits)*1000/float64(out.TotalCredits)) / 10
+		}
+		g.WithinTolerance = math.Abs(g.ActualPercentage-float64(g.CreditPercentage)) <= CreditPercentageTolerance
+		if !g.WithinTolerance {
+			out.Consistent = false
+		}
+	}
+	return out
+}
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CurriculumSearchDocument is the text indexed for full-text search. The GIN indexes created
+// by config's migrations use the same expression, so keep them in sync.
+const CurriculumSearchDocument = "coalesce(curriculums.code, '') || ' ' || coalesce(curriculums.name, '') || ' ' || coalesce(curriculums.description, '')"
+
+// Sort orders of SearchCurricula.
+const (
+	CurriculumSortRelevance   = "relevance"
+	CurriculumSortName        = "name"
+	CurriculumSortGPAXAsc     = "gpax_asc"
+	CurriculumSortGPAXDesc    = "gpax_desc"
+	CurriculumSortQuota       = "quota_desc"
+	CurriculumSortNewest      = "newest"
+	CurriculumSortClosingSoon = "closing_soon"
+)
+
+// GPAXRange is one bucket of the GPAX facet; Min and Max are inclusive at two decimals.
+type GPAXRange struct {
+	Label string
+	Min   float64
+	Max   float64
+}
+
+// GPAXRanges are the buckets of the GPAX facet, by the curriculum's minimum GPAX. A bucket's
+// value ("3.00-3.49") can be sent back as gpax_range.
+var GPAXRanges = []GPAXRange{
+	{Label: "0.00-2.49", Min: 0, Max: 2.49},
+	{Label: "2.50-2.99", Min: 2.5, Max: 2.99},
+	{Label: "3.00-3.49", Min: 3, Max: 3.49},
+	{Label: "3.50-4.00", Min: 3.5, Max: 4},
+}
+
+// CurriculumSearchParams are the filters of the student curriculum search. Filters of the
+// same facet are ORed, different facets are ANDed.
+type CurriculumSearchParams struct {
+	Query         string
+	FacultyIDs    []uint
+	ProgramIDs    []uint
+	SkillIDs      []uint
+	AcademicYears []string
+	Rounds        []string // ชื่อรอบ เช่น "Portfolio 1"
+	Statuses      []string // open / opening
+	GPAXMin       *float64 // เกณฑ์ GPAX ขั้นต่ำของหลักสูตร อยู่ในช่วง [GPAXMin, GPAXMax]
+	GPAXMax       *float64
+	Sort          string
+	Page          int
+	Limit         int
+}
+
+// FacetBucket is one value of a facet with the number of curricula that have it, given
+// every other selected filter.
+type FacetBucket struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+type CurriculumFacets struct {
+	Faculties     []FacetBucket `json:"faculties"`
+	Programs      []FacetBucket `json:"programs"`
+	AcademicYears []FacetBucket `json:"academic_years"`
+	Rounds        []FacetBucket `json:"rounds"`
+	Statuses      []FacetBucket `json:"statuses"`
+	GPAXRanges    []FacetBucket `json:"gpax_ranges"`
+	Skills        []FacetBucket `json:"skills"`
+}
+
+// CurriculumSearchResult is one page of results; Page and Limit are the values actually used.
+type CurriculumSearchResult struct {
+	Curricula []entity.Curriculum
+	Total     int64
+	Page      int
+	Limit     int
+	Facets    CurriculumFacets
+}
+
+// facet names, used to leave a facet's own filter out of its counts
+const (
+	facetFaculty  = "faculty"
+	facetProgram  = "program"
+	facetYear     = "academic_year"
+	facetRound    = "round"
+	facetStatus   = "status"
+	facetGPAX     = "gpax"
+	facetSkill    = "skill"
+	facetNoneSkip = ""
+)
+
+// ParseGPAXRange parses "3.00-3.49" into its bounds.
+func ParseGPAXRange(value string) (float64, float64, error) {
+	var lo, hi float64
+	if _, err := fmt.Sscanf(strings.TrimSpace(value), "%f-%f", &lo, &hi); err != nil || lo > hi {
+		return 0, 0, fmt.Errorf("invalid gpax_range %q, expected e.g. 3.00-3.49", value)
+	}
+	return lo, hi, nil
+}
+
+// SearchCurricula runs the student search: curricula visible to students matching the text
+// query and filters, one page of them, and the facet counts.
+func SearchCurricula(db *gorm.DB, p CurriculumSearchParams, now time.Time) (*CurriculumSearchResult, error) {
+	p.Query = strings.TrimSpace(p.Query)
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Limit < 1 || p.Limit > 100 {
+		p.Limit = 20
+	}
+	postgres := db.Dialector.Name() == "postgres"
+
+	base := func(except string) *gorm.DB {
+		q := VisibleToStudents(db.Model(&entity.Curriculum{}), now).
+			Joins("LEFT JOIN faculties ON faculties.id = curriculums.faculty_id").
+			Joins("LEFT JOIN programs ON programs.id = curriculums.program_id")
+		if p.Query != "" {
+			q = curriculumTextFilter(q, p.Query, postgres)
+		}
+		if except != facetFaculty && len(p.FacultyIDs) > 0 {
+			q = q.Where("curriculums.faculty_id IN ?", p.FacultyIDs)
+		}
+		if except != facetProgram && len(p.ProgramIDs) > 0 {
+			q = q.Where("curriculums.program_id IN ?", p.ProgramIDs)
+		}
+		if except != facetYear && len(p.AcademicYears) > 0 {
+			q = q.Where("curriculums.academic_year IN ?", p.AcademicYears)
+		}
+		if except != facetStatus && len(p.Statuses) > 0 {
+			q = q.Where("? IN ?", curriculumStatus(now), p.Statuses)
+		}
+		if except != facetRound && len(p.Rounds) > 0 {
+			q = q.Where("EXISTS (SELECT 1 FROM application_rounds ar WHERE ar.curriculum_id = curriculums.id AND ar.deleted_at IS NULL AND ar.name IN ?)", p.Rounds)
+		}
+		if except != facetSkill && len(p.SkillIDs) > 0 {
+			q = q.Where("EXISTS (SELECT 1 FROM curriculum_skills cs WHERE cs.curriculum_id = curriculums.id AND cs.deleted_at IS NULL AND cs.skill_id IN ?)", p.SkillIDs)
+		}
+		if except != facetGPAX {
+			// gpax_min เป็น float32 เทียบที่ทศนิยม 2 ตำแหน่งเพื่อไม่ให้ 2.49 หลุดขอบช่วง
+			if p.GPAXMin != nil {
+				q = q.Where("ROUND(CAST(curriculums.gpax_min AS NUMERIC), 2) >= ?", *p.GPAXMin)
+			}
+			if p.GPAXMax != nil {
+				q = q.Where("ROUND(CAST(curriculums.gpax_min AS NUMERIC), 2) <= ?", *p.GPAXMax)
+			}
+		}
+		return q
+	}
+
+	result := &CurriculumSearchResult{Curricula: []entity.Curriculum{}, Page: p.Page, Limit: p.Limit}
+	if err := base(facetNoneSkip).Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+
+	page := base(facetNoneSkip).
+		Select("curriculums.*").
+		Preload("Faculty").
+		Preload("Program").
+		Preload("Rounds", func(db *gorm.DB) *gorm.DB { return db.Order("opens_at asc") }).
+		Preload("Skills.Skill")
+	page = orderCurriculumSearch(page, p, postgres, now)
+	if err := page.Limit(p.Limit).Offset((p.Page - 1) * p.Limit).Find(&result.Curricula).Error; err != nil {
+		return nil, err
+	}
+	for i := range result.Curricula {
+		ApplyCurriculumStatus(&result.Curricula[i], now)
+	}
+
+	facets, err := curriculumFacets(db, base, now)
+	if err != nil {
+		return nil, err
+	}
+	result.Facets = facets
+	return result, nil
+}
+
+// curriculumTextFilter matches the query with full-text search on PostgreSQL (English
+// stemming plus the "simple" config for codes and Thai phrases). Thai is written without
+// spaces between words, so a substring match on names is ORed in as well.
+func curriculumTextFilter(q *gorm.DB, text string, postgres bool) *gorm.DB {
+	if !postgres {
+		like := "%" + strings.ToLower(text) + "%"
+		return q.Where(
+			"LOWER(curriculums.name) LIKE ? OR LOWER(curriculums.code) LIKE ? OR LOWER(curriculums.description) LIKE ? OR LOWER(faculties.name) LIKE ? OR LOWER(programs.name) LIKE ?",
+			like, like, like, like, like,
+		)
+	}
+	like := "%" + text + "%"
+	return q.Where(
+		"to_tsvector('english', "+CurriculumSearchDocument+") @@ websearch_to_tsquery('english', ?)"+
+			" OR to_tsvector('simple', "+CurriculumSearchDocument+") @@ websearch_to_tsquery('simple', ?)"+
+			" OR curriculums.name ILIKE ? OR curriculums.code ILIKE ? OR curriculums.description ILIKE ? OR faculties.name ILIKE ? OR programs.name ILIKE ?",
+		text, text, like, like, like, like, like,
+	)
+}
+
+func orderCurriculumSearch(q *gorm.DB, p CurriculumSearchParams, postgres bool, now time.Time) *gorm.DB {
+	sortBy := p.Sort
+	if sortBy == "" || (sortBy == CurriculumSortRelevance && p.Query == "") {
+		sortBy = CurriculumSortName
+		if p.Query != "" {
+			sortBy = CurriculumSortRelevance
+		}
+	}
+
+	// ORDER BY ทั้งก้อนเป็น expression เดียว เพราะ db.Order ไม่รับ clause.Expr ที่มี bind vars
+	var sql string
+	var vars []interface{}
+	switch sortBy {
+	case CurriculumSortRelevance:
+		if postgres {
+			sql = "ts_rank(to_tsvector('english', " + CurriculumSearchDocument + "), websearch_to_tsquery('english', ?))" +
+				" + ts_rank(to_tsvector('simple', " + CurriculumSearchDocument + "), websearch_to_tsquery('simple', ?))" +
+				" + CASE WHEN curriculums.name ILIKE ? THEN 1 ELSE 0 END DESC"
+			vars = []interface{}{p.Query, p.Query, "%" + p.Query + "%"}
+		} else {
+			sql = "CASE WHEN LOWER(curriculums.name) LIKE ? THEN 0 ELSE 1 END"
+			vars = []interface{}{"%" + strings.ToLower(p.Query) + "%"}
+		}
+	case CurriculumSortGPAXAsc:
+		sql = "curriculums.gpax_min asc"
+	case CurriculumSortGPAXDesc:
+		sql = "curriculums.gpax_min desc"
+	case CurriculumSortQuota:
+		sql = "curriculums.quota desc"
+	case CurriculumSortNewest:
+		sql = "curriculums.created_at desc"
+	case CurriculumSortClosingSoon:
+		// รอบที่ยังไม่ปิดซึ่งปิดเร็วที่สุดก่อน หลักสูตรที่ไม่มีรอบเหลืออยู่ท้ายสุด
+		sql = "COALESCE((SELECT MIN(ar.closes_at) FROM application_rounds ar WHERE ar.curriculum_id = curriculums.id AND ar.deleted_at IS NULL AND ar.closes_at >= ?), ?) asc"
+		vars = []interface{}{now, time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)}
+	}
+	if sql != "" {
+		sql += ", "
+	}
+	sql += "curriculums.name asc, curriculums.id asc"
+	return q.Order(clause.OrderBy{Expression: clause.Expr{SQL: sql, Vars: vars, WithoutParentheses: true}})
+}
+
+// ValidCurriculumSort reports whether s is a sort order accepted by SearchCurricula.
+func ValidCurriculumSort(s string) bool {
+	switch s {
+	case "", CurriculumSortRelevance, CurriculumSortName, CurriculumSortGPAXAsc, CurriculumSortGPAXDesc,
+		CurriculumSortQuota, CurriculumSortNewest, CurriculumSortClosingSoon:
+		return true
+	}
+	return false
+}
+
+// curriculumFacets counts each facet with every filter except its own applied, so the page
+// can show how many results picking another value would give.
+func curriculumFacets(db *gorm.DB, base func(except string) *gorm.DB, now time.Time) (CurriculumFacets, error) {
+	var f CurriculumFacets
+	count := "COUNT(DISTINCT curriculums.id) AS count"
+
+	queries := []struct {
+		out *[]FacetBucket
+		q   *gorm.DB
+	}{
+		{&f.Faculties, base(facetFaculty).
+			Select("curriculums.faculty_id AS value, faculties.name AS label, " + count).
+			Group("curriculums.faculty_id, faculties.name")},
+		{&f.Programs, base(facetProgram).
+			Select("curriculums.program_id AS value, programs.name AS label, " + count).
+			Group("curriculums.program_id, programs.name")},
+		{&f.AcademicYears, base(facetYear).
+			Select("curriculums.academic_year AS value, curriculums.academic_year AS label, " + count).
+			Where("curriculums.academic_year <> ''").
+			Group("curriculums.academic_year")},
+		// สถานะคำนวณจากรอบ ณ now; ทำใน subquery เพื่อ GROUP BY ค่าที่คำนวณแล้ว
+		{&f.Statuses, db.Table("(?) AS cs", base(facetStatus).Select("curriculums.id AS id, ? AS status", curriculumStatus(now))).
+			Select("cs.status AS value, cs.status AS label, COUNT(DISTINCT cs.id) AS count").
+			Group("cs.status")},
+		{&f.Rounds, base(facetRound).
+			Joins("JOIN application_rounds fr ON fr.curriculum_id = curriculums.id AND fr.deleted_at IS NULL").
+			Select("fr.name AS value, fr.name AS label, " + count).
+			Group("fr.name")},
+		{&f.Skills, base(facetSkill).
+			Joins("JOIN curriculum_skills fcs ON fcs.curriculum_id = curriculums.id AND fcs.deleted_at IS NULL").
+			Joins("JOIN skills fs ON fs.id = fcs.skill_id").
+			Select("fcs.skill_id AS value, fs.skill_name_th AS label, " + count).
+			Group("fcs.skill_id, fs.skill_name_th")},
+	}
+	for _, facet := range queries {
+		*facet.out = []FacetBucket{}
+		if err := facet.q.Scan(facet.out).Error; err != nil {
+			return f, err
+		}
+		sortFacet(*facet.out)
+	}
+
+	var gpax []float64
+	if err := base(facetGPAX).Pluck("curriculums.gpax_min", &gpax).Error; err != nil {
+		return f, err
+	}
+	f.GPAXRanges = make([]FacetBucket, 0, len(GPAXRanges))
+	for _, r := range GPAXRanges {
+		b := FacetBucket{Value: r.Label, Label: r.Label}
+		for _, v := range gpax {
+			if v = math.Round(v*100) / 100; v >= r.Min && v <= r.Max {
+				b.Count++
+			}
+		}
+		f.GPAXRanges = append(f.GPAXRanges, b)
+	}
+	return f, nil
+}
+
+// sortFacet puts the biggest buckets first, ties by label.
+func sortFacet(buckets []FacetBucket) {
+	sort.SliceStable(buckets, func(i, j int) bool {
+		if buckets[i].Count != buckets[j].Count {
+			return buckets[i].Count > buckets[j].Count
+		}
+		return buckets[i].Label < buckets[j].Label
+	})
+}
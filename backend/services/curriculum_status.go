@@ -0,0 +1,82 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CurriculumRounds returns the rounds of a curriculum ordered by opening time. Legacy
+// periods are converted to rounds when the server starts (see config.runDataMigrations).
+func CurriculumRounds(c *entity.Curriculum) []entity.ApplicationRound {
+	if len(c.Rounds) == 0 {
+		return nil
+	}
+
+	rounds := make([]entity.ApplicationRound, len(c.Rounds))
+	copy(rounds, c.Rounds)
+	sort.SliceStable(rounds, func(i, j int) bool { return rounds[i].OpensAt.Before(rounds[j].OpensAt) })
+	return rounds
+}
+
+// LoadCurriculumRounds fills c.Rounds when the caller did not preload them.
+func LoadCurriculumRounds(db *gorm.DB, c *entity.Curriculum) error {
+	if c.Rounds != nil {
+		return nil
+	}
+	return db.Where("curriculum_id = ?", c.ID).Order("opens_at asc").Find(&c.Rounds).Error
+}
+
+// CurrentRound returns the round that is open at now, otherwise the next one to open,
+// otherwise nil.
+func CurrentRound(c *entity.Curriculum, now time.Time) *entity.ApplicationRound {
+	var next *entity.ApplicationRound
+	for _, round := range CurriculumRounds(c) {
+		round := round
+		switch round.StatusAt(now) {
+		case entity.RoundStatusOpen:
+			return &round
+		case entity.RoundStatusOpening:
+			if next == nil {
+				next = &round
+			}
+		}
+	}
+	return next
+}
+
+// ComputeCurriculumStatus is the one place that decides whether a curriculum is
+// opening, open or closed: open while any round is open, opening while a round is
+// still ahead, closed otherwise. The stored Status column is only a snapshot; queries
+// use curriculumStatus and curriculumNotClosed instead.
+func ComputeCurriculumStatus(c *entity.Curriculum, now time.Time) string {
+	round := CurrentRound(c, now)
+	if round == nil {
+		return entity.RoundStatusClosed
+	}
+	return round.StatusAt(now)
+}
+
+// ApplyCurriculumStatus overwrites c.Status with the computed status.
+func ApplyCurriculumStatus(c *entity.Curriculum, now time.Time) {
+	c.Status = ComputeCurriculumStatus(c, now)
+}
+
+// curriculumRoundSQL selects the rounds of the outer query's curriculums row.
+const curriculumRoundSQL = "SELECT 1 FROM application_rounds ar WHERE ar.curriculum_id = curriculums.id AND ar.deleted_at IS NULL"
+
+// curriculumNotClosed is ComputeCurriculumStatus(c, now) != closed as an SQL condition: a
+// round of the curriculum has not closed yet.
+func curriculumNotClosed(now time.Time) clause.Expr {
+	return gorm.Expr("EXISTS ("+curriculumRoundSQL+" AND ar.closes_at >= ?)", now)
+}
+
+// curriculumStatus is ComputeCurriculumStatus(c, now) as an SQL expression.
+func curriculumStatus(now time.Time) clause.Expr {
+	return gorm.Expr("CASE WHEN EXISTS ("+curriculumRoundSQL+" AND ar.opens_at <= ? AND ar.closes_at >= ?) THEN ?"+
+		" WHEN EXISTS ("+curriculumRoundSQL+" AND ar.opens_at > ?) THEN ? ELSE ? END",
+		now, now, entity.RoundStatusOpen, now, entity.RoundStatusOpening, entity.RoundStatusClosed)
+}
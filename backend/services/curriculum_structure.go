@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// FullCreditPercentage is what the CreditPercentage of a curriculum's course groups must add up to.
+const FullCreditPercentage = 100
+
+// ErrCreditPercentageExceeded is returned when course groups would cover more than 100% of credits.
+var ErrCreditPercentageExceeded = errors.New("course group credit percentages exceed 100%")
+
+// CurriculumStructureOf sums the CreditPercentage of the course groups. The structure is
+// complete when there is at least one group and they add up to exactly 100%.
+func CurriculumStructureOf(groups []entity.CurriculumCourseGroup) entity.CurriculumStructure {
+	s := entity.CurriculumStructure{Groups: len(groups)}
+	for _, g := range groups {
+		s.TotalPercentage += g.CreditPercentage
+	}
+	s.Remaining = FullCreditPercentage - s.TotalPercentage
+	s.Complete = s.Groups > 0 && s.TotalPercentage == FullCreditPercentage
+	return s
+}
+
+// ValidateCreditPercentage checks a single group's percentage.
+func ValidateCreditPercentage(p int) error {
+	if p < 1 || p > FullCreditPercentage {
+		return fmt.Errorf("credit_percentage must be between 1 and %d", FullCreditPercentage)
+	}
+	return nil
+}
+
+// CheckCreditPercentageChange returns ErrCreditPercentageExceeded when setting the group
+// courseGroupID of the curriculum to percentage would push the total above 100%. Other
+// groups keep their current values, so a structure can be built up one group at a time.
+func CheckCreditPercentageChange(db *gorm.DB, curriculumID, courseGroupID uint, percentage int) (entity.CurriculumStructure, error) {
+	var groups []entity.CurriculumCourseGroup
+	if err := db.Where("curriculum_id = ? AND course_group_id <> ?", curriculumID, courseGroupID).Find(&groups).Error; err != nil {
+		return entity.CurriculumStructure{}, err
+	}
+	groups = append(groups, entity.CurriculumCourseGroup{CourseGroupID: courseGroupID, CreditPercentage: percentage})
+	s := CurriculumStructureOf(groups)
+	if s.TotalPercentage > FullCreditPercentage {
+		available := FullCreditPercentage - (s.TotalPercentage - percentage)
+		return s, fmt.Errorf("%w: total would be %d%%, only %d%% is available for this group",
+			ErrCreditPercentageExceeded, s.TotalPercentage, available)
+	}
+	return s, nil
+}
+
+// ValidateCurriculumStructure checks a complete list of groups for a bulk replace: every
+// percentage in range, no duplicate course group and a total of exactly 100%.
+func ValidateCurriculumStructure(groups []entity.CurriculumCourseGroup) error {
+	seen := map[uint]bool{}
+	for i, g := range groups {
+		if g.CourseGroupID == 0 {
+			return fmt.Errorf("course_groups[%d]: course_group_id is required", i)
+		}
+		if seen[g.CourseGroupID] {
+			return fmt.Errorf("course_groups[%d]: course group %d is listed twice", i, g.CourseGroupID)
+		}
+		seen[g.CourseGroupID] = true
+		if err := ValidateCreditPercentage(g.CreditPercentage); err != nil {
+			return fmt.Errorf("course_groups[%d]: %v", i, err)
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	if s := CurriculumStructureOf(groups); !s.Complete {
+		return fmt.Errorf("credit percentages must add up to %d%%, got %d%%", FullCreditPercentage, s.TotalPercentage)
+	}
+	return nil
+}
+
+// ReplaceCurriculumCourseGroups swaps the whole course group list of a curriculum in one transaction.
+func ReplaceCurriculumCourseGroups(db *gorm.DB, curriculumID uint, groups []entity.CurriculumCourseGroup) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("curriculum_id = ?", curriculumID).Delete(&entity.CurriculumCourseGroup{}).Error; err != nil {
+			return err
+		}
+		for i := range groups {
+			groups[i].ID = 0
+			groups[i].CurriculumID = curriculumID
+		}
+		if len(groups) == 0 {
+			return nil
+		}
+		return tx.Omit("Curriculum", "CourseGroup").Create(&groups).Error
+	})
+}
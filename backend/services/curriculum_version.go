@@ -0,0 +1,394 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// PreloadCurriculumChildren loads every child association that belongs to a curriculum,
+// i.e. everything that is copied by CloneCurriculum and tracked by its versions.
+func PreloadCurriculumChildren(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Rounds", func(db *gorm.DB) *gorm.DB { return db.Order("opens_at asc") }).
+		Preload("Requirements", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).
+		Preload("RequiredDocuments", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).
+		Preload("Skills", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).
+		Preload("CourseGroups", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") })
+}
+
+// -------------------- SNAPSHOT --------------------
+
+// CurriculumSnapshot is what a curriculum version stores. Row IDs and timestamps are left
+// out so that re-saving the same content (e.g. a bulk replace) is not seen as a change.
+type CurriculumSnapshot struct {
+	Code              string          `json:"code"`
+	Name              string          `json:"name"`
+	Description       string          `json:"description"`
+	Link              string          `json:"link"`
+	GPAXMin           float32         `json:"gpax_min"`
+	PortfolioMaxPages int             `json:"portfolio_max_pages"`
+	AcademicYear      string          `json:"academic_year"`
+	FacultyID         uint            `json:"faculty_id"`
+	ProgramID         uint            `json:"program_id"`
+	ApplicationPeriod string          `json:"application_period"`
+	Quota             int             `json:"quota"`
+	EligibilityRules  json.RawMessage `json:"eligibility_rules"`
+
+	Rounds            []RoundSnapshot            `json:"rounds"`
+	Requirements      []RequirementSnapshot      `json:"requirements"`
+	RequiredDocuments []RequiredDocumentSnapshot `json:"required_documents"`
+	Skills            []SkillSnapshot            `json:"skills"`
+	CourseGroups      []CourseGroupSnapshot      `json:"course_groups"`
+}
+
+type RoundSnapshot struct {
+	Name        string     `json:"name"`
+	OpensAt     time.Time  `json:"opens_at"`
+	ClosesAt    time.Time  `json:"closes_at"`
+	AnnouncesAt *time.Time `json:"announces_at"`
+	Timezone    string     `json:"timezone"`
+}
+
+type RequirementSnapshot struct {
+	Kind             string  `json:"kind"`
+	Group            string  `json:"group"`
+	Subject          string  `json:"subject"`
+	TestType         string  `json:"test_type"`
+	MinScore         float64 `json:"min_score"`
+	EducationLevelID *uint   `json:"education_level_id"`
+	CurriculumTypeID *uint   `json:"curriculum_type_id"`
+	Description      string  `json:"description"`
+}
+
+type RequiredDocumentSnapshot struct {
+	DocumentTypeID uint   `json:"document_type_id"`
+	IsOptional     bool   `json:"is_optional"`
+	Note           string `json:"note"`
+}
+
+type SkillSnapshot struct {
+	SkillID     uint   `json:"skill_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type CourseGroupSnapshot struct {
+	CourseGroupID    uint   `json:"course_group_id"`
+	CreditPercentage int    `json:"credit_percentage"`
+	Description      string `json:"description"`
+}
+
+// SnapshotCurriculum captures a curriculum loaded with PreloadCurriculumChildren.
+func SnapshotCurriculum(c *entity.Curriculum) CurriculumSnapshot {
+	s := CurriculumSnapshot{
+		Code:              c.Code,
+		Name:              c.Name,
+		Description:       c.Description,
+		Link:              c.Link,
+		GPAXMin:           c.GPAXMin,
+		PortfolioMaxPages: c.PortfolioMaxPages,
+		AcademicYear:      c.AcademicYear,
+		FacultyID:         c.FacultyID,
+		ProgramID:         c.ProgramID,
+		ApplicationPeriod: c.ApplicationPeriod,
+		Quota:             c.Quota,
+		Rounds:            []RoundSnapshot{},
+		Requirements:      []RequirementSnapshot{},
+		RequiredDocuments: []RequiredDocumentSnapshot{},
+		Skills:            []SkillSnapshot{},
+		CourseGroups:      []CourseGroupSnapshot{},
+	}
+	if len(c.EligibilityRules) > 0 {
+		s.EligibilityRules = json.RawMessage(c.EligibilityRules)
+	}
+	for _, r := range c.Rounds {
+		s.Rounds = append(s.Rounds, RoundSnapshot{Name: r.Name, OpensAt: r.OpensAt.UTC(), ClosesAt: r.ClosesAt.UTC(), AnnouncesAt: utcPtr(r.AnnouncesAt), Timezone: r.Timezone})
+	}
+	for _, r := range c.Requirements {
+		s.Requirements = append(s.Requirements, RequirementSnapshot{
+			Kind: r.Kind, Group: r.Group, Subject: r.Subject, TestType: r.TestType, MinScore: r.MinScore,
+			EducationLevelID: r.EducationLevelID, CurriculumTypeID: r.CurriculumTypeID, Description: r.Description,
+		})
+	}
+	for _, d := range c.RequiredDocuments {
+		s.RequiredDocuments = append(s.RequiredDocuments, RequiredDocumentSnapshot{DocumentTypeID: d.DocumentTypeID, IsOptional: d.IsOptional, Note: d.Note})
+	}
+	for _, sk := range c.Skills {
+		s.Skills = append(s.Skills, SkillSnapshot{SkillID: sk.SkillID, Name: sk.Name, Description: sk.Description})
+	}
+	for _, g := range c.CourseGroups {
+		s.CourseGroups = append(s.CourseGroups, CourseGroupSnapshot{CourseGroupID: g.CourseGroupID, CreditPercentage: g.CreditPercentage, Description: g.Description})
+	}
+	// bulk replace recreates rows, so compare children by content rather than insert order
+	sort.SliceStable(s.CourseGroups, func(i, j int) bool { return s.CourseGroups[i].CourseGroupID < s.CourseGroups[j].CourseGroupID })
+	return s
+}
+
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// -------------------- DIFF --------------------
+
+// FieldChange is one top-level snapshot field that differs between two versions.
+type FieldChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// DiffSnapshots compares two stored snapshots field by field (snapshot JSON keys), in key order.
+// A nil before means everything in after is new.
+func DiffSnapshots(before, after []byte) ([]FieldChange, error) {
+	var a, b map[string]json.RawMessage
+	if len(before) > 0 {
+		if err := json.Unmarshal(before, &a); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(after, &b); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]bool{}
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	names := make([]string, 0, len(keys))
+	for k := range keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	changes := []FieldChange{}
+	for _, k := range names {
+		if !jsonEqual(a[k], b[k]) {
+			changes = append(changes, FieldChange{Field: k, Before: nullIfEmpty(a[k]), After: nullIfEmpty(b[k])})
+		}
+	}
+	return changes, nil
+}
+
+func jsonEqual(x, y json.RawMessage) bool {
+	var cx, cy bytes.Buffer
+	if json.Compact(&cx, nullIfEmpty(x)) != nil || json.Compact(&cy, nullIfEmpty(y)) != nil {
+		return bytes.Equal(x, y)
+	}
+	return bytes.Equal(cx.Bytes(), cy.Bytes())
+}
+
+func nullIfEmpty(v json.RawMessage) json.RawMessage {
+	if len(v) == 0 {
+		return json.RawMessage("null")
+	}
+	return v
+}
+
+// -------------------- VERSIONS --------------------
+
+// RecordCurriculumVersion stores a new version of a published curriculum when its content
+// differs from the latest version. Unpublished curricula are not versioned; the first
+// version of a curriculum is its baseline. Returns nil when nothing was recorded.
+func RecordCurriculumVersion(db *gorm.DB, curriculumID uint, summary string, changedByID *uint) (*entity.CurriculumVersion, error) {
+	var cur entity.Curriculum
+	if err := PreloadCurriculumChildren(db).First(&cur, curriculumID).Error; err != nil {
+		return nil, err
+	}
+	if !cur.IsPublished() {
+		return nil, nil
+	}
+	snapshot, err := json.Marshal(SnapshotCurriculum(&cur))
+	if err != nil {
+		return nil, err
+	}
+
+	var latest entity.CurriculumVersion
+	err = db.Where("curriculum_id = ?", curriculumID).Order("version desc").First(&latest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	fields := []string{}
+	if latest.ID != 0 {
+		changes, err := DiffSnapshots(latest.Snapshot, snapshot)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) == 0 {
+			return nil, nil
+		}
+		for _, ch := range changes {
+			fields = append(fields, ch.Field)
+		}
+	}
+	changedFields, _ := json.Marshal(fields)
+
+	version := entity.CurriculumVersion{
+		CurriculumID:  curriculumID,
+		Version:       latest.Version + 1,
+		Summary:       strings.TrimSpace(summary),
+		ChangedFields: changedFields,
+		Snapshot:      snapshot,
+		ChangedByID:   changedByID,
+	}
+	if err := db.Create(&version).Error; err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// EnsureCurriculumBaseline records the current state of a published curriculum as its first
+// version if it has none yet, so the next change has something to be compared against.
+func EnsureCurriculumBaseline(db *gorm.DB, curriculumID uint, changedByID *uint) error {
+	var count int64
+	if err := db.Model(&entity.CurriculumVersion{}).Where("curriculum_id = ?", curriculumID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err := RecordCurriculumVersion(db, curriculumID, "baseline", changedByID)
+	return err
+}
+
+// -------------------- CLONE --------------------
+
+// CloneOptions says where a cloned curriculum goes.
+type CloneOptions struct {
+	AcademicYear string
+	// Code ว่าง = ใช้รหัสเดิม
+	Code string
+	// Rounds nil = เลื่อนรอบของหลักสูตรต้นฉบับไปตามจำนวนปีที่ต่างกัน
+	Rounds []entity.ApplicationRound
+	UserID uint
+}
+
+// CloneCurriculum deep-copies a curriculum loaded with PreloadCurriculumChildren, with its
+// rounds, requirements, eligibility rules, required documents, skills and course groups,
+// into a new draft for another academic year. Nothing is shared with the source rows.
+func CloneCurriculum(db *gorm.DB, src *entity.Curriculum, opts CloneOptions) (*entity.Curriculum, error) {
+	opts.AcademicYear = strings.TrimSpace(opts.AcademicYear)
+	if opts.AcademicYear == "" {
+		return nil, errors.New("academic_year is required")
+	}
+
+	rounds := opts.Rounds
+	if rounds == nil {
+		years, err := academicYearOffset(src.AcademicYear, opts.AcademicYear)
+		if err != nil {
+			return nil, err
+		}
+		if years == 0 && len(src.Rounds) > 0 {
+			return nil, errors.New("rounds are required when cloning within the same academic year")
+		}
+		rounds = shiftRounds(src.Rounds, years)
+	}
+	for i := range rounds {
+		rounds[i].ID = 0
+		rounds[i].CurriculumID = 0
+		if err := rounds[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	clone := entity.Curriculum{
+		Code:              src.Code,
+		Name:              src.Name,
+		Description:       src.Description,
+		Link:              src.Link,
+		GPAXMin:           src.GPAXMin,
+		PortfolioMaxPages: src.PortfolioMaxPages,
+		PublishState:      entity.CurriculumDraft,
+		ClonedFromID:      &src.ID,
+		AcademicYear:      opts.AcademicYear,
+		FacultyID:         src.FacultyID,
+		ProgramID:         src.ProgramID,
+		UserID:            src.UserID,
+		Quota:             src.Quota,
+		Rounds:            rounds,
+	}
+	if code := strings.TrimSpace(opts.Code); code != "" {
+		clone.Code = code
+	}
+	if opts.UserID != 0 {
+		clone.UserID = opts.UserID
+	}
+	if len(src.EligibilityRules) > 0 {
+		clone.EligibilityRules = append(clone.EligibilityRules, src.EligibilityRules...)
+	}
+
+	for _, r := range src.Requirements {
+		clone.Requirements = append(clone.Requirements, entity.CurriculumRequirement{
+			Kind: r.Kind, Group: r.Group, Subject: r.Subject, TestType: r.TestType, MinScore: r.MinScore,
+			EducationLevelID: r.EducationLevelID, CurriculumTypeID: r.CurriculumTypeID, Description: r.Description,
+		})
+	}
+	for _, d := range src.RequiredDocuments {
+		clone.RequiredDocuments = append(clone.RequiredDocuments, entity.CurriculumRequiredDocument{DocumentTypeID: d.DocumentTypeID, IsOptional: d.IsOptional, Note: d.Note})
+	}
+	for _, s := range src.Skills {
+		clone.Skills = append(clone.Skills, entity.CurriculumSkill{SkillID: s.SkillID, Name: s.Name, Description: s.Description})
+	}
+	for _, g := range src.CourseGroups {
+		clone.CourseGroups = append(clone.CourseGroups, entity.CurriculumCourseGroup{CourseGroupID: g.CourseGroupID, CreditPercentage: g.CreditPercentage, Description: g.Description})
+	}
+	ApplyCurriculumStatus(&clone, time.Now())
+
+	if err := db.Create(&clone).Error; err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
+// academicYearOffset returns how many years "to" is after "from" (e.g. 2568 -> 2569 = 1).
+// A source without an academic year counts as one year earlier.
+func academicYearOffset(from, to string) (int, error) {
+	target, err := strconv.Atoi(strings.TrimSpace(to))
+	if err != nil {
+		return 0, errors.New("academic_year must be a year, e.g. 2569")
+	}
+	if strings.TrimSpace(from) == "" {
+		return 1, nil
+	}
+	source, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil {
+		return 1, nil
+	}
+	return target - source, nil
+}
+
+// shiftRounds copies rounds moved by whole years, keeping their wall-clock time in the
+// round's own timezone.
+func shiftRounds(rounds []entity.ApplicationRound, years int) []entity.ApplicationRound {
+	out := make([]entity.ApplicationRound, 0, len(rounds))
+	for _, r := range rounds {
+		loc := r.Location()
+		shifted := entity.ApplicationRound{
+			Name:     r.Name,
+			OpensAt:  r.OpensAt.In(loc).AddDate(years, 0, 0),
+			ClosesAt: r.ClosesAt.In(loc).AddDate(years, 0, 0),
+			Timezone: r.Timezone,
+		}
+		if r.AnnouncesAt != nil {
+			announces := r.AnnouncesAt.In(loc).AddDate(years, 0, 0)
+			shifted.AnnouncesAt = &announces
+		}
+		out = append(out, shifted)
+	}
+	return out
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// VisibleToStudents limits a curriculum query to what students may see: published by the
+// review workflow and with a round that is open or still ahead at now.
+func VisibleToStudents(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Where("curriculums.publish_state = ?", entity.CurriculumPublished).Where(curriculumNotClosed(now))
+}
+
+// Workflow actions on a curriculum.
+const (
+	CurriculumActionSubmit  = "submit"  // draft -> in_review
+	CurriculumActionApprove = "approve" // in_review -> published
+	CurriculumActionReject  = "reject"  // in_review -> draft
+	CurriculumActionArchive = "archive" // published -> archived
+	CurriculumActionRestore = "restore" // archived -> draft
+	CurriculumActionRevise  = "revise"  // published -> in_review, when who may apply was edited
+)
+
+var curriculumTransitions = map[string]struct{ from, to string }{
+	CurriculumActionSubmit:  {entity.CurriculumDraft, entity.CurriculumInReview},
+	CurriculumActionApprove: {entity.CurriculumInReview, entity.CurriculumPublished},
+	CurriculumActionReject:  {entity.CurriculumInReview, entity.CurriculumDraft},
+	CurriculumActionArchive: {entity.CurriculumPublished, entity.CurriculumArchived},
+	CurriculumActionRestore: {entity.CurriculumArchived, entity.CurriculumDraft},
+	CurriculumActionRevise:  {entity.CurriculumPublished, entity.CurriculumInReview},
+}
+
+// ErrCurriculumTransition is returned when an action does not apply to the current state.
+var ErrCurriculumTransition = errors.New("invalid curriculum state transition")
+
+// ErrNotFacultyAdmin is returned when someone other than a faculty admin approves or rejects.
+var ErrNotFacultyAdmin = errors.New("only an admin of the curriculum's faculty may review it")
+
+// CanReviewCurriculum reports whether user may approve or reject the curriculum: an admin
+// assigned to its faculty, or any admin when the faculty has no assigned admins. Nobody
+// approves their own submission.
+func CanReviewCurriculum(db *gorm.DB, user *entity.User, cur *entity.Curriculum) (bool, error) {
+	if user == nil || user.AccountTypeID != entity.UserTypeAdmin {
+		return false, nil
+	}
+	if cur.SubmittedByID != nil && *cur.SubmittedByID == user.ID {
+		return false, nil
+	}
+
+	var assigned, mine int64
+	if err := db.Model(&entity.FacultyAdmin{}).Where("faculty_id = ?", cur.FacultyID).Count(&assigned).Error; err != nil {
+		return false, err
+	}
+	if assigned == 0 {
+		return true, nil
+	}
+	if err := db.Model(&entity.FacultyAdmin{}).Where("faculty_id = ? AND user_id = ?", cur.FacultyID, user.ID).Count(&mine).Error; err != nil {
+		return false, err
+	}
+	return mine > 0, nil
+}
+
+// TransitionCurriculum applies a workflow action by actor and saves the editorial fields.
+// Approve and reject must be checked with CanReviewCurriculum first; a rejection needs a note.
+// Rows without a PublishState count as published.
+func TransitionCurriculum(db *gorm.DB, cur *entity.Curriculum, action string, actorID uint, note string, now time.Time) error {
+	t, ok := curriculumTransitions[action]
+	if !ok {
+		return fmt.Errorf("unknown action %q", action)
+	}
+	current := cur.PublishState
+	if current == "" {
+		current = entity.CurriculumPublished
+	}
+	if current != t.from {
+		return fmt.Errorf("%w: cannot %s a curriculum that is %s", ErrCurriculumTransition, action, current)
+	}
+	note = strings.TrimSpace(note)
+	if action == CurriculumActionReject && note == "" {
+		return errors.New("a note is required when rejecting")
+	}
+
+	updates := map[string]interface{}{"publish_state": t.to}
+	switch action {
+	case CurriculumActionSubmit, CurriculumActionRevise:
+		updates["submitted_by_id"] = actorID
+		updates["submitted_at"] = now
+		updates["reviewed_by_id"] = nil
+		updates["reviewed_at"] = nil
+		updates["review_note"] = ""
+	case CurriculumActionApprove, CurriculumActionReject:
+		updates["reviewed_by_id"] = actorID
+		updates["reviewed_at"] = now
+		updates["review_note"] = note
+		if action == CurriculumActionApprove {
+			updates["published_at"] = now
+		}
+	}
+
+	if err := db.Model(cur).Updates(updates).Error; err != nil {
+		return err
+	}
+	return db.First(cur, cur.ID).Error
+}
+
+// reviewedSnapshotFields are the snapshot fields that decide who may apply and with what.
+// Changing one of them on a published curriculum needs a faculty admin's approval again;
+// other edits (name, description, link, rounds, skills) stay live and are only versioned.
+var reviewedSnapshotFields = map[string]bool{
+	"code": true, "academic_year": true, "faculty_id": true, "program_id": true,
+	"gpax_min": true, "quota": true, "portfolio_max_pages": true, "eligibility_rules": true,
+	"requirements": true, "required_documents": true, "course_groups": true,
+}
+
+// ReviseCurriculum handles an edit of a published curriculum: when the edit changed one of
+// reviewedSnapshotFields since the latest version, the curriculum goes back to in_review until
+// a faculty admin approves it; otherwise it stays live and the edit is recorded as a new
+// version. Curricula in any other state are left alone. revised reports whether the
+// curriculum went back to review.
+func ReviseCurriculum(db *gorm.DB, curriculumID uint, editorID uint, summary string, now time.Time) (revised bool, err error) {
+	var cur entity.Curriculum
+	if err := PreloadCurriculumChildren(db).First(&cur, curriculumID).Error; err != nil {
+		return false, err
+	}
+	if !cur.IsPublished() {
+		return false, nil
+	}
+	var changedBy *uint
+	if editorID != 0 {
+		changedBy = &editorID
+	}
+
+	var latest entity.CurriculumVersion
+	err = db.Where("curriculum_id = ?", curriculumID).Order("version desc").First(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// ไม่มีฉบับก่อนหน้าให้เทียบ บันทึกเป็น baseline ไว้
+		_, err = RecordCurriculumVersion(db, curriculumID, summary, changedBy)
+		return false, err
+	}
+	if err != nil {
+		return false, err
+	}
+	snapshot, err := json.Marshal(SnapshotCurriculum(&cur))
+	if err != nil {
+		return false, err
+	}
+	changes, err := DiffSnapshots(latest.Snapshot, snapshot)
+	if err != nil {
+		return false, err
+	}
+
+	for _, ch := range changes {
+		if reviewedSnapshotFields[ch.Field] {
+			if err := TransitionCurriculum(db, &cur, CurriculumActionRevise, editorID, "", now); err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+	_, err = RecordCurriculumVersion(db, curriculumID, summary, changedBy)
+	return false, err
+}
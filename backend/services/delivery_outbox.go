@@ -0,0 +1,77 @@
+package services
+
+import (
+	"time"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// claimLease is how long a claimed row waits before another run may try it again, in case
+// the instance sending it stops halfway.
+const claimLease = 5 * time.Minute
+
+// deliveryOutbox is the claim-and-retry policy of the queued deliveries: notification
+// deliveries (NotificationDispatcher) and emails (EmailQueue). model is the table's entity;
+// it needs the status, attempts, next_attempt_at, sent_at and last_error columns.
+type deliveryOutbox struct {
+	db          *gorm.DB
+	model       interface{}
+	maxAttempts int
+	retryDelay  time.Duration
+}
+
+var outboxWaiting = []string{entity.DeliveryPending, entity.DeliveryDeferred}
+
+// due loads into dest up to limit rows whose next attempt is due, oldest first.
+func (o deliveryOutbox) due(dest interface{}, limit int, now time.Time) error {
+	return o.db.Model(o.model).
+		Where("status IN ? AND next_attempt_at <= ?", outboxWaiting, now).
+		Order("next_attempt_at asc").
+		Limit(limit).
+		Find(dest).Error
+}
+
+// claim takes row id, read with attempts attempts, for this run, so several instances can
+// deliver at the same time. It is false when another instance took the row first.
+func (o deliveryOutbox) claim(id uint, attempts int, now time.Time) (bool, error) {
+	result := o.db.Model(o.model).
+		Where("id = ? AND attempts = ? AND status IN ?", id, attempts, outboxWaiting).
+		Updates(map[string]interface{}{"attempts": attempts + 1, "next_attempt_at": now.Add(claimLease)})
+	return result.RowsAffected > 0, result.Error
+}
+
+// finish stores the outcome of attempt number attempts and returns the new status: sent,
+// skipped when the error is permanent, failed after maxAttempts, otherwise pending again
+// after retryDelay doubled for every earlier attempt.
+func (o deliveryOutbox) finish(id uint, attempts int, sendErr error, permanent bool, now time.Time) (string, error) {
+	update := map[string]interface{}{}
+	switch {
+	case sendErr == nil:
+		update["status"], update["sent_at"], update["last_error"] = entity.DeliverySent, now, ""
+	case permanent:
+		update["status"], update["last_error"] = entity.DeliverySkipped, sendErr.Error()
+	case attempts >= o.maxAttempts:
+		update["status"], update["last_error"] = entity.DeliveryFailed, sendErr.Error()
+	default:
+		update["status"], update["last_error"] = entity.DeliveryPending, sendErr.Error()
+		update["next_attempt_at"] = now.Add(o.retryDelay << (attempts - 1))
+	}
+	return update["status"].(string), o.db.Model(o.model).Where("id = ?", id).Updates(update).Error
+}
+
+// deliverEach calls deliver for rows 0..n-1 and counts the ones sent; it stops at the
+// first error.
+func deliverEach(n int, deliver func(i int) (bool, error)) (int, error) {
+	sent := 0
+	for i := 0; i < n; i++ {
+		ok, err := deliver(i)
+		if err != nil {
+			return sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
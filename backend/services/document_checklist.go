@@ -0,0 +1,209 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// Where a checklist item was satisfied from.
+const (
+	DocumentSourceProfile = "profile"
+	DocumentSourceUpload  = "upload"
+)
+
+// ChecklistItem is one required document of a curriculum and whether the student has it.
+type ChecklistItem struct {
+	DocumentTypeID        uint   `json:"document_type_id"`
+	Name                  string `json:"name"`
+	Code                  string `json:"code"`
+	IsOptional            bool   `json:"is_optional"`
+	Note                  string `json:"note"`
+	Satisfied             bool   `json:"satisfied"`
+	Source                string `json:"source,omitempty"`
+	FilePath              string `json:"file_path,omitempty"`
+	ApplicationDocumentID *uint  `json:"application_document_id,omitempty"`
+}
+
+// DocumentChecklist is the document status of one student for one curriculum.
+type DocumentChecklist struct {
+	UserID          uint            `json:"user_id"`
+	CurriculumID    uint            `json:"curriculum_id"`
+	ApplicationID   *uint           `json:"application_id"`
+	Items           []ChecklistItem `json:"items"`
+	MissingRequired int             `json:"missing_required"`
+	MissingOptional int             `json:"missing_optional"`
+	Complete        bool            `json:"complete"`
+}
+
+// StudentDocuments are the certificate files already stored on the student's profile.
+type StudentDocuments struct {
+	TranscriptPath string
+	GEDCertPath    string
+	// test type (upper case) -> certificate file
+	LanguageCerts map[string]string
+}
+
+// Find returns the profile file that satisfies the document type, if any.
+func (s *StudentDocuments) Find(dt *entity.DocumentType) (string, bool) {
+	if dt == nil {
+		return "", false
+	}
+	switch dt.Code {
+	case entity.DocumentCodeTranscript:
+		return s.TranscriptPath, s.TranscriptPath != ""
+	case entity.DocumentCodeGEDCertificate:
+		return s.GEDCertPath, s.GEDCertPath != ""
+	case entity.DocumentCodeLanguageCertificate:
+		if testType := strings.ToUpper(strings.TrimSpace(dt.TestType)); testType != "" {
+			path, ok := s.LanguageCerts[testType]
+			return path, ok
+		}
+		// ไม่ระบุชนิดการสอบ: ใช้ใบแรกตามชื่อชนิดการสอบ ให้ได้ผลเดิมทุกครั้ง
+		testTypes := make([]string, 0, len(s.LanguageCerts))
+		for testType := range s.LanguageCerts {
+			testTypes = append(testTypes, testType)
+		}
+		if len(testTypes) > 0 {
+			sort.Strings(testTypes)
+			return s.LanguageCerts[testTypes[0]], true
+		}
+	}
+	return "", false
+}
+
+// LoadStudentDocuments collects the transcript, GED and language certificate files of a student.
+func LoadStudentDocuments(db *gorm.DB, userID uint) (*StudentDocuments, error) {
+	docs := &StudentDocuments{LanguageCerts: map[string]string{}}
+
+	var academic entity.AcademicScore
+	if err := db.Where("user_id = ?", userID).First(&academic).Error; err == nil {
+		docs.TranscriptPath = strings.TrimSpace(academic.TranscriptFilePath)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var ged entity.GEDScore
+	if err := db.Where("user_id = ?", userID).First(&ged).Error; err == nil {
+		docs.GEDCertPath = strings.TrimSpace(ged.CertFilePath)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var languages []entity.LanguageProficiencyScore
+	if err := db.Where("user_id = ? AND cert_file_path <> ''", userID).
+		Order("test_date desc").
+		Find(&languages).Error; err != nil {
+		return nil, err
+	}
+	for _, l := range languages {
+		testType := strings.ToUpper(strings.TrimSpace(l.TestType))
+		if _, seen := docs.LanguageCerts[testType]; !seen {
+			docs.LanguageCerts[testType] = l.CertFilePath
+		}
+	}
+
+	return docs, nil
+}
+
+// BuildDocumentChecklist checks every required document of the curriculum against the
+// student's profile and the files uploaded to their application. app may be nil, in which
+// case the student's active application to the curriculum (if any) is used.
+func BuildDocumentChecklist(db *gorm.DB, userID, curriculumID uint, app *entity.Application) (*DocumentChecklist, error) {
+	if app == nil {
+		var active entity.Application
+		err := db.Where("user_id = ? AND curriculum_id = ? AND status NOT IN ?", userID, curriculumID,
+			[]string{entity.ApplicationStatusWithdrawn, entity.ApplicationStatusRejected}).
+			Order("id desc").
+			First(&active).Error
+		if err == nil {
+			app = &active
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	var required []entity.CurriculumRequiredDocument
+	if err := db.Preload("DocumentType").
+		Where("curriculum_id = ?", curriculumID).
+		Order("is_optional asc, id asc").
+		Find(&required).Error; err != nil {
+		return nil, err
+	}
+
+	profile, err := LoadStudentDocuments(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	uploads := map[uint]entity.ApplicationDocument{}
+	checklist := &DocumentChecklist{UserID: userID, CurriculumID: curriculumID}
+	if app != nil {
+		checklist.ApplicationID = &app.ID
+
+		var docs []entity.ApplicationDocument
+		if err := db.Where("application_id = ?", app.ID).Order("id asc").Find(&docs).Error; err != nil {
+			return nil, err
+		}
+		for _, d := range docs {
+			uploads[d.DocumentTypeID] = d
+		}
+	}
+
+	for _, req := range required {
+		item := ChecklistItem{
+			DocumentTypeID: req.DocumentTypeID,
+			IsOptional:     req.IsOptional,
+			Note:           req.Note,
+		}
+		if req.DocumentType != nil {
+			item.Name = req.DocumentType.Name
+			item.Code = req.DocumentType.Code
+		}
+
+		// ไฟล์ที่อัปโหลดในใบสมัครมาก่อน แล้วค่อยดูจากโปรไฟล์
+		if upload, ok := uploads[req.DocumentTypeID]; ok {
+			id := upload.ID
+			item.Satisfied, item.Source, item.FilePath, item.ApplicationDocumentID = true, DocumentSourceUpload, upload.FilePath, &id
+		} else if path, ok := profile.Find(req.DocumentType); ok {
+			item.Satisfied, item.Source, item.FilePath = true, DocumentSourceProfile, path
+		}
+
+		if !item.Satisfied {
+			if item.IsOptional {
+				checklist.MissingOptional++
+			} else {
+				checklist.MissingRequired++
+			}
+		}
+		checklist.Items = append(checklist.Items, item)
+	}
+	checklist.Complete = checklist.MissingRequired == 0
+
+	return checklist, nil
+}
+
+// MissingRequiredDocuments lists the mandatory documents of the application's curriculum
+// that are neither on the student's profile nor uploaded to the application.
+func MissingRequiredDocuments(db *gorm.DB, app *entity.Application) ([]ApplicationIssue, error) {
+	checklist, err := BuildDocumentChecklist(db, app.UserID, app.CurriculumID, app)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []ApplicationIssue
+	for _, item := range checklist.Items {
+		if item.Satisfied || item.IsOptional {
+			continue
+		}
+		issues = append(issues, ApplicationIssue{
+			Code:           IssueMissingDocument,
+			Message:        item.Name + " is required",
+			DocumentTypeID: item.DocumentTypeID,
+		})
+	}
+	return issues, nil
+}
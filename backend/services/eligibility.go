@@ -0,0 +1,424 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// StudentProfile is the part of a student's profile that eligibility rules look at.
+type StudentProfile struct {
+	UserID    uint
+	Academic  *entity.AcademicScore
+	GED       *entity.GEDScore
+	Languages []entity.LanguageProficiencyScore
+	Education *entity.Education
+}
+
+// RequirementResult explains how one requirement was evaluated.
+type RequirementResult struct {
+	RequirementID uint   `json:"requirement_id,omitempty"`
+	Kind          string `json:"kind"`
+	Group         string `json:"group,omitempty"`
+	Label         string `json:"label"`
+	Expected      string `json:"expected"`
+	Actual        string `json:"actual"`
+	Passed        bool   `json:"passed"`
+	Reason        string `json:"reason"`
+}
+
+// EligibilityResult is the outcome of all requirements of one curriculum.
+type EligibilityResult struct {
+	CurriculumID uint                `json:"curriculum_id"`
+	Eligible     bool                `json:"eligible"`
+	Results      []RequirementResult `json:"results"`
+	// FailedGroups lists the groups (or single rules) that did not pass, for a short summary.
+	FailedGroups []string `json:"failed_groups"`
+	// Rules is the evaluation of the curriculum's EligibilityRules tree, if any.
+	Rules *RuleResult `json:"rules,omitempty"`
+}
+
+// LoadStudentProfile loads the academic, GED, language and education records of a student.
+// Missing records are left nil.
+func LoadStudentProfile(db *gorm.DB, userID uint) (*StudentProfile, error) {
+	profile := &StudentProfile{UserID: userID}
+
+	var academic entity.AcademicScore
+	if err := db.Where("user_id = ?", userID).First(&academic).Error; err == nil {
+		profile.Academic = &academic
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var ged entity.GEDScore
+	if err := db.Where("user_id = ?", userID).First(&ged).Error; err == nil {
+		profile.GED = &ged
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err := db.Where("user_id = ?", userID).Order("test_date desc").Find(&profile.Languages).Error; err != nil {
+		return nil, err
+	}
+
+	var education entity.Education
+	if err := db.Preload("EducationLevel").Preload("CurriculumType").Preload("SchoolType").
+		Where("user_id = ?", userID).First(&education).Error; err == nil {
+		profile.Education = &education
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// HasEligibilityRules reports whether the curriculum has a rule tree. A curriculum is checked
+// with either its requirement rows or its rule tree, never both: the tree replaces the rows.
+func HasEligibilityRules(c *entity.Curriculum) bool {
+	raw := strings.TrimSpace(string(c.EligibilityRules))
+	return raw != "" && raw != "null"
+}
+
+// CurriculumRequirements returns the requirements checked for the curriculum: its requirement
+// rows, unless it has a rule tree, plus the GPAXMin column as a gpax rule when no explicit
+// gpax rule exists. GPAXMin always applies.
+func CurriculumRequirements(c *entity.Curriculum) []entity.CurriculumRequirement {
+	rows := c.Requirements
+	if HasEligibilityRules(c) {
+		rows = nil
+	}
+	reqs := make([]entity.CurriculumRequirement, 0, len(rows)+1)
+	hasGPAX := false
+	for _, r := range rows {
+		if r.Kind == entity.RequirementGPAX {
+			hasGPAX = true
+		}
+		reqs = append(reqs, r)
+	}
+	if !hasGPAX && c.GPAXMin > 0 {
+		reqs = append([]entity.CurriculumRequirement{{
+			Kind:         entity.RequirementGPAX,
+			MinScore:     float64(c.GPAXMin),
+			CurriculumID: c.ID,
+		}}, reqs...)
+	}
+	return reqs
+}
+
+// EvaluateEligibility checks the curriculum against a profile: GPAXMin and either the rule
+// tree or, when there is none, the requirement rows (see CurriculumRequirements).
+// Requirements with the same Group are OR-ed, everything else (including the rule tree) is AND-ed.
+func EvaluateEligibility(c *entity.Curriculum, profile *StudentProfile) EligibilityResult {
+	result := EligibilityResult{CurriculumID: c.ID, Eligible: true, Results: []RequirementResult{}, FailedGroups: []string{}}
+
+	type group struct {
+		label  string
+		passed bool
+	}
+	var groups []*group
+	byName := map[string]*group{}
+	for _, req := range CurriculumRequirements(c) {
+		res := EvaluateRequirement(&req, profile)
+		result.Results = append(result.Results, res)
+
+		g, ok := byName[req.Group]
+		if !ok || req.Group == "" {
+			label := req.Group
+			if label == "" {
+				label = res.Label
+			}
+			g = &group{label: label}
+			groups = append(groups, g)
+			if req.Group != "" {
+				byName[req.Group] = g
+			}
+		}
+		g.passed = g.passed || res.Passed
+	}
+
+	for _, g := range groups {
+		if !g.passed {
+			result.Eligible = false
+			result.FailedGroups = append(result.FailedGroups, g.label)
+		}
+	}
+
+	if rule, err := ParseRules(c.EligibilityRules); err != nil {
+		result.Rules = &RuleResult{Label: "Eligibility rules", Reason: err.Error()}
+	} else if rule != nil {
+		res := EvaluateRule(rule, ProfileFacts(profile))
+		result.Rules = &res
+	}
+	if result.Rules != nil && !result.Rules.Passed {
+		result.Eligible = false
+		result.FailedGroups = append(result.FailedGroups, result.Rules.Label)
+	}
+	return result
+}
+
+// EvaluateRequirement checks one requirement and explains the outcome.
+func EvaluateRequirement(req *entity.CurriculumRequirement, p *StudentProfile) RequirementResult {
+	res := RequirementResult{RequirementID: req.ID, Kind: req.Kind, Group: req.Group}
+
+	switch req.Kind {
+	case entity.RequirementGPAX:
+		res.Label = "GPAX"
+		if p.Academic == nil {
+			return res.missing(fmt.Sprintf(">= %.2f", req.MinScore), "GPAX has not been recorded")
+		}
+		return res.atLeast(p.Academic.GPAX, req.MinScore)
+
+	case entity.RequirementSubjectGPA:
+		res.Label = "GPA " + req.Subject
+		if p.Academic == nil {
+			return res.missing(fmt.Sprintf(">= %.2f", req.MinScore), "subject GPAs have not been recorded")
+		}
+		score, ok := subjectGPA(p.Academic, req.Subject)
+		if !ok {
+			return res.missing(fmt.Sprintf(">= %.2f", req.MinScore), "unknown subject "+req.Subject)
+		}
+		return res.atLeast(score, req.MinScore)
+
+	case entity.RequirementGEDScore:
+		subject := req.Subject
+		if subject == "" {
+			subject = "total"
+		}
+		res.Label = "GED " + subject
+		if p.GED == nil {
+			return res.missing(fmt.Sprintf(">= %.0f", req.MinScore), "GED scores have not been recorded")
+		}
+		score, ok := gedScore(p.GED, subject)
+		if !ok {
+			return res.missing(fmt.Sprintf(">= %.0f", req.MinScore), "unknown GED subject "+subject)
+		}
+		return res.atLeast(float64(score), req.MinScore)
+
+	case entity.RequirementLanguageScore:
+		testType := strings.ToUpper(strings.TrimSpace(req.TestType))
+		res.Label = testType
+		expected := fmt.Sprintf(">= %s", formatScore(req.MinScore))
+		best, found := bestLanguageScore(p.Languages, testType)
+		if !found {
+			return res.missing(expected, "no "+testType+" score on the profile")
+		}
+		return res.atLeast(best, req.MinScore)
+
+	case entity.RequirementEducationLevel:
+		res.Label = "Education level"
+		res.Expected = requirementRefID(req.EducationLevelID)
+		if req.EducationLevel != nil {
+			res.Expected = req.EducationLevel.Name
+		}
+		if p.Education == nil {
+			return res.missing(res.Expected, "education has not been recorded")
+		}
+		if p.Education.EducationLevel != nil {
+			res.Actual = p.Education.EducationLevel.Name
+		}
+		res.Passed = req.EducationLevelID != nil && p.Education.EducationLevelID == *req.EducationLevelID
+		return res.explain()
+
+	case entity.RequirementCurriculumType:
+		res.Label = "School curriculum"
+		res.Expected = requirementRefID(req.CurriculumTypeID)
+		if req.CurriculumType != nil {
+			res.Expected = req.CurriculumType.Name
+		}
+		if p.Education == nil || p.Education.CurriculumTypeID == nil {
+			return res.missing(res.Expected, "school curriculum type has not been recorded")
+		}
+		if p.Education.CurriculumType != nil {
+			res.Actual = p.Education.CurriculumType.Name
+		}
+		res.Passed = req.CurriculumTypeID != nil && *p.Education.CurriculumTypeID == *req.CurriculumTypeID
+		return res.explain()
+	}
+
+	res.Label = req.Kind
+	res.Reason = "unsupported requirement kind"
+	return res
+}
+
+// EligibilityIssues turns the failed requirements of a result into application issues.
+// Alternatives of a group that passed through another rule are not reported.
+func EligibilityIssues(result EligibilityResult) []ApplicationIssue {
+	if result.Eligible {
+		return nil
+	}
+	passedGroups := map[string]bool{}
+	for _, r := range result.Results {
+		if r.Group != "" && r.Passed {
+			passedGroups[r.Group] = true
+		}
+	}
+
+	var issues []ApplicationIssue
+	for _, r := range result.Results {
+		if r.Passed || (r.Group != "" && passedGroups[r.Group]) {
+			continue
+		}
+		issues = append(issues, ApplicationIssue{Code: IssueRequirementNotMet, Message: r.Reason})
+	}
+	if result.Rules != nil && !result.Rules.Passed {
+		issues = append(issues, ApplicationIssue{Code: IssueRequirementNotMet, Message: result.Rules.Reason})
+	}
+	return issues
+}
+
+// EligibleCurricula evaluates every given curriculum for one profile, eligible ones first.
+func EligibleCurricula(curricula []entity.Curriculum, profile *StudentProfile) []EligibilityResult {
+	results := make([]EligibilityResult, 0, len(curricula))
+	for i := range curricula {
+		results = append(results, EvaluateEligibility(&curricula[i], profile))
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Eligible && !results[j].Eligible })
+	return results
+}
+
+// ===================== Subjects =====================
+
+// GPASubject is a subject with a GPA on the academic record.
+type GPASubject struct {
+	Name  string
+	Score func(a *entity.AcademicScore) float64
+}
+
+// GEDSubject is a GED test (or the total) on the GED record.
+type GEDSubject struct {
+	Name  string
+	Score func(g *entity.GEDScore) int
+}
+
+// GPASubjects and GEDSubjects are the only lists of subjects: requirements are validated
+// against them and the rule fields academic.gpa_<name> / ged.<name>_score come from them.
+var (
+	GPASubjects = []GPASubject{
+		{"math", func(a *entity.AcademicScore) float64 { return a.GPAMath }},
+		{"science", func(a *entity.AcademicScore) float64 { return a.GPAScience }},
+		{"thai", func(a *entity.AcademicScore) float64 { return a.GPAThai }},
+		{"english", func(a *entity.AcademicScore) float64 { return a.GPAEnglish }},
+		{"social", func(a *entity.AcademicScore) float64 { return a.GPASocial }},
+	}
+	GEDSubjects = []GEDSubject{
+		{"total", func(g *entity.GEDScore) int { return g.TotalScore }},
+		{"rla", func(g *entity.GEDScore) int { return g.RLAScore }},
+		{"math", func(g *entity.GEDScore) int { return g.MathScore }},
+		{"science", func(g *entity.GEDScore) int { return g.ScienceScore }},
+		{"social", func(g *entity.GEDScore) int { return g.SocialScore }},
+	}
+)
+
+// LookupGPASubject finds a GPA subject by name (case-insensitive).
+func LookupGPASubject(name string) (GPASubject, bool) {
+	for _, s := range GPASubjects {
+		if strings.EqualFold(s.Name, name) {
+			return s, true
+		}
+	}
+	return GPASubject{}, false
+}
+
+// LookupGEDSubject finds a GED subject by name (case-insensitive).
+func LookupGEDSubject(name string) (GEDSubject, bool) {
+	for _, s := range GEDSubjects {
+		if strings.EqualFold(s.Name, name) {
+			return s, true
+		}
+	}
+	return GEDSubject{}, false
+}
+
+// GPASubjectNames lists the GPA subjects, for error messages.
+func GPASubjectNames() []string {
+	names := make([]string, len(GPASubjects))
+	for i, s := range GPASubjects {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// GEDSubjectNames lists the GED subjects, for error messages.
+func GEDSubjectNames() []string {
+	names := make([]string, len(GEDSubjects))
+	for i, s := range GEDSubjects {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// ===================== Helpers =====================
+
+func (r RequirementResult) atLeast(actual, min float64) RequirementResult {
+	r.Expected = ">= " + formatScore(min)
+	r.Actual = formatScore(actual)
+	r.Passed = actual >= min
+	return r.explain()
+}
+
+func (r RequirementResult) missing(expected, reason string) RequirementResult {
+	r.Expected = expected
+	r.Passed = false
+	r.Reason = reason
+	return r
+}
+
+func (r RequirementResult) explain() RequirementResult {
+	if r.Passed {
+		r.Reason = fmt.Sprintf("%s %s meets %s", r.Label, r.Actual, r.Expected)
+	} else {
+		r.Reason = fmt.Sprintf("%s %s does not meet %s", r.Label, r.Actual, r.Expected)
+	}
+	return r
+}
+
+func formatScore(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func subjectGPA(a *entity.AcademicScore, subject string) (float64, bool) {
+	s, ok := LookupGPASubject(subject)
+	if !ok {
+		return 0, false
+	}
+	return s.Score(a), true
+}
+
+func gedScore(g *entity.GEDScore, subject string) (int, bool) {
+	s, ok := LookupGEDSubject(subject)
+	if !ok {
+		return 0, false
+	}
+	return s.Score(g), true
+}
+
+// bestLanguageScore returns the highest numeric score of the test type. Scores that
+// are not numbers (e.g. CEFR levels) are ignored.
+func bestLanguageScore(scores []entity.LanguageProficiencyScore, testType string) (float64, bool) {
+	best, found := 0.0, false
+	for _, s := range scores {
+		if !strings.EqualFold(strings.TrimSpace(s.TestType), testType) {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(s.Score), 64)
+		if err != nil {
+			continue
+		}
+		if !found || v > best {
+			best, found = v, true
+		}
+	}
+	return best, found
+}
+
+func requirementRefID(id *uint) string {
+	if id != nil {
+		return fmt.Sprintf("#%d", *id)
+	}
+	return "-"
+}
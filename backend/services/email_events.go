@@ -0,0 +1,157 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// DeadlineEmailLead is how long before a round closes the deadline email is sent to the
+// students following the curriculum.
+const DeadlineEmailLead = 24 * time.Hour
+
+// feedbackPreviewLength is how much of a comment the feedback email quotes.
+const feedbackPreviewLength = 500
+
+// notifyApplicationUpdate creates the in-app notification of an application event, titled
+// with the email's subject in the user's language, and queues the email in the same
+// transaction.
+func notifyApplicationUpdate(db *gorm.DB, userID uint, template, key, groupKey string, data EmailData, now time.Time) error {
+	settings, err := loadNotificationSettings(db, []uint{userID})
+	if err != nil {
+		return err
+	}
+	rendered, err := RenderEmail(template, emailSettingLanguage(settings[userID]), data)
+	if err != nil {
+		return err
+	}
+	notifications := []entity.Notification{{
+		Notification_Title:   rendered.Subject,
+		Notification_Type:    entity.NotificationTypeApplicationUpdate,
+		Notification_Message: rendered.Summary,
+		Sent_At:              now,
+		Created_At:           now,
+		UserID:               &userID,
+		Group_Key:            groupKey,
+	}}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := CreateNotifications(tx, notifications, now); err != nil {
+			return err
+		}
+		return QueueEmails(tx, []EmailEvent{{
+			Template:         template,
+			UserID:           userID,
+			Key:              key,
+			Data:             data,
+			NotificationType: entity.NotificationTypeApplicationUpdate,
+			Notification:     &notifications[0],
+		}}, now)
+	})
+}
+
+// loadApplicationCurriculum returns the name of the application's curriculum.
+func loadApplicationCurriculum(db *gorm.DB, app *entity.Application) (string, error) {
+	if app.Curriculum != nil {
+		return app.Curriculum.Name, nil
+	}
+	var curriculum entity.Curriculum
+	if err := db.Select("id, name").First(&curriculum, app.CurriculumID).Error; err != nil {
+		return "", err
+	}
+	return curriculum.Name, nil
+}
+
+// NotifySubmissionReceived tells the applicant their application was submitted.
+func NotifySubmissionReceived(db *gorm.DB, app *entity.Application, now time.Time) error {
+	curriculum, err := loadApplicationCurriculum(db, app)
+	if err != nil {
+		return err
+	}
+	return notifyApplicationUpdate(db, app.UserID, EmailSubmissionReceived,
+		fmt.Sprintf("%s:application:%d", EmailSubmissionReceived, app.ID),
+		entity.NotificationGroupKey("application", app.ID),
+		EmailData{Curriculum: curriculum, Status: app.Status, Time: now}, now)
+}
+
+// NotifyApplicationStatusChanged tells the applicant about a reviewer's decision.
+func NotifyApplicationStatusChanged(db *gorm.DB, app *entity.Application, now time.Time) error {
+	curriculum, err := loadApplicationCurriculum(db, app)
+	if err != nil {
+		return err
+	}
+	return notifyApplicationUpdate(db, app.UserID, EmailStatusChanged,
+		fmt.Sprintf("%s:application:%d:%s", EmailStatusChanged, app.ID, app.Status),
+		entity.NotificationGroupKey("application", app.ID),
+		EmailData{Curriculum: curriculum, Status: app.Status, Note: app.DecisionNote, Time: now}, now)
+}
+
+// NotifyFeedbackPosted tells the owner of a submission that someone else commented on it.
+// key identifies the comment, e.g. "comment:12".
+func NotifyFeedbackPosted(db *gorm.DB, submissionID, authorID uint, body, key string, now time.Time) error {
+	var submission entity.PortfolioSubmission
+	if err := db.Preload("Portfolio").First(&submission, submissionID).Error; err != nil {
+		return err
+	}
+	if submission.UserID == authorID {
+		return nil
+	}
+	var author entity.User
+	if err := db.First(&author, authorID).Error; err != nil {
+		return err
+	}
+
+	data := EmailData{Message: truncateRunes(strings.TrimSpace(body), feedbackPreviewLength), Time: now}
+	if submission.Portfolio != nil {
+		data.Title = submission.Portfolio.PortfolioName
+	}
+	settings, err := loadNotificationSettings(db, []uint{submission.UserID})
+	if err != nil {
+		return err
+	}
+	data.Author = emailRecipientName(&author, emailSettingLanguage(settings[submission.UserID]))
+
+	return notifyApplicationUpdate(db, submission.UserID, EmailFeedbackPosted,
+		EmailFeedbackPosted+":"+key,
+		entity.NotificationGroupKey("submission", submission.ID),
+		data, now)
+}
+
+// deadlineEmail is the email sent DeadlineEmailLead before a followed curriculum closes.
+func deadlineEmail(s entity.Selection, round *entity.ApplicationRound) EmailEvent {
+	return EmailEvent{
+		Template: EmailDeadlineApproaching,
+		UserID:   s.UserID,
+		Key: fmt.Sprintf("%s:curriculum:%d:%d:user:%d",
+			EmailDeadlineApproaching, s.CurriculumID, round.ClosesAt.Unix(), s.UserID),
+		Data: EmailData{
+			Curriculum: s.Curriculum.Name,
+			Deadline:   round.ClosesAt,
+			URL:        AppURL("/student/curricula"),
+		},
+		NotificationType: entity.NotificationTypeDeadlineReminder,
+	}
+}
+
+// announcementEmails are the emails for the notifications of a published announcement.
+func announcementEmails(announcement *entity.Announcement, notifications []entity.Notification) []EmailEvent {
+	events := make([]EmailEvent, 0, len(notifications))
+	for i := range notifications {
+		n := &notifications[i]
+		events = append(events, EmailEvent{
+			Template: EmailAnnouncementPublished,
+			UserID:   *n.UserID,
+			Key:      fmt.Sprintf("%s:%d:user:%d", EmailAnnouncementPublished, announcement.ID, *n.UserID),
+			Data: EmailData{
+				Title:   announcement.Title,
+				Message: n.Notification_Message,
+				Time:    n.Sent_At,
+			},
+			NotificationType: entity.NotificationTypeAnnouncement,
+			Notification:     n,
+		})
+	}
+	return events
+}
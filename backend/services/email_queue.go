@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EmailEvent is a transactional email to queue for one user.
+type EmailEvent struct {
+	Template string
+	UserID   uint
+	// Key identifies the event; an event is emailed once however often it is queued.
+	Key  string
+	Data EmailData
+	// NotificationType is the preference that turns this email off, and decides whether
+	// it waits for the end of quiet hours.
+	NotificationType string
+	// Notification, when set, gives the link when Data.URL is empty.
+	Notification *entity.Notification
+}
+
+// EmailQueue renders transactional emails into the EmailMessage outbox and sends them with
+// retries.
+type EmailQueue struct {
+	DB     *gorm.DB
+	Sender EmailSender
+	// MaxAttempts is how many times an email is tried before it is marked failed.
+	MaxAttempts int
+	// RetryDelay is the wait after the first failure; it doubles after every attempt.
+	RetryDelay time.Duration
+	// BatchSize is how many emails DeliverDue sends per run.
+	BatchSize int
+}
+
+// Emails is the queue used by QueueEmails. nil = SMTP is not configured, no email is sent.
+var Emails *EmailQueue
+
+// NewEmailQueue creates a queue that sends through sender.
+func NewEmailQueue(db *gorm.DB, sender EmailSender) *EmailQueue {
+	return &EmailQueue{
+		DB:          db,
+		Sender:      sender,
+		MaxAttempts: 5,
+		RetryDelay:  time.Minute,
+		BatchSize:   100,
+	}
+}
+
+// emailedByEvent are the notification types that get a transactional email from their
+// domain event, so the dispatcher does not email them a second time.
+var emailedByEvent = map[string]bool{
+	entity.NotificationTypeAnnouncement:      true,
+	entity.NotificationTypeDeadlineReminder:  true,
+	entity.NotificationTypeApplicationUpdate: true,
+}
+
+// QueueEmails queues the events on Emails. Does nothing when email is not configured.
+func QueueEmails(db *gorm.DB, events []EmailEvent, now time.Time) error {
+	if Emails == nil || len(events) == 0 {
+		return nil
+	}
+	return Emails.Queue(db, events, now)
+}
+
+// Queue renders the events in each user's language and stores them in the outbox. Events
+// already queued, users without an email address and users who turned email off for the
+// event's type are skipped.
+func (q *EmailQueue) Queue(db *gorm.DB, events []EmailEvent, now time.Time) error {
+	keys := make([]string, 0, len(events))
+	userIDs := make([]uint, 0, len(events))
+	for _, ev := range events {
+		keys = append(keys, ev.Key)
+		userIDs = append(userIDs, ev.UserID)
+	}
+	var queued []string
+	if err := db.Model(&entity.EmailMessage{}).Where("event_key IN ?", keys).Pluck("event_key", &queued).Error; err != nil {
+		return err
+	}
+	skip := make(map[string]bool, len(queued))
+	for _, k := range queued {
+		skip[k] = true
+	}
+	if len(skip) == len(events) {
+		return nil // ส่งครบแล้ว (กรณี scheduler เรียกซ้ำทุกรอบ)
+	}
+
+	var users []entity.User
+	if err := db.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]*entity.User, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+	prefs, err := loadPreferences(db, userIDs)
+	if err != nil {
+		return err
+	}
+	settings, err := loadNotificationSettings(db, userIDs)
+	if err != nil {
+		return err
+	}
+
+	messages := make([]entity.EmailMessage, 0, len(events))
+	for _, ev := range events {
+		user := byID[ev.UserID]
+		if skip[ev.Key] || user == nil || strings.TrimSpace(user.Email) == "" {
+			continue
+		}
+		typ := entity.NormalizeNotificationType(ev.NotificationType)
+		if !ChannelEnabled(prefs[user.ID], typ, entity.NotificationChannelEmail) {
+			continue
+		}
+		skip[ev.Key] = true
+
+		lang := emailSettingLanguage(settings[user.ID])
+		data := ev.Data
+		if data.Name == "" {
+			data.Name = emailRecipientName(user, lang)
+		}
+		if data.URL == "" && ev.Notification != nil {
+			data.URL = AppURL(pushURL(user, ev.Notification))
+		}
+		if data.Time.IsZero() {
+			data.Time = now
+		}
+		email, err := RenderEmail(ev.Template, lang, data)
+		if err != nil {
+			return err
+		}
+
+		status, at := entity.DeliveryPending, now
+		if !urgentNotificationTypes[typ] {
+			if end, quiet := QuietHoursEnd(settings[user.ID], now); quiet {
+				status, at = entity.DeliveryDeferred, end
+			}
+		}
+		userID := user.ID
+		messages = append(messages, entity.EmailMessage{
+			EventKey:      ev.Key,
+			Template:      ev.Template,
+			Language:      EmailLanguage(lang),
+			UserID:        &userID,
+			To:            strings.TrimSpace(user.Email),
+			Subject:       email.Subject,
+			HTMLBody:      email.HTML,
+			TextBody:      email.Text,
+			Status:        status,
+			NextAttemptAt: at,
+		})
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	// อีก instance อาจใส่ key เดียวกันไปพร้อมกัน
+	return db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "event_key"}}, DoNothing: true}).
+		CreateInBatches(&messages, notificationBatchSize).Error
+}
+
+// DeliverDue sends the emails that are due, and returns how many were sent. It uses the
+// same claim and retry policy as NotificationDispatcher.DeliverDue.
+func (q *EmailQueue) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	var due []entity.EmailMessage
+	if err := q.outbox().due(&due, q.BatchSize, now); err != nil {
+		return 0, err
+	}
+	return deliverEach(len(due), func(i int) (bool, error) { return q.deliver(ctx, &due[i], now) })
+}
+
+func (q *EmailQueue) outbox() deliveryOutbox {
+	return deliveryOutbox{db: q.DB, model: &entity.EmailMessage{}, maxAttempts: q.MaxAttempts, retryDelay: q.RetryDelay}
+}
+
+func (q *EmailQueue) deliver(ctx context.Context, msg *entity.EmailMessage, now time.Time) (bool, error) {
+	outbox := q.outbox()
+	claimed, err := outbox.claim(msg.ID, msg.Attempts, now)
+	if err != nil || !claimed {
+		return false, err // !claimed = อีก instance รับไปแล้ว
+	}
+	msg.Attempts++
+
+	sendErr := q.Sender.SendEmail(ctx, msg.To, &RenderedEmail{Subject: msg.Subject, HTML: msg.HTMLBody, Text: msg.TextBody})
+	status, err := outbox.finish(msg.ID, msg.Attempts, sendErr, false, now)
+	if status == entity.DeliveryFailed {
+		log.Printf("❌ Email %s to %s failed: %v", msg.EventKey, msg.To, sendErr)
+	}
+	if err != nil {
+		return false, err
+	}
+	return sendErr == nil, nil
+}
+
+// emailSettingLanguage is the user's email language, Thai by default.
+func emailSettingLanguage(setting *entity.NotificationSetting) string {
+	if setting == nil {
+		return entity.LanguageTH
+	}
+	return EmailLanguage(setting.Language)
+}
+
+// emailRecipientName is the user's name in lang, falling back to the other language and
+// then the email address.
+func emailRecipientName(user *entity.User, lang string) string {
+	preferred := strings.TrimSpace(user.FirstNameTH + " " + user.LastNameTH)
+	other := strings.TrimSpace(user.FirstNameEN + " " + user.LastNameEN)
+	if EmailLanguage(lang) == entity.LanguageEN {
+		preferred, other = other, preferred
+	}
+	switch {
+	case preferred != "":
+		return preferred
+	case other != "":
+		return other
+	}
+	return user.Email
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/sut68/team14/backend/entity"
+)
+
+// Email templates. Each one has a Thai and an English file in templates/email.
+const (
+	EmailSubmissionReceived    = "submission_received"
+	EmailStatusChanged         = "status_changed"
+	EmailFeedbackPosted        = "feedback_posted"
+	EmailDeadlineApproaching   = "deadline_approaching"
+	EmailAnnouncementPublished = "announcement_published"
+	// EmailNotification is for notifications sent through the dispatcher's email channel.
+	EmailNotification = "notification"
+)
+
+// EmailTemplates lists every email template.
+var EmailTemplates = []string{
+	EmailSubmissionReceived,
+	EmailStatusChanged,
+	EmailFeedbackPosted,
+	EmailDeadlineApproaching,
+	EmailAnnouncementPublished,
+	EmailNotification,
+}
+
+// EmailLanguages are the languages every template is written in; the first is the default.
+var EmailLanguages = []string{entity.LanguageTH, entity.LanguageEN}
+
+// EmailData is what the templates can show. Each template uses only the fields of its event.
+type EmailData struct {
+	Lang   string // ตั้งโดย RenderEmail
+	Name   string // ชื่อผู้รับ
+	URL    string // ลิงก์ไปยังหน้าที่เกี่ยวข้อง (ว่าง = ไม่มีปุ่ม)
+	Time   time.Time
+	Status string
+
+	Curriculum string
+	Deadline   time.Time
+	Note       string // หมายเหตุจากผู้พิจารณา
+	Author     string
+	Title      string
+	Message    string
+}
+
+// RenderedEmail is a rendered template: Summary is a one-line version for in-app
+// notifications.
+type RenderedEmail struct {
+	Subject string
+	Summary string
+	HTML    string
+	Text    string
+}
+
+//go:embed templates/email/*.html
+var emailTemplateFS embed.FS
+
+type emailTemplateSet struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// emailTemplateSets is keyed by template + "." + language.
+var emailTemplateSets = mustLoadEmailTemplates()
+
+var emailTemplateFuncs = map[string]interface{}{
+	"datetime":    FormatEmailTime,
+	"statusLabel": ApplicationStatusLabel,
+}
+
+func mustLoadEmailTemplates() map[string]emailTemplateSet {
+	sets := make(map[string]emailTemplateSet, len(EmailTemplates)*len(EmailLanguages))
+	for _, name := range EmailTemplates {
+		for _, lang := range EmailLanguages {
+			files := []string{"templates/email/layout.html", fmt.Sprintf("templates/email/%s.%s.html", name, lang)}
+			// html/template สำหรับเนื้อหา HTML, text/template สำหรับหัวเรื่องและข้อความล้วน (ไม่ต้อง escape)
+			html := htmltemplate.Must(htmltemplate.New(name).Funcs(emailTemplateFuncs).ParseFS(emailTemplateFS, files...))
+			text := texttemplate.Must(texttemplate.New(name).Funcs(emailTemplateFuncs).ParseFS(emailTemplateFS, files...))
+			sets[name+"."+lang] = emailTemplateSet{html: html, text: text}
+		}
+	}
+	return sets
+}
+
+// EmailLanguage returns lang if the templates are written in it, otherwise the default.
+func EmailLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	for _, l := range EmailLanguages {
+		if l == lang {
+			return l
+		}
+	}
+	return EmailLanguages[0]
+}
+
+// RenderEmail renders a template in the given language (Thai when it is not supported).
+func RenderEmail(name, lang string, data EmailData) (*RenderedEmail, error) {
+	data.Lang = EmailLanguage(lang)
+	set, ok := emailTemplateSets[name+"."+data.Lang]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+
+	out := &RenderedEmail{}
+	var b bytes.Buffer
+	for _, part := range []struct {
+		block string
+		dst   *string
+	}{{"subject", &out.Subject}, {"summary", &out.Summary}, {"text", &out.Text}} {
+		b.Reset()
+		if err := set.text.ExecuteTemplate(&b, part.block, data); err != nil {
+			return nil, err
+		}
+		*part.dst = b.String()
+	}
+	b.Reset()
+	if err := set.html.ExecuteTemplate(&b, "html", data); err != nil {
+		return nil, err
+	}
+	out.HTML = b.String()
+
+	// หัวเรื่องต้องอยู่บรรทัดเดียว
+	out.Subject = strings.Join(strings.Fields(out.Subject), " ")
+	out.Summary = strings.TrimSpace(out.Summary)
+	out.Text = strings.TrimSpace(out.Text) + "\n"
+	return out, nil
+}
+
+var thaiMonths = [...]string{"มกราคม", "กุมภาพันธ์", "มีนาคม", "เมษายน", "พฤษภาคม", "มิถุนายน",
+	"กรกฎาคม", "สิงหาคม", "กันยายน", "ตุลาคม", "พฤศจิกายน", "ธันวาคม"}
+
+// FormatEmailTime formats t in Thai time: "5 มีนาคม 2569 เวลา 16:30 น." (พ.ศ.) or
+// "5 March 2026, 16:30".
+func FormatEmailTime(lang string, t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	local := t.In(AnnouncementLocation())
+	if EmailLanguage(lang) == entity.LanguageEN {
+		return local.Format("2 January 2006, 15:04")
+	}
+	return fmt.Sprintf("%d %s %d เวลา %s น.", local.Day(), thaiMonths[local.Month()-1], local.Year()+543, local.Format("15:04"))
+}
+
+var applicationStatusLabels = map[string]map[string]string{
+	entity.LanguageTH: {
+		entity.ApplicationStatusSubmitted:   "ยื่นใบสมัครแล้ว",
+		entity.ApplicationStatusUnderReview: "อยู่ระหว่างพิจารณา",
+		entity.ApplicationStatusAccepted:    "ผ่านการคัดเลือก",
+		entity.ApplicationStatusRejected:    "ไม่ผ่านการคัดเลือก",
+		entity.ApplicationStatusWithdrawn:   "ยกเลิกใบสมัคร",
+	},
+	entity.LanguageEN: {
+		entity.ApplicationStatusSubmitted:   "Submitted",
+		entity.ApplicationStatusUnderReview: "Under review",
+		entity.ApplicationStatusAccepted:    "Accepted",
+		entity.ApplicationStatusRejected:    "Not accepted",
+		entity.ApplicationStatusWithdrawn:   "Withdrawn",
+	},
+}
+
+// ApplicationStatusLabel returns the application status as shown to applicants.
+func ApplicationStatusLabel(lang, status string) string {
+	if label, ok := applicationStatusLabels[EmailLanguage(lang)][status]; ok {
+		return label
+	}
+	return status
+}
+
+// AppURL returns APP_BASE_URL + path, for links in emails. Without APP_BASE_URL the links
+// are left out.
+func AppURL(path string) string {
+	base := strings.TrimRight(os.Getenv("APP_BASE_URL"), "/")
+	if base == "" {
+		return ""
+	}
+	return base + path
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// CarryOverFeedbackComments copies every comment thread of the previous
+// submission version onto the new one, keeping the resolved state so that
+// reviewers only see what is still open on the next round.
+//
+// Sections and blocks belong to the live portfolio, so each anchor is checked
+// against it: a comment on a block that moved follows it to its new section, a
+// comment on a deleted block falls back to the block's section, and a comment
+// whose section is gone too is carried over unanchored. Replies always take
+// the anchor of their root.
+func CarryOverFeedbackComments(tx *gorm.DB, fromSubmissionID, toSubmissionID uint) error {
+	var comments []entity.FeedbackComment
+	if err := tx.
+		Where("portfolio_submission_id = ?", fromSubmissionID).
+		Order("parent_id IS NOT NULL, id asc").
+		Find(&comments).Error; err != nil {
+		return err
+	}
+	if len(comments) == 0 {
+		return nil
+	}
+
+	anchors, err := loadCommentAnchors(tx, toSubmissionID, comments)
+	if err != nil {
+		return err
+	}
+
+	// old comment ID -> copy (roots are copied first, so replies can be remapped)
+	copied := make(map[uint]*entity.FeedbackComment, len(comments))
+
+	for _, old := range comments {
+		var parentID *uint
+		sectionID, blockID := anchors.reanchor(old.PortfolioSectionID, old.PortfolioBlockID)
+		if old.ParentID != nil {
+			parent, ok := copied[*old.ParentID]
+			if !ok {
+				continue
+			}
+			parentID = &parent.ID
+			sectionID, blockID = parent.PortfolioSectionID, parent.PortfolioBlockID
+		}
+
+		sourceID := old.ID
+		clone := entity.FeedbackComment{
+			Body:                  old.Body,
+			IsResolved:            old.IsResolved,
+			ResolvedAt:            old.ResolvedAt,
+			ResolvedByID:          old.ResolvedByID,
+			PortfolioSubmissionID: toSubmissionID,
+			PortfolioSectionID:    sectionID,
+			PortfolioBlockID:      blockID,
+			ParentID:              parentID,
+			CarriedFromID:         &sourceID,
+			UserID:                old.UserID,
+		}
+		clone.CreatedAt = old.CreatedAt
+
+		if err := tx.Create(&clone).Error; err != nil {
+			return err
+		}
+		copied[old.ID] = &clone
+	}
+
+	return nil
+}
+
+// commentAnchors is where the sections and blocks of a portfolio are now.
+type commentAnchors struct {
+	sections map[uint]bool // sections still in the portfolio
+	blocks   map[uint]uint // block -> its current section
+}
+
+// loadCommentAnchors looks up the sections and blocks the comments point at in the
+// portfolio of the submission.
+func loadCommentAnchors(tx *gorm.DB, submissionID uint, comments []entity.FeedbackComment) (*commentAnchors, error) {
+	var submission entity.PortfolioSubmission
+	if err := tx.Select("id, portfolio_id").First(&submission, submissionID).Error; err != nil {
+		return nil, err
+	}
+
+	var sectionIDs []uint
+	if err := tx.Model(&entity.PortfolioSection{}).
+		Where("portfolio_id = ?", submission.PortfolioID).
+		Pluck("id", &sectionIDs).Error; err != nil {
+		return nil, err
+	}
+	anchors := &commentAnchors{sections: make(map[uint]bool, len(sectionIDs)), blocks: map[uint]uint{}}
+	for _, id := range sectionIDs {
+		anchors.sections[id] = true
+	}
+
+	blockIDs := make([]uint, 0, len(comments))
+	for _, c := range comments {
+		if c.PortfolioBlockID != nil {
+			blockIDs = append(blockIDs, *c.PortfolioBlockID)
+		}
+	}
+	if len(blockIDs) > 0 && len(sectionIDs) > 0 {
+		var blocks []entity.PortfolioBlock
+		if err := tx.Select("id, portfolio_section_id").
+			Where("id IN ? AND portfolio_section_id IN ?", blockIDs, sectionIDs).
+			Find(&blocks).Error; err != nil {
+			return nil, err
+		}
+		for _, b := range blocks {
+			anchors.blocks[b.ID] = b.PortfolioSectionID
+		}
+	}
+	return anchors, nil
+}
+
+// reanchor returns where a comment on the given section/block belongs now.
+func (a *commentAnchors) reanchor(sectionID, blockID *uint) (*uint, *uint) {
+	if blockID != nil {
+		if section, ok := a.blocks[*blockID]; ok {
+			block := *blockID
+			return &section, &block
+		}
+	}
+	if sectionID != nil && a.sections[*sectionID] {
+		section := *sectionID
+		return &section, nil
+	}
+	return nil, nil
+}
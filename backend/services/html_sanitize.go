@@ -0,0 +1,200 @@
+package services
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// sanitizeAllowedAttrs lists the tags kept by SanitizeHTML and the attributes each may keep.
+// Any other tag is dropped but its text is kept.
+var sanitizeAllowedAttrs = map[string]map[string]bool{
+	"p": {}, "br": {}, "hr": {},
+	"h1": {}, "h2": {}, "h3": {}, "h4": {}, "h5": {}, "h6": {},
+	"strong": {}, "b": {}, "em": {}, "i": {}, "u": {}, "s": {}, "del": {},
+	"blockquote": {}, "code": {}, "pre": {},
+	"ul": {}, "ol": {"start": true}, "li": {},
+	"a":     {"href": true, "title": true},
+	"img":   {"src": true, "alt": true, "title": true, "width": true, "height": true},
+	"table": {}, "thead": {}, "tbody": {}, "tr": {},
+	"th": {"colspan": true, "rowspan": true, "align": true},
+	"td": {"colspan": true, "rowspan": true, "align": true},
+}
+
+// sanitizeDropContent are tags removed together with everything inside them.
+var sanitizeDropContent = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true, "noscript": true,
+	"textarea": true, "template": true, "svg": true, "math": true, "title": true, "head": true,
+}
+
+var sanitizeVoidTags = map[string]bool{"br": true, "hr": true, "img": true}
+
+var (
+	sanitizeNumberAttr = regexp.MustCompile(`^[0-9]{1,4}$`)
+	sanitizeAlignAttr  = regexp.MustCompile(`^(left|center|right)$`)
+)
+
+// SanitizeHTML keeps a small allowlist of formatting tags (headings, links, lists, tables,
+// images...) and drops everything else: scripts, event handlers, styles and javascript: or
+// data: URLs. Unclosed tags are closed. Links open in a new tab without a referrer.
+func SanitizeHTML(input string) string {
+	z := html.NewTokenizer(strings.NewReader(input))
+	var b strings.Builder
+	var open []string // allowed tags currently open
+	skipDepth := 0    // > 0 while inside a sanitizeDropContent tag
+	var skipTag string
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break // io.EOF หรือ HTML เสียจนอ่านต่อไม่ได้
+		}
+		tok := z.Token()
+
+		if skipDepth > 0 {
+			switch {
+			case tt == html.StartTagToken && tok.Data == skipTag:
+				skipDepth++
+			case tt == html.EndTagToken && tok.Data == skipTag:
+				skipDepth--
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			b.WriteString(html.EscapeString(tok.Data))
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if sanitizeDropContent[tok.Data] {
+				if tt == html.StartTagToken {
+					skipDepth, skipTag = 1, tok.Data
+				}
+				continue
+			}
+			allowed, ok := sanitizeAllowedAttrs[tok.Data]
+			if !ok {
+				continue
+			}
+			attrs, ok := sanitizeAttrs(tok.Data, tok.Attr, allowed)
+			if !ok {
+				continue // เช่น img ที่ไม่มี src ที่ปลอดภัย
+			}
+			b.WriteString("<" + tok.Data + attrs + ">")
+			if !sanitizeVoidTags[tok.Data] && tt == html.StartTagToken {
+				open = append(open, tok.Data)
+			}
+
+		case html.EndTagToken:
+			// ปิดเฉพาะแท็กที่เปิดไว้จริง พร้อมปิดแท็กที่ค้างอยู่ข้างใน
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != tok.Data {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
+
+func sanitizeAttrs(tag string, attrs []html.Attribute, allowed map[string]bool) (string, bool) {
+	var b strings.Builder
+	hasSrc := false
+	for _, a := range attrs {
+		key := strings.ToLower(a.Key)
+		if !allowed[key] || a.Namespace != "" {
+			continue
+		}
+		val := strings.TrimSpace(a.Val)
+		switch key {
+		case "href":
+			if !safeURL(val, true) {
+				continue
+			}
+		case "src":
+			if !safeURL(val, false) {
+				continue
+			}
+			hasSrc = true
+		case "width", "height", "colspan", "rowspan", "start":
+			if !sanitizeNumberAttr.MatchString(val) {
+				continue
+			}
+		case "align":
+			if val = strings.ToLower(val); !sanitizeAlignAttr.MatchString(val) {
+				continue
+			}
+		}
+		b.WriteString(" " + key + `="` + html.EscapeString(val) + `"`)
+	}
+	switch tag {
+	case "img":
+		if !hasSrc {
+			return "", false
+		}
+		b.WriteString(` loading="lazy"`)
+	case "a":
+		b.WriteString(` target="_blank" rel="noopener noreferrer nofollow"`)
+	}
+	return b.String(), true
+}
+
+// safeURL accepts http(s) URLs and site-relative paths, plus mailto: for links.
+func safeURL(raw string, link bool) bool {
+	// ตัดอักขระควบคุม/ช่องว่างที่ browser มองข้าม เช่น "java\tscript:"
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, raw)
+	if cleaned == "" {
+		return false
+	}
+	u, err := url.Parse(cleaned)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto":
+		return link
+	case "":
+		return strings.HasPrefix(cleaned, "/") || (link && strings.HasPrefix(cleaned, "#"))
+	}
+	return false
+}
+
+// HTMLToText strips the tags from sanitized HTML, for notification previews.
+func HTMLToText(input string) string {
+	z := html.NewTokenizer(strings.NewReader(input))
+	var b strings.Builder
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		switch tt {
+		case html.TextToken:
+			b.WriteString(string(z.Text()))
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "br", "p", "li", "tr", "h1", "h2", "h3", "h4", "h5", "h6", "blockquote", "pre":
+				b.WriteString(" ")
+			}
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RenderMarkdown turns the Markdown subset used by announcements into sanitized HTML:
+// headings, paragraphs (a newline is a line break), **bold**, *italic*, ~~strike~~, `code`,
+// fenced code blocks, block quotes, bullet and numbered lists, pipe tables, horizontal
+// rules, [links](url) and ![images](url). Raw HTML in the source is shown as text.
+func RenderMarkdown(source string) string {
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	return SanitizeHTML(renderMarkdownBlocks(lines))
+}
+
+var (
+	mdHeading      = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdRule         = regexp.MustCompile(`^ {0,3}((- *){3,}|(\* *){3,}|(_ *){3,})$`)
+	mdFence        = regexp.MustCompile("^ {0,3}(```|~~~)")
+	mdBullet       = regexp.MustCompile(`^ {0,3}[-*+]\s+(.*)$`)
+	mdNumbered     = regexp.MustCompile(`^ {0,3}(\d{1,9})[.)]\s+(.*)$`)
+	mdTableDivider = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+)
+
+func renderMarkdownBlocks(lines []string) string {
+	var b strings.Builder
+	var para []string
+	flush := func() {
+		if len(para) == 0 {
+			return
+		}
+		rendered := make([]string, len(para))
+		for i, l := range para {
+			rendered[i] = renderMarkdownInline(strings.TrimSpace(l))
+		}
+		b.WriteString("<p>" + strings.Join(rendered, "<br>") + "</p>\n")
+		para = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+
+		case mdFence.MatchString(line):
+			flush()
+			fence := mdFence.FindStringSubmatch(line)[1]
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			b.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+
+		case mdHeading.MatchString(line):
+			flush()
+			m := mdHeading.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + ">" + renderMarkdownInline(m[2]) + "</h" + level + ">\n")
+
+		case mdRule.MatchString(line):
+			flush()
+			b.WriteString("<hr>\n")
+
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				q := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(q, " "))
+			}
+			i--
+			b.WriteString("<blockquote>\n" + renderMarkdownBlocks(quoted) + "</blockquote>\n")
+
+		case mdBullet.MatchString(line) || mdNumbered.MatchString(line):
+			flush()
+			i = renderMarkdownList(&b, lines, i) - 1
+
+		case strings.Contains(line, "|") && i+1 < len(lines) && mdTableDivider.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-"):
+			flush()
+			i = renderMarkdownTable(&b, lines, i) - 1
+
+		default:
+			para = append(para, line)
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// renderMarkdownList writes the list starting at lines[start] and returns the index of the
+// first line after it. Indented lines continue the previous item.
+func renderMarkdownList(b *strings.Builder, lines []string, start int) int {
+	numbered := mdNumbered.MatchString(lines[start])
+	tag := "ul"
+	if numbered {
+		tag = "ol"
+		if n := mdNumbered.FindStringSubmatch(lines[start])[1]; n != "1" {
+			b.WriteString(`<ol start="` + strings.TrimLeft(n, "0") + `">` + "\n")
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+
+	var item []string
+	writeItem := func() {
+		if item != nil {
+			b.WriteString("<li>" + strings.Join(item, "<br>") + "</li>\n")
+		}
+		item = nil
+	}
+
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if numbered {
+			if m := mdNumbered.FindStringSubmatch(line); m != nil {
+				writeItem()
+				item = []string{renderMarkdownInline(m[2])}
+				continue
+			}
+		} else if m := mdBullet.FindStringSubmatch(line); m != nil {
+			writeItem()
+			item = []string{renderMarkdownInline(m[1])}
+			continue
+		}
+		if strings.TrimSpace(line) != "" && (strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "\t")) {
+			item = append(item, renderMarkdownInline(strings.TrimSpace(line)))
+			continue
+		}
+		break
+	}
+	writeItem()
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// renderMarkdownTable writes the pipe table whose header is lines[start] and returns the index
+// of the first line after it.
+func renderMarkdownTable(b *strings.Builder, lines []string, start int) int {
+	header := splitMarkdownRow(lines[start])
+	var aligns []string
+	for _, cell := range splitMarkdownRow(lines[start+1]) {
+		switch {
+		case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+			aligns = append(aligns, "center")
+		case strings.HasSuffix(cell, ":"):
+			aligns = append(aligns, "right")
+		case strings.HasPrefix(cell, ":"):
+			aligns = append(aligns, "left")
+		default:
+			aligns = append(aligns, "")
+		}
+	}
+
+	row := func(tag string, cells []string) {
+		b.WriteString("<tr>")
+		for i := range header {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			attr := ""
+			if i < len(aligns) && aligns[i] != "" {
+				attr = ` align="` + aligns[i] + `"`
+			}
+			b.WriteString("<" + tag + attr + ">" + renderMarkdownInline(cell) + "</" + tag + ">")
+		}
+		b.WriteString("</tr>\n")
+	}
+
+	b.WriteString("<table>\n<thead>\n")
+	row("th", header)
+	b.WriteString("</thead>\n<tbody>\n")
+	i := start + 2
+	for ; i < len(lines) && strings.TrimSpace(lines[i]) != "" && strings.Contains(lines[i], "|"); i++ {
+		row("td", splitMarkdownRow(lines[i]))
+	}
+	b.WriteString("</tbody>\n</table>\n")
+	return i
+}
+
+func splitMarkdownRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	// \| เป็นตัวอักษร | ในเซลล์
+	line = strings.ReplaceAll(line, `\|`, "\x00")
+	cells := strings.Split(line, "|")
+	for i, c := range cells {
+		cells[i] = strings.TrimSpace(strings.ReplaceAll(c, "\x00", "|"))
+	}
+	return cells
+}
+
+const mdPunctuation = "\\`*_{}[]()#+-.!|~<>\"'"
+
+// renderMarkdownInline renders the inline syntax of one line; everything else is escaped.
+func renderMarkdownInline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(mdPunctuation, s[i+1]) >= 0:
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end >= 0 {
+				b.WriteString("<code>" + html.EscapeString(s[i+1:i+1+end]) + "</code>")
+				i += end + 2
+				continue
+			}
+
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			if text, dest, title, n, ok := parseMarkdownLink(s[i+1:]); ok {
+				b.WriteString(`<img src="` + html.EscapeString(dest) + `" alt="` + html.EscapeString(text) + `"`)
+				if title != "" {
+					b.WriteString(` title="` + html.EscapeString(title) + `"`)
+				}
+				b.WriteString(">")
+				i += n + 1
+				continue
+			}
+
+		case c == '[':
+			if text, dest, title, n, ok := parseMarkdownLink(s[i:]); ok {
+				b.WriteString(`<a href="` + html.EscapeString(dest) + `"`)
+				if title != "" {
+					b.WriteString(` title="` + html.EscapeString(title) + `"`)
+				}
+				b.WriteString(">" + renderMarkdownInline(text) + "</a>")
+				i += n
+				continue
+			}
+
+		case c == '*' || c == '_' || c == '~':
+			if out, n, ok := renderMarkdownEmphasis(s, i); ok {
+				b.WriteString(out)
+				i += n
+				continue
+			}
+		}
+		b.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+	return b.String()
+}
+
+// renderMarkdownEmphasis handles **strong**, __strong__, *em*, _em_ and ~~del~~ at s[i].
+func renderMarkdownEmphasis(s string, i int) (string, int, bool) {
+	for _, d := range []struct{ delim, tag string }{
+		{"**", "strong"}, {"__", "strong"}, {"~~", "del"}, {"*", "em"}, {"_", "em"},
+	} {
+		if !strings.HasPrefix(s[i:], d.delim) {
+			continue
+		}
+		// _ ภายในคำ (เช่น snake_case) ไม่ใช่ตัวเน้น
+		if d.delim[0] == '_' && i > 0 && isMarkdownWordChar(s[i-1]) {
+			return "", 0, false
+		}
+		rest := s[i+len(d.delim):]
+		end := strings.Index(rest, d.delim)
+		if end <= 0 || rest[0] == ' ' || rest[end-1] == ' ' {
+			continue
+		}
+		if d.delim[0] == '_' && i+len(d.delim)+end+len(d.delim) < len(s) && isMarkdownWordChar(s[i+len(d.delim)+end+len(d.delim)]) {
+			continue
+		}
+		return "<" + d.tag + ">" + renderMarkdownInline(rest[:end]) + "</" + d.tag + ">", len(d.delim)*2 + end, true
+	}
+	return "", 0, false
+}
+
+func isMarkdownWordChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// parseMarkdownLink parses `[text](dest "title")` at the start of s and returns its parts and
+// length.
+func parseMarkdownLink(s string) (text, dest, title string, n int, ok bool) {
+	depth := 0
+	closeText := -1
+	for i := 0; i < len(s) && closeText < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			if depth--; depth == 0 {
+				closeText = i
+			}
+		}
+	}
+	if closeText < 0 || closeText+1 >= len(s) || s[closeText+1] != '(' {
+		return "", "", "", 0, false
+	}
+	closeDest := strings.IndexByte(s[closeText+2:], ')')
+	if closeDest < 0 {
+		return "", "", "", 0, false
+	}
+	inner := strings.TrimSpace(s[closeText+2 : closeText+2+closeDest])
+	dest = inner
+	if sp := strings.IndexAny(inner, " \t"); sp >= 0 {
+		dest = inner[:sp]
+		t := strings.TrimSpace(inner[sp:])
+		if len(t) >= 2 && (t[0] == '"' || t[0] == '\'') && t[len(t)-1] == t[0] {
+			title = t[1 : len(t)-1]
+		}
+	}
+	dest = strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+	if dest == "" {
+		return "", "", "", 0, false
+	}
+	return s[1:closeText], dest, title, closeText + 3 + closeDest, true
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sut68/team14/backend/entity"
+)
+
+// -------------------- EMAIL --------------------
+
+// EmailSender sends one rendered email.
+type EmailSender interface {
+	SendEmail(ctx context.Context, to string, email *RenderedEmail) error
+}
+
+// SMTPMailer sends email over SMTP. In development it points at MailHog.
+type SMTPMailer struct {
+	Addr string // host:port
+	From string
+	Auth smtp.Auth // nil = ไม่ต้องยืนยันตัวตน (เช่น MailHog)
+}
+
+// NewSMTPMailerFromEnv configures the mailer from SMTP_HOST, SMTP_PORT (default 587),
+// SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM.
+func NewSMTPMailerFromEnv() (*SMTPMailer, error) {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil, errors.New("SMTP_HOST is not set")
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		return nil, errors.New("SMTP_FROM is not set")
+	}
+	m := &SMTPMailer{Addr: net.JoinHostPort(host, port), From: from}
+	if user := os.Getenv("SMTP_USERNAME"); user != "" {
+		m.Auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	return m, nil
+}
+
+func (m *SMTPMailer) SendEmail(ctx context.Context, to string, email *RenderedEmail) error {
+	msg, err := buildEmail(m.From, to, email, time.Now())
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(m.Addr, m.Auth, m.From, []string{to}, msg)
+}
+
+// buildEmail builds a UTF-8 multipart/alternative message with the text and HTML bodies;
+// the subject is RFC 2047 encoded for Thai.
+func buildEmail(from, to string, email *RenderedEmail, now time.Time) ([]byte, error) {
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", email.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", mw.Boundary())
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", email.Text},
+		{"text/html; charset=UTF-8", email.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(strings.ReplaceAll(strings.ReplaceAll(part.body, "\r\n", "\n"), "\n", "\r\n"))); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// EmailChannel sends the dispatcher's notifications as email, rendered with the
+// "notification" template in the user's language.
+type EmailChannel struct {
+	Mailer EmailSender
+}
+
+func (e *EmailChannel) Name() string { return entity.NotificationChannelEmail }
+
+func (e *EmailChannel) Send(ctx context.Context, msg NotificationMessage) error {
+	to := strings.TrimSpace(msg.User.Email)
+	if to == "" {
+		return ErrNoRecipientAddress
+	}
+	n := msg.Notification
+	lang := emailSettingLanguage(msg.Setting)
+	email, err := RenderEmail(EmailNotification, lang, EmailData{
+		Name:    emailRecipientName(msg.User, lang),
+		URL:     AppURL(pushURL(msg.User, n)),
+		Time:    n.Sent_At,
+		Title:   n.Notification_Title,
+		Message: n.Notification_Message,
+	})
+	if err != nil {
+		return err
+	}
+	return e.Mailer.SendEmail(ctx, to, email)
+}
+
+// -------------------- WEBHOOK / LINE NOTIFY --------------------
+
+// WebhookChannel POSTs notifications to the URL in the user's NotificationSetting: JSON, or
+// a LINE Notify style form with a bearer token.
+type WebhookChannel struct {
+	Client *http.Client
+}
+
+// NewWebhookChannel creates a webhook channel that refuses to call private addresses, so a
+// user cannot make the server call into the internal network.
+func NewWebhookChannel() *WebhookChannel {
+	return &WebhookChannel{Client: newPublicHTTPClient()}
+}
+
+// newPublicHTTPClient is a client for user-supplied URLs: it only connects to public
+// addresses and does not follow redirects.
+func newPublicHTTPClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: refusePrivateAddress}
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{DialContext: dialer.DialContext, Proxy: nil},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+var errPrivateAddress = errors.New("address is not public")
+
+// refusePrivateAddress runs after DNS resolution, so names that resolve to private IPs are
+// refused too.
+func refusePrivateAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast() {
+		return errPrivateAddress
+	}
+	return nil
+}
+
+func (w *WebhookChannel) Name() string { return entity.NotificationChannelWebhook }
+
+func (w *WebhookChannel) Send(ctx context.Context, msg NotificationMessage) error {
+	if msg.Setting == nil || msg.Setting.WebhookURL == "" {
+		return ErrNoRecipientAddress
+	}
+	s, n := msg.Setting, msg.Notification
+
+	var body io.Reader
+	var contentType string
+	if s.WebhookFormat == entity.WebhookFormatLineNotify {
+		form := url.Values{"message": {n.Notification_Title + "\n" + n.Notification_Message}}
+		body, contentType = strings.NewReader(form.Encode()), "application/x-www-form-urlencoded"
+	} else {
+		payload, err := json.Marshal(map[string]interface{}{
+			"id":              n.ID,
+			"type":            entity.NormalizeNotificationType(n.Notification_Type),
+			"title":           n.Notification_Title,
+			"message":         n.Notification_Message,
+			"announcement_id": n.AnnouncementID,
+			"sent_at":         n.Sent_At,
+		})
+		if err != nil {
+			return err
+		}
+		body, contentType = bytes.NewReader(payload), "application/json"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.WebhookURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if s.WebhookToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.WebhookToken)
+	}
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+// ValidateWebhookURL accepts https URLs only.
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("webhook_url must be an https URL")
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// NotificationMessage is what a channel needs to send one notification.
+type NotificationMessage struct {
+	Notification *entity.Notification
+	User         *entity.User
+	Setting      *entity.NotificationSetting // nil = ผู้ใช้ยังไม่ได้ตั้งค่า
+}
+
+// NotificationChannel sends notifications outside the app (email, webhook, web push...).
+type NotificationChannel interface {
+	Name() string
+	Send(ctx context.Context, msg NotificationMessage) error
+}
+
+// ErrNoRecipientAddress is returned by a channel when the user has no address for it, such
+// as no webhook URL. The delivery is marked skipped instead of being retried.
+var ErrNoRecipientAddress = errors.New("recipient has no address for this channel")
+
+// channelDefaults is whether a channel is on for a type the user has no preference for.
+var channelDefaults = map[string]bool{
+	entity.NotificationChannelInApp:   true,
+	entity.NotificationChannelEmail:   true,
+	entity.NotificationChannelWebPush: true,
+	entity.NotificationChannelWebhook: false, // ต้องตั้ง URL และเปิดเอง
+}
+
+// DefaultChannelEnabled is whether the channel is on when the user has not chosen.
+func DefaultChannelEnabled(channel string) bool {
+	return channelDefaults[channel]
+}
+
+// urgentNotificationTypes are sent during quiet hours too: they are useless later.
+var urgentNotificationTypes = map[string]bool{
+	entity.NotificationTypeAlert:            true,
+	entity.NotificationTypeDeadlineReminder: true,
+}
+
+// NotificationDispatcher queues notifications for the registered channels and delivers them
+// with retries. In-app delivery is the Notification row itself, so it is not a channel here.
+type NotificationDispatcher struct {
+	DB       *gorm.DB
+	Channels map[string]NotificationChannel
+	// MaxAttempts is how many times a delivery is tried before it is marked failed.
+	MaxAttempts int
+	// RetryDelay is the wait after the first failure; it doubles after every attempt.
+	RetryDelay time.Duration
+	// BatchSize is how many deliveries DeliverDue handles per run.
+	BatchSize int
+}
+
+// Dispatcher is the dispatcher used by CreateNotifications. nil = in-app only.
+var Dispatcher *NotificationDispatcher
+
+// NewNotificationDispatcher creates a dispatcher for the given channels.
+func NewNotificationDispatcher(db *gorm.DB, channels ...NotificationChannel) *NotificationDispatcher {
+	d := &NotificationDispatcher{
+		DB:          db,
+		Channels:    map[string]NotificationChannel{},
+		MaxAttempts: 5,
+		RetryDelay:  time.Minute,
+		BatchSize:   200,
+	}
+	for _, ch := range channels {
+		d.Channels[ch.Name()] = ch
+	}
+	return d
+}
+
+// WebPush is the Web Push channel, nil when VAPID keys are not configured.
+var WebPush *WebPushChannel
+
+// InitNotificationDispatcher sets Dispatcher with the channels that are configured: email
+// (and the Emails queue) when SMTP_HOST is set, web push when VAPID_PRIVATE_KEY is set, and
+// webhooks.
+func InitNotificationDispatcher(db *gorm.DB) {
+	channels := []NotificationChannel{NewWebhookChannel()}
+	if mailer, err := NewSMTPMailerFromEnv(); err != nil {
+		log.Printf("⚠️ Email notifications disabled: %v", err)
+	} else {
+		Emails = NewEmailQueue(db, mailer)
+		channels = append(channels, &EmailChannel{Mailer: mailer})
+	}
+	if keys, err := NewVAPIDKeysFromEnv(); err != nil {
+		log.Printf("⚠️ Web push notifications disabled: %v", err)
+	} else {
+		WebPush = NewWebPushChannel(db, keys)
+		channels = append(channels, WebPush)
+	}
+	Dispatcher = NewNotificationDispatcher(db, channels...)
+	names := make([]string, 0, len(channels))
+	for _, ch := range channels {
+		names = append(names, ch.Name())
+	}
+	log.Printf("📨 Notification channels: in_app, %s", strings.Join(names, ", "))
+}
+
+// CreateNotifications stores the notifications (the in-app channel) and queues them on the
+// dispatcher's other channels, in one transaction.
+func CreateNotifications(db *gorm.DB, notifications []entity.Notification, now time.Time) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User", "Announcement", "Event").CreateInBatches(&notifications, notificationBatchSize).Error; err != nil {
+			return err
+		}
+		if Dispatcher == nil {
+			return nil
+		}
+		return Dispatcher.Enqueue(tx, notifications, now)
+	})
+}
+
+// channelNames returns the registered channels in a stable order.
+func (d *NotificationDispatcher) channelNames() []string {
+	names := make([]string, 0, len(d.Channels))
+	for name := range d.Channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Enqueue creates a delivery for every registered channel the recipient has enabled for the
+// notification's type. Deliveries that fall in the recipient's quiet hours are deferred to
+// their end, except for urgent types. Types emailed by their domain event (see QueueEmails)
+// get no email delivery.
+func (d *NotificationDispatcher) Enqueue(db *gorm.DB, notifications []entity.Notification, now time.Time) error {
+	channels := d.channelNames()
+	if len(channels) == 0 {
+		return nil
+	}
+
+	userIDs := make([]uint, 0, len(notifications))
+	seen := map[uint]bool{}
+	for _, n := range notifications {
+		if n.UserID != nil && !seen[*n.UserID] {
+			seen[*n.UserID] = true
+			userIDs = append(userIDs, *n.UserID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+	prefs, err := loadPreferences(db, userIDs)
+	if err != nil {
+		return err
+	}
+	settings, err := loadNotificationSettings(db, userIDs)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []entity.NotificationDelivery
+	for _, n := range notifications {
+		if n.UserID == nil {
+			continue
+		}
+		userID := *n.UserID
+		typ := entity.NormalizeNotificationType(n.Notification_Type)
+		status, at := entity.DeliveryPending, now
+		if !urgentNotificationTypes[typ] {
+			if end, quiet := QuietHoursEnd(settings[userID], now); quiet {
+				status, at = entity.DeliveryDeferred, end
+			}
+		}
+		for _, channel := range channels {
+			if !ChannelEnabled(prefs[userID], typ, channel) {
+				continue
+			}
+			if channel == entity.NotificationChannelEmail && emailedByEvent[typ] {
+				continue
+			}
+			deliveries = append(deliveries, entity.NotificationDelivery{
+				NotificationID: n.ID,
+				Channel:        channel,
+				UserID:         userID,
+				Status:         status,
+				NextAttemptAt:  at,
+			})
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return db.CreateInBatches(&deliveries, notificationBatchSize).Error
+}
+
+// preferenceKey is type + "/" + channel.
+func preferenceKey(typ, channel string) string { return typ + "/" + channel }
+
+func loadPreferences(db *gorm.DB, userIDs []uint) (map[uint]map[string]bool, error) {
+	var rows []entity.NotificationPreference
+	if err := db.Where("user_id IN ?", userIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	prefs := map[uint]map[string]bool{}
+	for _, p := range rows {
+		if prefs[p.UserID] == nil {
+			prefs[p.UserID] = map[string]bool{}
+		}
+		prefs[p.UserID][preferenceKey(p.Type, p.Channel)] = p.Enabled
+	}
+	return prefs, nil
+}
+
+func loadNotificationSettings(db *gorm.DB, userIDs []uint) (map[uint]*entity.NotificationSetting, error) {
+	var rows []entity.NotificationSetting
+	if err := db.Where("user_id IN ?", userIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	settings := make(map[uint]*entity.NotificationSetting, len(rows))
+	for i := range rows {
+		settings[rows[i].UserID] = &rows[i]
+	}
+	return settings, nil
+}
+
+// ChannelEnabled applies the user's preferences (type/channel → enabled) or the default.
+// In-app cannot be turned off.
+func ChannelEnabled(prefs map[string]bool, typ, channel string) bool {
+	if channel == entity.NotificationChannelInApp {
+		return true
+	}
+	if enabled, ok := prefs[preferenceKey(typ, channel)]; ok {
+		return enabled
+	}
+	return DefaultChannelEnabled(channel)
+}
+
+// ParseClock parses "HH:MM" into minutes after midnight.
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("time must be HH:MM")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// QuietHoursEnd reports whether now is inside the user's quiet hours (Thai time) and, if so,
+// when they end.
+func QuietHoursEnd(setting *entity.NotificationSetting, now time.Time) (time.Time, bool) {
+	if setting == nil || setting.QuietHoursStart == "" || setting.QuietHoursEnd == "" {
+		return time.Time{}, false
+	}
+	start, err1 := ParseClock(setting.QuietHoursStart)
+	end, err2 := ParseClock(setting.QuietHoursEnd)
+	if err1 != nil || err2 != nil || start == end {
+		return time.Time{}, false
+	}
+
+	local := now.In(AnnouncementLocation())
+	minute := local.Hour()*60 + local.Minute()
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	endToday := midnight.Add(time.Duration(end) * time.Minute)
+
+	if start < end {
+		// เช่น 13:00-14:00
+		if minute >= start && minute < end {
+			return endToday, true
+		}
+		return time.Time{}, false
+	}
+	// ข้ามเที่ยงคืน เช่น 22:00-07:00
+	switch {
+	case minute >= start:
+		return endToday.AddDate(0, 0, 1), true
+	case minute < end:
+		return endToday, true
+	}
+	return time.Time{}, false
+}
+
+// DeliverDue sends the deliveries that are due, and returns how many were sent. Each one is
+// claimed first, so several instances can run it at the same time.
+func (d *NotificationDispatcher) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	var due []entity.NotificationDelivery
+	if err := d.outbox().due(&due, d.BatchSize, now); err != nil {
+		return 0, err
+	}
+	return deliverEach(len(due), func(i int) (bool, error) { return d.deliver(ctx, &due[i], now) })
+}
+
+func (d *NotificationDispatcher) outbox() deliveryOutbox {
+	return deliveryOutbox{db: d.DB, model: &entity.NotificationDelivery{}, maxAttempts: d.MaxAttempts, retryDelay: d.RetryDelay}
+}
+
+func (d *NotificationDispatcher) deliver(ctx context.Context, delivery *entity.NotificationDelivery, now time.Time) (bool, error) {
+	outbox := d.outbox()
+	claimed, err := outbox.claim(delivery.ID, delivery.Attempts, now)
+	if err != nil || !claimed {
+		return false, err // !claimed = อีก instance รับไปแล้ว
+	}
+	delivery.Attempts++
+
+	sendErr := d.send(ctx, delivery)
+	permanent := errors.Is(sendErr, ErrNoRecipientAddress) || errors.Is(sendErr, gorm.ErrRecordNotFound)
+	status, err := outbox.finish(delivery.ID, delivery.Attempts, sendErr, permanent, now)
+	if status == entity.DeliveryFailed {
+		log.Printf("❌ Notification %d via %s failed: %v", delivery.NotificationID, delivery.Channel, sendErr)
+	}
+	if err != nil {
+		return false, err
+	}
+	return sendErr == nil, nil
+}
+
+func (d *NotificationDispatcher) send(ctx context.Context, delivery *entity.NotificationDelivery) error {
+	channel, ok := d.Channels[delivery.Channel]
+	if !ok {
+		return fmt.Errorf("%w: channel %s is not configured", ErrNoRecipientAddress, delivery.Channel)
+	}
+	var notification entity.Notification
+	if err := d.DB.First(&notification, delivery.NotificationID).Error; err != nil {
+		return err
+	}
+	var user entity.User
+	if err := d.DB.First(&user, delivery.UserID).Error; err != nil {
+		return err
+	}
+	msg := NotificationMessage{Notification: &notification, User: &user}
+	var setting entity.NotificationSetting
+	if err := d.DB.Where("user_id = ?", delivery.UserID).Limit(1).Find(&setting).Error; err != nil {
+		return err
+	}
+	if setting.ID != 0 {
+		msg.Setting = &setting
+	}
+	return channel.Send(ctx, msg)
+}
+
+// NotificationPreferenceMatrix returns, for every type and channel, whether the user gets
+// notifications of that type on that channel.
+func NotificationPreferenceMatrix(db *gorm.DB, userID uint) (map[string]map[string]bool, error) {
+	prefs, err := loadPreferences(db, []uint{userID})
+	if err != nil {
+		return nil, err
+	}
+	matrix := make(map[string]map[string]bool, len(entity.NotificationTypes))
+	for _, typ := range entity.NotificationTypes {
+		matrix[typ] = make(map[string]bool, len(entity.NotificationChannels))
+		for _, channel := range entity.NotificationChannels {
+			matrix[typ][channel] = ChannelEnabled(prefs[userID], typ, channel)
+		}
+	}
+	return matrix, nil
+}
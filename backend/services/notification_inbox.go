@@ -0,0 +1,268 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// Inbox page sizes.
+const (
+	DefaultInboxLimit = 20
+	MaxInboxLimit     = 100
+)
+
+// InboxFilter selects notifications from one user's inbox.
+type InboxFilter struct {
+	UserID   uint
+	Types    []string // ว่าง = ทุกประเภท
+	Read     *bool    // nil = ทั้งอ่านแล้วและยังไม่อ่าน
+	Archived bool     // true = เฉพาะที่เก็บถาวร, false = เฉพาะกล่องปกติ
+	// Group collapses notifications with the same type and Group_Key into their newest one.
+	Group bool
+	// Cursor is the ID of the last item of the previous page (0 = first page).
+	Cursor uint
+	Limit  int
+}
+
+// InboxItem is a notification in the inbox. When grouped, it is the newest of its group and
+// GroupCount/GroupUnread count the whole group.
+type InboxItem struct {
+	entity.Notification
+	GroupCount  int `json:"group_count"`
+	GroupUnread int `json:"group_unread"`
+}
+
+// where returns the filter's conditions on the given table alias.
+func (f InboxFilter) where(alias string) (string, []interface{}) {
+	conds := []string{alias + ".user_id = ?", alias + ".deleted_at IS NULL"}
+	args := []interface{}{f.UserID}
+	if f.Archived {
+		conds = append(conds, alias+".archived_at IS NOT NULL")
+	} else {
+		conds = append(conds, alias+".archived_at IS NULL")
+	}
+	if len(f.Types) > 0 {
+		conds = append(conds, alias+".notification_type IN ?")
+		args = append(args, f.Types)
+	}
+	if f.Read != nil {
+		conds = append(conds, alias+".is_read = ?")
+		args = append(args, *f.Read)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// ListInbox returns one page of the inbox, newest first, and the cursor of the next page
+// (0 when this is the last one).
+func ListInbox(db *gorm.DB, f InboxFilter) ([]InboxItem, uint, error) {
+	if f.Limit < 1 || f.Limit > MaxInboxLimit {
+		f.Limit = DefaultInboxLimit
+	}
+	for i, t := range f.Types {
+		f.Types[i] = entity.NormalizeNotificationType(t)
+	}
+
+	cond, args := f.where("notifications")
+	query := db.Model(&entity.Notification{}).Where(cond, args...)
+	if f.Group {
+		// แสดงเฉพาะรายการล่าสุดของแต่ละกลุ่ม
+		sub, subArgs := f.where("g")
+		query = query.Where("notifications.group_key = '' OR NOT EXISTS (SELECT 1 FROM notifications g WHERE "+
+			"g.group_key = notifications.group_key AND g.notification_type = notifications.notification_type AND "+
+			"g.id > notifications.id AND "+sub+")", subArgs...)
+	}
+	if f.Cursor > 0 {
+		query = query.Where("notifications.id < ?", f.Cursor)
+	}
+
+	var rows []entity.Notification
+	if err := query.Order("notifications.id DESC").Limit(f.Limit + 1).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	var next uint
+	if len(rows) > f.Limit {
+		rows = rows[:f.Limit]
+		next = rows[len(rows)-1].ID
+	}
+
+	items := make([]InboxItem, len(rows))
+	for i := range rows {
+		items[i] = InboxItem{Notification: rows[i], GroupCount: 1}
+		if !rows[i].Is_Read {
+			items[i].GroupUnread = 1
+		}
+	}
+	if f.Group {
+		if err := countInboxGroups(db, f, items); err != nil {
+			return nil, 0, err
+		}
+	}
+	return items, next, nil
+}
+
+// countInboxGroups fills GroupCount/GroupUnread for the grouped items.
+func countInboxGroups(db *gorm.DB, f InboxFilter, items []InboxItem) error {
+	keys := make([]string, 0, len(items))
+	for _, it := range items {
+		if it.Group_Key != "" {
+			keys = append(keys, it.Group_Key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var counts []struct {
+		NotificationType string
+		GroupKey         string
+		Total            int
+		Unread           int
+	}
+	cond, args := f.where("notifications")
+	if err := db.Model(&entity.Notification{}).
+		Select("notification_type, group_key, COUNT(*) AS total, SUM(CASE WHEN is_read THEN 0 ELSE 1 END) AS unread").
+		Where(cond, args...).
+		Where("group_key IN ?", keys).
+		Group("notification_type, group_key").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+	byGroup := make(map[string][2]int, len(counts))
+	for _, c := range counts {
+		byGroup[c.NotificationType+"/"+c.GroupKey] = [2]int{c.Total, c.Unread}
+	}
+	for i := range items {
+		if c, ok := byGroup[items[i].Notification_Type+"/"+items[i].Group_Key]; ok && items[i].Group_Key != "" {
+			items[i].GroupCount, items[i].GroupUnread = c[0], c[1]
+		}
+	}
+	return nil
+}
+
+// UnreadCounts returns the user's unread notifications outside the archive, per type.
+func UnreadCounts(db *gorm.DB, userID uint) (map[string]int64, int64, error) {
+	var rows []struct {
+		NotificationType string
+		Count            int64
+	}
+	if err := db.Model(&entity.Notification{}).
+		Select("notification_type, COUNT(*) AS count").
+		Where("user_id = ? AND is_read = ? AND archived_at IS NULL", userID, false).
+		Group("notification_type").
+		Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	byType := make(map[string]int64, len(entity.NotificationTypes))
+	for _, t := range entity.NotificationTypes {
+		byType[t] = 0
+	}
+	var total int64
+	for _, r := range rows {
+		byType[entity.NormalizeNotificationType(r.NotificationType)] += r.Count
+		total += r.Count
+	}
+	return byType, total, nil
+}
+
+// Inbox bulk actions.
+const (
+	InboxActionRead      = "read"
+	InboxActionUnread    = "unread"
+	InboxActionArchive   = "archive"
+	InboxActionUnarchive = "unarchive"
+	InboxActionDelete    = "delete"
+)
+
+var ErrInvalidInboxAction = errors.New("invalid inbox action")
+
+// expandInboxGroups returns the user's notifications among ids plus the other notifications
+// in their groups, since a grouped item stands for its whole group in the inbox.
+func expandInboxGroups(db *gorm.DB, userID uint, ids []uint) ([]uint, error) {
+	var picked []entity.Notification
+	if err := db.Select("id, notification_type, group_key").
+		Where("user_id = ? AND id IN ?", userID, ids).
+		Find(&picked).Error; err != nil {
+		return nil, err
+	}
+	expanded := make([]uint, 0, len(picked))
+	var groups []string
+	var args []interface{}
+	for _, n := range picked {
+		expanded = append(expanded, n.ID)
+		if n.Group_Key != "" {
+			groups = append(groups, "(notification_type = ? AND group_key = ?)")
+			args = append(args, n.Notification_Type, n.Group_Key)
+		}
+	}
+	if len(groups) == 0 {
+		return expanded, nil
+	}
+	var members []uint
+	if err := db.Model(&entity.Notification{}).
+		Where("user_id = ?", userID).
+		Where(strings.Join(groups, " OR "), args...).
+		Pluck("id", &members).Error; err != nil {
+		return nil, err
+	}
+	return append(expanded, members...), nil
+}
+
+// ApplyInboxAction marks, archives or deletes the user's notifications (and the rest of
+// their groups). Returns how many notifications changed.
+func ApplyInboxAction(db *gorm.DB, userID uint, action string, ids []uint, now time.Time) (int64, error) {
+	var update map[string]interface{}
+	switch action {
+	case InboxActionRead:
+		update = map[string]interface{}{"is_read": true, "read_at": now}
+	case InboxActionUnread:
+		update = map[string]interface{}{"is_read": false, "read_at": nil}
+	case InboxActionArchive:
+		update = map[string]interface{}{"archived_at": now}
+	case InboxActionUnarchive:
+		update = map[string]interface{}{"archived_at": nil}
+	case InboxActionDelete:
+	default:
+		return 0, ErrInvalidInboxAction
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var affected int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		targets, err := expandInboxGroups(tx, userID, ids)
+		if err != nil || len(targets) == 0 {
+			return err
+		}
+		query := tx.Model(&entity.Notification{}).Where("user_id = ? AND id IN ?", userID, targets)
+		var result *gorm.DB
+		if action == InboxActionDelete {
+			result = query.Delete(&entity.Notification{})
+		} else {
+			result = query.Updates(update)
+		}
+		affected = result.RowsAffected
+		return result.Error
+	})
+	return affected, err
+}
+
+// MarkAllNotificationsRead marks every unread notification in the user's inbox as read,
+// optionally only those of the given types.
+func MarkAllNotificationsRead(db *gorm.DB, userID uint, types []string, now time.Time) (int64, error) {
+	query := db.Model(&entity.Notification{}).
+		Where("user_id = ? AND is_read = ? AND archived_at IS NULL", userID, false)
+	if len(types) > 0 {
+		normalized := make([]string, len(types))
+		for i, t := range types {
+			normalized[i] = entity.NormalizeNotificationType(t)
+		}
+		query = query.Where("notification_type IN ?", normalized)
+	}
+	result := query.Updates(map[string]interface{}{"is_read": true, "read_at": now})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,550 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Rule is a node of an eligibility rule tree. A group node has Op ("and"/"or") and
+// child Rules; a leaf compares a profile Field with Value using Cmp.
+//
+//	{"op":"or","rules":[
+//	  {"field":"language.IELTS.score","cmp":">=","value":6.0},
+//	  {"field":"language.TOEFL.score","cmp":">=","value":79}]}
+type Rule struct {
+	Op    string          `json:"op,omitempty"`
+	Rules []Rule          `json:"rules,omitempty"`
+	Field string          `json:"field,omitempty"`
+	Cmp   string          `json:"cmp,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+	Label string          `json:"label,omitempty"`
+}
+
+// RuleResult explains how a rule node was evaluated.
+type RuleResult struct {
+	Label    string       `json:"label"`
+	Op       string       `json:"op,omitempty"`
+	Field    string       `json:"field,omitempty"`
+	Expected string       `json:"expected,omitempty"`
+	Actual   string       `json:"actual,omitempty"`
+	Passed   bool         `json:"passed"`
+	Reason   string       `json:"reason"`
+	Children []RuleResult `json:"children,omitempty"`
+}
+
+// Rule group operators.
+const (
+	RuleOpAnd = "and"
+	RuleOpOr  = "or"
+
+	maxRuleDepth = 10
+)
+
+// Types of profile fields.
+const (
+	FieldTypeNumber = "number"
+	FieldTypeString = "string"
+	FieldTypeBool   = "bool"
+)
+
+var comparatorsByType = map[string][]string{
+	FieldTypeNumber: {">=", ">", "<=", "<", "==", "!=", "in", "not_in", "exists"},
+	FieldTypeString: {"==", "!=", "in", "not_in", "exists"},
+	FieldTypeBool:   {"==", "exists"},
+}
+
+// RuleComparators lists the comparators each field type supports.
+func RuleComparators() map[string][]string {
+	out := make(map[string][]string, len(comparatorsByType))
+	for t, cmps := range comparatorsByType {
+		out[t] = append([]string(nil), cmps...)
+	}
+	return out
+}
+
+// RuleField describes a profile field that rules may reference.
+type RuleField struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Label string `json:"label"`
+}
+
+// ruleFields are the fixed profile fields; language.<TEST>.score is resolved dynamically.
+// The subject GPA and GED fields are added from GPASubjects and GEDSubjects.
+var ruleFields = withSubjectFields(map[string]RuleField{
+	"academic.gpax":             {Type: FieldTypeNumber, Label: "GPAX"},
+	"academic.gpax_semesters":   {Type: FieldTypeNumber, Label: "GPAX semesters"},
+	"academic.gpa_total_score":  {Type: FieldTypeNumber, Label: "GPA total score"},
+	"education.level":           {Type: FieldTypeString, Label: "Education level"},
+	"education.level_id":        {Type: FieldTypeNumber, Label: "Education level"},
+	"education.curriculum_type": {Type: FieldTypeString, Label: "School curriculum"},
+	"education.curriculum_type_id": {
+		Type: FieldTypeNumber, Label: "School curriculum",
+	},
+	"education.school_type":      {Type: FieldTypeString, Label: "School type"},
+	"education.is_project_based": {Type: FieldTypeBool, Label: "Project-based school"},
+})
+
+func withSubjectFields(fields map[string]RuleField) map[string]RuleField {
+	for _, s := range GPASubjects {
+		fields["academic.gpa_"+s.Name] = RuleField{Type: FieldTypeNumber, Label: "GPA " + s.Name}
+	}
+	for _, s := range GEDSubjects {
+		fields["ged."+s.Name+"_score"] = RuleField{Type: FieldTypeNumber, Label: "GED " + s.Name}
+	}
+	return fields
+}
+
+// LookupRuleField returns the definition of a field name.
+func LookupRuleField(name string) (RuleField, bool) {
+	if f, ok := ruleFields[name]; ok {
+		f.Name = name
+		return f, true
+	}
+	if testType, ok := languageField(name); ok {
+		return RuleField{Name: name, Type: FieldTypeNumber, Label: testType}, true
+	}
+	return RuleField{}, false
+}
+
+// RuleFields lists the fixed fields for the rule editor, sorted by name.
+func RuleFields() []RuleField {
+	fields := make([]RuleField, 0, len(ruleFields)+1)
+	for name := range ruleFields {
+		f, _ := LookupRuleField(name)
+		fields = append(fields, f)
+	}
+	fields = append(fields, RuleField{Name: "language.<TEST_TYPE>.score", Type: FieldTypeNumber, Label: "Language test score"})
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+// languageField parses "language.<TEST>.score" and returns the upper-cased test type.
+func languageField(name string) (string, bool) {
+	parts := strings.Split(name, ".")
+	if len(parts) != 3 || parts[0] != "language" || parts[2] != "score" || strings.TrimSpace(parts[1]) == "" {
+		return "", false
+	}
+	return strings.ToUpper(parts[1]), true
+}
+
+// FactValue is a resolved profile field.
+type FactValue struct {
+	Num     float64
+	Str     string
+	Bool    bool
+	Display string
+}
+
+// RuleFacts resolves profile fields. When a field has no value, found is false and
+// missing explains why.
+type RuleFacts interface {
+	Fact(field RuleField) (value FactValue, found bool, missing string)
+}
+
+// ParseRules decodes and validates a stored rule tree. Empty input means no rules.
+func ParseRules(raw []byte) (*Rule, error) {
+	if len(strings.TrimSpace(string(raw))) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var rule Rule
+	if err := json.Unmarshal(raw, &rule); err != nil {
+		return nil, fmt.Errorf("invalid rule JSON: %w", err)
+	}
+	if err := ValidateRule(&rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ValidateRule checks operators, field names, comparators and operand types of a tree.
+func ValidateRule(rule *Rule) error {
+	return validateRule(rule, "rule", 1)
+}
+
+func validateRule(rule *Rule, path string, depth int) error {
+	if depth > maxRuleDepth {
+		return fmt.Errorf("%s: rules are nested too deeply (max %d)", path, maxRuleDepth)
+	}
+
+	if rule.Op != "" {
+		if rule.Op != RuleOpAnd && rule.Op != RuleOpOr {
+			return fmt.Errorf("%s: op must be and or or", path)
+		}
+		if rule.Field != "" {
+			return fmt.Errorf("%s: a group cannot have a field", path)
+		}
+		if len(rule.Rules) == 0 {
+			return fmt.Errorf("%s: group needs at least one rule", path)
+		}
+		for i := range rule.Rules {
+			if err := validateRule(&rule.Rules[i], fmt.Sprintf("%s.rules[%d]", path, i), depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	field, ok := LookupRuleField(rule.Field)
+	if !ok {
+		return fmt.Errorf("%s: unknown field %q", path, rule.Field)
+	}
+	if !containsString(comparatorsByType[field.Type], rule.Cmp) {
+		return fmt.Errorf("%s: %s field %s does not support %q", path, field.Type, rule.Field, rule.Cmp)
+	}
+	if rule.Cmp == "exists" {
+		return nil
+	}
+	if _, err := decodeOperand(rule.Value, field.Type, rule.Cmp); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// operand is a decoded rule value.
+type operand struct {
+	nums []float64
+	strs []string
+	b    bool
+}
+
+func decodeOperand(raw json.RawMessage, fieldType, cmp string) (operand, error) {
+	var op operand
+	list := cmp == "in" || cmp == "not_in"
+	if len(raw) == 0 {
+		return op, fmt.Errorf("value is required")
+	}
+
+	switch fieldType {
+	case FieldTypeNumber:
+		if list {
+			if err := json.Unmarshal(raw, &op.nums); err != nil || len(op.nums) == 0 {
+				return op, fmt.Errorf("value must be a non-empty list of numbers")
+			}
+			return op, nil
+		}
+		var n float64
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return op, fmt.Errorf("value must be a number")
+		}
+		op.nums = []float64{n}
+	case FieldTypeString:
+		if list {
+			if err := json.Unmarshal(raw, &op.strs); err != nil || len(op.strs) == 0 {
+				return op, fmt.Errorf("value must be a non-empty list of strings")
+			}
+			return op, nil
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return op, fmt.Errorf("value must be a string")
+		}
+		op.strs = []string{s}
+	case FieldTypeBool:
+		if err := json.Unmarshal(raw, &op.b); err != nil {
+			return op, fmt.Errorf("value must be true or false")
+		}
+	}
+	return op, nil
+}
+
+// EvaluateRule evaluates a (validated) rule tree against the facts.
+func EvaluateRule(rule *Rule, facts RuleFacts) RuleResult {
+	if rule.Op != "" {
+		return evaluateGroup(rule, facts)
+	}
+	return evaluateLeaf(rule, facts)
+}
+
+func evaluateGroup(rule *Rule, facts RuleFacts) RuleResult {
+	res := RuleResult{Label: rule.Label, Op: rule.Op}
+	passed, failedLabels := 0, []string{}
+	for i := range rule.Rules {
+		child := EvaluateRule(&rule.Rules[i], facts)
+		res.Children = append(res.Children, child)
+		if child.Passed {
+			passed++
+		} else {
+			failedLabels = append(failedLabels, child.Label)
+		}
+	}
+
+	if res.Label == "" {
+		res.Label = strings.ToUpper(rule.Op) + " group"
+	}
+	if rule.Op == RuleOpOr {
+		res.Passed = passed > 0
+		if res.Passed {
+			res.Reason = fmt.Sprintf("%d of %d alternatives met", passed, len(rule.Rules))
+		} else {
+			res.Reason = "none of " + strings.Join(failedLabels, ", ") + " met"
+		}
+		return res
+	}
+
+	res.Passed = passed == len(rule.Rules)
+	if res.Passed {
+		res.Reason = fmt.Sprintf("all %d conditions met", len(rule.Rules))
+	} else {
+		res.Reason = "not met: " + strings.Join(failedLabels, ", ")
+	}
+	return res
+}
+
+func evaluateLeaf(rule *Rule, facts RuleFacts) RuleResult {
+	res := RuleResult{Label: rule.Label, Field: rule.Field}
+
+	field, ok := LookupRuleField(rule.Field)
+	if !ok {
+		res.Label = rule.Field
+		res.Reason = "unknown field " + rule.Field
+		return res
+	}
+	if res.Label == "" {
+		res.Label = field.Label
+	}
+
+	value, found, missing := facts.Fact(field)
+	if rule.Cmp == "exists" {
+		res.Expected = "recorded"
+		res.Passed = found
+		if found {
+			res.Actual = value.Display
+			res.Reason = res.Label + " is recorded"
+		} else {
+			res.Reason = missing
+		}
+		return res
+	}
+
+	op, err := decodeOperand(rule.Value, field.Type, rule.Cmp)
+	if err != nil {
+		res.Reason = err.Error()
+		return res
+	}
+	res.Expected = rule.Cmp + " " + formatOperand(op, field.Type, rule.Cmp)
+	if !found {
+		res.Reason = missing
+		return res
+	}
+
+	res.Actual = value.Display
+	res.Passed = compareFact(value, op, field.Type, rule.Cmp)
+	if res.Passed {
+		res.Reason = fmt.Sprintf("%s %s meets %s", res.Label, res.Actual, res.Expected)
+	} else {
+		res.Reason = fmt.Sprintf("%s %s does not meet %s", res.Label, res.Actual, res.Expected)
+	}
+	return res
+}
+
+func compareFact(v FactValue, op operand, fieldType, cmp string) bool {
+	switch fieldType {
+	case FieldTypeNumber:
+		switch cmp {
+		case ">=":
+			return v.Num >= op.nums[0]
+		case ">":
+			return v.Num > op.nums[0]
+		case "<=":
+			return v.Num <= op.nums[0]
+		case "<":
+			return v.Num < op.nums[0]
+		case "==":
+			return v.Num == op.nums[0]
+		case "!=":
+			return v.Num != op.nums[0]
+		case "in", "not_in":
+			in := false
+			for _, n := range op.nums {
+				if v.Num == n {
+					in = true
+				}
+			}
+			return in == (cmp == "in")
+		}
+	case FieldTypeString:
+		switch cmp {
+		case "==":
+			return strings.EqualFold(v.Str, op.strs[0])
+		case "!=":
+			return !strings.EqualFold(v.Str, op.strs[0])
+		case "in", "not_in":
+			in := false
+			for _, s := range op.strs {
+				if strings.EqualFold(v.Str, s) {
+					in = true
+				}
+			}
+			return in == (cmp == "in")
+		}
+	case FieldTypeBool:
+		return v.Bool == op.b
+	}
+	return false
+}
+
+func formatOperand(op operand, fieldType, cmp string) string {
+	list := cmp == "in" || cmp == "not_in"
+	switch fieldType {
+	case FieldTypeNumber:
+		parts := make([]string, len(op.nums))
+		for i, n := range op.nums {
+			parts[i] = formatScore(n)
+		}
+		if list {
+			return "[" + strings.Join(parts, ", ") + "]"
+		}
+		return parts[0]
+	case FieldTypeString:
+		if list {
+			return "[" + strings.Join(op.strs, ", ") + "]"
+		}
+		return op.strs[0]
+	}
+	return strconv.FormatBool(op.b)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// SampleFacts is a flat profile used by the rule test harness, e.g.
+// {"academic.gpax": 3.2, "language.IELTS.score": 6.5}.
+type SampleFacts map[string]interface{}
+
+// Fact implements RuleFacts.
+func (s SampleFacts) Fact(field RuleField) (FactValue, bool, string) {
+	raw, ok := s[field.Name]
+	if !ok {
+		// test types are matched case-insensitively, like real profiles
+		for name, v := range s {
+			if strings.EqualFold(name, field.Name) {
+				raw, ok = v, true
+				break
+			}
+		}
+	}
+	if !ok || raw == nil {
+		return FactValue{}, false, field.Label + " is not in the sample profile"
+	}
+
+	switch v := raw.(type) {
+	case float64:
+		return FactValue{Num: v, Str: formatScore(v), Display: formatScore(v)}, true, ""
+	case int:
+		return FactValue{Num: float64(v), Str: strconv.Itoa(v), Display: strconv.Itoa(v)}, true, ""
+	case bool:
+		return FactValue{Bool: v, Display: strconv.FormatBool(v)}, true, ""
+	case string:
+		if field.Type == FieldTypeNumber {
+			n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return FactValue{}, false, field.Label + " is not a number in the sample profile"
+			}
+			return FactValue{Num: n, Str: v, Display: v}, true, ""
+		}
+		return FactValue{Str: v, Display: v}, true, ""
+	}
+	return FactValue{}, false, field.Label + " has an unsupported value in the sample profile"
+}
+
+// profileFacts resolves rule fields from a loaded StudentProfile.
+type profileFacts struct {
+	p *StudentProfile
+}
+
+// ProfileFacts returns the RuleFacts of a student's profile.
+func ProfileFacts(p *StudentProfile) RuleFacts {
+	return profileFacts{p: p}
+}
+
+// Fact implements RuleFacts.
+func (f profileFacts) Fact(field RuleField) (FactValue, bool, string) {
+	p := f.p
+	if testType, ok := languageField(field.Name); ok {
+		best, found := bestLanguageScore(p.Languages, testType)
+		if !found {
+			return FactValue{}, false, "no " + testType + " score on the profile"
+		}
+		return numberFact(best), true, ""
+	}
+
+	section := strings.SplitN(field.Name, ".", 2)[0]
+	switch section {
+	case "academic":
+		if p.Academic == nil {
+			return FactValue{}, false, "academic scores have not been recorded"
+		}
+		a := p.Academic
+		switch field.Name {
+		case "academic.gpax":
+			return numberFact(a.GPAX), true, ""
+		case "academic.gpax_semesters":
+			return numberFact(float64(a.GPAXSemesters)), true, ""
+		case "academic.gpa_total_score":
+			return numberFact(a.GPATotalScore), true, ""
+		}
+		v, _ := subjectGPA(a, strings.TrimPrefix(field.Name, "academic.gpa_"))
+		return numberFact(v), true, ""
+
+	case "ged":
+		if p.GED == nil {
+			return FactValue{}, false, "GED scores have not been recorded"
+		}
+		v, _ := gedScore(p.GED, strings.TrimSuffix(strings.TrimPrefix(field.Name, "ged."), "_score"))
+		return numberFact(float64(v)), true, ""
+
+	case "education":
+		e := p.Education
+		if e == nil {
+			return FactValue{}, false, "education has not been recorded"
+		}
+		switch field.Name {
+		case "education.level_id":
+			return numberFact(float64(e.EducationLevelID)), true, ""
+		case "education.level":
+			if e.EducationLevel == nil {
+				return FactValue{}, false, "education level has not been recorded"
+			}
+			return stringFact(e.EducationLevel.Name), true, ""
+		case "education.curriculum_type_id":
+			if e.CurriculumTypeID == nil {
+				return FactValue{}, false, "school curriculum type has not been recorded"
+			}
+			return numberFact(float64(*e.CurriculumTypeID)), true, ""
+		case "education.curriculum_type":
+			if e.CurriculumType == nil {
+				return FactValue{}, false, "school curriculum type has not been recorded"
+			}
+			return stringFact(e.CurriculumType.Name), true, ""
+		case "education.school_type":
+			if e.SchoolType == nil {
+				return FactValue{}, false, "school type has not been recorded"
+			}
+			return stringFact(e.SchoolType.Name), true, ""
+		case "education.is_project_based":
+			if e.IsProjectBased == nil {
+				return FactValue{}, false, "project-based school has not been recorded"
+			}
+			return FactValue{Bool: *e.IsProjectBased, Display: strconv.FormatBool(*e.IsProjectBased)}, true, ""
+		}
+	}
+	return FactValue{}, false, "unknown field " + field.Name
+}
+
+func numberFact(v float64) FactValue {
+	return FactValue{Num: v, Str: formatScore(v), Display: formatScore(v)}
+}
+
+func stringFact(s string) FactValue {
+	return FactValue{Str: s, Display: s}
+}
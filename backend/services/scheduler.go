@@ -1,8 +1,8 @@
 package services
 
 import (
+	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/sut68/team14/backend/config"
@@ -17,10 +17,32 @@ func StartNotificationScheduler() {
 	go func() {
 		for range ticker.C {
 			CheckApplicationDeadlines()
+			deliverDueNotifications()
+			deliverDueEmails()
 		}
 	}()
 }
 
+// deliverDueNotifications ส่งแจ้งเตือนที่ถึงเวลาส่งแล้วผ่าน email/webhook
+func deliverDueNotifications() {
+	if Dispatcher == nil {
+		return
+	}
+	if _, err := Dispatcher.DeliverDue(context.Background(), time.Now()); err != nil {
+		fmt.Println("Notification delivery error:", err)
+	}
+}
+
+// deliverDueEmails ส่งอีเมลในคิวที่ถึงเวลาส่งแล้ว (รวมที่ส่งไม่สำเร็จรอบก่อน)
+func deliverDueEmails() {
+	if Emails == nil {
+		return
+	}
+	if _, err := Emails.DeliverDue(context.Background(), time.Now()); err != nil {
+		fmt.Println("Email delivery error:", err)
+	}
+}
+
 // CheckApplicationDeadlines ฟังก์ชันหลักสำหรับตรวจสอบเวลา
 func CheckApplicationDeadlines() {
 	db := config.GetDB()
@@ -28,12 +50,13 @@ func CheckApplicationDeadlines() {
 
 	// ✅ 1. Preload Curriculum และ Program เพื่อเอาชื่อสาขามาแสดง
 	// ดึงเฉพาะคนที่เปิดการแจ้งเตือนไว้ (is_notified = true)
-	if err := db.Preload("Curriculum").Preload("Curriculum.Program").Where("is_notified = ?", true).Find(&selections).Error; err != nil {
+	if err := db.Preload("Curriculum").Preload("Curriculum.Rounds").Preload("Curriculum.Program").Where("is_notified = ?", true).Find(&selections).Error; err != nil {
 		fmt.Println("Scheduler Error:", err)
 		return
 	}
 
 	now := time.Now()
+	var emails []EmailEvent
 
 	for _, s := range selections {
 		// เช็คความสมบูรณ์ของข้อมูล (กัน Nil Pointer Exception)
@@ -41,35 +64,19 @@ func CheckApplicationDeadlines() {
 			continue
 		}
 
-		// 2. แกะวันที่จาก ApplicationPeriod ("Start|End")
-		rawPeriod := s.Curriculum.ApplicationPeriod
-		parts := strings.Split(rawPeriod, "|")
-		if len(parts) < 2 {
+		// 2. หารอบรับสมัครที่เปิดอยู่ (ใช้ตัวคำนวณเดียวกับสถานะหลักสูตร)
+		round := CurrentRound(s.Curriculum, now)
+		if round == nil || round.StatusAt(now) != entity.RoundStatusOpen {
 			continue
 		}
+		endDate := round.ClosesAt
 
-		dateStr := strings.TrimSpace(parts[1])
-		var endDate time.Time
-		var err error
-
-		// 3. แปลงวันที่ (Parse Date) ให้รองรับ Format ของคุณและเวลาไทย
-		// ลองแบบไม่มีวินาที (Format ทั่วไปใน DB ของคุณ)
-		endDate, err = time.ParseInLocation("2006-01-02T15:04", dateStr, time.Local)
-		if err != nil {
-			// ลองแบบมีวินาที (เผื่อบางอันมี)
-			endDate, err = time.ParseInLocation("2006-01-02T15:04:05", dateStr, time.Local)
-			if err != nil {
-				// ลองแบบมาตรฐาน RFC3339
-				endDate, err = time.Parse(time.RFC3339, dateStr)
-			}
-		}
-
-		// ถ้าแปลงวันที่ไม่ได้ ให้ข้ามไป
-		if err != nil {
-			continue
+		// ✅ อีเมลเตือนล่วงหน้า 1 วัน (ส่งครั้งเดียวต่อรอบ)
+		if left := endDate.Sub(now); left > 0 && left <= DeadlineEmailLead {
+			emails = append(emails, deadlineEmail(s, round))
 		}
 
-		// 4. คำนวณเวลาที่เหลือ
+		// 3. คำนวณเวลาที่เหลือ
 		timeLeft := endDate.Sub(now)
 		minutesLeft := int(timeLeft.Minutes())
 		secondsLeft := int(timeLeft.Seconds()) % 60
@@ -83,7 +90,7 @@ func CheckApplicationDeadlines() {
 			continue
 		}
 
-		// ✅ 5. เตรียมข้อความแจ้งเตือน (ใช้ชื่อสาขาวิชา)
+		// ✅ 4. เตรียมข้อความแจ้งเตือน (ใช้ชื่อสาขาวิชา)
 		displayName := "ไม่ระบุสาขา"
 		if s.Curriculum.Program != nil {
 			displayName = s.Curriculum.Program.Name
@@ -104,7 +111,7 @@ func CheckApplicationDeadlines() {
 			message = fmt.Sprintf("ด่วนที่สุด! สาขา '%s' เหลือเวลาอีก 1 นาทีสุดท้าย", displayName)
 		}
 
-		// 6. บันทึกลงฐานข้อมูล (ถ้าเข้าเงื่อนไข)
+		// 5. บันทึกลงฐานข้อมูล (ถ้าเข้าเงื่อนไข)
 		if message != "" {
 			// Double Check: เช็คว่าเคยแจ้งเตือนข้อความเดิมไปหรือยังภายใน 2 นาทีที่ผ่านมา
 			// (กันพลาดกรณี Scheduler รันซ้ำ หรือ Restart Server)
@@ -117,15 +124,16 @@ func CheckApplicationDeadlines() {
 			if count == 0 {
 				noti := entity.Notification{
 					Notification_Title:   title,
-					Notification_Type:    "Reminder",
+					Notification_Type:    entity.NotificationTypeDeadlineReminder,
 					Notification_Message: message,
 					Is_Read:              false,
 					Sent_At:              now,
 					Created_At:           now,
 					UserID:               &s.UserID,
+					Group_Key:            entity.NotificationGroupKey("curriculum", s.CurriculumID),
 				}
 
-				if err := db.Create(&noti).Error; err == nil {
+				if err := CreateNotifications(db, []entity.Notification{noti}, now); err == nil {
 					fmt.Printf("✅ Sent Notification to User %d: %s\n", s.UserID, message)
 				} else {
 					fmt.Println("❌ Error creating notification:", err)
@@ -133,4 +141,8 @@ func CheckApplicationDeadlines() {
 			}
 		}
 	}
+
+	if err := QueueEmails(db, emails, now); err != nil {
+		fmt.Println("❌ Error queueing deadline emails:", err)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// Admission decisions written to the export.
+const (
+	DecisionAdmitted   = "admitted"
+	DecisionWaitlisted = "waitlisted"
+	DecisionRejected   = "rejected"
+	DecisionPending    = "pending"
+)
+
+// exportBatchSize is how many applicants are loaded per query while streaming an export.
+const exportBatchSize = 500
+
+// exportApplicationStatuses are the application states that appear in an export.
+// Drafts and withdrawn applications are not part of the round.
+var exportApplicationStatuses = []string{
+	entity.ApplicationStatusSubmitted,
+	entity.ApplicationStatusUnderReview,
+	entity.ApplicationStatusAccepted,
+	entity.ApplicationStatusRejected,
+}
+
+// ScorecardExportRow is one applicant of a curriculum round with their scores.
+type ScorecardExportRow struct {
+	Rank              int
+	UserID            uint
+	FirstNameTH       string
+	LastNameTH        string
+	FirstNameEN       string
+	LastNameEN        string
+	Email             string
+	GPAX              *float64
+	ApplicationID     uint
+	ApplicationStatus string
+	SubmissionID      *uint
+	SubmissionStatus  string
+	ScorecardID       *uint
+	TotalScore        *float64
+	MaxScore          *float64
+	CriteriaScores    map[string]float64
+	Decision          string
+}
+
+// ExportColumn is one column of the scorecard export. Value returns the cell typed for
+// spreadsheets (nil = empty), so numbers stay sortable in XLSX; CSV gets the same cell as text.
+type ExportColumn struct {
+	Header string
+	Value  func(row *ScorecardExportRow) interface{}
+}
+
+// ScorecardExportColumns are the columns of the export, with one column per criterion
+// between the application status and the totals.
+func ScorecardExportColumns(criteria []string) []ExportColumn {
+	columns := []ExportColumn{
+		{"rank", func(r *ScorecardExportRow) interface{} { return nonZeroInt(r.Rank) }},
+		{"application_id", func(r *ScorecardExportRow) interface{} { return r.ApplicationID }},
+		{"user_id", func(r *ScorecardExportRow) interface{} { return r.UserID }},
+		{"first_name_th", func(r *ScorecardExportRow) interface{} { return r.FirstNameTH }},
+		{"last_name_th", func(r *ScorecardExportRow) interface{} { return r.LastNameTH }},
+		{"first_name_en", func(r *ScorecardExportRow) interface{} { return r.FirstNameEN }},
+		{"last_name_en", func(r *ScorecardExportRow) interface{} { return r.LastNameEN }},
+		{"email", func(r *ScorecardExportRow) interface{} { return r.Email }},
+		{"gpax", func(r *ScorecardExportRow) interface{} { return floatOrNil(r.GPAX) }},
+		{"application_status", func(r *ScorecardExportRow) interface{} { return r.ApplicationStatus }},
+	}
+	for _, name := range criteria {
+		name := name
+		columns = append(columns, ExportColumn{name, func(r *ScorecardExportRow) interface{} {
+			if score, ok := r.CriteriaScores[name]; ok {
+				return score
+			}
+			return nil
+		}})
+	}
+	return append(columns,
+		ExportColumn{"total_score", func(r *ScorecardExportRow) interface{} { return floatOrNil(r.TotalScore) }},
+		ExportColumn{"max_score", func(r *ScorecardExportRow) interface{} { return floatOrNil(r.MaxScore) }},
+		ExportColumn{"decision", func(r *ScorecardExportRow) interface{} { return r.Decision }},
+	)
+}
+
+// ExportHeader returns the header row of the columns.
+func ExportHeader(columns []ExportColumn) []string {
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = SpreadsheetSafe(col.Header)
+	}
+	return header
+}
+
+// ExportValues returns the typed cells of a row. Text cells are made safe with SpreadsheetSafe.
+func ExportValues(columns []ExportColumn, row *ScorecardExportRow) []interface{} {
+	values := make([]interface{}, len(columns))
+	for i, col := range columns {
+		v := col.Value(row)
+		if s, ok := v.(string); ok {
+			v = SpreadsheetSafe(s)
+		}
+		values[i] = v
+	}
+	return values
+}
+
+// ExportRecord returns the cells of a row as CSV text; scores are written with two decimals.
+func ExportRecord(columns []ExportColumn, row *ScorecardExportRow) []string {
+	values := ExportValues(columns, row)
+	record := make([]string, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case nil:
+		case float64:
+			record[i] = fmt.Sprintf("%.2f", v)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return record
+}
+
+// SpreadsheetSafe prefixes text that Excel or Sheets would run as a formula (=, +, -, @, tab
+// or CR first) with a quote, so a name such as "=HYPERLINK(...)" is shown as typed.
+func SpreadsheetSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func nonZeroInt(v int) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
+
+func floatOrNil(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+// AdmissionRanker assigns competition ranks ("1224") to applicants ordered by
+// total score and marks the ones inside the quota as admitted. Applicants tied
+// on the last admitted rank are all admitted.
+type AdmissionRanker struct {
+	Quota int
+
+	position  int
+	rank      int
+	lastScore float64
+}
+
+// Next returns the rank and decision of the next applicant in score order.
+// Unscored and rejected applicants do not consume a rank.
+func (r *AdmissionRanker) Next(total *float64, rejected bool) (int, string) {
+	if rejected {
+		return 0, DecisionRejected
+	}
+	if total == nil {
+		return 0, DecisionPending
+	}
+
+	r.position++
+	if r.position == 1 || *total != r.lastScore {
+		r.rank = r.position
+		r.lastScore = *total
+	}
+
+	if r.Quota > 0 && r.rank <= r.Quota {
+		return r.rank, DecisionAdmitted
+	}
+	return r.rank, DecisionWaitlisted
+}
+
+// ListExportCriteria returns the distinct rubric criteria names used by scorecards
+// of the curriculum's applicants, in rubric order. They become the per-criterion columns.
+func ListExportCriteria(db *gorm.DB, curriculumID uint) ([]string, error) {
+	var names []string
+	err := db.Table("score_criteria").
+		Select("score_criteria.criteria_name").
+		Joins("JOIN scorecards ON scorecards.id = score_criteria.scorecard_id AND scorecards.deleted_at IS NULL").
+		Joins("JOIN portfolio_submissions ON portfolio_submissions.id = scorecards.portfolio_submission_id AND portfolio_submissions.deleted_at IS NULL").
+		Joins("JOIN applications ON applications.portfolio_submission_id = portfolio_submissions.id AND applications.deleted_at IS NULL").
+		Where("applications.curriculum_id = ? AND applications.status IN ? AND score_criteria.deleted_at IS NULL", curriculumID, exportApplicationStatuses).
+		Group("score_criteria.criteria_name").
+		Order("MIN(score_criteria.order_index), score_criteria.criteria_name").
+		Pluck("score_criteria.criteria_name", &names).Error
+	return names, err
+}
+
+// StreamCurriculumScorecards walks the applicants of a curriculum ordered by total
+// score (then GPAX) in batches and calls fn for each row with its rank and decision.
+// Applicants are the submitted applications of the curriculum; each is represented by
+// the submission created for the application and its latest scorecard.
+func StreamCurriculumScorecards(db *gorm.DB, curriculum *entity.Curriculum, fn func(row ScorecardExportRow) error) error {
+	ranker := AdmissionRanker{Quota: curriculum.Quota}
+
+	for offset := 0; ; offset += exportBatchSize {
+		rows, err := loadExportBatch(db, curriculum.ID, offset, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		if err := attachCriteriaScores(db, rows); err != nil {
+			return err
+		}
+
+		for i := range rows {
+			rows[i].Rank, rows[i].Decision = ranker.Next(rows[i].TotalScore, rows[i].ApplicationStatus == entity.ApplicationStatusRejected)
+			if err := fn(rows[i]); err != nil {
+				return err
+			}
+		}
+
+		if len(rows) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+func loadExportBatch(db *gorm.DB, curriculumID uint, offset, limit int) ([]ScorecardExportRow, error) {
+	type record struct {
+		UserID            uint
+		FirstNameTH       string
+		LastNameTH        string
+		FirstNameEN       string
+		LastNameEN        string
+		Email             string
+		GPAX              sql.NullFloat64
+		ApplicationID     uint
+		ApplicationStatus string
+		SubmissionID      sql.NullInt64
+		SubmissionStatus  sql.NullString
+		ScorecardID       sql.NullInt64
+		TotalScore        sql.NullFloat64
+		MaxScore          sql.NullFloat64
+	}
+
+	var records []record
+	err := db.Table("applications").
+		Select(`users.id AS user_id, users.first_name_th, users.last_name_th, users.first_name_en, users.last_name_en, users.email,
+			academic_scores.gpax AS gpax,
+			applications.id AS application_id, applications.status AS application_status,
+			portfolio_submissions.id AS submission_id, portfolio_submissions.status AS submission_status,
+			scorecards.id AS scorecard_id, scorecards.total_score AS total_score, scorecards.max_score AS max_score`).
+		Joins("JOIN users ON users.id = applications.user_id AND users.deleted_at IS NULL").
+		Joins("LEFT JOIN academic_scores ON academic_scores.user_id = users.id AND academic_scores.deleted_at IS NULL").
+		Joins("LEFT JOIN portfolio_submissions ON portfolio_submissions.id = applications.portfolio_submission_id AND portfolio_submissions.deleted_at IS NULL").
+		Joins(`LEFT JOIN scorecards ON scorecards.id = (
+			SELECT MAX(sc.id) FROM scorecards sc
+			WHERE sc.portfolio_submission_id = portfolio_submissions.id AND sc.deleted_at IS NULL)`).
+		Where("applications.curriculum_id = ? AND applications.status IN ? AND applications.deleted_at IS NULL", curriculumID, exportApplicationStatuses).
+		Order("scorecards.total_score IS NULL, scorecards.total_score DESC, academic_scores.gpax IS NULL, academic_scores.gpax DESC, users.id ASC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&records).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]ScorecardExportRow, 0, len(records))
+	for _, rec := range records {
+		row := ScorecardExportRow{
+			UserID:            rec.UserID,
+			FirstNameTH:       rec.FirstNameTH,
+			LastNameTH:        rec.LastNameTH,
+			FirstNameEN:       rec.FirstNameEN,
+			LastNameEN:        rec.LastNameEN,
+			Email:             rec.Email,
+			ApplicationID:     rec.ApplicationID,
+			ApplicationStatus: rec.ApplicationStatus,
+			SubmissionStatus:  rec.SubmissionStatus.String,
+			CriteriaScores:    map[string]float64{},
+		}
+		if rec.GPAX.Valid {
+			row.GPAX = &rec.GPAX.Float64
+		}
+		if rec.SubmissionID.Valid {
+			id := uint(rec.SubmissionID.Int64)
+			row.SubmissionID = &id
+		}
+		if rec.ScorecardID.Valid {
+			id := uint(rec.ScorecardID.Int64)
+			row.ScorecardID = &id
+		}
+		if rec.TotalScore.Valid {
+			row.TotalScore = &rec.TotalScore.Float64
+		}
+		if rec.MaxScore.Valid {
+			row.MaxScore = &rec.MaxScore.Float64
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// attachCriteriaScores loads the rubric scores of all scorecards in the batch with one query.
+func attachCriteriaScores(db *gorm.DB, rows []ScorecardExportRow) error {
+	index := make(map[uint]*ScorecardExportRow)
+	ids := make([]uint, 0, len(rows))
+	for i := range rows {
+		if rows[i].ScorecardID != nil {
+			index[*rows[i].ScorecardID] = &rows[i]
+			ids = append(ids, *rows[i].ScorecardID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var criteria []entity.ScoreCriteria
+	if err := db.Where("scorecard_id IN ?", ids).Find(&criteria).Error; err != nil {
+		return err
+	}
+	for _, c := range criteria {
+		if row, ok := index[c.ScorecardID]; ok {
+			row.CriteriaScores[c.Criteria_Name] = c.Score
+		}
+	}
+	return nil
+}
@@ -0,0 +1,405 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// SkillMastery is the amount of evidence (sum of type weights) at which a skill counts
+// as fully demonstrated.
+const SkillMastery = 3.0
+
+// directSkillImportance is the importance given to skills attached straight to a
+// curriculum (CurriculumSkill), on the same 1-5 scale as CourseGroupSkill.Importance.
+const directSkillImportance = 5
+
+// SkillWeight is the share of one skill in a curriculum's skill profile. Weights of a
+// profile sum to 1.
+type SkillWeight struct {
+	SkillID     uint    `json:"skill_id"`
+	SkillNameTH string  `json:"skill_name_th"`
+	SkillNameEN string  `json:"skill_name_en"`
+	Weight      float64 `json:"weight"`
+}
+
+// StudentSkill is how much evidence of a skill a student's activities and workings show.
+type StudentSkill struct {
+	SkillID     uint    `json:"skill_id"`
+	SkillNameTH string  `json:"skill_name_th"`
+	SkillNameEN string  `json:"skill_name_en"`
+	Evidence    float64 `json:"evidence"`
+	// Level is Evidence / SkillMastery capped at 1.
+	Level      float64       `json:"level"`
+	Activities int           `json:"activities"`
+	Workings   int           `json:"workings"`
+	Sources    []SkillSource `json:"sources"`
+}
+
+// TypeSuggestion is an activity or working type that builds a skill.
+type TypeSuggestion struct {
+	Kind     string `json:"kind"` // activity | working
+	TypeID   uint   `json:"type_id"`
+	TypeName string `json:"type_name"`
+	Weight   int    `json:"weight"`
+}
+
+// SkillGap is a curriculum skill the student has not fully demonstrated.
+type SkillGap struct {
+	SkillID     uint             `json:"skill_id"`
+	SkillNameTH string           `json:"skill_name_th"`
+	SkillNameEN string           `json:"skill_name_en"`
+	Weight      float64          `json:"weight"`
+	Level       float64          `json:"level"`
+	Gap         float64          `json:"gap"`
+	Suggestions []TypeSuggestion `json:"suggestions"`
+}
+
+// CurriculumRecommendation is how well a student's skills match one curriculum.
+type CurriculumRecommendation struct {
+	CurriculumID uint   `json:"curriculum_id"`
+	Code         string `json:"code"`
+	Name         string `json:"name"`
+	// Score is the weighted skill coverage, 0-100.
+	Score         float64       `json:"score"`
+	Profile       []SkillWeight `json:"profile"`
+	MissingSkills []SkillGap    `json:"missing_skills"`
+}
+
+// SkillRecommendation is the full result for one student.
+type SkillRecommendation struct {
+	UserID           uint                       `json:"user_id"`
+	Skills           []StudentSkill             `json:"skills"`
+	Recommendations  []CurriculumRecommendation `json:"recommendations"`
+	TopMissingSkills []SkillGap                 `json:"top_missing_skills"`
+}
+
+// SkillTypeMaps holds the type → skill mappings used to infer skills of untagged entries
+// and to suggest default tags.
+type SkillTypeMaps struct {
+	Activity []entity.TypeActivitySkill
+	Working  []entity.TypeWorkingSkill
+}
+
+// CurriculumSkillProfile derives the weighted skill profile of a curriculum from its
+// course groups (CreditPercentage × Importance) and its directly attached skills.
+// The curriculum needs CourseGroups.CourseGroup.CourseGroupSkills.Skill and Skills.Skill preloaded.
+func CurriculumSkillProfile(c *entity.Curriculum) []SkillWeight {
+	weights := map[uint]*SkillWeight{}
+	add := func(skillID uint, skill *entity.Skill, w float64) {
+		if w <= 0 || skillID == 0 {
+			return
+		}
+		sw, ok := weights[skillID]
+		if !ok {
+			sw = &SkillWeight{SkillID: skillID}
+			if skill != nil {
+				sw.SkillNameTH, sw.SkillNameEN = skill.SkillNameTH, skill.SkillNameEN
+			}
+			weights[skillID] = sw
+		}
+		sw.Weight += w
+	}
+
+	// กลุ่มวิชาที่ยังไม่ได้กำหนด CreditPercentage นับน้ำหนักเท่ากันหมด
+	totalCredit := 0
+	for _, ccg := range c.CourseGroups {
+		totalCredit += ccg.CreditPercentage
+	}
+	avgGroup := 1.0
+	if totalCredit > 0 && len(c.CourseGroups) > 0 {
+		avgGroup = float64(totalCredit) / float64(len(c.CourseGroups))
+	}
+
+	for _, ccg := range c.CourseGroups {
+		if ccg.CourseGroup == nil {
+			continue
+		}
+		groupWeight := 1.0
+		if totalCredit > 0 {
+			groupWeight = float64(ccg.CreditPercentage)
+		}
+		for _, cgs := range ccg.CourseGroup.CourseGroupSkills {
+			importance := cgs.Importance
+			if importance <= 0 {
+				importance = 1
+			}
+			add(cgs.SkillID, cgs.Skill, groupWeight*float64(importance))
+		}
+	}
+	for _, cs := range c.Skills {
+		add(cs.SkillID, cs.Skill, avgGroup*directSkillImportance)
+	}
+
+	total := 0.0
+	for _, sw := range weights {
+		total += sw.Weight
+	}
+	profile := make([]SkillWeight, 0, len(weights))
+	for _, sw := range weights {
+		sw.Weight = round3(sw.Weight / total)
+		profile = append(profile, *sw)
+	}
+	sort.Slice(profile, func(i, j int) bool {
+		if profile[i].Weight != profile[j].Weight {
+			return profile[i].Weight > profile[j].Weight
+		}
+		return profile[i].SkillID < profile[j].SkillID
+	})
+	return profile
+}
+
+// SkillSource is one activity or working that counts as evidence of a skill.
+type SkillSource struct {
+	Kind     string  `json:"kind"` // activity | working
+	ID       uint    `json:"id"`
+	Name     string  `json:"name"`
+	Evidence float64 `json:"evidence"`
+	// Tagged is true when the student tagged the skill explicitly, false when it was
+	// inferred from the activity/working type.
+	Tagged bool `json:"tagged"`
+	Level  int  `json:"level,omitempty"`
+}
+
+// InferStudentSkills turns a student's activities and workings into skill evidence. Entries
+// the student tagged with skills count those tags (evidence = Level, or 1 when not given);
+// untagged entries fall back to the type mappings. Activities need ActivityDetail and
+// Skills, workings need WorkingDetail and Skills preloaded.
+func InferStudentSkills(activities []entity.Activity, workings []entity.Working, maps SkillTypeMaps) []StudentSkill {
+	skills := map[uint]*StudentSkill{}
+	add := func(skillID uint, skill *entity.Skill, src SkillSource) {
+		s, ok := skills[skillID]
+		if !ok {
+			s = &StudentSkill{SkillID: skillID, Sources: []SkillSource{}}
+			skills[skillID] = s
+		}
+		if s.SkillNameEN == "" && skill != nil {
+			s.SkillNameTH, s.SkillNameEN = skill.SkillNameTH, skill.SkillNameEN
+		}
+		s.Evidence += src.Evidence
+		if src.Kind == "activity" {
+			s.Activities++
+		} else {
+			s.Workings++
+		}
+		s.Sources = append(s.Sources, src)
+	}
+
+	for _, a := range activities {
+		if len(a.Skills) > 0 {
+			for _, tag := range a.Skills {
+				add(tag.SkillID, tag.Skill, SkillSource{Kind: "activity", ID: a.ID, Name: a.ActivityName, Evidence: float64(typeWeight(tag.Level)), Tagged: true, Level: tag.Level})
+			}
+			continue
+		}
+		if a.ActivityDetail == nil {
+			continue
+		}
+		for _, m := range maps.Activity {
+			if m.TypeActivityID == a.ActivityDetail.TypeActivityID {
+				add(m.SkillID, m.Skill, SkillSource{Kind: "activity", ID: a.ID, Name: a.ActivityName, Evidence: float64(typeWeight(m.Weight))})
+			}
+		}
+	}
+	for _, w := range workings {
+		if len(w.Skills) > 0 {
+			for _, tag := range w.Skills {
+				add(tag.SkillID, tag.Skill, SkillSource{Kind: "working", ID: w.ID, Name: w.WorkingName, Evidence: float64(typeWeight(tag.Level)), Tagged: true, Level: tag.Level})
+			}
+			continue
+		}
+		if w.WorkingDetail == nil {
+			continue
+		}
+		for _, m := range maps.Working {
+			if m.TypeWorkingID == w.WorkingDetail.TypeWorkingID {
+				add(m.SkillID, m.Skill, SkillSource{Kind: "working", ID: w.ID, Name: w.WorkingName, Evidence: float64(typeWeight(m.Weight))})
+			}
+		}
+	}
+
+	out := make([]StudentSkill, 0, len(skills))
+	for _, s := range skills {
+		s.Level = round3(math.Min(1, s.Evidence/SkillMastery))
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Evidence != out[j].Evidence {
+			return out[i].Evidence > out[j].Evidence
+		}
+		return out[i].SkillID < out[j].SkillID
+	})
+	return out
+}
+
+// RecommendCurricula ranks curricula by weighted skill coverage and lists, per curriculum and
+// overall (across the top `limit` curricula), the skills with the largest gap plus the
+// activity/working types that would build them. limit <= 0 returns every curriculum.
+func RecommendCurricula(curricula []entity.Curriculum, skills []StudentSkill, maps SkillTypeMaps, limit int) ([]CurriculumRecommendation, []SkillGap) {
+	levels := make(map[uint]float64, len(skills))
+	for _, s := range skills {
+		levels[s.SkillID] = s.Level
+	}
+	suggestions := typeSuggestions(maps)
+
+	recs := make([]CurriculumRecommendation, 0, len(curricula))
+	for i := range curricula {
+		c := &curricula[i]
+		profile := CurriculumSkillProfile(c)
+		if len(profile) == 0 {
+			continue
+		}
+		rec := CurriculumRecommendation{CurriculumID: c.ID, Code: c.Code, Name: c.Name, Profile: profile, MissingSkills: []SkillGap{}}
+		score := 0.0
+		for _, sw := range profile {
+			level := levels[sw.SkillID]
+			score += sw.Weight * level
+			if level < 1 {
+				rec.MissingSkills = append(rec.MissingSkills, SkillGap{
+					SkillID:     sw.SkillID,
+					SkillNameTH: sw.SkillNameTH,
+					SkillNameEN: sw.SkillNameEN,
+					Weight:      sw.Weight,
+					Level:       level,
+					Gap:         round3(sw.Weight * (1 - level)),
+					Suggestions: suggestions[sw.SkillID],
+				})
+			}
+		}
+		rec.Score = math.Round(score*1000) / 10
+		sortGaps(rec.MissingSkills)
+		if len(rec.MissingSkills) > 3 {
+			rec.MissingSkills = rec.MissingSkills[:3]
+		}
+		recs = append(recs, rec)
+	}
+
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].Score > recs[j].Score })
+	if limit > 0 && len(recs) > limit {
+		recs = recs[:limit]
+	}
+
+	// รวมช่องว่างของทักษะจากหลักสูตรที่แนะนำทั้งหมด
+	overall := map[uint]*SkillGap{}
+	for _, rec := range recs {
+		for _, sw := range rec.Profile {
+			level := levels[sw.SkillID]
+			if level >= 1 {
+				continue
+			}
+			g, ok := overall[sw.SkillID]
+			if !ok {
+				g = &SkillGap{SkillID: sw.SkillID, SkillNameTH: sw.SkillNameTH, SkillNameEN: sw.SkillNameEN, Level: level, Suggestions: suggestions[sw.SkillID]}
+				overall[sw.SkillID] = g
+			}
+			g.Weight += sw.Weight
+			g.Gap += sw.Weight * (1 - level)
+		}
+	}
+	top := make([]SkillGap, 0, len(overall))
+	for _, g := range overall {
+		g.Weight, g.Gap = round3(g.Weight), round3(g.Gap)
+		top = append(top, *g)
+	}
+	sortGaps(top)
+	if len(top) > 5 {
+		top = top[:5]
+	}
+	return recs, top
+}
+
+// LoadSkillTypeMaps loads every type → skill mapping with its skill.
+func LoadSkillTypeMaps(db *gorm.DB) (SkillTypeMaps, error) {
+	var maps SkillTypeMaps
+	if err := db.Preload("Skill").Preload("TypeActivity").Find(&maps.Activity).Error; err != nil {
+		return maps, err
+	}
+	if err := db.Preload("Skill").Preload("TypeWorking").Find(&maps.Working).Error; err != nil {
+		return maps, err
+	}
+	return maps, nil
+}
+
+// LoadStudentSkills infers the skills of one student from their activities and workings.
+func LoadStudentSkills(db *gorm.DB, userID uint, maps SkillTypeMaps) ([]StudentSkill, error) {
+	var activities []entity.Activity
+	if err := db.Preload("ActivityDetail").Preload("Skills.Skill").Where("user_id = ?", userID).Find(&activities).Error; err != nil {
+		return nil, err
+	}
+	var workings []entity.Working
+	if err := db.Preload("WorkingDetail").Preload("Skills.Skill").Where("user_id = ?", userID).Find(&workings).Error; err != nil {
+		return nil, err
+	}
+	return InferStudentSkills(activities, workings, maps), nil
+}
+
+// BuildSkillRecommendation loads everything needed and recommends the open curricula to a student.
+func BuildSkillRecommendation(db *gorm.DB, userID uint, limit int) (*SkillRecommendation, error) {
+	maps, err := LoadSkillTypeMaps(db)
+	if err != nil {
+		return nil, err
+	}
+	skills, err := LoadStudentSkills(db, userID, maps)
+	if err != nil {
+		return nil, err
+	}
+
+	var curricula []entity.Curriculum
+	if err := VisibleToStudents(db, time.Now()).
+		Preload("CourseGroups.CourseGroup.CourseGroupSkills.Skill").
+		Preload("Skills.Skill").
+		Find(&curricula).Error; err != nil {
+		return nil, err
+	}
+
+	recs, top := RecommendCurricula(curricula, skills, maps, limit)
+	return &SkillRecommendation{UserID: userID, Skills: skills, Recommendations: recs, TopMissingSkills: top}, nil
+}
+
+// ===================== Helpers =====================
+
+func typeSuggestions(maps SkillTypeMaps) map[uint][]TypeSuggestion {
+	out := map[uint][]TypeSuggestion{}
+	for _, m := range maps.Activity {
+		s := TypeSuggestion{Kind: "activity", TypeID: m.TypeActivityID, Weight: typeWeight(m.Weight)}
+		if m.TypeActivity != nil {
+			s.TypeName = m.TypeActivity.TypeName
+		}
+		out[m.SkillID] = append(out[m.SkillID], s)
+	}
+	for _, m := range maps.Working {
+		s := TypeSuggestion{Kind: "working", TypeID: m.TypeWorkingID, Weight: typeWeight(m.Weight)}
+		if m.TypeWorking != nil {
+			s.TypeName = m.TypeWorking.TypeName
+		}
+		out[m.SkillID] = append(out[m.SkillID], s)
+	}
+	for id := range out {
+		list := out[id]
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Weight > list[j].Weight })
+	}
+	return out
+}
+
+func sortGaps(gaps []SkillGap) {
+	sort.Slice(gaps, func(i, j int) bool {
+		if gaps[i].Gap != gaps[j].Gap {
+			return gaps[i].Gap > gaps[j].Gap
+		}
+		return gaps[i].SkillID < gaps[j].SkillID
+	})
+}
+
+func typeWeight(w int) int {
+	if w <= 0 {
+		return 1
+	}
+	return w
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
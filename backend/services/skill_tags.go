@@ -0,0 +1,93 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// SkillTagInput is one skill tag sent by the client.
+type SkillTagInput struct {
+	SkillID uint `json:"skill_id"`
+	Level   int  `json:"level"`
+}
+
+// NormalizeSkillTags validates tags and merges duplicates (keeping the highest level).
+func NormalizeSkillTags(db *gorm.DB, tags []SkillTagInput) ([]SkillTagInput, error) {
+	out := make([]SkillTagInput, 0, len(tags))
+	index := map[uint]int{}
+	for i, t := range tags {
+		if t.SkillID == 0 {
+			return nil, fmt.Errorf("skills[%d]: skill_id is required", i)
+		}
+		if t.Level < 0 || t.Level > 5 {
+			return nil, fmt.Errorf("skills[%d]: level must be between 0 and 5", i)
+		}
+		if j, ok := index[t.SkillID]; ok {
+			if t.Level > out[j].Level {
+				out[j].Level = t.Level
+			}
+			continue
+		}
+		index[t.SkillID] = len(out)
+		out = append(out, t)
+	}
+
+	if len(out) > 0 {
+		ids := make([]uint, 0, len(out))
+		for _, t := range out {
+			ids = append(ids, t.SkillID)
+		}
+		var count int64
+		if err := db.Model(&entity.Skill{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if int(count) != len(ids) {
+			return nil, fmt.Errorf("unknown skill_id in skills")
+		}
+	}
+	return out, nil
+}
+
+// ReplaceActivitySkills replaces all skill tags of an activity.
+func ReplaceActivitySkills(db *gorm.DB, activityID uint, tags []SkillTagInput) ([]entity.ActivitySkill, error) {
+	links := make([]entity.ActivitySkill, 0, len(tags))
+	for _, t := range tags {
+		links = append(links, entity.ActivitySkill{ActivityID: activityID, SkillID: t.SkillID, Level: t.Level})
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("activity_id = ?", activityID).Delete(&entity.ActivitySkill{}).Error; err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			return nil
+		}
+		return tx.Create(&links).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// ReplaceWorkingSkills replaces all skill tags of a working.
+func ReplaceWorkingSkills(db *gorm.DB, workingID uint, tags []SkillTagInput) ([]entity.WorkingSkill, error) {
+	links := make([]entity.WorkingSkill, 0, len(tags))
+	for _, t := range tags {
+		links = append(links, entity.WorkingSkill{WorkingID: workingID, SkillID: t.SkillID, Level: t.Level})
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("working_id = ?", workingID).Delete(&entity.WorkingSkill{}).Error; err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			return nil
+		}
+		return tx.Create(&links).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
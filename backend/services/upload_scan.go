@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// Upload purposes recorded in UploadScan.Purpose.
+const (
+	UploadPurposeGeneral                = "upload"
+	UploadPurposeAnnouncementImage      = "announcement_image"
+	UploadPurposeAnnouncementAttachment = "announcement_attachment"
+)
+
+// UploadMeta describes an upload for StoreScannedUpload.
+type UploadMeta struct {
+	FileName    string
+	ContentType string
+	Size        int64
+	Purpose     string
+	UserID      *uint
+}
+
+// StoreScannedUpload is the upload pipeline: the file is recorded as quarantined, scanned with
+// UploadScanner, and only put in the store once it is clean. Infected files and files that
+// could not be scanned are rejected (ErrFileInfected / ErrScanUnavailable) and their record
+// stays as the audit trail. The returned record has the stored file's URL.
+func StoreScannedUpload(ctx context.Context, db *gorm.DB, store FileStore, meta UploadMeta, file io.ReadSeeker) (*entity.UploadScan, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	scan := entity.UploadScan{
+		FileName:    meta.FileName,
+		ContentType: meta.ContentType,
+		Size:        meta.Size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		Purpose:     meta.Purpose,
+		Status:      entity.UploadScanQuarantined,
+		UserID:      meta.UserID,
+	}
+	if err := db.Create(&scan).Error; err != nil {
+		return nil, err
+	}
+
+	scanner := UploadScanner
+	if scanner == nil {
+		scan.Status = entity.UploadScanSkipped
+	} else {
+		result, err := scanner.Scan(ctx, meta.FileName, file)
+		now := time.Now()
+		scan.Scanner, scan.ScannedAt = scanner.Name(), &now
+		switch {
+		case err != nil:
+			scan.Status, scan.Detail = entity.UploadScanFailed, err.Error()
+		case !result.Clean:
+			scan.Status, scan.Threat = entity.UploadScanInfected, result.Threat
+		default:
+			scan.Status = entity.UploadScanClean
+		}
+	}
+	if err := db.Save(&scan).Error; err != nil {
+		return nil, err
+	}
+
+	switch scan.Status {
+	case entity.UploadScanInfected:
+		log.Printf("🦠 Upload %d rejected: %s in %q (user %v)", scan.ID, scan.Threat, scan.FileName, userIDForLog(scan.UserID))
+		return &scan, fmt.Errorf("%w: %s", ErrFileInfected, scan.Threat)
+	case entity.UploadScanFailed:
+		log.Printf("⚠️ Upload %d rejected: scan failed: %s", scan.ID, scan.Detail)
+		return &scan, ErrScanUnavailable
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return &scan, err
+	}
+	fileURL, err := store.UploadFile(file, meta.FileName, meta.ContentType)
+	if err != nil {
+		return &scan, fmt.Errorf("failed to upload file: %w", err)
+	}
+	scan.FileURL = fileURL
+	if err := db.Model(&scan).UpdateColumn("file_url", fileURL).Error; err != nil {
+		return &scan, err
+	}
+	return &scan, nil
+}
+
+// ErrUploadNotUsable is returned by FindUserUpload for uploads that are not the user's, or
+// that were not stored because of the virus scan.
+var ErrUploadNotUsable = errors.New("upload not found or not cleared by the virus scan")
+
+// FindUserUpload returns an upload of the user that can be attached to a record: it passed
+// the virus scan (or was stored while no scanner was configured) and has a file URL.
+func FindUserUpload(db *gorm.DB, uploadID, userID uint) (*entity.UploadScan, error) {
+	var scan entity.UploadScan
+	err := db.Where("id = ? AND user_id = ? AND status IN ? AND file_url <> ''",
+		uploadID, userID, []string{entity.UploadScanClean, entity.UploadScanSkipped}).
+		First(&scan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUploadNotUsable
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &scan, nil
+}
+
+func userIDForLog(id *uint) interface{} {
+	if id == nil {
+		return "anonymous"
+	}
+	return *id
+}
@@ -0,0 +1,404 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/sut68/team14/backend/entity"
+	"gorm.io/gorm"
+)
+
+// VAPIDKeys identify this server to push services (RFC 8292). The keys are base64url
+// encoded: the public key is the uncompressed P-256 point the browser subscribes with.
+type VAPIDKeys struct {
+	PublicKey string
+	Subject   string // mailto: หรือ https: ของผู้ดูแลระบบ
+	private   *ecdsa.PrivateKey
+}
+
+// GenerateVAPIDKeys creates a new key pair for VAPID_PUBLIC_KEY / VAPID_PRIVATE_KEY.
+func GenerateVAPIDKeys() (publicKey, privateKey string, err error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		base64.RawURLEncoding.EncodeToString(key.Bytes()), nil
+}
+
+// decodeBase64URL accepts base64url with or without padding, as browsers and key
+// generators differ.
+func decodeBase64URL(s string) ([]byte, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.URLEncoding.DecodeString(s)
+}
+
+// NewVAPIDKeys parses a base64url private key (32 bytes) and derives its public key.
+func NewVAPIDKeys(privateKey, subject string) (*VAPIDKeys, error) {
+	d, err := decodeBase64URL(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	key, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	if subject == "" {
+		return nil, errors.New("VAPID subject is required (mailto: or https: URL)")
+	}
+	pub := key.PublicKey().Bytes() // 0x04 || X || Y
+	return &VAPIDKeys{
+		PublicKey: base64.RawURLEncoding.EncodeToString(pub),
+		Subject:   subject,
+		private: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(pub[1:33]), Y: new(big.Int).SetBytes(pub[33:])},
+			D:         new(big.Int).SetBytes(d),
+		},
+	}, nil
+}
+
+// NewVAPIDKeysFromEnv reads VAPID_PRIVATE_KEY and VAPID_SUBJECT. VAPID_PUBLIC_KEY, when set,
+// must match the private key.
+func NewVAPIDKeysFromEnv() (*VAPIDKeys, error) {
+	private := os.Getenv("VAPID_PRIVATE_KEY")
+	if private == "" {
+		return nil, errors.New("VAPID_PRIVATE_KEY is not set")
+	}
+	keys, err := NewVAPIDKeys(private, os.Getenv("VAPID_SUBJECT"))
+	if err != nil {
+		return nil, err
+	}
+	if public := os.Getenv("VAPID_PUBLIC_KEY"); public != "" {
+		if b, err := decodeBase64URL(public); err != nil || base64.RawURLEncoding.EncodeToString(b) != keys.PublicKey {
+			return nil, errors.New("VAPID_PUBLIC_KEY does not match VAPID_PRIVATE_KEY")
+		}
+	}
+	return keys, nil
+}
+
+// authorization builds the "vapid t=<jwt>, k=<public key>" header for the push service that
+// hosts endpoint.
+func (k *VAPIDKeys) authorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(12 * time.Hour).Unix(),
+		"sub": k.Subject,
+	}).SignedString(k.private)
+	if err != nil {
+		return "", err
+	}
+	return "vapid t=" + token + ", k=" + k.PublicKey, nil
+}
+
+// webPushRecordSize is the rs field of the aes128gcm header; the payload fits in one record.
+const webPushRecordSize = 4096
+
+// MaxWebPushPayload is the largest payload push services must accept.
+const MaxWebPushPayload = 3993
+
+// EncryptWebPush encrypts payload for a subscription with the aes128gcm content coding
+// (RFC 8291 / RFC 8188). p256dh and auth are the subscription keys from the browser.
+func EncryptWebPush(payload []byte, p256dh, auth string) ([]byte, error) {
+	if len(payload) > MaxWebPushPayload {
+		return nil, fmt.Errorf("push payload is %d bytes, max %d", len(payload), MaxWebPushPayload)
+	}
+	uaPublicBytes, err := decodeBase64URL(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := decodeBase64URL(auth)
+	if err != nil || len(authSecret) != 16 {
+		return nil, errors.New("invalid auth secret")
+	}
+
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+	shared, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	// IKM = HKDF(auth_secret, ecdh_secret, "WebPush: info" || 0x00 || ua_public || as_public, 32)
+	keyInfo := append(append([]byte("WebPush: info\x00"), uaPublicBytes...), asPublic...)
+	ikm, err := hkdf.Key(sha256.New, shared, authSecret, string(keyInfo), 32)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// 0x02 = record สุดท้าย ไม่มี padding
+	sealed := gcm.Seal(nil, nonce, append(append([]byte{}, payload...), 0x02), nil)
+
+	var body bytes.Buffer
+	body.Write(salt)
+	binary.Write(&body, binary.BigEndian, uint32(webPushRecordSize))
+	body.WriteByte(byte(len(asPublic)))
+	body.Write(asPublic)
+	body.Write(sealed)
+	return body.Bytes(), nil
+}
+
+// ErrPushSubscriptionGone is returned when the push service no longer knows the
+// subscription (404/410); it should be deleted.
+var ErrPushSubscriptionGone = errors.New("push subscription has expired or was removed")
+
+// WebPushOptions are the push service headers for one message.
+type WebPushOptions struct {
+	TTL     time.Duration // เก็บไว้นานเท่าไรถ้าเครื่องออฟไลน์
+	Urgency string        // very-low | low | normal | high
+}
+
+// SendWebPush encrypts payload and posts it to the subscription's push service.
+func (k *VAPIDKeys) SendWebPush(ctx context.Context, client *http.Client, sub *entity.PushSubscription, payload []byte, opts WebPushOptions, now time.Time) error {
+	body, err := EncryptWebPush(payload, sub.P256dh, sub.Auth)
+	if err != nil {
+		return err
+	}
+	authorization, err := k.authorization(sub.Endpoint, now)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if opts.Urgency == "" {
+		opts.Urgency = "normal"
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(opts.TTL.Seconds())))
+	req.Header.Set("Urgency", opts.Urgency)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrPushSubscriptionGone
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("push service responded %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+	return nil
+}
+
+// -------------------- CHANNEL --------------------
+
+// WebPushChannel sends notifications to every browser the user subscribed with. Expired
+// subscriptions are deleted.
+type WebPushChannel struct {
+	DB     *gorm.DB
+	Keys   *VAPIDKeys
+	Client *http.Client
+}
+
+// NewWebPushChannel creates a channel that, like webhooks, refuses private addresses: the
+// endpoint comes from the browser.
+func NewWebPushChannel(db *gorm.DB, keys *VAPIDKeys) *WebPushChannel {
+	return &WebPushChannel{DB: db, Keys: keys, Client: newPublicHTTPClient()}
+}
+
+func (w *WebPushChannel) Name() string { return entity.NotificationChannelWebPush }
+
+// PushPayload is the JSON the service worker receives.
+type PushPayload struct {
+	Title          string `json:"title"`
+	Body           string `json:"body"`
+	URL            string `json:"url"`
+	Tag            string `json:"tag,omitempty"` // แจ้งเตือนกลุ่มเดียวกันแทนที่กันบนเครื่อง
+	Type           string `json:"type"`
+	NotificationID uint   `json:"notification_id"`
+}
+
+// pushURL is the page to open when the user clicks the notification, or the link in its email.
+func pushURL(user *entity.User, n *entity.Notification) string {
+	typ := entity.NormalizeNotificationType(n.Notification_Type)
+	switch {
+	case n.AnnouncementID != nil && user.AccountTypeID == entity.UserTypeStudent:
+		return fmt.Sprintf("/student/announcements/%d", *n.AnnouncementID)
+	case n.AnnouncementID != nil && user.AccountTypeID == entity.UserTypeTeacher:
+		return "/teacher/announcements"
+	case n.AnnouncementID != nil && user.AccountTypeID == entity.UserTypeAdmin:
+		return "/admin/announcements"
+	case typ == entity.NotificationTypeDeadlineReminder:
+		return "/student/curricula"
+	case typ == entity.NotificationTypeApplicationUpdate:
+		return "/student/portfolio"
+	}
+	return "/"
+}
+
+// pushOptions keeps deadline reminders short-lived: they are useless once the round closes.
+func pushOptions(typ string) WebPushOptions {
+	switch typ {
+	case entity.NotificationTypeDeadlineReminder:
+		return WebPushOptions{TTL: 10 * time.Minute, Urgency: "high"}
+	case entity.NotificationTypeAlert:
+		return WebPushOptions{TTL: 24 * time.Hour, Urgency: "high"}
+	}
+	return WebPushOptions{TTL: 24 * time.Hour, Urgency: "normal"}
+}
+
+func (w *WebPushChannel) Send(ctx context.Context, msg NotificationMessage) error {
+	var subs []entity.PushSubscription
+	if err := w.DB.Where("user_id = ?", msg.User.ID).Find(&subs).Error; err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return ErrNoRecipientAddress
+	}
+
+	n := msg.Notification
+	typ := entity.NormalizeNotificationType(n.Notification_Type)
+	tag := n.Group_Key
+	if tag != "" {
+		tag = typ + ":" + tag
+	}
+	payload, err := json.Marshal(PushPayload{
+		Title:          n.Notification_Title,
+		Body:           truncateRunes(n.Notification_Message, 500),
+		URL:            pushURL(msg.User, n),
+		Tag:            tag,
+		Type:           typ,
+		NotificationID: n.ID,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.SendToSubscriptions(ctx, subs, payload, pushOptions(typ), time.Now())
+	return err
+}
+
+// truncateRunes cuts s to at most max characters, adding "…" when it was cut.
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max-1]) + "…"
+}
+
+// PushResult is the outcome for one subscription.
+type PushResult struct {
+	SubscriptionID uint   `json:"subscription_id"`
+	Status         string `json:"status"` // sent | removed | failed
+	Error          string `json:"error,omitempty"`
+}
+
+// SendToSubscriptions pushes payload to each subscription and records the outcome. It fails
+// only when no device received it: with ErrNoRecipientAddress if all subscriptions were gone,
+// so the delivery is skipped rather than retried.
+func (w *WebPushChannel) SendToSubscriptions(ctx context.Context, subs []entity.PushSubscription, payload []byte, opts WebPushOptions, now time.Time) ([]PushResult, error) {
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	results := make([]PushResult, 0, len(subs))
+	var sent, removed int
+	var lastErr error
+	for i := range subs {
+		sub := &subs[i]
+		err := w.Keys.SendWebPush(ctx, client, sub, payload, opts, now)
+		switch {
+		case err == nil:
+			sent++
+			results = append(results, PushResult{SubscriptionID: sub.ID, Status: "sent"})
+			w.DB.Model(sub).Updates(map[string]interface{}{"last_used_at": now, "last_error": ""})
+		case errors.Is(err, ErrPushSubscriptionGone):
+			removed++
+			results = append(results, PushResult{SubscriptionID: sub.ID, Status: "removed"})
+			// ลบจริง เพื่อให้ endpoint เดิมสมัครใหม่ได้ (unique index)
+			if err := w.DB.Unscoped().Delete(sub).Error; err != nil {
+				log.Printf("⚠️ Failed to delete push subscription %d: %v", sub.ID, err)
+			}
+		default:
+			lastErr = err
+			results = append(results, PushResult{SubscriptionID: sub.ID, Status: "failed", Error: err.Error()})
+			w.DB.Model(sub).Update("last_error", truncateRunes(err.Error(), 500))
+		}
+	}
+	switch {
+	case sent > 0:
+		return results, nil
+	case removed == len(subs):
+		return results, ErrNoRecipientAddress
+	}
+	return results, lastErr
+}
+
+// ValidatePushSubscription checks what the browser's PushManager.subscribe returned.
+func ValidatePushSubscription(endpoint, p256dh, auth string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("endpoint must be an https URL")
+	}
+	key, err := decodeBase64URL(p256dh)
+	if err != nil {
+		return errors.New("invalid p256dh key")
+	}
+	if _, err := ecdh.P256().NewPublicKey(key); err != nil {
+		return errors.New("invalid p256dh key")
+	}
+	if secret, err := decodeBase64URL(auth); err != nil || len(secret) != 16 {
+		return errors.New("invalid auth secret")
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm"
+)
+
+// memoryStore is a services.FileStore that keeps files in memory.
+type memoryStore struct {
+	files map[string][]byte
+	next  int
+}
+
+func (s *memoryStore) UploadFile(file io.Reader, fileName string, contentType string) (string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	s.next++
+	key := fmt.Sprintf("%d-%s", s.next, fileName)
+	s.files[key] = data
+	return "https://files.example.com/uploads/" + key, nil
+}
+
+func (s *memoryStore) DeleteFile(fileName string) error {
+	delete(s.files, fileName)
+	return nil
+}
+
+var (
+	samplePDF  = []byte("%PDF-1.7\n1 0 obj\n<<>>\nendobj\n")
+	samplePNG  = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	sampleDOCX = []byte("PK\x03\x04\x14\x00\x06\x00")
+	sampleDOC  = []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1\x00\x00")
+)
+
+func TestAnnouncementAttachments(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newTestDB(t, &entity.Announcement{}, &entity.Announcement_Attachment{}, &entity.UploadScan{})
+
+	announcement := entity.Announcement{Title: "รับสมัคร", Content: "รายละเอียดการรับสมัคร", Status: entity.AnnouncementDraft, UserID: 1, CetagoryID: 1}
+	g.Expect(db.Create(&announcement).Error).To(BeNil())
+
+	store := &memoryStore{files: map[string][]byte{}}
+	now := time.Now()
+	upload := func(name string, data []byte) (*entity.Announcement_Attachment, error) {
+		return services.StoreAnnouncementAttachment(context.Background(), db, store, announcement.ID,
+			name, int64(len(data)), bytes.NewReader(data), 1, now)
+	}
+
+	t.Run("Files are checked by their content", func(t *testing.T) {
+		cases := []struct {
+			name    string
+			data    []byte
+			wantErr string
+		}{
+			{"ประกาศ.pdf", samplePDF, ""},
+			{"รูป.PNG", samplePNG, ""},
+			{"ใบสมัคร.docx", sampleDOCX, ""},
+			{"เก่า.doc", sampleDOC, ""},
+			{"script.exe", samplePDF, "invalid attachment: only images"},
+			{"fake.pdf", []byte("<html><script>x()</script>"), "invalid attachment: file content does not match .pdf"},
+			{"fake.png", samplePDF, "invalid attachment: file content does not match .png"},
+			{"empty.pdf", nil, "invalid attachment: file is empty"},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				g := NewGomegaWithT(t)
+				_, err := services.ValidateAttachment(tc.name, int64(len(tc.data)), bytes.NewReader(tc.data))
+				if tc.wantErr == "" {
+					g.Expect(err).To(BeNil())
+				} else {
+					g.Expect(err.Error()).To(HavePrefix(tc.wantErr))
+				}
+			})
+		}
+
+		_, err := services.ValidateAttachment("big.pdf", services.MaxAttachmentSize+1, bytes.NewReader(samplePDF))
+		g.Expect(err).To(MatchError(services.ErrInvalidAttachment))
+	})
+
+	t.Run("Upload stores the file and records it", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		att, err := upload(`C:\Users\admin\ประกาศ.pdf`, samplePDF)
+		g.Expect(err).To(BeNil())
+		g.Expect(att.File_name).To(Equal("ประกาศ.pdf"))
+		g.Expect(att.Content_Type).To(Equal("application/pdf"))
+		g.Expect(att.File_Size).To(Equal(int64(len(samplePDF))))
+		g.Expect(store.files).To(HaveKeyWithValue(att.Storage_Key, samplePDF))
+		g.Expect(att.File_path).To(HaveSuffix("/" + att.Storage_Key))
+	})
+
+	t.Run("Infected files are rejected before they are stored", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		services.UploadScanner = &services.FakeScanner{}
+		defer func() { services.UploadScanner = nil }()
+
+		stored := len(store.files)
+		_, err := upload("virus.pdf", append(append([]byte{}, samplePDF...), services.EICARTestString...))
+		g.Expect(err).To(MatchError(services.ErrFileInfected))
+		g.Expect(err.Error()).To(ContainSubstring("Eicar-Test-Signature"))
+		g.Expect(store.files).To(HaveLen(stored))
+
+		_, err = upload("clean.pdf", samplePDF)
+		g.Expect(err).To(BeNil())
+	})
+
+	t.Run("Downloads are counted", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		var att entity.Announcement_Attachment
+		g.Expect(db.First(&att).Error).To(BeNil())
+		g.Expect(services.RecordAttachmentDownload(db, &att)).To(Succeed())
+		g.Expect(services.RecordAttachmentDownload(db, &att)).To(Succeed())
+		g.Expect(db.First(&att, att.ID).Error).To(BeNil())
+		g.Expect(att.Download_Count).To(Equal(2))
+
+		atts := []entity.Announcement_Attachment{att}
+		services.SetAttachmentDownloadURLs(atts)
+		g.Expect(atts[0].Download_URL).To(Equal(fmt.Sprintf("/announcements/%d/attachments/%d/download", announcement.ID, att.ID)))
+	})
+
+	t.Run("An announcement has a limited number of attachments", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		var err error
+		for i := 0; err == nil && i < services.MaxAttachmentsPerAnnouncement; i++ {
+			_, err = upload(fmt.Sprintf("file%d.png", i), samplePNG)
+		}
+		g.Expect(err).NotTo(BeNil())
+		g.Expect(err.Error()).To(ContainSubstring(fmt.Sprintf("at most %d attachments", services.MaxAttachmentsPerAnnouncement)))
+	})
+
+	t.Run("Deleting the announcement removes its attachments and files", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(services.DeleteAnnouncementWithAttachments(db, store, announcement.ID)).To(Succeed())
+
+		var count int64
+		g.Expect(db.Unscoped().Model(&entity.Announcement_Attachment{}).Count(&count).Error).To(BeNil())
+		g.Expect(count).To(BeZero())
+		g.Expect(store.files).To(BeEmpty())
+
+		err := services.DeleteAnnouncementWithAttachments(db, store, announcement.ID)
+		g.Expect(err).To(MatchError(gorm.ErrRecordNotFound))
+	})
+
+	t.Run("Names are reduced to their base name", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		long := strings.Repeat("ก", 300) + ".pdf"
+		a2 := entity.Announcement{Title: "อีกประกาศ", Content: "รายละเอียดเพิ่มเติม", Status: entity.AnnouncementDraft, UserID: 1, CetagoryID: 1}
+		g.Expect(db.Create(&a2).Error).To(BeNil())
+		att, err := services.StoreAnnouncementAttachment(context.Background(), db, store, a2.ID,
+			"../../"+long, int64(len(samplePDF)), bytes.NewReader(samplePDF), 1, now)
+		g.Expect(err).To(BeNil())
+		g.Expect([]rune(att.File_name)).To(HaveLen(255))
+		g.Expect(att.File_name).To(HaveSuffix(".pdf"))
+	})
+}
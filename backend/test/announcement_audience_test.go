@@ -0,0 +1,167 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm"
+)
+
+func newAnnouncementAudienceDB(t *testing.T, g *WithT) *gorm.DB {
+	db := newTestDB(t, &entity.User{}, &entity.Faculty{}, &entity.Program{}, &entity.Curriculum{},
+		&entity.Selection{}, &entity.Application{}, &entity.FacultyAdmin{}, &entity.Announcement{}, &entity.AnnouncementAudience{},
+		&entity.AnnouncementReceipt{}, &entity.Notification{})
+
+	g.Expect(db.Create(&[]entity.Faculty{{Name: "Engineering"}, {Name: "Science"}}).Error).To(BeNil())
+	g.Expect(db.Create(&[]entity.Program{{Name: "Computer", FacultyID: 1}, {Name: "Physics", FacultyID: 2}}).Error).To(BeNil())
+	g.Expect(db.Create(&[]entity.Curriculum{
+		{Code: "CPE", Name: "Computer Engineering", FacultyID: 1, ProgramID: 1, AcademicYear: "2568"},
+		{Code: "PHY", Name: "Physics", FacultyID: 2, ProgramID: 2, AcademicYear: "2569"},
+	}).Error).To(BeNil())
+
+	// 1, 2 นักเรียน / 3 อาจารย์ / 4 ผู้ดูแลคณะวิศวะ / 5 นักเรียนที่สมัคร PHY / 6 อาจารย์ผู้รับผิดชอบ PHY
+	users := []entity.User{
+		{Email: "s1@example.com", AccountTypeID: entity.UserTypeStudent},
+		{Email: "s2@example.com", AccountTypeID: entity.UserTypeStudent},
+		{Email: "t1@example.com", AccountTypeID: entity.UserTypeTeacher},
+		{Email: "a1@example.com", AccountTypeID: entity.UserTypeAdmin},
+		{Email: "s3@example.com", AccountTypeID: entity.UserTypeStudent},
+		{Email: "t2@example.com", AccountTypeID: entity.UserTypeTeacher},
+	}
+	g.Expect(db.Create(&users).Error).To(BeNil())
+	g.Expect(db.Create(&[]entity.Selection{{UserID: 1, CurriculumID: 1}, {UserID: 2, CurriculumID: 2}}).Error).To(BeNil())
+	g.Expect(db.Create(&entity.FacultyAdmin{FacultyID: 1, UserID: 4}).Error).To(BeNil())
+	g.Expect(db.Create(&[]entity.Application{
+		{Status: entity.ApplicationStatusSubmitted, UserID: 5, CurriculumID: 2, PortfolioID: 1},
+		{Status: entity.ApplicationStatusWithdrawn, UserID: 1, CurriculumID: 2, PortfolioID: 2},
+	}).Error).To(BeNil())
+	g.Expect(db.Model(&entity.Curriculum{}).Where("id = ?", 2).Update("user_id", 6).Error).To(BeNil())
+	return db
+}
+
+func audience(kind string, targetID uint, value string) entity.AnnouncementAudience {
+	return entity.AnnouncementAudience{Kind: kind, TargetID: targetID, Value: value}
+}
+
+func TestAnnouncementAudience(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newAnnouncementAudienceDB(t, g)
+
+	member := func(g *WithT, id uint) *services.AudienceMember {
+		var user entity.User
+		g.Expect(db.First(&user, id).Error).To(BeNil())
+		m, err := services.LoadAudienceMember(db, &user)
+		g.Expect(err).To(BeNil())
+		return m
+	}
+
+	t.Run("Resolve and match agree", func(t *testing.T) {
+		cases := []struct {
+			name      string
+			audiences []entity.AnnouncementAudience
+			want      []uint
+		}{
+			{"everyone", nil, []uint{1, 2, 3, 4, 5, 6}},
+			{"role", []entity.AnnouncementAudience{audience(entity.AudienceRole, entity.UserTypeStudent, "")}, []uint{1, 2, 5}},
+			{"curriculum selection", []entity.AnnouncementAudience{audience(entity.AudienceCurriculum, 1, "")}, []uint{1}},
+			{"faculty includes its admins", []entity.AnnouncementAudience{audience(entity.AudienceFaculty, 1, "")}, []uint{1, 4}},
+			{"students of a faculty", []entity.AnnouncementAudience{
+				audience(entity.AudienceRole, entity.UserTypeStudent, ""), audience(entity.AudienceFaculty, 1, "")}, []uint{1}},
+			{"academic year or explicit user", []entity.AnnouncementAudience{
+				audience(entity.AudienceAcademicYear, 0, "2569"), audience(entity.AudienceUser, 3, "")}, []uint{2, 3, 5, 6}},
+			{"program: bookmarks, applications and the teacher in charge", []entity.AnnouncementAudience{audience(entity.AudienceProgram, 2, "")}, []uint{2, 5, 6}},
+			{"students of a faculty they applied to", []entity.AnnouncementAudience{
+				audience(entity.AudienceRole, entity.UserTypeStudent, ""), audience(entity.AudienceFaculty, 2, "")}, []uint{2, 5}},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				g := NewGomegaWithT(t)
+				ids, err := services.ResolveAudienceUserIDs(db, tc.audiences)
+				g.Expect(err).To(BeNil())
+				g.Expect(ids).To(Equal(tc.want))
+
+				var matched []uint
+				for id := uint(1); id <= 6; id++ {
+					if services.AudienceMatches(tc.audiences, member(g, id)) {
+						matched = append(matched, id)
+					}
+				}
+				g.Expect(matched).To(Equal(tc.want))
+			})
+		}
+	})
+
+	t.Run("Anonymous visitors only see announcements for everyone", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		anon, err := services.LoadAudienceMember(db, nil)
+		g.Expect(err).To(BeNil())
+		g.Expect(services.AudienceMatches(nil, anon)).To(BeTrue())
+		g.Expect(services.AudienceMatches([]entity.AnnouncementAudience{audience(entity.AudienceRole, entity.UserTypeStudent, "")}, anon)).To(BeFalse())
+	})
+
+	t.Run("Normalize rejects unknown targets and drops duplicates", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		out, err := services.NormalizeAudiences(db, []entity.AnnouncementAudience{
+			audience(entity.AudienceFaculty, 1, ""), audience(" faculty ", 1, "x"),
+		})
+		g.Expect(err).To(BeNil())
+		g.Expect(out).To(HaveLen(1))
+
+		_, err = services.NormalizeAudiences(db, []entity.AnnouncementAudience{audience(entity.AudienceCurriculum, 99, "")})
+		g.Expect(err.Error()).To(Equal("audiences[0]: curriculum 99 not found"))
+
+		_, err = services.NormalizeAudiences(db, []entity.AnnouncementAudience{audience(entity.AudienceRole, 9, "")})
+		g.Expect(err.Error()).To(Equal("audiences[0]: unknown role 9"))
+
+		_, err = services.NormalizeAudiences(db, []entity.AnnouncementAudience{audience("group", 1, "")})
+		g.Expect(err).NotTo(BeNil())
+	})
+
+	t.Run("Notifications fan out once per user", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		announcement := entity.Announcement{Title: "สอบสัมภาษณ์", Content: "นักเรียนวิศวะทุกคน", Status: "PUBLISHED", UserID: 4, CetagoryID: 1,
+			Audiences: []entity.AnnouncementAudience{audience(entity.AudienceFaculty, 1, "")}}
+		g.Expect(db.Create(&announcement).Error).To(BeNil())
+
+		n, err := services.NotifyAnnouncementAudience(db, &entity.Announcement{Model: announcement.Model, Title: announcement.Title}, time.Now())
+		g.Expect(err).To(BeNil())
+		g.Expect(n).To(Equal(2))
+
+		// เรียกซ้ำไม่ส่งซ้ำ
+		n, err = services.NotifyAnnouncementAudience(db, &announcement, time.Now())
+		g.Expect(err).To(BeNil())
+		g.Expect(n).To(BeZero())
+
+		var recipients []uint
+		g.Expect(db.Model(&entity.Notification{}).Where("announcement_id = ?", announcement.ID).Order("user_id").Pluck("user_id", &recipients).Error).To(BeNil())
+		g.Expect(recipients).To(Equal([]uint{1, 4}))
+	})
+
+	t.Run("Emails are queued with the notifications", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		services.Emails = services.NewEmailQueue(db, &fakeMailer{})
+		defer func() { services.Emails = nil }()
+
+		announcement := entity.Announcement{Title: "ปฐมนิเทศ", Content: "นักเรียนฟิสิกส์", Status: "PUBLISHED", UserID: 4, CetagoryID: 1,
+			Audiences: []entity.AnnouncementAudience{audience(entity.AudienceProgram, 2, "")}}
+		g.Expect(db.Create(&announcement).Error).To(BeNil())
+
+		// ยังไม่มีตารางอีเมล: เข้าคิวไม่ได้ การแจ้งเตือนต้องย้อนกลับด้วย
+		_, err := services.NotifyAnnouncementAudience(db, &announcement, time.Now())
+		g.Expect(err).NotTo(BeNil())
+		var count int64
+		g.Expect(db.Model(&entity.Notification{}).Where("announcement_id = ?", announcement.ID).Count(&count).Error).To(BeNil())
+		g.Expect(count).To(BeZero())
+
+		g.Expect(db.AutoMigrate(&entity.EmailMessage{}, &entity.NotificationPreference{}, &entity.NotificationSetting{})).To(Succeed())
+		n, err := services.NotifyAnnouncementAudience(db, &announcement, time.Now())
+		g.Expect(err).To(BeNil())
+		g.Expect(n).To(Equal(3))
+		var emails []string
+		g.Expect(db.Model(&entity.EmailMessage{}).Pluck("to", &emails).Error).To(BeNil())
+		g.Expect(emails).To(ConsistOf("s2@example.com", "s3@example.com", "t2@example.com"))
+	})
+}
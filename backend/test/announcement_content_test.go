@@ -0,0 +1,140 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	cases := []struct {
+		name, input, want string
+	}{
+		{"keeps formatting", `<h2>รับสมัคร</h2><p><strong>ด่วน</strong> <em>ภายใน</em> 31 ม.ค.</p>`,
+			`<h2>รับสมัคร</h2><p><strong>ด่วน</strong> <em>ภายใน</em> 31 ม.ค.</p>`},
+		{"drops scripts with their content", `<p>a</p><script>alert(1)</script><p>b</p>`, `<p>a</p><p>b</p>`},
+		{"drops event handlers and styles", `<p onclick="x()" style="color:red">hi</p>`, `<p>hi</p>`},
+		{"drops javascript links", `<a href="java	script:alert(1)">x</a>`,
+			`<a target="_blank" rel="noopener noreferrer nofollow">x</a>`},
+		{"keeps safe links", `<a href="https://sut.ac.th/?a=1&b=2" title="SUT">SUT</a>`,
+			`<a href="https://sut.ac.th/?a=1&amp;b=2" title="SUT" target="_blank" rel="noopener noreferrer nofollow">SUT</a>`},
+		{"drops images without a safe src", `<img src="data:image/png;base64,AAA" onerror="x()"><img src="/uploads/a.png" alt="a">`,
+			`<img src="/uploads/a.png" alt="a" loading="lazy">`},
+		{"unknown tags keep their text", `<div><font>ข้อความ</font></div>`, `ข้อความ`},
+		{"escapes text", `1 < 2 &amp; "q"`, `1 &lt; 2 &amp; &#34;q&#34;`},
+		{"closes unclosed tags", `<ul><li><b>one`, `<ul><li><b>one</b></li></ul>`},
+		{"ignores stray end tags", `</p>x</table>`, `x`},
+		{"table attributes are checked", `<table><tr><td colspan="2" align="center" width="expression(x)">c</td></tr></table>`,
+			`<table><tr><td colspan="2" align="center">c</td></tr></table>`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			g.Expect(services.SanitizeHTML(tc.input)).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	source := strings.Join([]string{
+		"# ประกาศ **สำคัญ**",
+		"",
+		"บรรทัดแรก",
+		"บรรทัดที่สอง [ลิงก์](https://sut.ac.th \"SUT\") และ `code`",
+		"",
+		"- หนึ่ง",
+		"- สอง",
+		"  ต่อ",
+		"",
+		"3. สาม",
+		"4. สี่",
+		"",
+		"| รอบ | วันที่ |",
+		"|:---|---:|",
+		"| Portfolio | 1 ม.ค. |",
+		"",
+		"> อ้างอิง",
+		"",
+		"---",
+		"![แผนที่](/uploads/map.png)",
+		"```",
+		"<b>ไม่แปลง</b>",
+		"```",
+		"ตัวแปร snake_case_name และ ~~ลบ~~ _เอียง_",
+	}, "\n")
+
+	g.Expect(services.RenderMarkdown(source)).To(Equal(strings.Join([]string{
+		"<h1>ประกาศ <strong>สำคัญ</strong></h1>",
+		`<p>บรรทัดแรก<br>บรรทัดที่สอง <a href="https://sut.ac.th" title="SUT" target="_blank" rel="noopener noreferrer nofollow">ลิงก์</a> และ <code>code</code></p>`,
+		"<ul>",
+		"<li>หนึ่ง</li>",
+		"<li>สอง<br>ต่อ</li>",
+		"</ul>",
+		`<ol start="3">`,
+		"<li>สาม</li>",
+		"<li>สี่</li>",
+		"</ol>",
+		"<table>",
+		"<thead>",
+		`<tr><th align="left">รอบ</th><th align="right">วันที่</th></tr>`,
+		"</thead>",
+		"<tbody>",
+		`<tr><td align="left">Portfolio</td><td align="right">1 ม.ค.</td></tr>`,
+		"</tbody>",
+		"</table>",
+		"<blockquote>",
+		"<p>อ้างอิง</p>",
+		"</blockquote>",
+		"<hr>",
+		`<p><img src="/uploads/map.png" alt="แผนที่" loading="lazy"></p>`,
+		"<pre><code>&lt;b&gt;ไม่แปลง&lt;/b&gt;</code></pre>",
+		"<p>ตัวแปร snake_case_name และ <del>ลบ</del> <em>เอียง</em></p>",
+		"",
+	}, "\n")))
+
+	t.Run("Raw HTML and unsafe URLs do not get through", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		out := services.RenderMarkdown("<script>alert(1)</script> [x](javascript:alert(1)) ![y](data:text/html,z)")
+		g.Expect(out).NotTo(ContainSubstring("<script"))
+		g.Expect(out).NotTo(ContainSubstring("javascript:"))
+		g.Expect(out).NotTo(ContainSubstring("<img"))
+		g.Expect(out).To(ContainSubstring("&lt;script&gt;"))
+	})
+}
+
+func TestPrepareAnnouncementContent(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	format, content, err := services.PrepareAnnouncementContent("", "ข้อความธรรมดา")
+	g.Expect(err).To(BeNil())
+	g.Expect(format).To(Equal(entity.AnnouncementFormatPlain))
+	g.Expect(content).To(Equal("ข้อความธรรมดา"))
+
+	// HTML ถูก sanitize ก่อนบันทึก
+	format, content, err = services.PrepareAnnouncementContent("HTML", `<p onmouseover="x()">สวัสดี</p><script>x()</script>`)
+	g.Expect(err).To(BeNil())
+	g.Expect(format).To(Equal(entity.AnnouncementFormatHTML))
+	g.Expect(content).To(Equal("<p>สวัสดี</p>"))
+
+	_, _, err = services.PrepareAnnouncementContent("docx", "x")
+	g.Expect(err.Error()).To(Equal("content_format must be plain, markdown or html"))
+
+	_, _, err = services.PrepareAnnouncementContent("markdown", strings.Repeat("ก", services.MaxAnnouncementContentLength+1))
+	g.Expect(err.Error()).To(Equal("content must not exceed 50000 characters"))
+
+	t.Run("Plain text keeps paragraphs; previews are plain text", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		a := &entity.Announcement{Content: "บรรทัด 1\nบรรทัด <2>\n\nย่อหน้าใหม่"}
+		services.RenderAnnouncement(a)
+		g.Expect(a.ContentHTML).To(Equal("<p>บรรทัด 1<br>บรรทัด &lt;2&gt;</p>\n<p>ย่อหน้าใหม่</p>\n"))
+
+		md := &entity.Announcement{ContentFormat: entity.AnnouncementFormatMarkdown, Content: "# หัวข้อ\n\nรายละเอียด **สำคัญ** มาก"}
+		g.Expect(services.AnnouncementPreview(md, 100)).To(Equal("หัวข้อ รายละเอียด สำคัญ มาก"))
+		g.Expect(services.AnnouncementPreview(md, 9)).To(Equal("หัวข้อ ร…"))
+	})
+}
@@ -0,0 +1,211 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/config"
+	"github.com/sut68/team14/backend/controller"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm"
+)
+
+func newAnnouncementLifecycleDB(t *testing.T, g *WithT) *gorm.DB {
+	db := newTestDB(t, &entity.User{}, &entity.Faculty{}, &entity.Announcement{}, &entity.AnnouncementAudience{},
+		&entity.AnnouncementRevision{})
+	g.Expect(db.Create(&entity.Faculty{Name: "Engineering"}).Error).To(BeNil())
+	return db
+}
+
+func TestAnnouncementLifecycle(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newAnnouncementLifecycleDB(t, g)
+	now := time.Now()
+	editor := uint(7)
+
+	newDraft := func(g *WithT) *entity.Announcement {
+		a := &entity.Announcement{Title: "ประกาศรับสมัคร", Content: "รอบ Portfolio เปิดรับสมัครแล้ว", Status: entity.AnnouncementDraft,
+			UserID: editor, CetagoryID: 1}
+		_, err := services.SaveAnnouncement(db, a, &[]entity.AnnouncementAudience{}, services.AnnouncementRevisionCreate, &editor)
+		g.Expect(err).To(BeNil())
+		return a
+	}
+
+	t.Run("Draft, schedule, publish, expire", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		a := newDraft(g)
+
+		err := services.TransitionAnnouncement(a, services.AnnouncementActionSchedule, &now, now)
+		g.Expect(err.Error()).To(Equal("scheduled_publish_at must be in the future"))
+
+		at := now.Add(time.Hour)
+		g.Expect(services.TransitionAnnouncement(a, services.AnnouncementActionSchedule, &at, now)).To(Succeed())
+		_, err = services.SaveAnnouncement(db, a, nil, services.AnnouncementActionSchedule, &editor)
+		g.Expect(err).To(BeNil())
+
+		// scheduler ยังไม่ถึงเวลา
+		published, err := services.PublishDueAnnouncements(db, now)
+		g.Expect(err).To(BeNil())
+		g.Expect(published).To(BeEmpty())
+
+		published, err = services.PublishDueAnnouncements(db, at)
+		g.Expect(err).To(BeNil())
+		g.Expect(published).To(HaveLen(1))
+		g.Expect(published[0].Status).To(Equal(entity.AnnouncementPublished))
+
+		expiry := at.Add(time.Hour)
+		g.Expect(db.Model(&entity.Announcement{}).Where("id = ?", a.ID).Update("expires_at", expiry).Error).To(BeNil())
+		expired, err := services.ExpireDueAnnouncements(db, expiry)
+		g.Expect(err).To(BeNil())
+		g.Expect(expired).To(HaveLen(1))
+
+		var revisions []entity.AnnouncementRevision
+		g.Expect(db.Where("announcement_id = ?", a.ID).Order("revision").Find(&revisions).Error).To(BeNil())
+		actions := []string{}
+		for _, r := range revisions {
+			actions = append(actions, r.Action)
+		}
+		g.Expect(actions).To(Equal([]string{"create", "schedule", "publish", "expire"}))
+		g.Expect(*revisions[1].EditorID).To(Equal(editor))
+		g.Expect(revisions[2].EditorID).To(BeNil()) // scheduler
+	})
+
+	t.Run("Actions must match the current status", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		a := newDraft(g)
+		err := services.TransitionAnnouncement(a, services.AnnouncementActionExpire, nil, now)
+		g.Expect(errors.Is(err, services.ErrAnnouncementTransition)).To(BeTrue())
+		g.Expect(err.Error()).To(Equal("invalid announcement state transition: cannot expire an announcement that is draft"))
+
+		g.Expect(services.TransitionAnnouncement(a, services.AnnouncementActionArchive, nil, now)).To(Succeed())
+		err = services.TransitionAnnouncement(a, services.AnnouncementActionPublish, nil, now)
+		g.Expect(errors.Is(err, services.ErrAnnouncementTransition)).To(BeTrue())
+	})
+
+	t.Run("Status names from older clients map to actions", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		a := &entity.Announcement{Status: "PUBLISHED"}
+		action, err := services.AnnouncementActionForStatus(a, "DRAFT")
+		g.Expect(err).To(BeNil())
+		g.Expect(action).To(Equal(services.AnnouncementActionUnpublish))
+
+		action, _ = services.AnnouncementActionForStatus(a, "published")
+		g.Expect(action).To(BeEmpty())
+
+		_, err = services.AnnouncementActionForStatus(a, "deleted")
+		g.Expect(err).NotTo(BeNil())
+	})
+
+	t.Run("Restore brings back content and audience as a new revision", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		a := newDraft(g)
+
+		a.Title = "ประกาศรับสมัคร (แก้ไข)"
+		a.Content = "เลื่อนวันปิดรับสมัคร"
+		_, err := services.SaveAnnouncement(db, a, &[]entity.AnnouncementAudience{{Kind: entity.AudienceFaculty, TargetID: 1}},
+			services.AnnouncementRevisionEdit, &editor)
+		g.Expect(err).To(BeNil())
+		g.Expect(a.Revision).To(Equal(2))
+		g.Expect(a.Audiences).To(HaveLen(1))
+
+		g.Expect(services.TransitionAnnouncement(a, services.AnnouncementActionPublish, nil, now)).To(Succeed())
+		_, err = services.SaveAnnouncement(db, a, nil, services.AnnouncementActionPublish, &editor)
+		g.Expect(err).To(BeNil())
+
+		rev, err := services.RestoreAnnouncementRevision(db, a, 1, 9)
+		g.Expect(err).To(BeNil())
+		g.Expect(rev.Revision).To(Equal(4))
+		g.Expect(*rev.RestoredFrom).To(Equal(1))
+		g.Expect(*rev.EditorID).To(Equal(uint(9)))
+
+		var saved entity.Announcement
+		g.Expect(db.Preload("Audiences").First(&saved, a.ID).Error).To(BeNil())
+		g.Expect(saved.Title).To(Equal("ประกาศรับสมัคร"))
+		g.Expect(saved.Audiences).To(BeEmpty())
+		g.Expect(saved.Status).To(Equal(entity.AnnouncementPublished)) // สถานะไม่ถูกย้อน
+		g.Expect(saved.Revision).To(Equal(4))
+
+		_, err = services.RestoreAnnouncementRevision(db, a, 99, 9)
+		g.Expect(errors.Is(err, gorm.ErrRecordNotFound)).To(BeTrue())
+	})
+
+	t.Run("Announcements from before revisions get a baseline", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		legacy := entity.Announcement{Title: "เก่า", Content: "ประกาศเดิมก่อนมีประวัติ", Status: entity.AnnouncementPublished, UserID: 1, CetagoryID: 1}
+		g.Expect(db.Create(&legacy).Error).To(BeNil())
+
+		g.Expect(services.EnsureAnnouncementBaseline(db, &legacy)).To(Succeed())
+		g.Expect(services.EnsureAnnouncementBaseline(db, &legacy)).To(Succeed())
+
+		var revisions []entity.AnnouncementRevision
+		g.Expect(db.Where("announcement_id = ?", legacy.ID).Find(&revisions).Error).To(BeNil())
+		g.Expect(revisions).To(HaveLen(1))
+		g.Expect(revisions[0].Action).To(Equal(services.AnnouncementRevisionBaseline))
+	})
+}
+
+func TestAnnouncementManagementNeedsOwnerOrAdmin(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newAnnouncementLifecycleDB(t, g)
+	g.Expect(db.AutoMigrate(&entity.Announcement_Attachment{})).To(Succeed())
+	g.Expect(db.Create(&[]entity.User{
+		{Email: "teacher@example.com", AccountTypeID: entity.UserTypeTeacher},
+		{Email: "student@example.com", AccountTypeID: entity.UserTypeStudent},
+	}).Error).To(BeNil())
+	author := uint(1)
+	a := &entity.Announcement{Title: "ประกาศ", Content: "เนื้อหา", Status: entity.AnnouncementDraft, UserID: author, CetagoryID: 1}
+	_, err := services.SaveAnnouncement(db, a, &[]entity.AnnouncementAudience{}, services.AnnouncementRevisionCreate, &author)
+	g.Expect(err).To(BeNil())
+
+	prev := config.GetDB()
+	config.SetDB(db)
+	t.Cleanup(func() { config.SetDB(prev) })
+
+	send := func(userID uint, method, route, path, body string, handler gin.HandlerFunc) int {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Handle(method, route, func(c *gin.Context) { c.Set("user_id", userID) }, handler)
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	call := func(userID uint, route, path string, handler gin.HandlerFunc) int {
+		return send(userID, http.MethodPost, route, path, "", handler)
+	}
+
+	student := uint(2)
+	g.Expect(call(student, "/admin/announcements/:id/publish", "/admin/announcements/1/publish", controller.PublishAnnouncement)).
+		To(Equal(http.StatusForbidden))
+	g.Expect(call(student, "/admin/announcements/:id/revisions/:revision/restore", "/admin/announcements/1/revisions/1/restore",
+		controller.RestoreAnnouncementRevision)).To(Equal(http.StatusForbidden))
+	g.Expect(send(student, http.MethodPut, "/admin/announcements/:id", "/admin/announcements/1",
+		`{"title":"แก้โดยคนอื่น","status":"published"}`, controller.UpdateAdminAnnouncement)).To(Equal(http.StatusForbidden))
+	g.Expect(send(student, http.MethodGet, "/admin/announcements/:id/attachments", "/admin/announcements/1/attachments", "",
+		controller.ListManagedAnnouncementAttachments)).To(Equal(http.StatusForbidden))
+	g.Expect(send(student, http.MethodDelete, "/admin/announcements/:id", "/admin/announcements/1", "",
+		controller.DeleteAnnouncement)).To(Equal(http.StatusForbidden))
+
+	var stored entity.Announcement
+	g.Expect(db.First(&stored, a.ID).Error).To(BeNil())
+	g.Expect(stored.Status).To(Equal(entity.AnnouncementDraft))
+	g.Expect(stored.Title).To(Equal("ประกาศ"))
+
+	g.Expect(call(author, "/admin/announcements/:id/publish", "/admin/announcements/1/publish", controller.PublishAnnouncement)).
+		To(Equal(http.StatusOK))
+	g.Expect(send(author, http.MethodPut, "/admin/announcements/:id", "/admin/announcements/1",
+		`{"title":"ประกาศ (แก้ไข)"}`, controller.UpdateAdminAnnouncement)).To(Equal(http.StatusOK))
+	g.Expect(send(author, http.MethodGet, "/admin/announcements/:id/attachments", "/admin/announcements/1/attachments", "",
+		controller.ListManagedAnnouncementAttachments)).To(Equal(http.StatusOK))
+	g.Expect(send(author, http.MethodDelete, "/admin/announcements/:id", "/admin/announcements/1", "",
+		controller.DeleteAnnouncement)).To(Equal(http.StatusOK))
+	g.Expect(db.First(&stored, a.ID).Error).To(MatchError(gorm.ErrRecordNotFound))
+}
@@ -0,0 +1,148 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/config"
+	"github.com/sut68/team14/backend/controller"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+func TestAnnouncementReceipts(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newAnnouncementAudienceDB(t, g)
+	now := time.Date(2026, 1, 10, 9, 0, 0, 0, services.AnnouncementLocation())
+
+	deadline := now.Add(12 * time.Hour)
+	announcement := entity.Announcement{Title: "ยืนยันสิทธิ์", Content: "นักเรียนวิศวะกดรับทราบ", Status: entity.AnnouncementPublished,
+		UserID: 4, CetagoryID: 1, RequiresAck: true, AckDeadline: &deadline,
+		Audiences: []entity.AnnouncementAudience{audience(entity.AudienceFaculty, 1, "")}}
+	g.Expect(db.Create(&announcement).Error).To(BeNil())
+
+	t.Run("Views are counted per user", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r, err := services.MarkAnnouncementViewed(db, announcement.ID, 1, now)
+		g.Expect(err).To(BeNil())
+		g.Expect(r.ViewCount).To(Equal(1))
+
+		r, err = services.MarkAnnouncementViewed(db, announcement.ID, 1, now.Add(time.Hour))
+		g.Expect(err).To(BeNil())
+		g.Expect(r.ViewCount).To(Equal(2))
+		g.Expect(r.FirstViewedAt.Equal(now)).To(BeTrue())
+		g.Expect(r.LastViewedAt.Equal(now.Add(time.Hour))).To(BeTrue())
+	})
+
+	t.Run("Acknowledging keeps the first time", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r, err := services.AcknowledgeAnnouncement(db, &announcement, 1, now.Add(time.Hour))
+		g.Expect(err).To(BeNil())
+		g.Expect(r.AcknowledgedAt).NotTo(BeNil())
+
+		r, err = services.AcknowledgeAnnouncement(db, &announcement, 1, now.Add(2*time.Hour))
+		g.Expect(err).To(BeNil())
+		g.Expect(r.AcknowledgedAt.Equal(now.Add(time.Hour))).To(BeTrue())
+
+		_, err = services.AcknowledgeAnnouncement(db, &entity.Announcement{Model: announcement.Model}, 1, now)
+		g.Expect(err).To(MatchError(services.ErrAckNotRequired))
+	})
+
+	t.Run("Receipts are attached to the reader's list", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		list := []entity.Announcement{announcement}
+		g.Expect(services.AttachReceipts(db, list, 1)).To(Succeed())
+		g.Expect(list[0].Receipt).NotTo(BeNil())
+
+		list = []entity.Announcement{announcement}
+		g.Expect(services.AttachReceipts(db, list, 4)).To(Succeed())
+		g.Expect(list[0].Receipt).To(BeNil())
+	})
+
+	t.Run("Reminders go once to those who have not acknowledged", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		// ยังไม่ถึงช่วงเตือน
+		n, err := services.SendDueAckReminders(db, now.Add(-13*time.Hour))
+		g.Expect(err).To(BeNil())
+		g.Expect(n).To(BeZero())
+
+		n, err = services.SendDueAckReminders(db, now)
+		g.Expect(err).To(BeNil())
+		g.Expect(n).To(Equal(1))
+
+		n, err = services.SendDueAckReminders(db, now.Add(time.Hour))
+		g.Expect(err).To(BeNil())
+		g.Expect(n).To(BeZero())
+
+		var recipients []uint
+		g.Expect(db.Model(&entity.Notification{}).
+			Where("announcement_id = ? AND notification_type = ?", announcement.ID, entity.NotificationTypeAnnouncementReminder).
+			Pluck("user_id", &recipients).Error).To(BeNil())
+		g.Expect(recipients).To(Equal([]uint{4}))
+	})
+
+	t.Run("Analytics count reach, reads and acknowledgements", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		// ผู้ใช้ 2 อยู่นอกกลุ่มเป้าหมาย ไม่ถูกนับ
+		_, err := services.MarkAnnouncementViewed(db, announcement.ID, 2, now)
+		g.Expect(err).To(BeNil())
+		_, err = services.MarkAnnouncementViewed(db, announcement.ID, 4, now)
+		g.Expect(err).To(BeNil())
+
+		report, err := services.BuildAnnouncementAnalytics(db, &announcement, now.Add(24*time.Hour))
+		g.Expect(err).To(BeNil())
+		g.Expect(report.Total).To(Equal(services.AnnouncementEngagement{Reach: 2, Read: 2, Acknowledged: 1, ReadRate: 1, AckRate: 0.5}))
+		g.Expect(report.Overdue).To(Equal(1))
+
+		g.Expect(report.Segments).To(HaveLen(1))
+		g.Expect(report.Segments[0].Label).To(Equal("Engineering"))
+		g.Expect(report.Segments[0].Reach).To(Equal(2))
+
+		g.Expect(report.Roles).To(HaveLen(2))
+		g.Expect(report.Roles[0].Label).To(Equal("student"))
+		g.Expect(report.Roles[0].Acknowledged).To(Equal(1))
+		g.Expect(report.Roles[1].Label).To(Equal("admin"))
+		g.Expect(report.Roles[1].Acknowledged).To(BeZero())
+	})
+}
+
+func TestAnnouncementRemindersAndAnalyticsNeedOwnerOrAdmin(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newAnnouncementAudienceDB(t, g)
+	deadline := time.Now().Add(time.Hour)
+	announcement := entity.Announcement{Title: "ยืนยันสิทธิ์", Content: "กดรับทราบ", Status: entity.AnnouncementPublished,
+		UserID: 3, CetagoryID: 1, RequiresAck: true, AckDeadline: &deadline,
+		Audiences: []entity.AnnouncementAudience{audience(entity.AudienceFaculty, 1, "")}}
+	g.Expect(db.Create(&announcement).Error).To(BeNil())
+
+	prev := config.GetDB()
+	config.SetDB(db)
+	t.Cleanup(func() { config.SetDB(prev) })
+
+	call := func(userID uint, method, route, path string, handler gin.HandlerFunc) int {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Handle(method, route, func(c *gin.Context) { c.Set("user_id", userID) }, handler)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w.Code
+	}
+
+	student := uint(1)
+	g.Expect(call(student, http.MethodPost, "/admin/announcements/:id/remind", "/admin/announcements/1/remind",
+		controller.RemindAnnouncement)).To(Equal(http.StatusForbidden))
+	g.Expect(call(student, http.MethodGet, "/admin/announcements/:id/analytics", "/admin/announcements/1/analytics",
+		controller.GetAnnouncementAnalytics)).To(Equal(http.StatusForbidden))
+
+	var notified int64
+	db.Model(&entity.Notification{}).Count(&notified)
+	g.Expect(notified).To(BeZero())
+
+	admin := uint(4)
+	g.Expect(call(admin, http.MethodGet, "/admin/announcements/:id/analytics", "/admin/announcements/1/analytics",
+		controller.GetAnnouncementAnalytics)).To(Equal(http.StatusOK))
+}
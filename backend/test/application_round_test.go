@@ -0,0 +1,149 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/config"
+	"github.com/sut68/team14/backend/controller"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+func TestApplicationRound(t *testing.T) {
+	bangkok := entity.LoadRoundLocation(entity.DefaultRoundTimezone)
+
+	t.Run("Parses legacy application period in Thai time", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		start, end, err := entity.ParseLegacyApplicationPeriod("2026-01-10T08:00|2026-02-10T16:30:00", bangkok)
+		g.Expect(err).To(BeNil())
+		g.Expect(start.UTC()).To(Equal(time.Date(2026, 1, 10, 1, 0, 0, 0, time.UTC)))
+		g.Expect(end.UTC()).To(Equal(time.Date(2026, 2, 10, 9, 30, 0, 0, time.UTC)))
+	})
+
+	t.Run("Rejects malformed period", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, _, err := entity.ParseLegacyApplicationPeriod("1 - 31 มกราคม 2567", bangkok)
+		g.Expect(err).NotTo(BeNil())
+	})
+
+	t.Run("RFC3339 keeps its own offset", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ts, err := entity.ParseRoundTime("2026-01-10T08:00:00Z", bangkok)
+		g.Expect(err).To(BeNil())
+		g.Expect(ts).To(Equal(time.Date(2026, 1, 10, 8, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("Closing must be after opening", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		now := time.Now()
+		round := entity.ApplicationRound{Name: "Portfolio 1", OpensAt: now, ClosesAt: now.Add(-time.Hour)}
+		g.Expect(round.Validate()).NotTo(BeNil())
+	})
+
+	t.Run("Unknown timezone", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		now := time.Now()
+		round := entity.ApplicationRound{Name: "Portfolio 1", OpensAt: now, ClosesAt: now.Add(time.Hour), Timezone: "Mars/Olympus"}
+		g.Expect(round.Validate()).NotTo(BeNil())
+	})
+
+	t.Run("Legacy round prefers StartDate and EndDate", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := entity.Curriculum{
+			RoundName:         "Portfolio 1/2569",
+			StartDate:         time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:           time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+			ApplicationPeriod: "2026-01-10T08:00|2026-02-10T16:30",
+		}
+		round, ok := c.LegacyRound()
+		g.Expect(ok).To(BeTrue())
+		g.Expect(round.Name).To(Equal("Portfolio 1/2569"))
+		g.Expect(round.OpensAt).To(Equal(c.StartDate))
+		g.Expect(round.ClosesAt).To(Equal(c.EndDate))
+	})
+
+	t.Run("No legacy round without dates", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, ok := (&entity.Curriculum{ApplicationPeriod: "กุมภาพันธ์ 2568"}).LegacyRound()
+		g.Expect(ok).To(BeFalse())
+	})
+}
+
+func TestComputeCurriculumStatus(t *testing.T) {
+	now := time.Date(2026, 5, 15, 12, 0, 0, 0, time.UTC)
+	round := func(name string, opens, closes time.Duration) entity.ApplicationRound {
+		return entity.ApplicationRound{Name: name, OpensAt: now.Add(opens), ClosesAt: now.Add(closes)}
+	}
+
+	t.Run("Open while any round is open", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := entity.Curriculum{Rounds: []entity.ApplicationRound{
+			round("Quota", 48*time.Hour, 96*time.Hour),
+			round("Portfolio", -time.Hour, time.Hour),
+		}}
+		g.Expect(services.ComputeCurriculumStatus(&c, now)).To(Equal(entity.RoundStatusOpen))
+		g.Expect(services.CurrentRound(&c, now).Name).To(Equal("Portfolio"))
+	})
+
+	t.Run("Opening between rounds", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := entity.Curriculum{Rounds: []entity.ApplicationRound{
+			round("Portfolio", -96*time.Hour, -48*time.Hour),
+			round("Quota", 48*time.Hour, 96*time.Hour),
+		}}
+		g.Expect(services.ComputeCurriculumStatus(&c, now)).To(Equal(entity.RoundStatusOpening))
+		g.Expect(services.CurrentRound(&c, now).Name).To(Equal("Quota"))
+	})
+
+	t.Run("Closed after the last round", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := entity.Curriculum{Rounds: []entity.ApplicationRound{round("Portfolio", -96*time.Hour, -48*time.Hour)}}
+		g.Expect(services.ComputeCurriculumStatus(&c, now)).To(Equal(entity.RoundStatusClosed))
+		g.Expect(services.CurrentRound(&c, now)).To(BeNil())
+	})
+
+	t.Run("Closing time is inclusive", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := entity.Curriculum{Rounds: []entity.ApplicationRound{round("Portfolio", -time.Hour, 0)}}
+		g.Expect(services.ComputeCurriculumStatus(&c, now)).To(Equal(entity.RoundStatusOpen))
+	})
+
+	t.Run("Closed without any period", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(services.ComputeCurriculumStatus(&entity.Curriculum{}, now)).To(Equal(entity.RoundStatusClosed))
+	})
+}
+
+func TestCreateCurriculumKeepsNoLegacyPeriod(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newTestDB(t, &entity.Curriculum{}, &entity.ApplicationRound{})
+	prev := config.GetDB()
+	config.SetDB(db)
+	t.Cleanup(func() { config.SetDB(prev) })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/admin/curricula", controller.NewCurriculumController().CreateCurriculum)
+	body, _ := json.Marshal(map[string]interface{}{
+		"code": "CPE", "name": "Computer Engineering", "rounds": []interface{}{},
+		"application_period": "2026-01-10T08:00|2026-02-10T16:30",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/admin/curricula", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	g.Expect(w.Code).To(Equal(http.StatusCreated))
+
+	// ไม่มีช่วงรับสมัครเดิมค้างไว้ให้ migration สร้างรอบขึ้นมาตอนเริ่มเซิร์ฟเวอร์
+	var cur entity.Curriculum
+	g.Expect(db.Preload("Rounds").First(&cur).Error).To(BeNil())
+	g.Expect(cur.ApplicationPeriod).To(BeEmpty())
+	g.Expect(cur.Rounds).To(BeEmpty())
+}
@@ -0,0 +1,228 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/controller"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+func TestApplicationValidation(t *testing.T) {
+	t.Run("Valid Application", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		app := entity.Application{
+			Status:       entity.ApplicationStatusDraft,
+			UserID:       1,
+			CurriculumID: 1,
+			PortfolioID:  1,
+		}
+
+		ok, err := govalidator.ValidateStruct(app)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(err).To(BeNil())
+	})
+
+	t.Run("Status must be known", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		app := entity.Application{
+			Status:       "pending",
+			UserID:       1,
+			CurriculumID: 1,
+			PortfolioID:  1,
+		}
+
+		ok, err := govalidator.ValidateStruct(app)
+		g.Expect(ok).To(BeFalse())
+		g.Expect(err.Error()).To(ContainSubstring("Status must be"))
+	})
+
+	t.Run("Curriculum is required", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		app := entity.Application{
+			Status:      entity.ApplicationStatusDraft,
+			UserID:      1,
+			PortfolioID: 1,
+		}
+
+		ok, err := govalidator.ValidateStruct(app)
+		g.Expect(ok).To(BeFalse())
+		g.Expect(err.Error()).To(Equal("CurriculumID is required"))
+	})
+
+	t.Run("Document needs a file", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		doc := entity.ApplicationDocument{
+			FileName:       "transcript.pdf",
+			ApplicationID:  1,
+			DocumentTypeID: 1,
+		}
+
+		ok, err := govalidator.ValidateStruct(doc)
+		g.Expect(ok).To(BeFalse())
+		g.Expect(err.Error()).To(Equal("File path is required"))
+	})
+}
+
+func TestStudentDocumentsFind(t *testing.T) {
+	docs := services.StudentDocuments{
+		TranscriptPath: "transcripts/1.pdf",
+		LanguageCerts:  map[string]string{"TOEFL": "lang/toefl.pdf"},
+	}
+
+	t.Run("Transcript comes from academic score", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		path, ok := docs.Find(&entity.DocumentType{Code: entity.DocumentCodeTranscript})
+		g.Expect(ok).To(BeTrue())
+		g.Expect(path).To(Equal("transcripts/1.pdf"))
+	})
+
+	t.Run("Missing GED certificate", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, ok := docs.Find(&entity.DocumentType{Code: entity.DocumentCodeGEDCertificate})
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("Any language certificate", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		path, ok := docs.Find(&entity.DocumentType{Code: entity.DocumentCodeLanguageCertificate})
+		g.Expect(ok).To(BeTrue())
+		g.Expect(path).To(Equal("lang/toefl.pdf"))
+	})
+
+	t.Run("Any language certificate is picked the same way every time", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		many := services.StudentDocuments{LanguageCerts: map[string]string{
+			"TOEFL": "lang/toefl.pdf", "IELTS": "lang/ielts.pdf", "CU-TEP": "lang/cutep.pdf", "TOEIC": "lang/toeic.pdf",
+		}}
+		for i := 0; i < 20; i++ {
+			path, ok := many.Find(&entity.DocumentType{Code: entity.DocumentCodeLanguageCertificate})
+			g.Expect(ok).To(BeTrue())
+			g.Expect(path).To(Equal("lang/cutep.pdf"))
+		}
+	})
+
+	t.Run("Language certificate of another test type", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, ok := docs.Find(&entity.DocumentType{Code: entity.DocumentCodeLanguageCertificate, TestType: "ielts"})
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("Upload-only documents are never on the profile", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, ok := docs.Find(&entity.DocumentType{Name: "ID card"})
+		g.Expect(ok).To(BeFalse())
+	})
+}
+
+func TestCreateApplication(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newTestDB(t, &entity.Curriculum{}, &entity.ApplicationRound{}, &entity.CurriculumRequirement{},
+		&entity.AcademicScore{}, &entity.GEDScore{}, &entity.LanguageProficiencyScore{}, &entity.Education{},
+		&entity.Application{})
+
+	now := time.Now()
+	curriculum := entity.Curriculum{Code: "CPE", Name: "Computer Engineering", Rounds: []entity.ApplicationRound{
+		{Name: "Portfolio 1", OpensAt: now.Add(-time.Hour), ClosesAt: now.Add(time.Hour)},
+	}}
+	g.Expect(db.Create(&curriculum).Error).To(BeNil())
+
+	apply := func() (*entity.Application, error) {
+		app := &entity.Application{UserID: 1, CurriculumID: curriculum.ID, PortfolioID: 1}
+		_, err := services.CreateApplication(db, app, now)
+		return app, err
+	}
+
+	t.Run("Draft is stored in the open round", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		app, err := apply()
+		g.Expect(err).To(BeNil())
+		g.Expect(app.Status).To(Equal(entity.ApplicationStatusDraft))
+		g.Expect(*app.ApplicationRoundID).To(Equal(curriculum.Rounds[0].ID))
+	})
+
+	t.Run("Second active application is refused", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, err := apply()
+		g.Expect(errors.Is(err, services.ErrApplicationExists)).To(BeTrue())
+
+		// ถ้าหลุดการตรวจไปได้ unique index ก็ยังกันไว้
+		dup := entity.Application{Status: entity.ApplicationStatusSubmitted, UserID: 1, CurriculumID: curriculum.ID, PortfolioID: 2}
+		g.Expect(db.Create(&dup).Error).NotTo(BeNil())
+	})
+
+	t.Run("Withdrawn applications do not count", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(db.Model(&entity.Application{}).Where("user_id = ?", 1).
+			Update("status", entity.ApplicationStatusWithdrawn).Error).To(BeNil())
+		_, err := apply()
+		g.Expect(err).To(BeNil())
+	})
+
+	t.Run("Submitting twice only goes through once", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		app := entity.Application{Status: entity.ApplicationStatusSubmitted, UserID: 2, CurriculumID: curriculum.ID, PortfolioID: 3}
+		g.Expect(db.Create(&app).Error).To(BeNil())
+		_, err := services.SubmitApplication(db, &app, now)
+		g.Expect(errors.Is(err, services.ErrApplicationSubmitted)).To(BeTrue())
+	})
+}
+
+func TestDecisionIsStoredWithItsNotification(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newTestDB(t, &entity.User{}, &entity.Curriculum{}, &entity.Application{}, &entity.Notification{},
+		&entity.NotificationSetting{})
+	g.Expect(db.Create(&[]entity.User{
+		{Email: "teacher@example.com", AccountTypeID: entity.UserTypeTeacher},
+		{Email: "s1@example.com", AccountTypeID: entity.UserTypeStudent},
+		{Email: "s2@example.com", AccountTypeID: entity.UserTypeStudent},
+	}).Error).To(BeNil())
+	curriculum := entity.Curriculum{Code: "CPE", Name: "Computer Engineering"}
+	g.Expect(db.Create(&curriculum).Error).To(BeNil())
+	apps := []entity.Application{
+		{Status: entity.ApplicationStatusSubmitted, UserID: 2, CurriculumID: curriculum.ID, PortfolioID: 1},
+		{Status: entity.ApplicationStatusSubmitted, UserID: 3, CurriculumID: curriculum.ID, PortfolioID: 2},
+	}
+	g.Expect(db.Create(&apps).Error).To(BeNil())
+
+	ac := controller.ApplicationController{DB: db}
+	decide := func(appID uint, status string) int {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.PATCH("/api/applications/:id/decision", func(c *gin.Context) { c.Set("user_id", uint(1)) }, ac.Decide)
+		req := httptest.NewRequest(http.MethodPatch, "/api/applications/"+strconv.Itoa(int(appID))+"/decision",
+			strings.NewReader(`{"status":"`+status+`","note":"ผลการพิจารณา"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("The applicant is notified with the decision", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(decide(apps[0].ID, entity.ApplicationStatusAccepted)).To(Equal(http.StatusOK))
+		var notifications []entity.Notification
+		g.Expect(db.Where("user_id = ?", 2).Find(&notifications).Error).To(BeNil())
+		g.Expect(notifications).To(HaveLen(1))
+		g.Expect(notifications[0].Notification_Type).To(Equal(entity.NotificationTypeApplicationUpdate))
+	})
+
+	t.Run("A decision is rolled back when its notification cannot be stored", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(db.Migrator().DropTable(&entity.Notification{})).To(Succeed())
+		g.Expect(decide(apps[1].ID, entity.ApplicationStatusRejected)).To(Equal(http.StatusInternalServerError))
+		var stored entity.Application
+		g.Expect(db.First(&stored, apps[1].ID).Error).To(BeNil())
+		g.Expect(stored.Status).To(Equal(entity.ApplicationStatusSubmitted))
+		g.Expect(stored.DecidedAt).To(BeNil())
+	})
+}
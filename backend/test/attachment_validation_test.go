@@ -17,7 +17,6 @@ func TestAnnouncementAttachmentValidation(t *testing.T) {
 			File_path:      "/uploads/documents/document.pdf",
 			Uploaded_At:    time.Now(),
 			AnnouncementID: 1,
-			CetagoryID:     1,
 		}
 
 		ok, err := govalidator.ValidateStruct(attachment)
@@ -31,7 +30,6 @@ func TestAnnouncementAttachmentValidation(t *testing.T) {
 			File_path:      "/uploads/documents/document.pdf",
 			Uploaded_At:    time.Now(),
 			AnnouncementID: 1,
-			CetagoryID:     1,
 		}
 
 		ok, err := govalidator.ValidateStruct(attachment)
@@ -46,7 +44,6 @@ func TestAnnouncementAttachmentValidation(t *testing.T) {
 			File_path:      "",
 			Uploaded_At:    time.Now(),
 			AnnouncementID: 1,
-			CetagoryID:     1,
 		}
 
 		ok, err := govalidator.ValidateStruct(attachment)
@@ -61,7 +58,6 @@ func TestAnnouncementAttachmentValidation(t *testing.T) {
 			File_path:      "/uploads/documents/document.pdf",
 			Uploaded_At:    time.Time{},
 			AnnouncementID: 1,
-			CetagoryID:     1,
 		}
 
 		ok, err := govalidator.ValidateStruct(attachment)
@@ -75,7 +71,6 @@ func TestAnnouncementAttachmentValidation(t *testing.T) {
 			File_path:      "/uploads/documents/document.pdf",
 			Uploaded_At:    time.Now(),
 			AnnouncementID: 0,
-			CetagoryID:     1,
 		}
 
 		ok, err := govalidator.ValidateStruct(attachment)
@@ -83,19 +78,4 @@ func TestAnnouncementAttachmentValidation(t *testing.T) {
 		g.Expect(err).NotTo(BeNil())
 		g.Expect(err.Error()).To(ContainSubstring("AnnouncementID"))
 	})
-
-	t.Run("CetagoryID is required", func(t *testing.T) {
-		attachment := entity.Announcement_Attachment{
-			File_name:      "document.pdf",
-			File_path:      "/uploads/documents/document.pdf",
-			Uploaded_At:    time.Now(),
-			AnnouncementID: 1,
-			CetagoryID:     0,
-		}
-
-		ok, err := govalidator.ValidateStruct(attachment)
-		g.Expect(ok).NotTo(BeTrue())
-		g.Expect(err).NotTo(BeNil())
-		g.Expect(err.Error()).To(ContainSubstring("CetagoryID"))
-	})
 }
\ No newline at end of file
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/asaskevich/govalidator"
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+func TestCommentSnippetValidation(t *testing.T) {
+	t.Run("Valid CommentSnippet", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		snippet := entity.CommentSnippet{
+			Title:    "Clear structure",
+			Body:     "{{student_name}} organised the portfolio clearly.",
+			Category: entity.SnippetCategoryStrengths,
+			UserID:   2,
+		}
+
+		ok, err := govalidator.ValidateStruct(snippet)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(err).To(BeNil())
+	})
+
+	t.Run("Category must be a feedback field", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		snippet := entity.CommentSnippet{
+			Title:    "Clear structure",
+			Body:     "Organised the portfolio clearly.",
+			Category: "praise",
+			UserID:   2,
+		}
+
+		ok, err := govalidator.ValidateStruct(snippet)
+		g.Expect(ok).To(BeFalse())
+		g.Expect(err.Error()).To(ContainSubstring("Category must be"))
+	})
+
+	t.Run("Body is required", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		snippet := entity.CommentSnippet{
+			Title:    "Empty",
+			Category: entity.SnippetCategoryOverall,
+			UserID:   2,
+		}
+
+		ok, err := govalidator.ValidateStruct(snippet)
+		g.Expect(ok).To(BeFalse())
+		g.Expect(err.Error()).To(ContainSubstring("Body is required"))
+	})
+}
+
+func TestRenderSnippet(t *testing.T) {
+	vars := map[string]string{
+		services.PlaceholderStudentName: "สมชาย ทดสอบ",
+		services.PlaceholderCurriculum:  "Computer Engineering",
+	}
+
+	t.Run("Known placeholders are substituted", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		out := services.RenderSnippet("{{student_name}} fits {{ curriculum }} well.", vars)
+		g.Expect(out).To(Equal("สมชาย ทดสอบ fits Computer Engineering well."))
+	})
+
+	t.Run("Unknown or empty placeholders are kept", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		out := services.RenderSnippet("Dear {{student_first_name}}, see {{unknown}}.", vars)
+		g.Expect(out).To(Equal("Dear {{student_first_name}}, see {{unknown}}."))
+	})
+}
@@ -0,0 +1,108 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+func TestParseCourseCSV(t *testing.T) {
+	t.Run("Valid rows with course groups", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		csv := "course_code,course_name_th,course_name_en,credits,category,course_groups\n" +
+			"cpe101,การเขียนโปรแกรม,Programming,4,3,Programming; วิชาคำนวณและตรรกะ\n" +
+			"\n" +
+			"ENG101,ภาษาอังกฤษ,,3,,\n"
+		rows, errs, err := services.ParseCourseCSV(strings.NewReader(csv))
+		g.Expect(err).To(BeNil())
+		g.Expect(errs).To(BeEmpty())
+		g.Expect(rows).To(HaveLen(2))
+		g.Expect(rows[0].Course.CourseCode).To(Equal("CPE101"))
+		g.Expect(rows[0].Course.Credits).To(Equal(4))
+		g.Expect(rows[0].CourseGroups).To(Equal([]string{"Programming", "วิชาคำนวณและตรรกะ"}))
+		g.Expect(rows[1].CourseGroups).To(BeEmpty())
+	})
+
+	t.Run("Invalid rows are reported by line", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		csv := "course_code,course_name_th,credits\n" +
+			"MAT101,แคลคูลัส,four\n" +
+			"MAT102,,3\n" +
+			"MAT103,สถิติ,3\n" +
+			"MAT103,สถิติ 2,3\n"
+		rows, errs, err := services.ParseCourseCSV(strings.NewReader(csv))
+		g.Expect(err).To(BeNil())
+		g.Expect(rows).To(HaveLen(1))
+		g.Expect(errs).To(HaveLen(3))
+		g.Expect(errs[0]).To(Equal(services.CourseImportError{Line: 2, Message: "credits must be a whole number"}))
+		g.Expect(errs[1]).To(Equal(services.CourseImportError{Line: 3, Message: "Thai course name is required"}))
+		g.Expect(errs[2].Line).To(Equal(5))
+	})
+
+	t.Run("Missing required column", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, _, err := services.ParseCourseCSV(strings.NewReader("code,name\nA,B\n"))
+		g.Expect(err).NotTo(BeNil())
+	})
+}
+
+func TestCurriculumCreditBreakdown(t *testing.T) {
+	g := NewGomegaWithT(t)
+	math := entity.CourseGroup{Name: "Math", Courses: []entity.Course{{Credits: 4}, {Credits: 4}}}
+	prog := entity.CourseGroup{Name: "Programming", Courses: []entity.Course{{Credits: 4}, {Credits: 4}, {Credits: 4}, {Credits: 4}}}
+	c := entity.Curriculum{CourseGroups: []entity.CurriculumCourseGroup{
+		{CourseGroupID: 1, CourseGroup: &math, CreditPercentage: 30},
+		{CourseGroupID: 2, CourseGroup: &prog, CreditPercentage: 70},
+	}}
+
+	res := services.CurriculumCreditBreakdown(&c)
+	g.Expect(res.TotalCredits).To(Equal(24))
+	g.Expect(res.Groups[0].ActualPercentage).To(Equal(33.3))
+	g.Expect(res.Groups[0].WithinTolerance).To(BeTrue())
+	g.Expect(res.Groups[1].Courses).To(Equal(4))
+	g.Expect(res.Consistent).To(BeTrue())
+
+	c.CourseGroups[0].CreditPercentage = 10
+	res = services.CurriculumCreditBreakdown(&c)
+	g.Expect(res.Groups[0].WithinTolerance).To(BeFalse())
+	g.Expect(res.Consistent).To(BeFalse())
+}
+
+func TestCreateCourse(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newTestDB(t, &entity.CourseGroup{}, &entity.Course{})
+	group := entity.CourseGroup{Name: "Math"}
+	g.Expect(db.Create(&group).Error).To(BeNil())
+
+	course := entity.Course{CourseCode: "MTH101", CourseNameTH: "แคลคูลัส 1", Credits: 4}
+	g.Expect(services.CreateCourse(db, &course, []entity.CourseGroup{group})).To(Succeed())
+
+	t.Run("Live course code is taken", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		dup := entity.Course{CourseCode: "MTH101", CourseNameTH: "ซ้ำ"}
+		g.Expect(services.CreateCourse(db, &dup, nil)).To(MatchError(services.ErrCourseCodeTaken))
+	})
+
+	t.Run("Deleted course is restored on re-create", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(db.Model(&course).Association("CourseGroups").Clear()).To(Succeed())
+		g.Expect(db.Delete(&course).Error).To(BeNil())
+
+		taken, err := services.CourseCodeTaken(db, "MTH101", 0)
+		g.Expect(err).To(BeNil())
+		g.Expect(taken).To(BeTrue())
+
+		again := entity.Course{CourseCode: "MTH101", CourseNameTH: "แคลคูลัส 1 (ใหม่)", Credits: 3}
+		g.Expect(services.CreateCourse(db, &again, []entity.CourseGroup{group})).To(Succeed())
+		g.Expect(again.ID).To(Equal(course.ID))
+
+		var loaded entity.Course
+		g.Expect(db.Preload("CourseGroups").First(&loaded, course.ID).Error).To(BeNil())
+		g.Expect(loaded.CourseNameTH).To(Equal("แคลคูลัส 1 (ใหม่)"))
+		g.Expect(loaded.Credits).To(Equal(3))
+		g.Expect(loaded.CourseGroups).To(HaveLen(1))
+	})
+}
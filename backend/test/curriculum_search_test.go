@@ -0,0 +1,162 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm"
+)
+
+func newCurriculumSearchDB(t *testing.T, g *WithT) *gorm.DB {
+	db := newTestDB(t, &entity.Faculty{}, &entity.Program{}, &entity.Skill{}, &entity.Curriculum{},
+		&entity.ApplicationRound{}, &entity.CurriculumSkill{})
+
+	g.Expect(db.Create(&[]entity.Faculty{{Name: "วิศวกรรมศาสตร์"}, {Name: "Science"}}).Error).To(BeNil())
+	g.Expect(db.Create(&[]entity.Program{{Name: "Computer", FacultyID: 1}, {Name: "Physics", FacultyID: 2}}).Error).To(BeNil())
+	g.Expect(db.Create(&[]entity.Skill{{SkillNameTH: "เขียนโปรแกรม"}, {SkillNameTH: "คณิตศาสตร์"}}).Error).To(BeNil())
+
+	now := time.Now()
+	round := func(name string, opens time.Time) []entity.ApplicationRound {
+		return []entity.ApplicationRound{{Name: name, OpensAt: opens, ClosesAt: opens.AddDate(0, 1, 0), Timezone: entity.DefaultRoundTimezone}}
+	}
+	// Status ที่บันทึกไว้ไม่ตรงกับรอบ: การค้นหาต้องดูจากรอบเท่านั้น
+	curricula := []entity.Curriculum{
+		{Code: "CPE", Name: "วิศวกรรมคอมพิวเตอร์", Description: "software and AI", GPAXMin: 2.75, Status: "opening", AcademicYear: "2568",
+			FacultyID: 1, ProgramID: 1, Quota: 40, Rounds: round("Portfolio 1", now.AddDate(0, 0, -1)),
+			Skills: []entity.CurriculumSkill{{SkillID: 1}, {SkillID: 2}}},
+		{Code: "EE", Name: "วิศวกรรมไฟฟ้า", Description: "power systems", GPAXMin: 2.49, Status: "open", AcademicYear: "2568",
+			FacultyID: 1, ProgramID: 1, Quota: 30, Rounds: round("Quota 2", now.AddDate(0, 1, 0)),
+			Skills: []entity.CurriculumSkill{{SkillID: 2}}},
+		{Code: "PHY", Name: "Physics", Description: "computational physics", GPAXMin: 3.5, Status: "closed", AcademicYear: "2569",
+			FacultyID: 2, ProgramID: 2, Quota: 20, Rounds: round("Portfolio 1", now.AddDate(0, 0, -2))},
+		// ฉบับร่าง นักเรียนไม่เห็น
+		{Code: "DRAFT", Name: "วิศวกรรมร่าง", Status: "open", PublishState: entity.CurriculumDraft, AcademicYear: "2568",
+			FacultyID: 1, ProgramID: 1, Quota: 10, Rounds: round("Portfolio 1", now.AddDate(0, 0, -1))},
+	}
+	g.Expect(db.Create(&curricula).Error).To(BeNil())
+	return db
+}
+
+func searchCodes(r *services.CurriculumSearchResult) []string {
+	codes := []string{}
+	for _, c := range r.Curricula {
+		codes = append(codes, c.Code)
+	}
+	return codes
+}
+
+func facetCount(buckets []services.FacetBucket, label string) int64 {
+	for _, b := range buckets {
+		if b.Label == label {
+			return b.Count
+		}
+	}
+	return 0
+}
+
+func TestSearchCurricula(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newCurriculumSearchDB(t, g)
+	now := time.Now()
+
+	t.Run("Without filters lists published curricula by name", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r, err := services.SearchCurricula(db, services.CurriculumSearchParams{}, now)
+		g.Expect(err).To(BeNil())
+		g.Expect(r.Total).To(Equal(int64(3)))
+		g.Expect(searchCodes(r)).To(Equal([]string{"PHY", "CPE", "EE"}))
+		g.Expect(r.Curricula[1].Faculty.Name).To(Equal("วิศวกรรมศาสตร์"))
+	})
+
+	t.Run("Text query matches Thai substrings, English words and faculty names", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r, _ := services.SearchCurricula(db, services.CurriculumSearchParams{Query: "คอมพิวเตอร์"}, now)
+		g.Expect(searchCodes(r)).To(Equal([]string{"CPE"}))
+
+		r, _ = services.SearchCurricula(db, services.CurriculumSearchParams{Query: "Computational"}, now)
+		g.Expect(searchCodes(r)).To(Equal([]string{"PHY"}))
+
+		r, _ = services.SearchCurricula(db, services.CurriculumSearchParams{Query: "science"}, now)
+		g.Expect(searchCodes(r)).To(Equal([]string{"PHY"}))
+	})
+
+	t.Run("Facet counts ignore their own filter", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r, err := services.SearchCurricula(db, services.CurriculumSearchParams{FacultyIDs: []uint{1}}, now)
+		g.Expect(err).To(BeNil())
+		g.Expect(searchCodes(r)).To(ConsistOf("CPE", "EE"))
+
+		g.Expect(facetCount(r.Facets.Faculties, "วิศวกรรมศาสตร์")).To(Equal(int64(2)))
+		g.Expect(facetCount(r.Facets.Faculties, "Science")).To(Equal(int64(1)))
+		g.Expect(facetCount(r.Facets.Rounds, "Portfolio 1")).To(Equal(int64(1)))
+		g.Expect(facetCount(r.Facets.Statuses, "opening")).To(Equal(int64(1)))
+		g.Expect(facetCount(r.Facets.Skills, "คณิตศาสตร์")).To(Equal(int64(2)))
+		g.Expect(facetCount(r.Facets.AcademicYears, "2569")).To(BeZero())
+		g.Expect(facetCount(r.Facets.GPAXRanges, "0.00-2.49")).To(Equal(int64(1)))
+		g.Expect(facetCount(r.Facets.GPAXRanges, "2.50-2.99")).To(Equal(int64(1)))
+	})
+
+	t.Run("Filters combine across facets", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r, _ := services.SearchCurricula(db, services.CurriculumSearchParams{Rounds: []string{"Portfolio 1"}, SkillIDs: []uint{2}}, now)
+		g.Expect(searchCodes(r)).To(Equal([]string{"CPE"}))
+
+		lo, hi, err := services.ParseGPAXRange("0.00-2.49")
+		g.Expect(err).To(BeNil())
+		r, _ = services.SearchCurricula(db, services.CurriculumSearchParams{GPAXMin: &lo, GPAXMax: &hi}, now)
+		g.Expect(searchCodes(r)).To(Equal([]string{"EE"}))
+
+		r, _ = services.SearchCurricula(db, services.CurriculumSearchParams{AcademicYears: []string{"2569"}, Statuses: []string{"open"}}, now)
+		g.Expect(searchCodes(r)).To(Equal([]string{"PHY"}))
+	})
+
+	t.Run("Status comes from the rounds at now", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r, err := services.SearchCurricula(db, services.CurriculumSearchParams{Statuses: []string{"open"}}, now)
+		g.Expect(err).To(BeNil())
+		g.Expect(searchCodes(r)).To(Equal([]string{"PHY", "CPE"}))
+		for _, c := range r.Curricula {
+			g.Expect(c.Status).To(Equal("open"))
+		}
+		g.Expect(facetCount(r.Facets.Statuses, "open")).To(Equal(int64(2)))
+		g.Expect(facetCount(r.Facets.Statuses, "opening")).To(Equal(int64(1)))
+
+		// อีกเดือนครึ่ง รอบของ CPE และ PHY ปิดแล้ว เหลือ EE ที่เปิดอยู่
+		later := now.AddDate(0, 1, 15)
+		r, err = services.SearchCurricula(db, services.CurriculumSearchParams{}, later)
+		g.Expect(err).To(BeNil())
+		g.Expect(searchCodes(r)).To(Equal([]string{"EE"}))
+		g.Expect(facetCount(r.Facets.Statuses, "open")).To(Equal(int64(1)))
+		g.Expect(facetCount(r.Facets.Statuses, "closed")).To(BeZero())
+	})
+
+	t.Run("Sorting and pagination", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r, _ := services.SearchCurricula(db, services.CurriculumSearchParams{Sort: services.CurriculumSortGPAXDesc, Limit: 2}, now)
+		g.Expect(r.Total).To(Equal(int64(3)))
+		g.Expect(searchCodes(r)).To(Equal([]string{"PHY", "CPE"}))
+
+		r, _ = services.SearchCurricula(db, services.CurriculumSearchParams{Sort: services.CurriculumSortGPAXDesc, Limit: 2, Page: 2}, now)
+		g.Expect(searchCodes(r)).To(Equal([]string{"EE"}))
+
+		// PHY ปิดก่อน CPE หนึ่งวัน, EE ยังไม่เปิด
+		r, _ = services.SearchCurricula(db, services.CurriculumSearchParams{Sort: services.CurriculumSortClosingSoon}, now)
+		g.Expect(searchCodes(r)).To(Equal([]string{"PHY", "CPE", "EE"}))
+
+		r, _ = services.SearchCurricula(db, services.CurriculumSearchParams{Sort: services.CurriculumSortQuota}, now)
+		g.Expect(searchCodes(r)).To(Equal([]string{"CPE", "EE", "PHY"}))
+
+		// ชื่อที่ตรงกับคำค้นขึ้นก่อนคำอธิบาย
+		r, _ = services.SearchCurricula(db, services.CurriculumSearchParams{Query: "physics"}, now)
+		g.Expect(searchCodes(r)).To(Equal([]string{"PHY"}))
+	})
+
+	t.Run("Invalid GPAX range", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, _, err := services.ParseGPAXRange("3.5-3")
+		g.Expect(err).NotTo(BeNil())
+	})
+}
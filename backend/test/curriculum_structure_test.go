@@ -0,0 +1,81 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+func TestCurriculumStructure(t *testing.T) {
+	t.Run("Incomplete structure reports remaining", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := services.CurriculumStructureOf([]entity.CurriculumCourseGroup{{CreditPercentage: 30}, {CreditPercentage: 10}})
+		g.Expect(s).To(Equal(entity.CurriculumStructure{Groups: 2, TotalPercentage: 40, Remaining: 60, Complete: false}))
+	})
+
+	t.Run("No groups is not complete", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(services.CurriculumStructureOf(nil).Complete).To(BeFalse())
+	})
+
+	t.Run("Bulk replace must total 100", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		err := services.ValidateCurriculumStructure([]entity.CurriculumCourseGroup{
+			{CourseGroupID: 1, CreditPercentage: 70},
+			{CourseGroupID: 2, CreditPercentage: 100},
+		})
+		g.Expect(err).NotTo(BeNil())
+		g.Expect(err.Error()).To(Equal("credit percentages must add up to 100%, got 170%"))
+
+		err = services.ValidateCurriculumStructure([]entity.CurriculumCourseGroup{
+			{CourseGroupID: 1, CreditPercentage: 70},
+			{CourseGroupID: 2, CreditPercentage: 30},
+		})
+		g.Expect(err).To(BeNil())
+	})
+
+	t.Run("Bulk replace rejects duplicates and bad percentages", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		err := services.ValidateCurriculumStructure([]entity.CurriculumCourseGroup{
+			{CourseGroupID: 1, CreditPercentage: 50},
+			{CourseGroupID: 1, CreditPercentage: 50},
+		})
+		g.Expect(err.Error()).To(Equal("course_groups[1]: course group 1 is listed twice"))
+
+		err = services.ValidateCurriculumStructure([]entity.CurriculumCourseGroup{{CourseGroupID: 1, CreditPercentage: 0}})
+		g.Expect(err.Error()).To(Equal("course_groups[0]: credit_percentage must be between 1 and 100"))
+	})
+}
+
+func TestCheckCreditPercentageChange(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newTestDB(t, &entity.CurriculumCourseGroup{})
+	g.Expect(db.Create(&[]entity.CurriculumCourseGroup{
+		{CurriculumID: 1, CourseGroupID: 1, CreditPercentage: 60},
+		{CurriculumID: 1, CourseGroupID: 2, CreditPercentage: 30},
+	}).Error).To(BeNil())
+
+	t.Run("Adding within the remaining percentage", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s, err := services.CheckCreditPercentageChange(db, 1, 3, 10)
+		g.Expect(err).To(BeNil())
+		g.Expect(s.Complete).To(BeTrue())
+	})
+
+	t.Run("Adding above the remaining percentage", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, err := services.CheckCreditPercentageChange(db, 1, 3, 20)
+		g.Expect(errors.Is(err, services.ErrCreditPercentageExceeded)).To(BeTrue())
+		g.Expect(err.Error()).To(ContainSubstring("only 10% is available"))
+	})
+
+	t.Run("Updating a group replaces its old value", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s, err := services.CheckCreditPercentageChange(db, 1, 2, 40)
+		g.Expect(err).To(BeNil())
+		g.Expect(s.TotalPercentage).To(Equal(100))
+	})
+}
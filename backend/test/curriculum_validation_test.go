@@ -93,13 +93,13 @@ func TestCurriculumCompleteValidation(t *testing.T) {
 		g.Expect(err.Error()).To(gomega.ContainSubstring("Quota must be positive"))
 	})
 
-	// Case 7: Application Period missing
-	t.Run("Should fail when Application Period is missing", func(t *testing.T) {
+	// Case 7: Application Period missing (รอบรับสมัครอยู่ใน Rounds แทนแล้ว)
+	t.Run("Should pass when Application Period is missing", func(t *testing.T) {
 		c := validCurriculum
 		c.ApplicationPeriod = ""
 		ok, err := govalidator.ValidateStruct(c)
-		g.Expect(ok).To(gomega.BeFalse())
-		g.Expect(err.Error()).To(gomega.ContainSubstring("Application Period is required"))
+		g.Expect(ok).To(gomega.BeTrue())
+		g.Expect(err).To(gomega.BeNil())
 	})
 
 	// Case 8: GPAX out of range
@@ -0,0 +1,163 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+func newCurriculumVersionDB(t *testing.T) *gorm.DB {
+	return newTestDB(t,
+		&entity.Curriculum{},
+		&entity.ApplicationRound{},
+		&entity.CurriculumRequirement{},
+		&entity.CurriculumRequiredDocument{},
+		&entity.CurriculumSkill{},
+		&entity.CurriculumCourseGroup{},
+		&entity.CurriculumVersion{},
+		&entity.FacultyAdmin{},
+	)
+}
+
+func seedVersionedCurriculum(g *WithT, db *gorm.DB) *entity.Curriculum {
+	loc := entity.LoadRoundLocation(entity.DefaultRoundTimezone)
+	announces := time.Date(2025, 12, 15, 9, 0, 0, 0, loc)
+	cur := entity.Curriculum{
+		Code:              "CPE",
+		Name:              "วิศวกรรมคอมพิวเตอร์",
+		Link:              "https://example.com/cpe",
+		GPAXMin:           3,
+		PortfolioMaxPages: 10,
+		Status:            "open",
+		AcademicYear:      "2568",
+		FacultyID:         1,
+		ProgramID:         1,
+		ApplicationPeriod: "2025-11-01T09:00|2025-11-30T16:30",
+		Quota:             30,
+		EligibilityRules:  datatypes.JSON(`{"field":"academic.gpax","cmp":">=","value":3}`),
+		Rounds: []entity.ApplicationRound{{
+			Name:        "Portfolio 1",
+			OpensAt:     time.Date(2025, 11, 1, 9, 0, 0, 0, loc),
+			ClosesAt:    time.Date(2025, 11, 30, 16, 30, 0, 0, loc),
+			AnnouncesAt: &announces,
+			Timezone:    entity.DefaultRoundTimezone,
+		}},
+		Requirements:      []entity.CurriculumRequirement{{Kind: entity.RequirementLanguageScore, TestType: "IELTS", MinScore: 6}},
+		RequiredDocuments: []entity.CurriculumRequiredDocument{{DocumentTypeID: 2, Note: "ใบ ปพ.1"}},
+		Skills:            []entity.CurriculumSkill{{SkillID: 3, Name: "Programming"}},
+		CourseGroups: []entity.CurriculumCourseGroup{
+			{CourseGroupID: 4, CreditPercentage: 70},
+			{CourseGroupID: 5, CreditPercentage: 30},
+		},
+	}
+	g.Expect(db.Create(&cur).Error).To(BeNil())
+
+	var loaded entity.Curriculum
+	g.Expect(services.PreloadCurriculumChildren(db).First(&loaded, cur.ID).Error).To(BeNil())
+	return &loaded
+}
+
+func TestCloneCurriculum(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newCurriculumVersionDB(t)
+	src := seedVersionedCurriculum(g, db)
+
+	t.Run("Clone is a draft with copied children and shifted rounds", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		clone, err := services.CloneCurriculum(db, src, services.CloneOptions{AcademicYear: "2569"})
+		g.Expect(err).To(BeNil())
+
+		var loaded entity.Curriculum
+		g.Expect(services.PreloadCurriculumChildren(db).First(&loaded, clone.ID).Error).To(BeNil())
+		g.Expect(loaded.ID).NotTo(Equal(src.ID))
+		g.Expect(loaded.PublishState).To(Equal(entity.CurriculumDraft))
+		g.Expect(loaded.IsPublished()).To(BeFalse())
+		g.Expect(*loaded.ClonedFromID).To(Equal(src.ID))
+		g.Expect(loaded.AcademicYear).To(Equal("2569"))
+		g.Expect(string(loaded.EligibilityRules)).To(Equal(string(src.EligibilityRules)))
+		// ช่วงรับสมัครแบบเดิมไม่ถูกคัดลอก มีแค่ Rounds
+		g.Expect(loaded.ApplicationPeriod).To(BeEmpty())
+
+		g.Expect(loaded.Rounds).To(HaveLen(1))
+		g.Expect(loaded.Rounds[0].ID).NotTo(Equal(src.Rounds[0].ID))
+		g.Expect(loaded.Rounds[0].OpensAt.Equal(src.Rounds[0].OpensAt.AddDate(1, 0, 0))).To(BeTrue())
+		g.Expect(loaded.Rounds[0].AnnouncesAt.Equal(src.Rounds[0].AnnouncesAt.AddDate(1, 0, 0))).To(BeTrue())
+
+		g.Expect(loaded.Requirements).To(HaveLen(1))
+		g.Expect(loaded.Requirements[0].ID).NotTo(Equal(src.Requirements[0].ID))
+		g.Expect(loaded.RequiredDocuments).To(HaveLen(1))
+		g.Expect(loaded.Skills).To(HaveLen(1))
+		g.Expect(loaded.CourseGroups).To(HaveLen(2))
+
+		// ต้นฉบับไม่ถูกแตะต้อง
+		var count int64
+		db.Model(&entity.CurriculumCourseGroup{}).Where("curriculum_id = ?", src.ID).Count(&count)
+		g.Expect(count).To(Equal(int64(2)))
+	})
+
+	t.Run("Academic year is required", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, err := services.CloneCurriculum(db, src, services.CloneOptions{})
+		g.Expect(err.Error()).To(Equal("academic_year is required"))
+	})
+
+	t.Run("Same academic year needs new rounds", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, err := services.CloneCurriculum(db, src, services.CloneOptions{AcademicYear: "2568"})
+		g.Expect(err.Error()).To(Equal("rounds are required when cloning within the same academic year"))
+	})
+}
+
+func TestCurriculumVersions(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newCurriculumVersionDB(t)
+	src := seedVersionedCurriculum(g, db)
+
+	g.Expect(services.EnsureCurriculumBaseline(db, src.ID, nil)).To(Succeed())
+
+	t.Run("Re-saving the same content records nothing", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		v, err := services.RecordCurriculumVersion(db, src.ID, "no-op", nil)
+		g.Expect(err).To(BeNil())
+		g.Expect(v).To(BeNil())
+	})
+
+	t.Run("A change records the changed fields", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(db.Model(&entity.Curriculum{}).Where("id = ?", src.ID).Update("quota", 40).Error).To(BeNil())
+		g.Expect(db.Model(&entity.CurriculumCourseGroup{}).Where("curriculum_id = ? AND course_group_id = ?", src.ID, 4).Update("credit_percentage", 60).Error).To(BeNil())
+
+		v, err := services.RecordCurriculumVersion(db, src.ID, "updated quota", nil)
+		g.Expect(err).To(BeNil())
+		g.Expect(v.Version).To(Equal(2))
+
+		var fields []string
+		g.Expect(json.Unmarshal(v.ChangedFields, &fields)).To(Succeed())
+		g.Expect(fields).To(Equal([]string{"course_groups", "quota"}))
+
+		var baseline entity.CurriculumVersion
+		g.Expect(db.Where("curriculum_id = ? AND version = 1", src.ID).First(&baseline).Error).To(BeNil())
+		changes, err := services.DiffSnapshots(baseline.Snapshot, v.Snapshot)
+		g.Expect(err).To(BeNil())
+		g.Expect(changes[1].Field).To(Equal("quota"))
+		g.Expect(string(changes[1].Before)).To(Equal("30"))
+		g.Expect(string(changes[1].After)).To(Equal("40"))
+	})
+
+	t.Run("Drafts are not versioned", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		clone, err := services.CloneCurriculum(db, src, services.CloneOptions{AcademicYear: "2569"})
+		g.Expect(err).To(BeNil())
+		g.Expect(services.EnsureCurriculumBaseline(db, clone.ID, nil)).To(Succeed())
+
+		var count int64
+		db.Model(&entity.CurriculumVersion{}).Where("curriculum_id = ?", clone.ID).Count(&count)
+		g.Expect(count).To(BeZero())
+	})
+}
@@ -0,0 +1,155 @@
+package test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+func TestCurriculumWorkflow(t *testing.T) {
+	db := newCurriculumVersionDB(t)
+	now := time.Now()
+
+	teacher := &entity.User{AccountTypeID: entity.UserTypeTeacher}
+	teacher.ID = 10
+	admin := &entity.User{AccountTypeID: entity.UserTypeAdmin}
+	admin.ID = 20
+	otherAdmin := &entity.User{AccountTypeID: entity.UserTypeAdmin}
+	otherAdmin.ID = 30
+
+	newDraft := func(g *WithT, facultyID uint) *entity.Curriculum {
+		cur := entity.Curriculum{Code: "WF", Name: "Workflow", Link: "https://example.com", Status: entity.RoundStatusOpen,
+			PublishState: entity.CurriculumDraft, FacultyID: facultyID, ProgramID: 1, ApplicationPeriod: "-", Quota: 1}
+		g.Expect(db.Create(&cur).Error).To(BeNil())
+		return &cur
+	}
+
+	t.Run("Submit, approve and archive", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		cur := newDraft(g, 100)
+
+		g.Expect(services.TransitionCurriculum(db, cur, services.CurriculumActionSubmit, teacher.ID, "", now)).To(Succeed())
+		g.Expect(cur.PublishState).To(Equal(entity.CurriculumInReview))
+		g.Expect(*cur.SubmittedByID).To(Equal(teacher.ID))
+
+		ok, err := services.CanReviewCurriculum(db, admin, cur)
+		g.Expect(err).To(BeNil())
+		g.Expect(ok).To(BeTrue())
+
+		g.Expect(services.TransitionCurriculum(db, cur, services.CurriculumActionApprove, admin.ID, "", now)).To(Succeed())
+		g.Expect(cur.IsPublished()).To(BeTrue())
+		g.Expect(cur.PublishedAt).NotTo(BeNil())
+		// สถานะตามเวลาไม่ถูกแตะ
+		g.Expect(cur.Status).To(Equal(entity.RoundStatusOpen))
+
+		g.Expect(services.TransitionCurriculum(db, cur, services.CurriculumActionArchive, admin.ID, "", now)).To(Succeed())
+		g.Expect(cur.PublishState).To(Equal(entity.CurriculumArchived))
+	})
+
+	t.Run("Actions must match the current state", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		cur := newDraft(g, 100)
+		err := services.TransitionCurriculum(db, cur, services.CurriculumActionApprove, admin.ID, "", now)
+		g.Expect(errors.Is(err, services.ErrCurriculumTransition)).To(BeTrue())
+		g.Expect(err.Error()).To(Equal("invalid curriculum state transition: cannot approve a curriculum that is draft"))
+	})
+
+	t.Run("Reject needs a note and returns to draft", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		cur := newDraft(g, 100)
+		g.Expect(services.TransitionCurriculum(db, cur, services.CurriculumActionSubmit, teacher.ID, "", now)).To(Succeed())
+
+		err := services.TransitionCurriculum(db, cur, services.CurriculumActionReject, admin.ID, " ", now)
+		g.Expect(err.Error()).To(Equal("a note is required when rejecting"))
+
+		g.Expect(services.TransitionCurriculum(db, cur, services.CurriculumActionReject, admin.ID, "เพิ่มเกณฑ์ภาษาอังกฤษ", now)).To(Succeed())
+		g.Expect(cur.PublishState).To(Equal(entity.CurriculumDraft))
+		g.Expect(cur.ReviewNote).To(Equal("เพิ่มเกณฑ์ภาษาอังกฤษ"))
+	})
+
+	t.Run("Only the faculty's admins review, never the submitter", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(db.Create(&entity.FacultyAdmin{FacultyID: 200, UserID: otherAdmin.ID}).Error).To(BeNil())
+		cur := newDraft(g, 200)
+		g.Expect(services.TransitionCurriculum(db, cur, services.CurriculumActionSubmit, otherAdmin.ID, "", now)).To(Succeed())
+
+		ok, _ := services.CanReviewCurriculum(db, admin, cur)
+		g.Expect(ok).To(BeFalse()) // ไม่ได้เป็นผู้ดูแลคณะ 200
+		ok, _ = services.CanReviewCurriculum(db, otherAdmin, cur)
+		g.Expect(ok).To(BeFalse()) // ส่งเอง อนุมัติเองไม่ได้
+		ok, _ = services.CanReviewCurriculum(db, teacher, cur)
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("Routine edits of a published curriculum stay live", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		cur := newDraft(g, 100)
+		g.Expect(services.TransitionCurriculum(db, cur, services.CurriculumActionSubmit, teacher.ID, "", now)).To(Succeed())
+		g.Expect(services.TransitionCurriculum(db, cur, services.CurriculumActionApprove, admin.ID, "", now)).To(Succeed())
+		g.Expect(services.EnsureCurriculumBaseline(db, cur.ID, &admin.ID)).To(Succeed())
+
+		g.Expect(db.Model(cur).Update("description", "แก้คำผิด").Error).To(BeNil())
+		g.Expect(db.Create(&entity.ApplicationRound{CurriculumID: cur.ID, Name: "Quota", OpensAt: now.Add(-2 * time.Hour), ClosesAt: now.Add(-time.Hour)}).Error).To(BeNil())
+		revised, err := services.ReviseCurriculum(db, cur.ID, otherAdmin.ID, "updated curriculum", now)
+		g.Expect(err).To(BeNil())
+		g.Expect(revised).To(BeFalse())
+		g.Expect(db.First(cur, cur.ID).Error).To(BeNil())
+		g.Expect(cur.IsPublished()).To(BeTrue())
+
+		var versions []entity.CurriculumVersion
+		g.Expect(db.Where("curriculum_id = ?", cur.ID).Order("version").Find(&versions).Error).To(BeNil())
+		g.Expect(versions).To(HaveLen(2))
+		g.Expect(string(versions[1].ChangedFields)).To(Equal(`["description","rounds"]`))
+	})
+
+	t.Run("Editing who may apply sends a published curriculum back to review", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		cur := newDraft(g, 100)
+		g.Expect(services.TransitionCurriculum(db, cur, services.CurriculumActionSubmit, teacher.ID, "", now)).To(Succeed())
+		g.Expect(services.TransitionCurriculum(db, cur, services.CurriculumActionApprove, admin.ID, "", now)).To(Succeed())
+		g.Expect(services.EnsureCurriculumBaseline(db, cur.ID, &admin.ID)).To(Succeed())
+
+		g.Expect(db.Model(cur).Update("quota", 50).Error).To(BeNil())
+		revised, err := services.ReviseCurriculum(db, cur.ID, otherAdmin.ID, "updated curriculum", now)
+		g.Expect(err).To(BeNil())
+		g.Expect(revised).To(BeTrue())
+		g.Expect(db.First(cur, cur.ID).Error).To(BeNil())
+		g.Expect(cur.PublishState).To(Equal(entity.CurriculumInReview))
+		g.Expect(*cur.SubmittedByID).To(Equal(otherAdmin.ID))
+		g.Expect(cur.ReviewedByID).To(BeNil())
+
+		// ฉบับร่างแก้ได้ตามปกติ
+		draft := newDraft(g, 100)
+		revised, err = services.ReviseCurriculum(db, draft.ID, teacher.ID, "updated curriculum", now)
+		g.Expect(err).To(BeNil())
+		g.Expect(revised).To(BeFalse())
+	})
+
+	t.Run("Students only see published curricula", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		var codes []string
+		g.Expect(services.VisibleToStudents(db, now).Model(&entity.Curriculum{}).Where("code = ?", "WF").Pluck("publish_state", &codes).Error).To(BeNil())
+		g.Expect(codes).To(BeEmpty()) // ทั้งหมดเป็น draft/archived
+	})
+
+	t.Run("Students only see curricula with a round that has not closed", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		round := func(opens time.Time) []entity.ApplicationRound {
+			return []entity.ApplicationRound{{Name: "Portfolio 1", OpensAt: opens, ClosesAt: opens.Add(24 * time.Hour)}}
+		}
+		// Status ที่บันทึกไว้ยังเป็น open แต่รอบปิดไปแล้ว
+		g.Expect(db.Create(&[]entity.Curriculum{
+			{Code: "CLOSED", Name: "Closed", Status: entity.RoundStatusOpen, PublishState: entity.CurriculumPublished, Rounds: round(now.Add(-48 * time.Hour))},
+			{Code: "LATER", Name: "Later", Status: entity.RoundStatusClosed, PublishState: entity.CurriculumPublished, Rounds: round(now.Add(time.Hour))},
+			{Code: "NOROUND", Name: "No round", Status: entity.RoundStatusOpen, PublishState: entity.CurriculumPublished},
+		}).Error).To(BeNil())
+
+		var codes []string
+		g.Expect(services.VisibleToStudents(db, now).Model(&entity.Curriculum{}).Order("code").Pluck("code", &codes).Error).To(BeNil())
+		g.Expect(codes).To(Equal([]string{"LATER"}))
+	})
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/asaskevich/govalidator"
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+func TestEvaluateEligibility(t *testing.T) {
+	highSchool := uint(1)
+	profile := &services.StudentProfile{
+		Academic: &entity.AcademicScore{GPAX: 3.25, GPAMath: 3.5, GPAEnglish: 2.5},
+		Languages: []entity.LanguageProficiencyScore{
+			{TestType: "ielts", Score: "6.0"},
+			{TestType: "IELTS", Score: "6.5"},
+			{TestType: "CU-TEP", Score: "B2"},
+		},
+		Education: &entity.Education{EducationLevelID: highSchool},
+	}
+
+	t.Run("GPAXMin becomes a rule", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := entity.Curriculum{GPAXMin: 3.5}
+		res := services.EvaluateEligibility(&c, profile)
+		g.Expect(res.Eligible).To(BeFalse())
+		g.Expect(res.Results).To(HaveLen(1))
+		g.Expect(res.Results[0].Reason).To(Equal("GPAX 3.25 does not meet >= 3.5"))
+	})
+
+	t.Run("Explicit gpax rule replaces GPAXMin", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := entity.Curriculum{GPAXMin: 3.5, Requirements: []entity.CurriculumRequirement{
+			{Kind: entity.RequirementGPAX, MinScore: 3},
+		}}
+		res := services.EvaluateEligibility(&c, profile)
+		g.Expect(res.Eligible).To(BeTrue())
+		g.Expect(res.Results).To(HaveLen(1))
+	})
+
+	t.Run("Subject GPA", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := entity.Curriculum{Requirements: []entity.CurriculumRequirement{
+			{Kind: entity.RequirementSubjectGPA, Subject: "math", MinScore: 3},
+			{Kind: entity.RequirementSubjectGPA, Subject: "english", MinScore: 3},
+		}}
+		res := services.EvaluateEligibility(&c, profile)
+		g.Expect(res.Eligible).To(BeFalse())
+		g.Expect(res.FailedGroups).To(Equal([]string{"GPA english"}))
+	})
+
+	t.Run("Language alternatives in one group", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := entity.Curriculum{Requirements: []entity.CurriculumRequirement{
+			{Kind: entity.RequirementLanguageScore, Group: "english", TestType: "TOEFL", MinScore: 80},
+			{Kind: entity.RequirementLanguageScore, Group: "english", TestType: "IELTS", MinScore: 6.5},
+		}}
+		res := services.EvaluateEligibility(&c, profile)
+		g.Expect(res.Eligible).To(BeTrue())
+		g.Expect(res.Results[0].Passed).To(BeFalse())
+		g.Expect(res.Results[1].Actual).To(Equal("6.5"))
+		g.Expect(services.EligibilityIssues(res)).To(BeEmpty())
+	})
+
+	t.Run("Non numeric language scores are ignored", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := entity.Curriculum{Requirements: []entity.CurriculumRequirement{
+			{Kind: entity.RequirementLanguageScore, TestType: "CU-TEP", MinScore: 60},
+		}}
+		res := services.EvaluateEligibility(&c, profile)
+		g.Expect(res.Eligible).To(BeFalse())
+		g.Expect(res.Results[0].Reason).To(Equal("no CU-TEP score on the profile"))
+	})
+
+	t.Run("Missing GED scores", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := entity.Curriculum{Requirements: []entity.CurriculumRequirement{
+			{Kind: entity.RequirementGEDScore, MinScore: 600},
+		}}
+		res := services.EvaluateEligibility(&c, profile)
+		g.Expect(res.Eligible).To(BeFalse())
+		issues := services.EligibilityIssues(res)
+		g.Expect(issues).To(HaveLen(1))
+		g.Expect(issues[0].Code).To(Equal(services.IssueRequirementNotMet))
+	})
+
+	t.Run("Education level", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		vocational := uint(2)
+		c := entity.Curriculum{Requirements: []entity.CurriculumRequirement{
+			{Kind: entity.RequirementEducationLevel, Group: "level", EducationLevelID: &vocational},
+			{Kind: entity.RequirementEducationLevel, Group: "level", EducationLevelID: &highSchool},
+		}}
+		g.Expect(services.EvaluateEligibility(&c, profile).Eligible).To(BeTrue())
+	})
+
+	t.Run("No requirements means eligible", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		res := services.EvaluateEligibility(&entity.Curriculum{}, &services.StudentProfile{})
+		g.Expect(res.Eligible).To(BeTrue())
+		g.Expect(res.Results).To(BeEmpty())
+	})
+}
+
+func TestCurriculumRequirementValidation(t *testing.T) {
+	t.Run("Unknown kind", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		req := entity.CurriculumRequirement{Kind: "age", CurriculumID: 1}
+		ok, err := govalidator.ValidateStruct(req)
+		g.Expect(ok).To(BeFalse())
+		g.Expect(err.Error()).To(Equal("Kind is not supported"))
+	})
+}
@@ -0,0 +1,294 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+// fakeMailer records the emails it sends and fails while Err is set.
+type fakeMailer struct {
+	Err  error
+	Sent []string // "<to>: <subject>"
+}
+
+func (f *fakeMailer) SendEmail(ctx context.Context, to string, email *services.RenderedEmail) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.Sent = append(f.Sent, to+": "+email.Subject)
+	return nil
+}
+
+// smtpCatcher is a tiny SMTP server standing in for MailHog: it accepts every message and
+// hands its DATA to Received.
+type smtpCatcher struct {
+	ln       net.Listener
+	Received chan []byte
+}
+
+func startSMTPCatcher(g *WithT) *smtpCatcher {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).To(BeNil())
+	s := &smtpCatcher{ln: ln, Received: make(chan []byte, 1)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpCatcher) Addr() string { return s.ln.Addr().String() }
+
+func (s *smtpCatcher) Close() { s.ln.Close() }
+
+func (s *smtpCatcher) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(strings.Fields(line + " ")[0]); cmd {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250 localhost")
+		case "DATA":
+			tp.PrintfLine("354 end with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.Received <- data
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default: // MAIL, RCPT, RSET, NOOP
+			tp.PrintfLine("250 OK")
+		}
+	}
+}
+
+func TestEmailTemplates(t *testing.T) {
+	deadline := time.Date(2026, 3, 5, 9, 30, 0, 0, time.UTC) // 16:30 เวลาไทย
+	data := services.EmailData{
+		Name: "สมหญิง ใจดี", URL: "https://portfolio.example.com/student/portfolio", Time: deadline, Deadline: deadline,
+		Status: entity.ApplicationStatusAccepted, Curriculum: "วิศวกรรมคอมพิวเตอร์", Note: "ยินดีด้วย",
+		Author: "อ.สมชาย", Title: "Portfolio ปี 2569", Message: "ควรเพิ่ม <script>alert(1)</script> & ตัวอย่างผลงาน",
+	}
+
+	t.Run("Every template renders in every language", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		for _, name := range services.EmailTemplates {
+			for _, lang := range services.EmailLanguages {
+				email, err := services.RenderEmail(name, lang, data)
+				g.Expect(err).To(BeNil(), name+"."+lang)
+				g.Expect(email.Subject).NotTo(BeEmpty(), name+"."+lang)
+				g.Expect(email.Subject).NotTo(ContainSubstring("\n"))
+				g.Expect(email.Summary).NotTo(BeEmpty(), name+"."+lang)
+				g.Expect(email.HTML).To(ContainSubstring(`<html lang="` + lang + `">`))
+				g.Expect(email.HTML).To(ContainSubstring(`href="https://portfolio.example.com/student/portfolio"`))
+				g.Expect(email.Text).To(ContainSubstring("https://portfolio.example.com/student/portfolio"))
+			}
+		}
+	})
+
+	t.Run("Thai and English variants", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		th, err := services.RenderEmail(services.EmailStatusChanged, entity.LanguageTH, data)
+		g.Expect(err).To(BeNil())
+		g.Expect(th.Subject).To(Equal("สถานะใบสมัครหลักสูตร วิศวกรรมคอมพิวเตอร์: ผ่านการคัดเลือก"))
+		g.Expect(th.Text).To(ContainSubstring("เมื่อ 5 มีนาคม 2569 เวลา 16:30 น."))
+		g.Expect(th.Text).To(ContainSubstring("ยินดีด้วย"))
+
+		en, err := services.RenderEmail(services.EmailStatusChanged, entity.LanguageEN, data)
+		g.Expect(err).To(BeNil())
+		g.Expect(en.Subject).To(Equal("Application to วิศวกรรมคอมพิวเตอร์: Accepted"))
+		g.Expect(en.Text).To(ContainSubstring("on 5 March 2026, 16:30"))
+
+		fallback, err := services.RenderEmail(services.EmailStatusChanged, "jp", data)
+		g.Expect(err).To(BeNil())
+		g.Expect(fallback.Subject).To(Equal(th.Subject))
+
+		_, err = services.RenderEmail("welcome", entity.LanguageTH, data)
+		g.Expect(err).NotTo(BeNil())
+	})
+
+	t.Run("HTML is escaped but text is not", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		email, err := services.RenderEmail(services.EmailFeedbackPosted, entity.LanguageTH, data)
+		g.Expect(err).To(BeNil())
+		g.Expect(email.HTML).NotTo(ContainSubstring("<script>"))
+		g.Expect(email.HTML).To(ContainSubstring("&lt;script&gt;"))
+		g.Expect(email.Text).To(ContainSubstring("<script>alert(1)</script> & ตัวอย่างผลงาน"))
+	})
+}
+
+func TestEmailQueue(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newTestDB(t, &entity.User{}, &entity.Curriculum{}, &entity.Application{}, &entity.Notification{},
+		&entity.NotificationPreference{}, &entity.NotificationSetting{}, &entity.NotificationDelivery{},
+		&entity.EmailMessage{})
+
+	users := []entity.User{
+		{Email: "th@example.com", FirstNameTH: "สมหญิง", LastNameTH: "ใจดี"},
+		{Email: "en@example.com", FirstNameTH: "จอห์น", FirstNameEN: "John", LastNameEN: "Smith"},
+	}
+	g.Expect(db.Create(&users).Error).To(BeNil())
+	g.Expect(db.Create(&entity.NotificationSetting{UserID: users[1].ID, Language: entity.LanguageEN}).Error).To(BeNil())
+	curriculum := entity.Curriculum{Code: "CPE", Name: "วิศวกรรมคอมพิวเตอร์"}
+	g.Expect(db.Create(&curriculum).Error).To(BeNil())
+
+	mailer := &fakeMailer{}
+	queue := services.NewEmailQueue(db, mailer)
+	services.Emails = queue
+	defer func() { services.Emails = nil }()
+
+	now := time.Date(2026, 1, 10, 9, 0, 0, 0, services.AnnouncementLocation())
+	apps := []entity.Application{
+		{Status: entity.ApplicationStatusAccepted, UserID: users[0].ID, CurriculumID: curriculum.ID, DecisionNote: "ยินดีด้วย"},
+		{Status: entity.ApplicationStatusAccepted, UserID: users[1].ID, CurriculumID: curriculum.ID},
+	}
+	g.Expect(db.Create(&apps).Error).To(BeNil())
+
+	messages := func(g *WithT) []entity.EmailMessage {
+		var rows []entity.EmailMessage
+		g.Expect(db.Order("id").Find(&rows).Error).To(BeNil())
+		return rows
+	}
+
+	t.Run("Events are rendered in the user's language and queued once", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		for _, app := range apps {
+			app := app
+			g.Expect(services.NotifyApplicationStatusChanged(db, &app, now)).To(Succeed())
+		}
+		g.Expect(services.NotifyApplicationStatusChanged(db, &apps[0], now)).To(Succeed()) // ซ้ำ
+
+		rows := messages(g)
+		g.Expect(rows).To(HaveLen(2))
+		g.Expect(rows[0].To).To(Equal("th@example.com"))
+		g.Expect(rows[0].Language).To(Equal(entity.LanguageTH))
+		g.Expect(rows[0].Subject).To(Equal("สถานะใบสมัครหลักสูตร วิศวกรรมคอมพิวเตอร์: ผ่านการคัดเลือก"))
+		g.Expect(rows[0].TextBody).To(ContainSubstring("เรียน คุณสมหญิง ใจดี"))
+		g.Expect(rows[0].Status).To(Equal(entity.DeliveryPending))
+		g.Expect(rows[1].Language).To(Equal(entity.LanguageEN))
+		g.Expect(rows[1].Subject).To(Equal("Application to วิศวกรรมคอมพิวเตอร์: Accepted"))
+		g.Expect(rows[1].TextBody).To(ContainSubstring("Dear John Smith,"))
+
+		// แจ้งเตือนในแอปใช้หัวเรื่องเดียวกับอีเมล ในภาษาของผู้ใช้
+		var inApp entity.Notification
+		g.Expect(db.Where("user_id = ?", users[1].ID).First(&inApp).Error).To(BeNil())
+		g.Expect(inApp.Notification_Type).To(Equal(entity.NotificationTypeApplicationUpdate))
+		g.Expect(inApp.Notification_Title).To(Equal(rows[1].Subject))
+		g.Expect(inApp.Group_Key).To(Equal(entity.NotificationGroupKey("application", apps[1].ID)))
+
+		n, err := queue.DeliverDue(context.Background(), now)
+		g.Expect(err).To(BeNil())
+		g.Expect(n).To(Equal(2))
+		g.Expect(mailer.Sent).To(Equal([]string{
+			"th@example.com: สถานะใบสมัครหลักสูตร วิศวกรรมคอมพิวเตอร์: ผ่านการคัดเลือก",
+			"en@example.com: Application to วิศวกรรมคอมพิวเตอร์: Accepted",
+		}))
+		g.Expect(messages(g)[0].Status).To(Equal(entity.DeliverySent))
+		g.Expect(messages(g)[0].SentAt).NotTo(BeNil())
+	})
+
+	t.Run("Users who turned email off get none", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(db.Create(&entity.NotificationPreference{UserID: users[0].ID, Type: entity.NotificationTypeApplicationUpdate,
+			Channel: entity.NotificationChannelEmail, Enabled: false}).Error).To(BeNil())
+		app := apps[0]
+		app.Status = entity.ApplicationStatusRejected
+		g.Expect(services.NotifyApplicationStatusChanged(db, &app, now)).To(Succeed())
+		g.Expect(messages(g)).To(HaveLen(2))
+	})
+
+	t.Run("Failures are retried with backoff and then given up", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		queue.MaxAttempts = 3
+		mailer.Err = errors.New("smtp down")
+		defer func() { queue.MaxAttempts, mailer.Err = 5, nil }()
+
+		app := apps[1]
+		app.Status = entity.ApplicationStatusUnderReview
+		g.Expect(services.NotifyApplicationStatusChanged(db, &app, now)).To(Succeed())
+		last := func(g *WithT) entity.EmailMessage { rows := messages(g); return rows[len(rows)-1] }
+
+		at := now
+		for attempt, wait := range []time.Duration{time.Minute, 2 * time.Minute} {
+			_, err := queue.DeliverDue(context.Background(), at)
+			g.Expect(err).To(BeNil())
+			msg := last(g)
+			g.Expect(msg.Status).To(Equal(entity.DeliveryPending))
+			g.Expect(msg.Attempts).To(Equal(attempt + 1))
+			g.Expect(msg.LastError).To(Equal("smtp down"))
+			g.Expect(msg.NextAttemptAt.Equal(at.Add(wait))).To(BeTrue())
+			at = msg.NextAttemptAt
+		}
+		_, err := queue.DeliverDue(context.Background(), at)
+		g.Expect(err).To(BeNil())
+		g.Expect(last(g).Status).To(Equal(entity.DeliveryFailed))
+		g.Expect(last(g).Attempts).To(Equal(3))
+	})
+}
+
+func TestSMTPMailer(t *testing.T) {
+	g := NewGomegaWithT(t)
+	catcher := startSMTPCatcher(g)
+	defer catcher.Close()
+
+	email, err := services.RenderEmail(services.EmailSubmissionReceived, entity.LanguageTH, services.EmailData{
+		Name: "สมหญิง", Curriculum: "วิศวกรรมคอมพิวเตอร์", Time: time.Now(),
+	})
+	g.Expect(err).To(BeNil())
+	mailer := &services.SMTPMailer{Addr: catcher.Addr(), From: "no-reply@example.com"}
+	g.Expect(mailer.SendEmail(context.Background(), "student@example.com", email)).To(Succeed())
+
+	var raw []byte
+	g.Eventually(catcher.Received).Should(Receive(&raw))
+	msg, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(string(raw))))
+	g.Expect(err).To(BeNil())
+	g.Expect(msg.Header.Get("To")).To(Equal("student@example.com"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	g.Expect(err).To(BeNil())
+	g.Expect(subject).To(Equal(email.Subject))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	g.Expect(err).To(BeNil())
+	g.Expect(mediaType).To(Equal("multipart/alternative"))
+	parts := map[string]string{}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		g.Expect(err).To(BeNil())
+		body, err := io.ReadAll(part) // quoted-printable ถูกถอดให้อัตโนมัติ
+		g.Expect(err).To(BeNil())
+		parts[strings.Split(part.Header.Get("Content-Type"), ";")[0]] = strings.ReplaceAll(string(body), "\r\n", "\n")
+	}
+	g.Expect(parts["text/plain"]).To(Equal(email.Text))
+	g.Expect(parts["text/html"]).To(Equal(email.HTML))
+}
@@ -0,0 +1,37 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/asaskevich/govalidator"
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+)
+
+func TestEvidenceSkillValidation(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("Valid activity skill", func(t *testing.T) {
+		ok, err := govalidator.ValidateStruct(entity.ActivitySkill{SkillID: 1, Level: 3})
+		g.Expect(ok).To(BeTrue())
+		g.Expect(err).To(BeNil())
+	})
+
+	t.Run("Level is optional", func(t *testing.T) {
+		ok, err := govalidator.ValidateStruct(entity.WorkingSkill{SkillID: 1})
+		g.Expect(ok).To(BeTrue())
+		g.Expect(err).To(BeNil())
+	})
+
+	t.Run("Skill is required", func(t *testing.T) {
+		ok, err := govalidator.ValidateStruct(entity.ActivitySkill{Level: 3})
+		g.Expect(ok).To(BeFalse())
+		g.Expect(err.Error()).To(Equal("Skill is required"))
+	})
+
+	t.Run("Level out of range", func(t *testing.T) {
+		ok, err := govalidator.ValidateStruct(entity.WorkingSkill{SkillID: 1, Level: 6})
+		g.Expect(ok).To(BeFalse())
+		g.Expect(err.Error()).To(Equal("Level must be between 0 and 5"))
+	})
+}
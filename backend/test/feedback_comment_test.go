@@ -0,0 +1,125 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+func TestCarryOverFeedbackComments(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newTestDB(t, &entity.User{}, &entity.Portfolio{}, &entity.PortfolioSection{}, &entity.PortfolioBlock{},
+		&entity.PortfolioSubmission{}, &entity.FeedbackComment{})
+
+	student, reviewer := uint(1), uint(2)
+	portfolio := entity.Portfolio{UserID: student}
+	g.Expect(db.Create(&portfolio).Error).To(BeNil())
+	sections := []entity.PortfolioSection{
+		{SectionTitle: "ประวัติ", PortfolioID: portfolio.ID},
+		{SectionTitle: "กิจกรรม", PortfolioID: portfolio.ID},
+		{SectionTitle: "ผลงาน", PortfolioID: portfolio.ID},
+	}
+	g.Expect(db.Create(&sections).Error).To(BeNil())
+	blocks := []entity.PortfolioBlock{
+		{BlockPortType: "text", PortfolioSectionID: sections[0].ID},  // ย้ายไปหัวข้ออื่น
+		{BlockPortType: "image", PortfolioSectionID: sections[1].ID}, // ถูกลบ
+		{BlockPortType: "text", PortfolioSectionID: sections[2].ID},  // ถูกลบพร้อมหัวข้อ
+		{BlockPortType: "text", PortfolioSectionID: sections[1].ID},  // ไม่เปลี่ยน
+	}
+	g.Expect(db.Create(&blocks).Error).To(BeNil())
+
+	v1 := entity.PortfolioSubmission{Version: 1, Status: "submitted", Submission_at: time.Now(), PortfolioID: portfolio.ID, UserID: student}
+	g.Expect(db.Create(&v1).Error).To(BeNil())
+
+	comment := func(body string, block *entity.PortfolioBlock, parent *entity.FeedbackComment) *entity.FeedbackComment {
+		c := entity.FeedbackComment{Body: body, PortfolioSubmissionID: v1.ID, UserID: reviewer}
+		if parent != nil {
+			c.ParentID = &parent.ID
+			c.UserID = student
+		}
+		sectionID := block.PortfolioSectionID
+		c.PortfolioSectionID = &sectionID
+		c.PortfolioBlockID = &block.ID
+		g.Expect(db.Create(&c).Error).To(BeNil())
+		return &c
+	}
+	moved := comment("ย่อหน้านี้ยาวไป", &blocks[0], nil)
+	deleted := comment("รูปไม่ชัด", &blocks[1], nil)
+	reply := comment("แก้แล้วครับ", &blocks[1], deleted)
+	gone := comment("ผลงานนี้ไม่เกี่ยว", &blocks[2], nil)
+	kept := comment("ดีมาก", &blocks[3], nil)
+	resolvedAt := time.Now()
+	g.Expect(db.Model(kept).Updates(map[string]interface{}{"is_resolved": true, "resolved_at": resolvedAt, "resolved_by_id": reviewer}).Error).To(BeNil())
+
+	// นักเรียนแก้พอร์ตก่อนส่งรอบใหม่
+	g.Expect(db.Model(&blocks[0]).Update("portfolio_section_id", sections[1].ID).Error).To(BeNil())
+	g.Expect(db.Delete(&blocks[1]).Error).To(BeNil())
+	g.Expect(db.Delete(&blocks[2]).Error).To(BeNil())
+	g.Expect(db.Delete(&sections[2]).Error).To(BeNil())
+
+	v2 := entity.PortfolioSubmission{Version: 2, Status: "submitted", Submission_at: time.Now(), PortfolioID: portfolio.ID, UserID: student}
+	g.Expect(db.Create(&v2).Error).To(BeNil())
+	g.Expect(services.CarryOverFeedbackComments(db, v1.ID, v2.ID)).To(Succeed())
+
+	var carried []entity.FeedbackComment
+	g.Expect(db.Where("portfolio_submission_id = ?", v2.ID).Find(&carried).Error).To(BeNil())
+	g.Expect(carried).To(HaveLen(5))
+	byOrigin := map[uint]entity.FeedbackComment{}
+	for _, c := range carried {
+		g.Expect(c.CarriedFromID).NotTo(BeNil())
+		byOrigin[*c.CarriedFromID] = c
+	}
+
+	t.Run("Comments follow a block that moved", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := byOrigin[moved.ID]
+		g.Expect(*c.PortfolioBlockID).To(Equal(blocks[0].ID))
+		g.Expect(*c.PortfolioSectionID).To(Equal(sections[1].ID))
+	})
+
+	t.Run("Comments on a deleted block fall back to its section", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := byOrigin[deleted.ID]
+		g.Expect(c.PortfolioBlockID).To(BeNil())
+		g.Expect(*c.PortfolioSectionID).To(Equal(sections[1].ID))
+	})
+
+	t.Run("Comments whose section was deleted are unanchored", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := byOrigin[gone.ID]
+		g.Expect(c.IsAnchored()).To(BeFalse())
+		g.Expect(c.IsResolved).To(BeFalse())
+	})
+
+	t.Run("Replies follow their parent", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := byOrigin[reply.ID]
+		parent := byOrigin[deleted.ID]
+		g.Expect(*c.ParentID).To(Equal(parent.ID))
+		g.Expect(c.PortfolioBlockID).To(BeNil())
+		g.Expect(*c.PortfolioSectionID).To(Equal(*parent.PortfolioSectionID))
+		g.Expect(c.UserID).To(Equal(student))
+	})
+
+	t.Run("Unchanged anchors and resolved state are kept", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := byOrigin[kept.ID]
+		g.Expect(*c.PortfolioBlockID).To(Equal(blocks[3].ID))
+		g.Expect(*c.PortfolioSectionID).To(Equal(sections[1].ID))
+		g.Expect(c.IsResolved).To(BeTrue())
+		g.Expect(*c.ResolvedByID).To(Equal(reviewer))
+	})
+
+	t.Run("Nothing is copied from a submission without comments", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		v3 := entity.PortfolioSubmission{Version: 3, Status: "submitted", Submission_at: time.Now(), PortfolioID: portfolio.ID, UserID: student}
+		g.Expect(db.Create(&v3).Error).To(BeNil())
+		g.Expect(services.CarryOverFeedbackComments(db, v3.ID, v3.ID)).To(Succeed())
+		var count int64
+		g.Expect(db.Model(&entity.FeedbackComment{}).Where("portfolio_submission_id = ?", v3.ID).Count(&count).Error).To(BeNil())
+		g.Expect(count).To(BeZero())
+	})
+}
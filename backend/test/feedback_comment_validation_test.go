@@ -0,0 +1,70 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/asaskevich/govalidator"
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+)
+
+func TestFeedbackCommentValidation(t *testing.T) {
+	g := NewGomegaWithT(t)
+	sectionID := uint(3)
+
+	t.Run("Valid FeedbackComment", func(t *testing.T) {
+		comment := entity.FeedbackComment{
+			Body:                  "Please add a caption to this image",
+			PortfolioSubmissionID: 1,
+			PortfolioSectionID:    &sectionID,
+			UserID:                2,
+		}
+
+		ok, err := govalidator.ValidateStruct(comment)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(err).To(BeNil())
+		g.Expect(comment.IsAnchored()).To(BeTrue())
+	})
+
+	t.Run("Body is required", func(t *testing.T) {
+		comment := entity.FeedbackComment{
+			Body:                  "",
+			PortfolioSubmissionID: 1,
+			UserID:                2,
+		}
+
+		ok, err := govalidator.ValidateStruct(comment)
+		g.Expect(ok).NotTo(BeTrue())
+		g.Expect(err).NotTo(BeNil())
+		g.Expect(err.Error()).To(ContainSubstring("Body is required"))
+	})
+
+	t.Run("Body must not exceed 2000 characters", func(t *testing.T) {
+		comment := entity.FeedbackComment{
+			Body:                  strings.Repeat("a", 2001),
+			PortfolioSubmissionID: 1,
+			UserID:                2,
+		}
+
+		ok, err := govalidator.ValidateStruct(comment)
+		g.Expect(ok).NotTo(BeTrue())
+		g.Expect(err.Error()).To(ContainSubstring("Body must be between 1-2000 characters"))
+	})
+
+	t.Run("PortfolioSubmissionID is required", func(t *testing.T) {
+		comment := entity.FeedbackComment{
+			Body:   "Nice layout",
+			UserID: 2,
+		}
+
+		ok, err := govalidator.ValidateStruct(comment)
+		g.Expect(ok).NotTo(BeTrue())
+		g.Expect(err.Error()).To(ContainSubstring("PortfolioSubmissionID is required"))
+	})
+
+	t.Run("Comment without section or block is not anchored", func(t *testing.T) {
+		comment := entity.FeedbackComment{Body: "General note"}
+		g.Expect(comment.IsAnchored()).To(BeFalse())
+	})
+}
@@ -0,0 +1,268 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm"
+)
+
+// fakeChannel records what it sends and fails while Err is set.
+type fakeChannel struct {
+	name string
+	Err  error
+	Sent []string // "<user email>: <title>"
+}
+
+func (f *fakeChannel) Name() string { return f.name }
+
+func (f *fakeChannel) Send(ctx context.Context, msg services.NotificationMessage) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.Sent = append(f.Sent, msg.User.Email+": "+msg.Notification.Notification_Title)
+	return nil
+}
+
+func TestNotificationDispatcher(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newTestDB(t, &entity.User{}, &entity.Notification{}, &entity.NotificationPreference{},
+		&entity.NotificationSetting{}, &entity.NotificationDelivery{})
+
+	users := []entity.User{{Email: "s1@example.com"}, {Email: "s2@example.com"}}
+	g.Expect(db.Create(&users).Error).To(BeNil())
+
+	email := &fakeChannel{name: entity.NotificationChannelEmail}
+	webhook := &fakeChannel{name: entity.NotificationChannelWebhook}
+	d := services.NewNotificationDispatcher(db, email, webhook)
+	services.Dispatcher = d
+	defer func() { services.Dispatcher = nil }()
+
+	// 23:00 เวลาไทย
+	now := time.Date(2026, 1, 10, 23, 0, 0, 0, services.AnnouncementLocation())
+	notify := func(g *WithT, typ, title string, userIDs ...uint) []entity.Notification {
+		notifications := make([]entity.Notification, 0, len(userIDs))
+		for i := range userIDs {
+			notifications = append(notifications, entity.Notification{
+				Notification_Title: title, Notification_Type: typ, UserID: &userIDs[i], Sent_At: now,
+			})
+		}
+		g.Expect(services.CreateNotifications(db, notifications, now)).To(Succeed())
+		return notifications
+	}
+	deliveries := func(g *WithT, n entity.Notification) map[string]entity.NotificationDelivery {
+		var rows []entity.NotificationDelivery
+		g.Expect(db.Where("notification_id = ?", n.ID).Find(&rows).Error).To(BeNil())
+		byChannel := map[string]entity.NotificationDelivery{}
+		for _, r := range rows {
+			byChannel[r.Channel] = r
+		}
+		return byChannel
+	}
+
+	t.Run("Channels follow preferences and defaults", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(db.Create(&[]entity.NotificationPreference{
+			{UserID: 2, Type: entity.NotificationTypeSystem, Channel: entity.NotificationChannelEmail, Enabled: false},
+			{UserID: 2, Type: entity.NotificationTypeSystem, Channel: entity.NotificationChannelWebhook, Enabled: true},
+		}).Error).To(BeNil())
+
+		sent := notify(g, "System", "ปิดปรับปรุงระบบ", 1, 2)
+		g.Expect(sent[0].Notification_Type).To(Equal("System")) // ตัว row เก็บตามที่ส่งมา
+
+		first, second := deliveries(g, sent[0]), deliveries(g, sent[1])
+		g.Expect(first).To(HaveLen(1))
+		g.Expect(first).To(HaveKey(entity.NotificationChannelEmail))
+		g.Expect(second).To(HaveLen(1))
+		g.Expect(second).To(HaveKey(entity.NotificationChannelWebhook))
+
+		n, err := d.DeliverDue(context.Background(), now)
+		g.Expect(err).To(BeNil())
+		g.Expect(n).To(Equal(2))
+		g.Expect(email.Sent).To(Equal([]string{"s1@example.com: ปิดปรับปรุงระบบ"}))
+		g.Expect(webhook.Sent).To(Equal([]string{"s2@example.com: ปิดปรับปรุงระบบ"}))
+
+		delivery := deliveries(g, sent[0])[entity.NotificationChannelEmail]
+		g.Expect(delivery.Status).To(Equal(entity.DeliverySent))
+		g.Expect(delivery.Attempts).To(Equal(1))
+		g.Expect(delivery.SentAt).NotTo(BeNil())
+
+		// ส่งแล้วไม่ส่งซ้ำ
+		n, err = d.DeliverDue(context.Background(), now.Add(time.Hour))
+		g.Expect(err).To(BeNil())
+		g.Expect(n).To(BeZero())
+	})
+
+	t.Run("Quiet hours defer all but urgent notifications", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(db.Create(&entity.NotificationSetting{UserID: 1, QuietHoursStart: "22:00", QuietHoursEnd: "07:00"}).Error).To(BeNil())
+		email.Sent = nil
+
+		later := notify(g, entity.NotificationTypeSystem, "ประกาศใหม่", 1)[0]
+		urgent := notify(g, entity.NotificationTypeAlert, "ใกล้ปิดรับสมัคร", 1)[0]
+
+		deferred := deliveries(g, later)[entity.NotificationChannelEmail]
+		g.Expect(deferred.Status).To(Equal(entity.DeliveryDeferred))
+		g.Expect(deferred.NextAttemptAt.Equal(time.Date(2026, 1, 11, 7, 0, 0, 0, services.AnnouncementLocation()))).To(BeTrue())
+		g.Expect(deliveries(g, urgent)[entity.NotificationChannelEmail].Status).To(Equal(entity.DeliveryPending))
+
+		_, err := d.DeliverDue(context.Background(), now)
+		g.Expect(err).To(BeNil())
+		g.Expect(email.Sent).To(Equal([]string{"s1@example.com: ใกล้ปิดรับสมัคร"}))
+
+		_, err = d.DeliverDue(context.Background(), deferred.NextAttemptAt)
+		g.Expect(err).To(BeNil())
+		g.Expect(email.Sent).To(Equal([]string{"s1@example.com: ใกล้ปิดรับสมัคร", "s1@example.com: ประกาศใหม่"}))
+	})
+
+	t.Run("Types with event emails get no email delivery", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		n := notify(g, entity.NotificationTypeAnnouncement, "ประกาศใหม่", 2)[0]
+		g.Expect(deliveries(g, n)).NotTo(HaveKey(entity.NotificationChannelEmail))
+	})
+
+	t.Run("Quiet hours", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		bkk := services.AnnouncementLocation()
+		at := func(h, m int) time.Time { return time.Date(2026, 1, 10, h, m, 0, 0, bkk) }
+
+		overnight := &entity.NotificationSetting{QuietHoursStart: "22:00", QuietHoursEnd: "07:00"}
+		end, quiet := services.QuietHoursEnd(overnight, at(6, 59))
+		g.Expect(quiet).To(BeTrue())
+		g.Expect(end.Equal(at(7, 0))).To(BeTrue())
+		_, quiet = services.QuietHoursEnd(overnight, at(7, 0))
+		g.Expect(quiet).To(BeFalse())
+
+		lunch := &entity.NotificationSetting{QuietHoursStart: "12:00", QuietHoursEnd: "13:00"}
+		end, quiet = services.QuietHoursEnd(lunch, at(12, 30).UTC())
+		g.Expect(quiet).To(BeTrue())
+		g.Expect(end.Equal(at(13, 0))).To(BeTrue())
+
+		_, quiet = services.QuietHoursEnd(nil, at(23, 0))
+		g.Expect(quiet).To(BeFalse())
+	})
+
+	t.Run("Failures are retried with backoff and then given up", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		d.MaxAttempts = 3
+		email.Err = errors.New("smtp down")
+		defer func() { d.MaxAttempts, email.Err = 5, nil }()
+
+		n := notify(g, entity.NotificationTypeAlert, "ด่วน", 1)[0]
+		at := now
+		for attempt, wait := range []time.Duration{time.Minute, 2 * time.Minute} {
+			_, err := d.DeliverDue(context.Background(), at)
+			g.Expect(err).To(BeNil())
+			delivery := deliveries(g, n)[entity.NotificationChannelEmail]
+			g.Expect(delivery.Status).To(Equal(entity.DeliveryPending))
+			g.Expect(delivery.Attempts).To(Equal(attempt + 1))
+			g.Expect(delivery.LastError).To(Equal("smtp down"))
+			g.Expect(delivery.NextAttemptAt.Equal(at.Add(wait))).To(BeTrue())
+			at = delivery.NextAttemptAt
+		}
+		_, err := d.DeliverDue(context.Background(), at)
+		g.Expect(err).To(BeNil())
+		delivery := deliveries(g, n)[entity.NotificationChannelEmail]
+		g.Expect(delivery.Status).To(Equal(entity.DeliveryFailed))
+		g.Expect(delivery.Attempts).To(Equal(3))
+	})
+
+	t.Run("Users without an address are skipped", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		webhook.Err = services.ErrNoRecipientAddress
+		defer func() { webhook.Err = nil }()
+
+		n := notify(g, entity.NotificationTypeSystem, "ทดสอบ", 2)[0]
+		_, err := d.DeliverDue(context.Background(), now)
+		g.Expect(err).To(BeNil())
+		delivery := deliveries(g, n)[entity.NotificationChannelWebhook]
+		g.Expect(delivery.Status).To(Equal(entity.DeliverySkipped))
+		g.Expect(delivery.Attempts).To(Equal(1))
+	})
+
+	t.Run("Preference matrix", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		matrix, err := services.NotificationPreferenceMatrix(db, 2)
+		g.Expect(err).To(BeNil())
+		g.Expect(matrix).To(HaveLen(len(entity.NotificationTypes)))
+		g.Expect(matrix[entity.NotificationTypeSystem]).To(Equal(map[string]bool{
+			entity.NotificationChannelInApp: true, entity.NotificationChannelEmail: false,
+			entity.NotificationChannelWebhook: true, entity.NotificationChannelWebPush: true,
+		}))
+		g.Expect(matrix[entity.NotificationTypeAlert][entity.NotificationChannelWebhook]).To(BeFalse())
+	})
+}
+
+func TestWebhookChannel(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	userID := uint(1)
+	msg := services.NotificationMessage{
+		Notification: &entity.Notification{Model: gorm.Model{ID: 9}, Notification_Title: "ประกาศ", Notification_Message: "รายละเอียด",
+			Notification_Type: "ANNOUNCEMENT", UserID: &userID},
+		User:    &entity.User{Email: "s1@example.com"},
+		Setting: &entity.NotificationSetting{WebhookURL: srv.URL, WebhookFormat: entity.WebhookFormatJSON, WebhookToken: "secret"},
+	}
+	// ใช้ client ของ httptest เพราะ NewWebhookChannel ไม่ยอมเรียก loopback
+	channel := &services.WebhookChannel{Client: srv.Client()}
+
+	t.Run("JSON", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(channel.Send(context.Background(), msg)).To(Succeed())
+		g.Expect(got.Header.Get("Content-Type")).To(Equal("application/json"))
+		g.Expect(got.Header.Get("Authorization")).To(Equal("Bearer secret"))
+		var payload map[string]interface{}
+		g.Expect(json.Unmarshal(body, &payload)).To(Succeed())
+		g.Expect(payload["type"]).To(Equal(entity.NotificationTypeAnnouncement))
+		g.Expect(payload["title"]).To(Equal("ประกาศ"))
+	})
+
+	t.Run("LINE Notify", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		line := msg
+		line.Setting = &entity.NotificationSetting{WebhookURL: srv.URL, WebhookFormat: entity.WebhookFormatLineNotify, WebhookToken: "line-token"}
+		g.Expect(channel.Send(context.Background(), line)).To(Succeed())
+		form, err := url.ParseQuery(string(body))
+		g.Expect(err).To(BeNil())
+		g.Expect(form.Get("message")).To(Equal("ประกาศ\nรายละเอียด"))
+		g.Expect(got.Header.Get("Authorization")).To(Equal("Bearer line-token"))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		status = http.StatusBadGateway
+		defer func() { status = http.StatusOK }()
+		g.Expect(channel.Send(context.Background(), msg)).NotTo(Succeed())
+
+		noURL := msg
+		noURL.Setting = nil
+		g.Expect(channel.Send(context.Background(), noURL)).To(MatchError(services.ErrNoRecipientAddress))
+	})
+
+	t.Run("Private addresses are refused", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		err := services.NewWebhookChannel().Send(context.Background(), msg)
+		g.Expect(err).NotTo(BeNil())
+		g.Expect(err.Error()).To(ContainSubstring("not public"))
+		g.Expect(services.ValidateWebhookURL("http://example.com/hook")).NotTo(Succeed())
+		g.Expect(services.ValidateWebhookURL("https://example.com/hook")).To(Succeed())
+	})
+}
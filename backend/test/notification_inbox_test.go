@@ -0,0 +1,160 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+func TestNotificationInbox(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newTestDB(t, &entity.Notification{})
+
+	now := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	me, other := uint(1), uint(2)
+	add := func(userID uint, typ, title, group string) uint {
+		n := entity.Notification{Notification_Title: title, Notification_Type: typ, UserID: &userID, Group_Key: group, Sent_At: now}
+		g.Expect(db.Create(&n).Error).To(BeNil())
+		return n.ID
+	}
+
+	curriculum := entity.NotificationGroupKey("curriculum", 5)
+	system := add(me, entity.NotificationTypeSystem, "ยินดีต้อนรับ", "")
+	add(me, entity.NotificationTypeDeadlineReminder, "เหลือ 7 นาที", curriculum)
+	add(me, entity.NotificationTypeDeadlineReminder, "เหลือ 3 นาที", curriculum)
+	announcement := add(me, entity.NotificationTypeAnnouncement, "ประกาศใหม่", "")
+	lastReminder := add(me, entity.NotificationTypeDeadlineReminder, "นาทีสุดท้าย", curriculum)
+	add(other, entity.NotificationTypeSystem, "ของคนอื่น", "")
+
+	titles := func(items []services.InboxItem) []string {
+		out := make([]string, len(items))
+		for i, it := range items {
+			out[i] = it.Notification_Title
+		}
+		return out
+	}
+
+	t.Run("Repeated notifications are grouped", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		items, next, err := services.ListInbox(db, services.InboxFilter{UserID: me, Group: true})
+		g.Expect(err).To(BeNil())
+		g.Expect(next).To(BeZero())
+		g.Expect(titles(items)).To(Equal([]string{"นาทีสุดท้าย", "ประกาศใหม่", "ยินดีต้อนรับ"}))
+		g.Expect(items[0].GroupCount).To(Equal(3))
+		g.Expect(items[0].GroupUnread).To(Equal(3))
+		g.Expect(items[1].GroupCount).To(Equal(1))
+
+		items, _, err = services.ListInbox(db, services.InboxFilter{UserID: me})
+		g.Expect(err).To(BeNil())
+		g.Expect(items).To(HaveLen(5))
+	})
+
+	t.Run("Pages follow the cursor", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		first, next, err := services.ListInbox(db, services.InboxFilter{UserID: me, Limit: 3})
+		g.Expect(err).To(BeNil())
+		g.Expect(first).To(HaveLen(3))
+		g.Expect(next).To(Equal(first[2].ID))
+
+		second, next, err := services.ListInbox(db, services.InboxFilter{UserID: me, Limit: 3, Cursor: next})
+		g.Expect(err).To(BeNil())
+		g.Expect(titles(second)).To(Equal([]string{"เหลือ 7 นาที", "ยินดีต้อนรับ"}))
+		g.Expect(next).To(BeZero())
+	})
+
+	t.Run("Filters by type", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		items, _, err := services.ListInbox(db, services.InboxFilter{UserID: me, Types: []string{"Reminder"}})
+		g.Expect(err).To(BeNil())
+		g.Expect(items).To(HaveLen(3))
+	})
+
+	t.Run("Reading a grouped item reads its group", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		n, err := services.ApplyInboxAction(db, me, services.InboxActionRead, []uint{lastReminder}, now)
+		g.Expect(err).To(BeNil())
+		g.Expect(n).To(Equal(int64(3)))
+
+		unread := false
+		items, _, err := services.ListInbox(db, services.InboxFilter{UserID: me, Read: &unread, Group: true})
+		g.Expect(err).To(BeNil())
+		g.Expect(titles(items)).To(Equal([]string{"ประกาศใหม่", "ยินดีต้อนรับ"}))
+
+		byType, total, err := services.UnreadCounts(db, me)
+		g.Expect(err).To(BeNil())
+		g.Expect(total).To(Equal(int64(2)))
+		g.Expect(byType[entity.NotificationTypeDeadlineReminder]).To(BeZero())
+		g.Expect(byType[entity.NotificationTypeSystem]).To(Equal(int64(1)))
+	})
+
+	t.Run("Marking unread clears read_at", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		var n entity.Notification
+		g.Expect(db.First(&n, lastReminder).Error).To(BeNil())
+		g.Expect(n.Read_At).NotTo(BeNil())
+		g.Expect(n.Read_At.Equal(now)).To(BeTrue())
+
+		_, err := services.ApplyInboxAction(db, me, services.InboxActionUnread, []uint{lastReminder}, now)
+		g.Expect(err).To(BeNil())
+		n = entity.Notification{}
+		g.Expect(db.First(&n, lastReminder).Error).To(BeNil())
+		g.Expect(n.Is_Read).To(BeFalse())
+		g.Expect(n.Read_At).To(BeNil())
+
+		_, err = services.ApplyInboxAction(db, me, services.InboxActionRead, []uint{lastReminder}, now)
+		g.Expect(err).To(BeNil())
+	})
+
+	t.Run("Archived notifications leave the inbox", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, err := services.ApplyInboxAction(db, me, services.InboxActionArchive, []uint{announcement}, now)
+		g.Expect(err).To(BeNil())
+
+		items, _, err := services.ListInbox(db, services.InboxFilter{UserID: me, Group: true})
+		g.Expect(err).To(BeNil())
+		g.Expect(titles(items)).To(Equal([]string{"นาทีสุดท้าย", "ยินดีต้อนรับ"}))
+
+		items, _, err = services.ListInbox(db, services.InboxFilter{UserID: me, Archived: true})
+		g.Expect(err).To(BeNil())
+		g.Expect(titles(items)).To(Equal([]string{"ประกาศใหม่"}))
+
+		_, total, err := services.UnreadCounts(db, me)
+		g.Expect(err).To(BeNil())
+		g.Expect(total).To(Equal(int64(1)))
+	})
+
+	t.Run("Mark all read only touches the inbox", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		n, err := services.MarkAllNotificationsRead(db, me, nil, now)
+		g.Expect(err).To(BeNil())
+		g.Expect(n).To(Equal(int64(1)))
+
+		var archived entity.Notification
+		g.Expect(db.First(&archived, announcement).Error).To(BeNil())
+		g.Expect(archived.Is_Read).To(BeFalse())
+
+		_, total, err := services.UnreadCounts(db, other)
+		g.Expect(err).To(BeNil())
+		g.Expect(total).To(Equal(int64(1)))
+	})
+
+	t.Run("Bulk delete only removes the user's own notifications", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		var othersID uint
+		g.Expect(db.Model(&entity.Notification{}).Where("user_id = ?", other).Select("id").Scan(&othersID).Error).To(BeNil())
+
+		n, err := services.ApplyInboxAction(db, me, services.InboxActionDelete, []uint{system, othersID}, now)
+		g.Expect(err).To(BeNil())
+		g.Expect(n).To(Equal(int64(1)))
+
+		var count int64
+		g.Expect(db.Model(&entity.Notification{}).Where("user_id = ?", other).Count(&count).Error).To(BeNil())
+		g.Expect(count).To(Equal(int64(1)))
+
+		_, err = services.ApplyInboxAction(db, me, "star", []uint{system}, now)
+		g.Expect(err).To(MatchError(services.ErrInvalidInboxAction))
+	})
+}
@@ -0,0 +1,193 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/config"
+	"github.com/sut68/team14/backend/controller"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+func TestParseRules(t *testing.T) {
+	t.Run("Empty rules", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		rule, err := services.ParseRules(nil)
+		g.Expect(err).To(BeNil())
+		g.Expect(rule).To(BeNil())
+	})
+
+	t.Run("Valid nested rules", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, err := services.ParseRules([]byte(`{"op":"and","rules":[
+			{"field":"academic.gpa_math","cmp":">=","value":3},
+			{"op":"or","rules":[
+				{"field":"language.IELTS.score","cmp":">=","value":6.0},
+				{"field":"language.TOEFL.score","cmp":">=","value":79}]},
+			{"field":"education.curriculum_type","cmp":"in","value":["ปวช.","ปวส."]}]}`))
+		g.Expect(err).To(BeNil())
+	})
+
+	t.Run("Unknown field", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, err := services.ParseRules([]byte(`{"field":"academic.height","cmp":">=","value":3}`))
+		g.Expect(err).NotTo(BeNil())
+		g.Expect(err.Error()).To(Equal(`rule: unknown field "academic.height"`))
+	})
+
+	t.Run("Operand type must match field", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, err := services.ParseRules([]byte(`{"op":"or","rules":[{"field":"ged.total_score","cmp":">=","value":"580"}]}`))
+		g.Expect(err).NotTo(BeNil())
+		g.Expect(err.Error()).To(Equal("rule.rules[0]: value must be a number"))
+	})
+
+	t.Run("Comparator not supported by type", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, err := services.ParseRules([]byte(`{"field":"education.school_type","cmp":">=","value":"x"}`))
+		g.Expect(err).NotTo(BeNil())
+	})
+
+	t.Run("Empty group", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, err := services.ParseRules([]byte(`{"op":"and","rules":[]}`))
+		g.Expect(err).NotTo(BeNil())
+	})
+}
+
+func TestEvaluateRuleWithSampleProfiles(t *testing.T) {
+	english, err := services.ParseRules([]byte(`{"op":"or","label":"English","rules":[
+		{"field":"language.IELTS.score","cmp":">=","value":6.0,"label":"IELTS"},
+		{"field":"language.TOEFL.score","cmp":">=","value":79,"label":"TOEFL iBT"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("One alternative is enough", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		res := services.EvaluateRule(english, services.SampleFacts{"language.toefl.score": 80.0})
+		g.Expect(res.Passed).To(BeTrue())
+		g.Expect(res.Reason).To(Equal("1 of 2 alternatives met"))
+	})
+
+	t.Run("Missing facts are explained", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		res := services.EvaluateRule(english, services.SampleFacts{"language.IELTS.score": 5.5})
+		g.Expect(res.Passed).To(BeFalse())
+		g.Expect(res.Children[0].Reason).To(Equal("IELTS 5.5 does not meet >= 6"))
+		g.Expect(res.Children[1].Reason).To(Equal("TOEFL is not in the sample profile"))
+		g.Expect(res.Reason).To(Equal("none of IELTS, TOEFL iBT met"))
+	})
+}
+
+func TestEligibilityWithRuleTree(t *testing.T) {
+	vocational := uint(3)
+	profile := &services.StudentProfile{
+		Academic:  &entity.AcademicScore{GPAX: 3.1, GPAMath: 2.8},
+		GED:       &entity.GEDScore{TotalScore: 600},
+		Languages: []entity.LanguageProficiencyScore{{TestType: "IELTS", Score: "6.5"}},
+		Education: &entity.Education{
+			CurriculumTypeID: &vocational,
+			CurriculumType:   &entity.CurriculumType{Name: "ปวช."},
+		},
+	}
+	rules := func(v interface{}) []byte {
+		b, _ := json.Marshal(v)
+		return b
+	}
+
+	t.Run("Rule tree passes", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := entity.Curriculum{GPAXMin: 3, EligibilityRules: rules(map[string]interface{}{
+			"op": "and", "rules": []map[string]interface{}{
+				{"field": "ged.total_score", "cmp": ">=", "value": 580},
+				{"field": "education.curriculum_type", "cmp": "in", "value": []string{"ปวช.", "ปวส."}},
+			},
+		})}
+		res := services.EvaluateEligibility(&c, profile)
+		g.Expect(res.Eligible).To(BeTrue())
+		g.Expect(res.Rules).NotTo(BeNil())
+		g.Expect(res.Rules.Passed).To(BeTrue())
+	})
+
+	t.Run("Rule tree replaces the requirement rows but not GPAXMin", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := entity.Curriculum{GPAXMin: 3,
+			Requirements:     []entity.CurriculumRequirement{{Kind: entity.RequirementGEDScore, Subject: "total", MinScore: 700}},
+			EligibilityRules: rules(map[string]interface{}{"field": "ged.total_score", "cmp": ">=", "value": 580}),
+		}
+		g.Expect(services.HasEligibilityRules(&c)).To(BeTrue())
+		reqs := services.CurriculumRequirements(&c)
+		g.Expect(reqs).To(HaveLen(1))
+		g.Expect(reqs[0].Kind).To(Equal(entity.RequirementGPAX))
+	})
+
+	t.Run("Failed rule tree makes the student ineligible", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := entity.Curriculum{EligibilityRules: rules(map[string]interface{}{
+			"field": "academic.gpa_math", "cmp": ">=", "value": 3, "label": "GPA Math",
+		})}
+		res := services.EvaluateEligibility(&c, profile)
+		g.Expect(res.Eligible).To(BeFalse())
+		g.Expect(res.FailedGroups).To(Equal([]string{"GPA Math"}))
+		issues := services.EligibilityIssues(res)
+		g.Expect(issues).To(HaveLen(1))
+		g.Expect(issues[0].Message).To(Equal("GPA Math 2.8 does not meet >= 3"))
+	})
+}
+
+func TestEligibilityRulesEndpointIsAdminOnly(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newTestDB(t, &entity.User{})
+	g.Expect(db.Create(&[]entity.User{
+		{Email: "student@example.com", AccountTypeID: entity.UserTypeStudent},
+		{Email: "admin@example.com", AccountTypeID: entity.UserTypeAdmin},
+	}).Error).To(BeNil())
+
+	prev := config.GetDB()
+	config.SetDB(db)
+	t.Cleanup(func() { config.SetDB(prev) })
+	cc := controller.NewCurriculumController()
+
+	rules := map[string]interface{}{"field": "academic.gpax", "cmp": ">=", "value": 3}
+	post := func(userID uint, payload map[string]interface{}) *httptest.ResponseRecorder {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.POST("/admin/eligibility-rules/test", func(c *gin.Context) {
+			c.Set("user_id", userID)
+		}, cc.TestEligibilityRules)
+
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest(http.MethodPost, "/admin/eligibility-rules/test", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Student cannot read other students' profiles", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(post(1, map[string]interface{}{"rules": rules, "user_ids": []uint{2}}).Code).To(Equal(http.StatusForbidden))
+	})
+
+	t.Run("Unknown caller is unauthorized", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(post(99, map[string]interface{}{"rules": rules, "user_ids": []uint{2}}).Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	t.Run("Admin can test sample profiles", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		w := post(2, map[string]interface{}{
+			"rules":    rules,
+			"profiles": []map[string]interface{}{{"name": "GPAX 3.5", "facts": map[string]interface{}{"academic.gpax": 3.5}}},
+		})
+		g.Expect(w.Code).To(Equal(http.StatusOK))
+		g.Expect(w.Body.String()).To(ContainSubstring(`"passed":true`))
+	})
+}
@@ -0,0 +1,111 @@
+package test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/services"
+)
+
+func TestAdmissionRanker(t *testing.T) {
+	score := func(v float64) *float64 { return &v }
+
+	t.Run("Applicants inside quota are admitted", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r := services.AdmissionRanker{Quota: 2}
+
+		rank, decision := r.Next(score(90), false)
+		g.Expect(rank).To(Equal(1))
+		g.Expect(decision).To(Equal(services.DecisionAdmitted))
+
+		rank, decision = r.Next(score(80), false)
+		g.Expect(rank).To(Equal(2))
+		g.Expect(decision).To(Equal(services.DecisionAdmitted))
+
+		rank, decision = r.Next(score(70), false)
+		g.Expect(rank).To(Equal(3))
+		g.Expect(decision).To(Equal(services.DecisionWaitlisted))
+	})
+
+	t.Run("Tied scores share a rank", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r := services.AdmissionRanker{Quota: 1}
+
+		rank, decision := r.Next(score(85), false)
+		g.Expect(rank).To(Equal(1))
+		g.Expect(decision).To(Equal(services.DecisionAdmitted))
+
+		rank, decision = r.Next(score(85), false)
+		g.Expect(rank).To(Equal(1))
+		g.Expect(decision).To(Equal(services.DecisionAdmitted))
+
+		rank, _ = r.Next(score(60), false)
+		g.Expect(rank).To(Equal(3))
+	})
+
+	t.Run("Rejected and unscored applicants are not ranked", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r := services.AdmissionRanker{Quota: 5}
+
+		rank, decision := r.Next(score(99), true)
+		g.Expect(rank).To(Equal(0))
+		g.Expect(decision).To(Equal(services.DecisionRejected))
+
+		rank, decision = r.Next(nil, false)
+		g.Expect(rank).To(Equal(0))
+		g.Expect(decision).To(Equal(services.DecisionPending))
+
+		rank, _ = r.Next(score(50), false)
+		g.Expect(rank).To(Equal(1))
+	})
+}
+
+func TestScorecardExportColumns(t *testing.T) {
+	score := func(v float64) *float64 { return &v }
+	criteria := []string{"Portfolio", "Interview"}
+	columns := services.ScorecardExportColumns(criteria)
+	row := services.ScorecardExportRow{
+		Rank: 2, ApplicationID: 11, UserID: 7,
+		FirstNameTH: "=HYPERLINK(\"http://evil\")", LastNameTH: "+66", FirstNameEN: "-1", LastNameEN: "@SUM(A1)",
+		Email: "\tmail@example.com", GPAX: score(3.5), ApplicationStatus: "submitted",
+		TotalScore: score(170), MaxScore: score(200),
+		CriteriaScores: map[string]float64{"Interview": 80},
+		Decision:       services.DecisionAdmitted,
+	}
+
+	t.Run("Header follows the column order", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(services.ExportHeader(columns)).To(Equal([]string{
+			"rank", "application_id", "user_id", "first_name_th", "last_name_th", "first_name_en", "last_name_en",
+			"email", "gpax", "application_status", "Portfolio", "Interview", "total_score", "max_score", "decision",
+		}))
+	})
+
+	t.Run("Typed values and CSV text come from the same columns", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		values := services.ExportValues(columns, &row)
+		record := services.ExportRecord(columns, &row)
+		g.Expect(values).To(HaveLen(len(columns)))
+		g.Expect(record).To(HaveLen(len(columns)))
+
+		g.Expect(values[0]).To(Equal(2))
+		g.Expect(values[8]).To(Equal(3.5))
+		g.Expect(values[10]).To(BeNil())
+		g.Expect(values[11]).To(Equal(80.0))
+		g.Expect(values[12]).To(Equal(170.0))
+		g.Expect(record[8]).To(Equal("3.50"))
+		g.Expect(record[10]).To(Equal(""))
+		g.Expect(record[11]).To(Equal("80.00"))
+		g.Expect(record[14]).To(Equal(services.DecisionAdmitted))
+	})
+
+	t.Run("Text that looks like a formula is escaped", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		record := services.ExportRecord(columns, &row)
+		g.Expect(record[3:8]).To(Equal([]string{"'=HYPERLINK(\"http://evil\")", "'+66", "'-1", "'@SUM(A1)", "'\tmail@example.com"}))
+		g.Expect(services.ExportValues(columns, &row)[3]).To(Equal("'=HYPERLINK(\"http://evil\")"))
+		g.Expect(services.SpreadsheetSafe("\r=1")).To(Equal("'\r=1"))
+		g.Expect(services.SpreadsheetSafe("สมชาย")).To(Equal("สมชาย"))
+		g.Expect(services.SpreadsheetSafe("")).To(Equal(""))
+	})
+}
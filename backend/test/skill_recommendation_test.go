@@ -0,0 +1,83 @@
+package test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+	"gorm.io/gorm"
+)
+
+func TestSkillRecommendation(t *testing.T) {
+	logic := &entity.Skill{Model: gorm.Model{ID: 1}, SkillNameEN: "Logical Thinking"}
+	comm := &entity.Skill{Model: gorm.Model{ID: 2}, SkillNameEN: "Communication"}
+
+	programming := entity.CourseGroup{CourseGroupSkills: []entity.CourseGroupSkill{{SkillID: 1, Skill: logic, Importance: 5}}}
+	presentation := entity.CourseGroup{CourseGroupSkills: []entity.CourseGroupSkill{{SkillID: 2, Skill: comm, Importance: 5}}}
+
+	cs := entity.Curriculum{Model: gorm.Model{ID: 10}, Code: "CS", CourseGroups: []entity.CurriculumCourseGroup{
+		{CourseGroup: &programming, CreditPercentage: 75},
+		{CourseGroup: &presentation, CreditPercentage: 25},
+	}}
+	comArts := entity.Curriculum{Model: gorm.Model{ID: 20}, Code: "COMM", CourseGroups: []entity.CurriculumCourseGroup{
+		{CourseGroup: &presentation, CreditPercentage: 100},
+	}}
+
+	maps := services.SkillTypeMaps{
+		Activity: []entity.TypeActivitySkill{{TypeActivityID: 4, SkillID: 1, Skill: logic, Weight: 3, TypeActivity: &entity.TypeActivity{TypeName: "คอมพิวเตอร์"}}},
+		Working:  []entity.TypeWorkingSkill{{TypeWorkingID: 2, SkillID: 2, Skill: comm, Weight: 2, TypeWorking: &entity.TypeWorking{TypeName: "ศิลปะ"}}},
+	}
+
+	t.Run("Curriculum profile weights credits and importance", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		profile := services.CurriculumSkillProfile(&cs)
+		g.Expect(profile).To(HaveLen(2))
+		g.Expect(profile[0].SkillID).To(Equal(uint(1)))
+		g.Expect(profile[0].Weight).To(Equal(0.75))
+		g.Expect(profile[1].Weight).To(Equal(0.25))
+	})
+
+	t.Run("Skills inferred from activity types", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		activities := []entity.Activity{{ActivityDetail: &entity.ActivityDetail{TypeActivityID: 4}}}
+		workings := []entity.Working{{WorkingDetail: &entity.WorkingDetail{TypeWorkingID: 9}}}
+		skills := services.InferStudentSkills(activities, workings, maps)
+		g.Expect(skills).To(HaveLen(1))
+		g.Expect(skills[0].SkillID).To(Equal(uint(1)))
+		g.Expect(skills[0].Level).To(Equal(1.0))
+		g.Expect(skills[0].Activities).To(Equal(1))
+	})
+
+	t.Run("Explicit tags replace type inference", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		activities := []entity.Activity{{
+			ActivityName:   "Debate",
+			ActivityDetail: &entity.ActivityDetail{TypeActivityID: 4},
+			Skills:         []entity.ActivitySkill{{SkillID: 2, Skill: comm, Level: 2}},
+		}}
+		skills := services.InferStudentSkills(activities, nil, maps)
+		g.Expect(skills).To(HaveLen(1))
+		g.Expect(skills[0].SkillID).To(Equal(uint(2)))
+		g.Expect(skills[0].Evidence).To(Equal(2.0))
+		g.Expect(skills[0].Sources).To(HaveLen(1))
+		g.Expect(skills[0].Sources[0].Tagged).To(BeTrue())
+		g.Expect(skills[0].Sources[0].Name).To(Equal("Debate"))
+	})
+
+	t.Run("Ranking and missing skills", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		skills := []services.StudentSkill{{SkillID: 1, Level: 1}}
+		recs, top := services.RecommendCurricula([]entity.Curriculum{comArts, cs}, skills, maps, 0)
+		g.Expect(recs).To(HaveLen(2))
+		g.Expect(recs[0].Code).To(Equal("CS"))
+		g.Expect(recs[0].Score).To(Equal(75.0))
+		g.Expect(recs[1].Score).To(Equal(0.0))
+
+		g.Expect(top).To(HaveLen(1))
+		g.Expect(top[0].SkillID).To(Equal(uint(2)))
+		g.Expect(top[0].Suggestions).To(HaveLen(1))
+		g.Expect(top[0].Suggestions[0].Kind).To(Equal("working"))
+		g.Expect(top[0].Suggestions[0].TypeName).To(Equal("ศิลปะ"))
+	})
+}
@@ -0,0 +1,192 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+// startFakeClamd answers PING and INSTREAM like clamd, reporting the EICAR string as found.
+func startFakeClamd(g *WithT) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).To(BeNil())
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				cmd, err := r.ReadString(0)
+				if err != nil {
+					return
+				}
+				switch cmd {
+				case "zPING\x00":
+					conn.Write([]byte("PONG\x00"))
+				case "zINSTREAM\x00":
+					var data bytes.Buffer
+					size := make([]byte, 4)
+					for {
+						if _, err := io.ReadFull(r, size); err != nil {
+							return
+						}
+						n := binary.BigEndian.Uint32(size)
+						if n == 0 {
+							break
+						}
+						if _, err := io.CopyN(&data, r, int64(n)); err != nil {
+							return
+						}
+					}
+					if strings.Contains(data.String(), services.EICARTestString) {
+						conn.Write([]byte("stream: Win.Test.EICAR_HDB-1 FOUND\x00"))
+					} else {
+						conn.Write([]byte("stream: OK\x00"))
+					}
+				default:
+					conn.Write([]byte("UNKNOWN COMMAND\x00"))
+				}
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestClamAVScanner(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Run("Addresses", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		for address, want := range map[string][2]string{
+			"tcp://clamav:3310":                {"tcp", "clamav:3310"},
+			"unix:///var/run/clamav/clamd.ctl": {"unix", "/var/run/clamav/clamd.ctl"},
+			"/tmp/clamd.sock":                  {"unix", "/tmp/clamd.sock"},
+			"localhost:3310":                   {"tcp", "localhost:3310"},
+		} {
+			s, err := services.NewClamAVScanner(address)
+			g.Expect(err).To(BeNil())
+			g.Expect([2]string{s.Network, s.Address}).To(Equal(want))
+		}
+		_, err := services.NewClamAVScanner("tcp://")
+		g.Expect(err).NotTo(BeNil())
+	})
+
+	scanner, err := services.NewClamAVScanner(startFakeClamd(g))
+	g.Expect(err).To(BeNil())
+	scanner.ChunkSize = 16 // หลาย chunk ต่อไฟล์
+
+	g.Expect(scanner.Ping(context.Background())).To(Succeed())
+
+	result, err := scanner.Scan(context.Background(), "ok.pdf", strings.NewReader("%PDF-1.7 a clean document"))
+	g.Expect(err).To(BeNil())
+	g.Expect(result.Clean).To(BeTrue())
+
+	result, err = scanner.Scan(context.Background(), "eicar.com", strings.NewReader("prefix "+services.EICARTestString))
+	g.Expect(err).To(BeNil())
+	g.Expect(result).To(Equal(services.ScanResult{Threat: "Win.Test.EICAR_HDB-1"}))
+
+	t.Run("A scanner that is down is an error", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		g.Expect(err).To(BeNil())
+		down, _ := services.NewClamAVScanner(ln.Addr().String())
+		ln.Close()
+		_, err = down.Scan(context.Background(), "x.pdf", strings.NewReader("x"))
+		g.Expect(err).NotTo(BeNil())
+	})
+}
+
+func TestStoreScannedUpload(t *testing.T) {
+	db := newTestDB(t, &entity.UploadScan{})
+
+	store := &memoryStore{files: map[string][]byte{}}
+	userID := uint(7)
+	upload := func(name, content string) (*entity.UploadScan, error) {
+		return services.StoreScannedUpload(context.Background(), db, store, services.UploadMeta{
+			FileName: name, ContentType: "application/pdf", Size: int64(len(content)),
+			Purpose: services.UploadPurposeGeneral, UserID: &userID,
+		}, strings.NewReader(content))
+	}
+
+	fake := &services.FakeScanner{}
+	services.UploadScanner = fake
+	defer func() { services.UploadScanner = nil }()
+
+	t.Run("Clean files are stored", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		scan, err := upload("ok.pdf", "%PDF clean")
+		g.Expect(err).To(BeNil())
+		g.Expect(scan.Status).To(Equal(entity.UploadScanClean))
+		g.Expect(scan.Scanner).To(Equal("fake"))
+		g.Expect(scan.SHA256).To(HaveLen(64))
+		g.Expect(scan.FileURL).NotTo(BeEmpty())
+		g.Expect(store.files).To(HaveLen(1))
+		g.Expect(fake.Scanned).To(Equal([]string{"ok.pdf"}))
+	})
+
+	t.Run("Infected files are rejected and audited", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		scan, err := upload("eicar.pdf", services.EICARTestString)
+		g.Expect(err).To(MatchError(services.ErrFileInfected))
+		g.Expect(store.files).To(HaveLen(1))
+
+		var saved entity.UploadScan
+		g.Expect(db.First(&saved, scan.ID).Error).To(BeNil())
+		g.Expect(saved.Status).To(Equal(entity.UploadScanInfected))
+		g.Expect(saved.Threat).To(Equal("Eicar-Test-Signature"))
+		g.Expect(saved.FileURL).To(BeEmpty())
+		g.Expect(*saved.UserID).To(Equal(userID))
+	})
+
+	t.Run("Files are rejected when the scanner fails", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		fake.Err = errors.New("connection refused")
+		defer func() { fake.Err = nil }()
+
+		scan, err := upload("later.pdf", "%PDF")
+		g.Expect(err).To(MatchError(services.ErrScanUnavailable))
+		g.Expect(scan.Status).To(Equal(entity.UploadScanFailed))
+		g.Expect(scan.Detail).To(Equal("connection refused"))
+		g.Expect(store.files).To(HaveLen(1))
+	})
+
+	t.Run("Without a scanner files are stored and marked skipped", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		services.UploadScanner = nil
+		defer func() { services.UploadScanner = fake }()
+
+		scan, err := upload("dev.pdf", "%PDF")
+		g.Expect(err).To(BeNil())
+		g.Expect(scan.Status).To(Equal(entity.UploadScanSkipped))
+		g.Expect(store.files).To(HaveLen(2))
+	})
+
+	t.Run("Only the user's stored uploads can be attached", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		var clean, infected entity.UploadScan
+		g.Expect(db.Where("status = ?", entity.UploadScanClean).First(&clean).Error).To(BeNil())
+		g.Expect(db.Where("status = ?", entity.UploadScanInfected).First(&infected).Error).To(BeNil())
+
+		found, err := services.FindUserUpload(db, clean.ID, userID)
+		g.Expect(err).To(BeNil())
+		g.Expect(found.FileURL).To(Equal(clean.FileURL))
+
+		_, err = services.FindUserUpload(db, clean.ID, userID+1)
+		g.Expect(err).To(MatchError(services.ErrUploadNotUsable))
+		_, err = services.FindUserUpload(db, infected.ID, userID)
+		g.Expect(err).To(MatchError(services.ErrUploadNotUsable))
+	})
+}
@@ -0,0 +1,255 @@
+package test
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	. "github.com/onsi/gomega"
+	"github.com/sut68/team14/backend/entity"
+	"github.com/sut68/team14/backend/services"
+)
+
+// pushBrowser plays the browser side of a push subscription.
+type pushBrowser struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+}
+
+func newPushBrowser(g *WithT) *pushBrowser {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	g.Expect(err).To(BeNil())
+	auth := make([]byte, 16)
+	_, err = rand.Read(auth)
+	g.Expect(err).To(BeNil())
+	return &pushBrowser{key: key, auth: auth}
+}
+
+func (b *pushBrowser) subscription(userID uint, endpoint string) entity.PushSubscription {
+	return entity.PushSubscription{
+		UserID:   userID,
+		Endpoint: endpoint,
+		P256dh:   base64.RawURLEncoding.EncodeToString(b.key.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(b.auth),
+	}
+}
+
+// decrypt reverses the aes128gcm content coding of RFC 8291.
+func (b *pushBrowser) decrypt(g *WithT, body []byte) []byte {
+	g.Expect(len(body)).To(BeNumerically(">", 86))
+	salt, rs, idlen := body[:16], binary.BigEndian.Uint32(body[16:20]), int(body[20])
+	g.Expect(rs).To(Equal(uint32(4096)))
+	asPublicBytes := body[21 : 21+idlen]
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	g.Expect(err).To(BeNil())
+	shared, err := b.key.ECDH(asPublic)
+	g.Expect(err).To(BeNil())
+
+	info := append(append([]byte("WebPush: info\x00"), b.key.PublicKey().Bytes()...), asPublicBytes...)
+	ikm, err := hkdf.Key(sha256.New, shared, b.auth, string(info), 32)
+	g.Expect(err).To(BeNil())
+	cek, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	g.Expect(err).To(BeNil())
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	g.Expect(err).To(BeNil())
+
+	block, err := aes.NewCipher(cek)
+	g.Expect(err).To(BeNil())
+	gcm, err := cipher.NewGCM(block)
+	g.Expect(err).To(BeNil())
+	plain, err := gcm.Open(nil, nonce, body[21+idlen:], nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(plain[len(plain)-1]).To(Equal(byte(0x02)))
+	return plain[:len(plain)-1]
+}
+
+func TestVAPIDKeys(t *testing.T) {
+	g := NewGomegaWithT(t)
+	public, private, err := services.GenerateVAPIDKeys()
+	g.Expect(err).To(BeNil())
+
+	keys, err := services.NewVAPIDKeys(private, "mailto:admin@example.com")
+	g.Expect(err).To(BeNil())
+	g.Expect(keys.PublicKey).To(Equal(public))
+
+	_, err = services.NewVAPIDKeys(private, "")
+	g.Expect(err).NotTo(BeNil())
+	_, err = services.NewVAPIDKeys("not-a-key", "mailto:admin@example.com")
+	g.Expect(err).NotTo(BeNil())
+
+	browser := newPushBrowser(g)
+	sub := browser.subscription(1, "https://push.example.com/send/abc")
+	g.Expect(services.ValidatePushSubscription(sub.Endpoint, sub.P256dh, sub.Auth)).To(Succeed())
+	g.Expect(services.ValidatePushSubscription("http://push.example.com/x", sub.P256dh, sub.Auth)).NotTo(Succeed())
+	g.Expect(services.ValidatePushSubscription(sub.Endpoint, public[:20], sub.Auth)).NotTo(Succeed())
+	g.Expect(services.ValidatePushSubscription(sub.Endpoint, sub.P256dh, "c2hvcnQ")).NotTo(Succeed())
+}
+
+func TestEncryptWebPush(t *testing.T) {
+	g := NewGomegaWithT(t)
+	browser := newPushBrowser(g)
+	sub := browser.subscription(1, "https://push.example.com/send/abc")
+
+	payload := []byte(`{"title":"เหลือเวลาอีก 3 นาที!"}`)
+	body, err := services.EncryptWebPush(payload, sub.P256dh, sub.Auth)
+	g.Expect(err).To(BeNil())
+	g.Expect(browser.decrypt(g, body)).To(Equal(payload))
+
+	// เข้ารหัสแต่ละครั้งใช้ salt/คีย์ชั่วคราวใหม่
+	again, err := services.EncryptWebPush(payload, sub.P256dh, sub.Auth)
+	g.Expect(err).To(BeNil())
+	g.Expect(again).NotTo(Equal(body))
+
+	_, err = services.EncryptWebPush(make([]byte, services.MaxWebPushPayload+1), sub.P256dh, sub.Auth)
+	g.Expect(err).NotTo(BeNil())
+}
+
+func TestWebPushChannel(t *testing.T) {
+	g := NewGomegaWithT(t)
+	db := newTestDB(t, &entity.User{}, &entity.Notification{}, &entity.NotificationPreference{},
+		&entity.NotificationSetting{}, &entity.NotificationDelivery{}, &entity.PushSubscription{})
+	student := entity.User{Email: "s1@example.com", AccountTypeID: entity.UserTypeStudent}
+	g.Expect(db.Create(&student).Error).To(BeNil())
+
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	var got []received
+	statuses := map[string]int{} // path → status
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, received{r.Header.Clone(), body})
+		if status, ok := statuses[r.URL.Path]; ok {
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	_, private, err := services.GenerateVAPIDKeys()
+	g.Expect(err).To(BeNil())
+	keys, err := services.NewVAPIDKeys(private, "mailto:admin@example.com")
+	g.Expect(err).To(BeNil())
+	// ใช้ client ของ httptest เพราะ NewWebPushChannel ไม่ยอมเรียก loopback
+	channel := &services.WebPushChannel{DB: db, Keys: keys, Client: srv.Client()}
+
+	phone, laptop := newPushBrowser(g), newPushBrowser(g)
+	subs := []entity.PushSubscription{
+		phone.subscription(student.ID, srv.URL+"/phone"),
+		laptop.subscription(student.ID, srv.URL+"/laptop"),
+	}
+	g.Expect(db.Create(&subs).Error).To(BeNil())
+
+	d := services.NewNotificationDispatcher(db, channel)
+	services.Dispatcher = d
+	defer func() { services.Dispatcher = nil }()
+	now := time.Now()
+
+	t.Run("The dispatcher pushes to every device", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		curriculumID := uint(5)
+		notification := entity.Notification{
+			Notification_Title: "⚠️ เหลือเวลาอีก 3 นาที!", Notification_Message: "สาขา 'Computer' ใกล้ปิดรับสมัครแล้ว",
+			Notification_Type: entity.NotificationTypeDeadlineReminder, UserID: &student.ID, Sent_At: now,
+			Group_Key: entity.NotificationGroupKey("curriculum", curriculumID),
+		}
+		g.Expect(services.CreateNotifications(db, []entity.Notification{notification}, now)).To(Succeed())
+		n, err := d.DeliverDue(context.Background(), now)
+		g.Expect(err).To(BeNil())
+		g.Expect(n).To(Equal(1))
+		g.Expect(got).To(HaveLen(2))
+
+		h := got[0].header
+		g.Expect(h.Get("Content-Encoding")).To(Equal("aes128gcm"))
+		g.Expect(h.Get("Urgency")).To(Equal("high"))
+		g.Expect(h.Get("TTL")).To(Equal("600"))
+
+		// Authorization: vapid t=<jwt>, k=<public key>
+		auth := strings.TrimPrefix(h.Get("Authorization"), "vapid t=")
+		parts := strings.SplitN(auth, ", k=", 2)
+		g.Expect(parts).To(HaveLen(2))
+		g.Expect(parts[1]).To(Equal(keys.PublicKey))
+		token, err := jwt.Parse(parts[0], func(token *jwt.Token) (interface{}, error) {
+			g.Expect(token.Method).To(Equal(jwt.SigningMethodES256))
+			return vapidPublicKey(g, keys.PublicKey), nil
+		})
+		g.Expect(err).To(BeNil())
+		claims := token.Claims.(jwt.MapClaims)
+		g.Expect(claims["aud"]).To(Equal(srv.URL))
+		g.Expect(claims["sub"]).To(Equal("mailto:admin@example.com"))
+
+		var payload services.PushPayload
+		g.Expect(json.Unmarshal(phone.decrypt(g, got[0].body), &payload)).To(Succeed())
+		g.Expect(payload.Title).To(Equal(notification.Notification_Title))
+		g.Expect(payload.URL).To(Equal("/student/curricula"))
+		g.Expect(payload.Tag).To(Equal("deadline_reminder:curriculum:5"))
+		g.Expect(laptop.decrypt(g, got[1].body)).NotTo(BeEmpty())
+
+		var sub entity.PushSubscription
+		g.Expect(db.First(&sub, subs[0].ID).Error).To(BeNil())
+		g.Expect(sub.LastUsedAt).NotTo(BeNil())
+	})
+
+	t.Run("Expired subscriptions are removed", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		statuses["/phone"] = http.StatusGone
+		results, err := channel.SendToSubscriptions(context.Background(), subs, []byte(`{}`), services.WebPushOptions{}, now)
+		g.Expect(err).To(BeNil()) // laptop ยังได้รับ
+		g.Expect(results[0].Status).To(Equal("removed"))
+		g.Expect(results[1].Status).To(Equal("sent"))
+
+		var count int64
+		g.Expect(db.Unscoped().Model(&entity.PushSubscription{}).Where("endpoint = ?", srv.URL+"/phone").Count(&count).Error).To(BeNil())
+		g.Expect(count).To(BeZero())
+
+		statuses["/laptop"] = http.StatusNotFound
+		_, err = channel.SendToSubscriptions(context.Background(), subs[1:], []byte(`{}`), services.WebPushOptions{}, now)
+		g.Expect(err).To(MatchError(services.ErrNoRecipientAddress))
+	})
+
+	t.Run("Push service errors are retried", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		tablet := newPushBrowser(g).subscription(student.ID, srv.URL+"/tablet")
+		g.Expect(db.Create(&tablet).Error).To(BeNil())
+		statuses["/tablet"] = http.StatusTooManyRequests
+
+		err := channel.Send(context.Background(), services.NotificationMessage{
+			Notification: &entity.Notification{Notification_Title: "ประกาศ", Notification_Type: entity.NotificationTypeAnnouncement},
+			User:         &student,
+		})
+		g.Expect(err).NotTo(BeNil())
+		g.Expect(err).NotTo(MatchError(services.ErrNoRecipientAddress))
+		g.Expect(db.First(&tablet, tablet.ID).Error).To(BeNil())
+		g.Expect(tablet.LastError).To(ContainSubstring("429"))
+	})
+}
+
+// vapidPublicKey converts the base64url VAPID public key to the key jwt-go verifies with.
+func vapidPublicKey(g *WithT, key string) interface{} {
+	point, err := base64.RawURLEncoding.DecodeString(key)
+	g.Expect(err).To(BeNil())
+	pub, err := ecdh.P256().NewPublicKey(point)
+	g.Expect(err).To(BeNil())
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	g.Expect(err).To(BeNil())
+	ecdsaKey, err := x509.ParsePKIXPublicKey(der)
+	g.Expect(err).To(BeNil())
+	return ecdsaKey
+}
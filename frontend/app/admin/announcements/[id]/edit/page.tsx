@@ -202,7 +202,7 @@ const EditAnnouncementForm: React.FC = () => {
       // Delete marked attachments
       for (const attachmentId of attachmentsToDelete) {
         try {
-          await AnnouncementService.deleteAttachment(attachmentId);
+          await AnnouncementService.deleteAttachment(announcementId, attachmentId);
         } catch (error) {
           console.error("Failed to delete attachment:", error);
         }
@@ -210,32 +210,12 @@ const EditAnnouncementForm: React.FC = () => {
 
       // Upload new featured image if changed
       if (featuredImage) {
-        const imageRes = await AnnouncementService.uploadFile(
-          featuredImage,
-          announcementId
-        );
-
-        await AnnouncementService.createAttachment({
-          file_name: featuredImage.name,
-          file_path: imageRes.file_path,
-          announcement_id: announcementId,
-          cetagory_id: formData.cetagory_id,
-        });
+        await AnnouncementService.uploadAttachment(announcementId, featuredImage);
       }
 
       // Upload new attachments
       for (const file of attachments) {
-        const uploadRes = await AnnouncementService.uploadFile(
-          file,
-          announcementId
-        );
-
-        await AnnouncementService.createAttachment({
-          file_name: file.name,
-          file_path: uploadRes.file_path,
-          announcement_id: announcementId,
-          cetagory_id: formData.cetagory_id,
-        });
+        await AnnouncementService.uploadAttachment(announcementId, file);
       }
 
       // Log the edit action
@@ -244,17 +224,6 @@ const EditAnnouncementForm: React.FC = () => {
         announcement_id: announcementId,
       });
 
-      // Send notification if enabled
-      if (formData.send_notification) {
-        await AnnouncementService.createNotification({
-          notification_title: formData.title || "",
-          notification_type: "ANNOUNCEMENT",
-          notification_message: formData.content || "",
-          is_read: false,
-          announcement_id: announcementId,
-        });
-      }
-
       alert("อัปเดตประกาศสำเร็จ 🎉");
       router.push("/admin/announcements");
     } catch (error: any) {
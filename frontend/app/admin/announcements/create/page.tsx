@@ -137,31 +137,12 @@ const CreateAnnouncementForm: React.FC = () => {
 
       // featured image
       if (featuredImage) {
-        const imageRes =
-          await AnnouncementService.uploadFile(
-            featuredImage,
-            announcementId
-          );
-
-        await AnnouncementService.createAttachment({
-          file_name: featuredImage.name,
-          file_path: imageRes.file_path,
-          announcement_id: announcementId,
-          cetagory_id: formData.cetagory_id,
-        });
+        await AnnouncementService.uploadAttachment(announcementId, featuredImage);
       }
 
       // attachments
       for (const file of attachments) {
-        const uploadRes =
-          await AnnouncementService.uploadFile(file, announcementId);
-
-        await AnnouncementService.createAttachment({
-          file_name: file.name,
-          file_path: uploadRes.file_path,
-          announcement_id: announcementId,
-          cetagory_id: formData.cetagory_id,
-        });
+        await AnnouncementService.uploadAttachment(announcementId, file);
       }
 
       alert("บันทึกฉบับร่างเรียบร้อย 📝");
@@ -209,31 +190,12 @@ const CreateAnnouncementForm: React.FC = () => {
 
       // featured image
       if (featuredImage) {
-        const imageRes =
-          await AnnouncementService.uploadFile(
-            featuredImage,
-            announcementId
-          );
-
-        await AnnouncementService.createAttachment({
-          file_name: featuredImage.name,
-          file_path: imageRes.file_path,
-          announcement_id: announcementId,
-          cetagory_id: formData.cetagory_id,
-        });
+        await AnnouncementService.uploadAttachment(announcementId, featuredImage);
       }
 
       // attachments
       for (const file of attachments) {
-        const uploadRes =
-          await AnnouncementService.uploadFile(file, announcementId);
-
-        await AnnouncementService.createAttachment({
-          file_name: file.name,
-          file_path: uploadRes.file_path,
-          announcement_id: announcementId,
-          cetagory_id: formData.cetagory_id,
-        });
+        await AnnouncementService.uploadAttachment(announcementId, file);
       }
       
       await AnnouncementService.createAdminLog({
@@ -241,16 +203,6 @@ const CreateAnnouncementForm: React.FC = () => {
         announcement_id: announcementId,
       });
 
-      if (formData.send_notification) {
-        await AnnouncementService.createNotification({
-          notification_title: formData.title,
-          notification_type: "ANNOUNCEMENT",
-          notification_message: formData.content,
-          is_read: false,
-          announcement_id: announcementId,
-        });
-      }
-
       alert(
         status === "SCHEDULED"
           ? "ตั้งเวลาเผยแพร่เรียบร้อย ⏰"
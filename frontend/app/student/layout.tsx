@@ -4,6 +4,7 @@ import { useEffect, useState } from "react";
 import { usePathname, useRouter } from "next/navigation";
 import PageLayout from "@/src/components/layout/pagelayout";
 import NotificationSocket from "@/components/NotificationPoller";
+import { syncPushSubscription } from "@/services/push";
 
 const API_URL = process.env.NEXT_PUBLIC_API_URL || "http://localhost:8080";
 
@@ -85,6 +86,7 @@ export default function StudentLayout({ children }: { children: React.ReactNode
         }
 
         setReady(true);
+        syncPushSubscription().catch(() => {});
       } catch (err) {
         localStorage.removeItem("token");
         localStorage.removeItem("user");
@@ -14,6 +14,7 @@ import {
   fetchMyProfile,
 } from "@/services/profile";
 import { ProfileImageUploader } from "@/components/ProfileImageUploader";
+import { PushNotificationToggle } from "@/components/PushNotificationToggle";
 
 type Option = { id: number; name: string };
 
@@ -603,6 +604,14 @@ export default function ProfilePage() {
             )}
           </div>
         </SectionCard>
+
+        <SectionCard
+          title="การแจ้งเตือน"
+          subtitle="รับแจ้งเตือนก่อนปิดรับสมัครและประกาศใหม่ แม้ไม่ได้เปิดเว็บไว้"
+          noDivider
+        >
+          <PushNotificationToggle />
+        </SectionCard>
       </div>
     </div>
   );
@@ -106,17 +106,7 @@ const AnnouncementDetailPage = () => {
 
   const handleDownload = async (attachment: Attachment) => {
     try {
-      const fileUrl = getFileUrl(attachment.file_path);
-      console.log('Downloading from:', fileUrl);
-      
-      // ใช้ fetch เพื่อดาวน์โหลดไฟล์
-      const response = await fetch(fileUrl);
-      
-      if (!response.ok) {
-        throw new Error('Failed to download file');
-      }
-      
-      const blob = await response.blob();
+      const blob = await AnnouncementService.downloadAttachment(attachment);
       const url = window.URL.createObjectURL(blob);
       const link = document.createElement('a');
       link.href = url;
@@ -0,0 +1,82 @@
+"use client";
+
+import React, { useEffect, useState } from "react";
+import {
+  disablePushNotifications,
+  enablePushNotifications,
+  isPushEnabled,
+  isPushSupported,
+  sendTestPush,
+} from "@/services/push";
+
+// ปุ่มเปิด/ปิดการแจ้งเตือนแบบ Push บนอุปกรณ์นี้ (เช่นเตือนก่อนปิดรับสมัคร แม้ไม่ได้เปิดเว็บไว้)
+export function PushNotificationToggle() {
+  const [supported, setSupported] = useState(false);
+  const [enabled, setEnabled] = useState(false);
+  const [busy, setBusy] = useState(false);
+  const [message, setMessage] = useState<string | null>(null);
+
+  useEffect(() => {
+    setSupported(isPushSupported());
+    isPushEnabled().then(setEnabled).catch(() => setEnabled(false));
+  }, []);
+
+  if (!supported) {
+    return <p className="text-sm text-gray-500">เบราว์เซอร์นี้ไม่รองรับการแจ้งเตือนแบบ Push</p>;
+  }
+
+  const run = async (action: () => Promise<void>) => {
+    setBusy(true);
+    setMessage(null);
+    try {
+      await action();
+    } catch (err: any) {
+      setMessage(err.message);
+    } finally {
+      setBusy(false);
+    }
+  };
+
+  return (
+    <div className="flex flex-col gap-2">
+      <div className="flex flex-wrap items-center gap-3">
+        <button
+          type="button"
+          disabled={busy}
+          onClick={() =>
+            run(async () => {
+              if (enabled) {
+                await disablePushNotifications();
+                setEnabled(false);
+              } else {
+                await enablePushNotifications();
+                setEnabled(true);
+              }
+            })
+          }
+          className={`px-4 py-2 rounded-lg text-sm font-medium text-white disabled:opacity-50 ${
+            enabled ? "bg-gray-500 hover:bg-gray-600" : "bg-orange-500 hover:bg-orange-600"
+          }`}
+        >
+          {enabled ? "ปิดการแจ้งเตือนบนอุปกรณ์นี้" : "เปิดการแจ้งเตือนบนอุปกรณ์นี้"}
+        </button>
+        {enabled && (
+          <button
+            type="button"
+            disabled={busy}
+            onClick={() =>
+              run(async () => {
+                await sendTestPush();
+                setMessage("ส่งแจ้งเตือนทดสอบแล้ว");
+              })
+            }
+            className="text-sm text-orange-500 hover:underline disabled:opacity-50"
+          >
+            ส่งแจ้งเตือนทดสอบ
+          </button>
+        )}
+      </div>
+      {message && <p className="text-sm text-gray-600">{message}</p>}
+    </div>
+  );
+}
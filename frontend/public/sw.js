@@ -0,0 +1,35 @@
+// Service worker สำหรับ Web Push: แสดงแจ้งเตือนและเปิดหน้าที่เกี่ยวข้องเมื่อกด
+self.addEventListener("push", (event) => {
+  let data = {};
+  try {
+    data = event.data ? event.data.json() : {};
+  } catch (e) {
+    data = { title: "แจ้งเตือนใหม่", body: event.data ? event.data.text() : "" };
+  }
+
+  const options = {
+    body: data.body || "",
+    icon: "/logo.sut2.png",
+    badge: "/logo.sut2.png",
+    data: { url: data.url || "/", notification_id: data.notification_id },
+  };
+  // แจ้งเตือนกลุ่มเดียวกัน (เช่นเตือนปิดรับสมัครหลักสูตรเดียวกัน) แทนที่อันเดิม
+  if (data.tag) {
+    options.tag = data.tag;
+    options.renotify = true;
+  }
+  event.waitUntil(self.registration.showNotification(data.title || "แจ้งเตือนใหม่", options));
+});
+
+self.addEventListener("notificationclick", (event) => {
+  event.notification.close();
+  const url = new URL((event.notification.data && event.notification.data.url) || "/", self.location.origin).href;
+  event.waitUntil(
+    self.clients.matchAll({ type: "window", includeUncontrolled: true }).then((clients) => {
+      for (const client of clients) {
+        if (client.url === url && "focus" in client) return client.focus();
+      }
+      return self.clients.openWindow(url);
+    })
+  );
+});
@@ -14,6 +14,8 @@ export interface User {
 
 // ---------- Announcement ----------
 
+export type ContentFormat = "plain" | "markdown" | "html";
+
 export interface Announcement {
   attachments: number;
   ID?: number;
@@ -21,11 +23,16 @@ export interface Announcement {
   UpdatedAt?: string;
   title: string;
   content: string;
+  content_format?: ContentFormat;
+  content_html?: string; // sanitized HTML rendered by the backend
   is_pinned: boolean;
   scheduled_publish_at: string;
   published_at?: string;
   expires_at?: string | null;
   send_notification: boolean;
+  requires_ack?: boolean;
+  ack_deadline?: string | null;
+  receipt?: AnnouncementReceipt; // the signed-in user's read/acknowledge state
 
   cetagory?: {
     ID: number;
@@ -38,10 +45,13 @@ export interface Announcement {
 export interface CreateAnnouncementPayload {
   title: string;
   content: string;
+  content_format?: ContentFormat;
   is_pinned: boolean;
   scheduled_publish_at: string;
   expires_at?: string | null;
   send_notification: boolean;
+  requires_ack?: boolean;
+  ack_deadline?: string | null;
   cetagory_id: number;
   status?: "DRAFT" | "PUBLISHED" | "SCHEDULED";
 }
@@ -49,14 +59,24 @@ export interface CreateAnnouncementPayload {
 export interface UpdateAnnouncementPayload {
   title?: string;
   content?: string;
+  content_format?: ContentFormat;
   is_pinned?: boolean;
   scheduled_publish_at?: string;
   expires_at?: string | null;
   send_notification?: boolean;
+  requires_ack?: boolean;
+  ack_deadline?: string | null;
   cetagory_id?: number;
   status?: "DRAFT" | "PUBLISHED" | "SCHEDULED";
 }
 
+export interface AnnouncementReceipt {
+  first_viewed_at: string;
+  last_viewed_at: string;
+  view_count: number;
+  acknowledged_at?: string | null;
+}
+
 // ---------- Category ----------
 
 export interface Cetagory {
@@ -71,13 +91,14 @@ export interface Attachment {
   file_name: string;
   file_path: string;
   file_size?: number;
+  content_type?: string;
+  download_count?: number;
+  download_url?: string; // counts the download, then redirects to file_path
   uploaded_at?: string;
 
   announcement_id: number;
-  cetagory_id?: number;
 
   announcement?: Announcement;
-  cetagory?: Cetagory;
 }
 
 // ---------- Admin Log ----------
@@ -103,8 +124,10 @@ export interface Notification {
   notification_message: string;
 
   is_read: boolean;
-  read_at?: string;
+  read_at?: string | null;
   sent_at?: string;
+  group_key?: string;
+  archived_at?: string | null;
 
   announcement_id: number;
 
@@ -115,9 +138,6 @@ export interface Notification {
 // ==================== Service ====================
 
 class AnnouncementService {
-  deleteAttachment(attachmentId: number) {
-    throw new Error("Method not implemented.");
-  }
   private getAuthHeaders() {
     const token = localStorage.getItem('token');
     
@@ -190,10 +210,13 @@ class AnnouncementService {
   }
 
   async getAnnouncementById(id: number): Promise<Announcement> {
+    // ส่ง token ถ้ามี เพื่อเปิดประกาศเฉพาะกลุ่ม และฉบับร่างของแอดมินได้
+    const token = localStorage.getItem('token');
     const response = await fetch(`${API_URL}/announcements/${id}`, {
       method: 'GET',
       headers: {
         'Content-Type': 'application/json',
+        ...(token ? { Authorization: `Bearer ${token}` } : {}),
       },
     });
 
@@ -266,16 +289,17 @@ class AnnouncementService {
 
   // ===================== Attachment =====================
 
-  async createAttachment(data: {
-    file_name: string;
-    file_path: string;
-    announcement_id: number;
-    cetagory_id: number;
-  }) {
-    const response = await fetch(`${API_URL}/attachments`, {
+  async uploadAttachment(announcementId: number, file: File): Promise<Attachment> {
+    const formData = new FormData();
+    formData.append("file", file);
+
+    const token = localStorage.getItem("token");
+    const response = await fetch(`${API_URL}/admin/announcements/${announcementId}/attachments`, {
       method: "POST",
-      headers: this.getAuthHeaders(),
-      body: JSON.stringify(data),
+      headers: {
+        Authorization: `Bearer ${token}`,
+      },
+      body: formData,
     });
 
     if (!response.ok) {
@@ -283,15 +307,33 @@ class AnnouncementService {
       throw new Error(err);
     }
 
+    const data = await response.json();
+    return data.data;
+  }
+
+  async deleteAttachment(announcementId: number, attachmentId: number) {
+    const response = await fetch(
+      `${API_URL}/admin/announcements/${announcementId}/attachments/${attachmentId}`,
+      {
+        method: "DELETE",
+        headers: this.getAuthHeaders(),
+      }
+    );
+
+    if (!response.ok) {
+      throw new Error("Failed to delete attachment");
+    }
+
     return response.json();
   }
 
-  //  เพิ่ม function 
   async getAttachmentsByAnnouncementId(announcementId: number): Promise<Attachment[]> {
-    const response = await fetch(`${API_URL}/attachments/announcement/${announcementId}`, {
+    const token = localStorage.getItem('token');
+    const response = await fetch(`${API_URL}/announcements/${announcementId}/attachments`, {
       method: 'GET',
       headers: {
         'Content-Type': 'application/json',
+        ...(token ? { Authorization: `Bearer ${token}` } : {}),
       },
     });
 
@@ -302,6 +344,23 @@ class AnnouncementService {
     return response.json();
   }
 
+  // ผ่าน download_url เพื่อให้นับจำนวนดาวน์โหลด
+  async downloadAttachment(attachment: Attachment): Promise<Blob> {
+    const token = localStorage.getItem('token');
+    const url = attachment.download_url
+      ? `${API_URL}${attachment.download_url}`
+      : attachment.file_path;
+    const response = await fetch(url, {
+      headers: token ? { Authorization: `Bearer ${token}` } : {},
+    });
+
+    if (!response.ok) {
+      throw new Error('Failed to download file');
+    }
+
+    return response.blob();
+  }
+
   // ===================== Admin Log =====================
 
   async createAdminLog(data: Omit<AdminLog, 'user_id'>): Promise<AdminLog> {
@@ -331,22 +390,6 @@ class AnnouncementService {
     return response.json();
   }
 
-  // ===================== Notification =====================
-
-  async createNotification(data: Notification): Promise<Notification> {
-    const response = await fetch(`${API_URL}/notifications`, {
-      method: 'POST',
-      headers: this.getAuthHeaders(),
-      body: JSON.stringify(data),
-    });
-
-    if (!response.ok) {
-      throw new Error('Failed to create notification');
-    }
-
-    return response.json();
-  }
-
   // ===================== File Upload =====================
 
   async uploadFile(
@@ -301,22 +301,51 @@ export async function updateCurriculumRecommendation(
   return normalizeCurriculum(json.data);
 }
 
-// ดึงข้อความแจ้งเตือน (Polling)
-export async function fetchNotificationsAPI(userId: number) {
-  const res = await fetch(`${API_URL}/notifications?user_id=${userId}`);
+// ดึงข้อความแจ้งเตือนที่ยังไม่ได้อ่าน (Polling)
+export async function fetchNotificationsAPI() {
+  const res = await fetch(`${API_URL}/notifications?read=false`, {
+    headers: authHeaders(),
+  });
   if (!res.ok) return [];
   const json = await res.json();
   return json.data || [];
 }
+export async function fetchUnreadNotificationCountAPI(): Promise<number> {
+  const res = await fetch(`${API_URL}/notifications/unread-count`, {
+    headers: authHeaders(),
+  });
+  if (!res.ok) return 0;
+  const json = await res.json();
+  return json.data?.total ?? 0;
+}
 export async function markNotificationReadAPI(notiId: number) {
   try {
     await fetch(`${API_URL}/notifications/${notiId}/read`, {
       method: "PATCH",
+      headers: authHeaders(),
     });
   } catch (error) {
     console.error("Failed to mark notification as read", error);
   }
 }
+export async function markAllNotificationsReadAPI() {
+  const res = await fetch(`${API_URL}/notifications/read-all`, {
+    method: "POST",
+    headers: authHeaders(),
+  });
+  if (!res.ok) throw new Error("Failed to mark notifications as read");
+}
+export async function bulkUpdateNotificationsAPI(
+  action: "read" | "unread" | "archive" | "unarchive" | "delete",
+  ids: number[]
+) {
+  const res = await fetch(`${API_URL}/notifications/bulk`, {
+    method: "POST",
+    headers: authHeaders(),
+    body: JSON.stringify({ action, ids }),
+  });
+  if (!res.ok) throw new Error("Failed to update notifications");
+}
 // ✅ เพิ่ม type สำหรับข้อมูลสถิติ
 export interface StatsDTO {
   faculty_stats: { name: string; value: number }[];
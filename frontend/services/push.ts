@@ -0,0 +1,104 @@
+const API_URL = process.env.NEXT_PUBLIC_API_URL || "http://localhost:8080";
+
+function authHeaders() {
+  const token =
+    typeof window !== "undefined" ? localStorage.getItem("token") : null;
+  return {
+    "Content-Type": "application/json",
+    ...(token ? { Authorization: `Bearer ${token}` } : {}),
+  };
+}
+
+export function isPushSupported() {
+  return (
+    typeof window !== "undefined" &&
+    "serviceWorker" in navigator &&
+    "PushManager" in window &&
+    "Notification" in window
+  );
+}
+
+// applicationServerKey ต้องเป็น Uint8Array ไม่ใช่ base64url
+function base64UrlToUint8Array(value: string) {
+  const padding = "=".repeat((4 - (value.length % 4)) % 4);
+  const base64 = (value + padding).replace(/-/g, "+").replace(/_/g, "/");
+  const raw = atob(base64);
+  return Uint8Array.from(raw, (c) => c.charCodeAt(0));
+}
+
+async function getRegistration() {
+  return navigator.serviceWorker.register("/sw.js");
+}
+
+async function fetchPublicKey(): Promise<string> {
+  const res = await fetch(`${API_URL}/notifications/push/public-key`, {
+    headers: authHeaders(),
+  });
+  if (!res.ok) throw new Error("ระบบยังไม่เปิดใช้การแจ้งเตือนแบบ Push");
+  const json = await res.json();
+  return json.data.public_key;
+}
+
+async function saveSubscription(subscription: PushSubscription) {
+  const res = await fetch(`${API_URL}/notifications/push/subscriptions`, {
+    method: "POST",
+    headers: authHeaders(),
+    body: JSON.stringify(subscription.toJSON()),
+  });
+  if (!res.ok) throw new Error("บันทึกการสมัครรับแจ้งเตือนไม่สำเร็จ");
+}
+
+// เปิดรับแจ้งเตือนบนอุปกรณ์นี้ (ขออนุญาตจากผู้ใช้)
+export async function enablePushNotifications() {
+  if (!isPushSupported()) throw new Error("เบราว์เซอร์นี้ไม่รองรับการแจ้งเตือนแบบ Push");
+  const permission = await Notification.requestPermission();
+  if (permission !== "granted") throw new Error("ไม่ได้รับอนุญาตให้แสดงการแจ้งเตือน");
+
+  const registration = await getRegistration();
+  const publicKey = await fetchPublicKey();
+  let subscription = await registration.pushManager.getSubscription();
+  if (!subscription) {
+    subscription = await registration.pushManager.subscribe({
+      userVisibleOnly: true,
+      applicationServerKey: base64UrlToUint8Array(publicKey),
+    });
+  }
+  await saveSubscription(subscription);
+}
+
+// ส่ง subscription เดิมให้ server อีกครั้ง (เช่นหลังล็อกอินบัญชีอื่นบนเครื่องเดียวกัน) โดยไม่ถามผู้ใช้
+export async function syncPushSubscription() {
+  if (!isPushSupported() || Notification.permission !== "granted") return;
+  const registration = await navigator.serviceWorker.getRegistration("/sw.js");
+  const subscription = await registration?.pushManager.getSubscription();
+  if (subscription) await saveSubscription(subscription);
+}
+
+export async function disablePushNotifications() {
+  if (!isPushSupported()) return;
+  const registration = await navigator.serviceWorker.getRegistration("/sw.js");
+  const subscription = await registration?.pushManager.getSubscription();
+  if (!subscription) return;
+  await fetch(`${API_URL}/notifications/push/unsubscribe`, {
+    method: "POST",
+    headers: authHeaders(),
+    body: JSON.stringify({ endpoint: subscription.endpoint }),
+  });
+  await subscription.unsubscribe();
+}
+
+export async function isPushEnabled() {
+  if (!isPushSupported() || Notification.permission !== "granted") return false;
+  const registration = await navigator.serviceWorker.getRegistration("/sw.js");
+  return !!(await registration?.pushManager.getSubscription());
+}
+
+export async function sendTestPush() {
+  const res = await fetch(`${API_URL}/notifications/push/test`, {
+    method: "POST",
+    headers: authHeaders(),
+  });
+  const json = await res.json().catch(() => ({}));
+  if (!res.ok) throw new Error(json.error || "ส่งแจ้งเตือนทดสอบไม่สำเร็จ");
+  return json.data;
+}